@@ -17,10 +17,24 @@ import (
 
 // InitSpec defines what this init container should wait for.
 type InitSpec struct {
-	Namespace            string   `json:"namespace"`
-	WaitForJobs          []string `json:"waitForJobs"`
-	WaitForServices      []string `json:"waitForServices"`
-	PrepareWritablePaths []string `json:"prepareWritablePaths,omitempty"`
+	Namespace            string      `json:"namespace"`
+	Project              string      `json:"project,omitempty"`
+	WaitForJobs          []string    `json:"waitForJobs"`
+	WaitForServices      []string    `json:"waitForServices"`
+	WaitForStarted       []string    `json:"waitForStarted,omitempty"`
+	PrepareWritablePaths []string    `json:"prepareWritablePaths,omitempty"`
+	OwnedFiles           []OwnedFile `json:"ownedFiles,omitempty"`
+}
+
+// OwnedFile is a secret or config file to copy to Target with the given
+// ownership and mode. Secret and configMap volumes can't set an owner
+// beyond fsGroup, so the workload mounts the copy instead.
+type OwnedFile struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	UID    int    `json:"uid"`
+	GID    int    `json:"gid"`
+	Mode   uint32 `json:"mode"`
 }
 
 func main() {
@@ -36,8 +50,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	if len(spec.WaitForJobs) == 0 && len(spec.WaitForServices) == 0 && len(spec.PrepareWritablePaths) == 0 {
-		fmt.Println("No jobs/services to wait for and no writable paths to prepare")
+	if len(spec.WaitForJobs) == 0 && len(spec.WaitForServices) == 0 && len(spec.WaitForStarted) == 0 && len(spec.PrepareWritablePaths) == 0 && len(spec.OwnedFiles) == 0 {
+		fmt.Println("No jobs/services to wait for and no writable paths or owned files to prepare")
 		os.Exit(0)
 	}
 
@@ -49,6 +63,14 @@ func main() {
 		}
 	}
 
+	if len(spec.OwnedFiles) > 0 {
+		fmt.Printf("Preparing %d owned file(s)\n", len(spec.OwnedFiles))
+		if err := prepareOwnedFiles(spec.OwnedFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to prepare owned files: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to get in-cluster config: %v\n", err)
@@ -64,6 +86,16 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
+	// Wait for service_started dependencies to have a pod past Pending
+	if len(spec.WaitForStarted) > 0 {
+		fmt.Printf("Waiting for services to start: %v\n", spec.WaitForStarted)
+		if err := waitForStarted(ctx, clientset, spec.Namespace, spec.Project, spec.WaitForStarted); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("All dependency services have started")
+	}
+
 	// Wait for all jobs to complete
 	if len(spec.WaitForJobs) > 0 {
 		fmt.Printf("Waiting for jobs to complete: %v\n", spec.WaitForJobs)
@@ -77,7 +109,7 @@ func main() {
 	// Wait for all services to become ready
 	if len(spec.WaitForServices) > 0 {
 		fmt.Printf("Waiting for services to become ready: %v\n", spec.WaitForServices)
-		if err := waitForServices(ctx, clientset, spec.Namespace, spec.WaitForServices); err != nil {
+		if err := waitForServices(ctx, clientset, spec.Namespace, spec.Project, spec.WaitForServices); err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			os.Exit(1)
 		}
@@ -118,11 +150,11 @@ func waitForJobs(ctx context.Context, clientset *kubernetes.Clientset, namespace
 	}
 }
 
-func waitForServices(ctx context.Context, clientset *kubernetes.Clientset, namespace string, services []string) error {
+func waitForServices(ctx context.Context, clientset *kubernetes.Clientset, namespace, project string, services []string) error {
 	for {
 		allReady := true
 		for _, svcName := range services {
-			ready, err := isServiceReady(ctx, clientset, namespace, svcName)
+			ready, err := isServiceReady(ctx, clientset, namespace, project, svcName)
 			if err != nil {
 				fmt.Printf("Waiting for service %s: %v\n", svcName, err)
 				allReady = false
@@ -147,10 +179,57 @@ func waitForServices(ctx context.Context, clientset *kubernetes.Clientset, names
 	}
 }
 
+func waitForStarted(ctx context.Context, clientset *kubernetes.Clientset, namespace, project string, services []string) error {
+	for {
+		allStarted := true
+		for _, svcName := range services {
+			started, err := isServiceStarted(ctx, clientset, namespace, project, svcName)
+			if err != nil {
+				fmt.Printf("Waiting for service %s: %v\n", svcName, err)
+				allStarted = false
+				break
+			}
+			if !started {
+				fmt.Printf("Service %s not yet started\n", svcName)
+				allStarted = false
+				break
+			}
+		}
+
+		if allStarted {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for services to start")
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// isServiceStarted checks if at least one pod for the service exists and is
+// past Pending (scheduled with its containers started, or already finished).
+func isServiceStarted(ctx context.Context, clientset *kubernetes.Clientset, namespace, project, serviceName string) (bool, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: serviceSelector(project, serviceName),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, pod := range pods.Items {
+		if isPodStarted(&pod) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // isServiceReady checks if at least one pod for the service has Ready=True condition.
-func isServiceReady(ctx context.Context, clientset *kubernetes.Clientset, namespace, serviceName string) (bool, error) {
+func isServiceReady(ctx context.Context, clientset *kubernetes.Clientset, namespace, project, serviceName string) (bool, error) {
 	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("kappal.io/service=%s", serviceName),
+		LabelSelector: serviceSelector(project, serviceName),
 	})
 	if err != nil {
 		return false, err
@@ -164,6 +243,15 @@ func isServiceReady(ctx context.Context, clientset *kubernetes.Clientset, namesp
 	return false, nil
 }
 
+// serviceSelector matches a service's pods. Specs from older kappal versions
+// carry no project, so the service label alone is used then.
+func serviceSelector(project, serviceName string) string {
+	if project == "" {
+		return fmt.Sprintf("kappal.io/service=%s", serviceName)
+	}
+	return fmt.Sprintf("kappal.io/project=%s,kappal.io/service=%s", project, serviceName)
+}
+
 func isPodReady(pod *corev1.Pod) bool {
 	for _, cond := range pod.Status.Conditions {
 		if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
@@ -173,6 +261,10 @@ func isPodReady(pod *corev1.Pod) bool {
 	return false
 }
 
+func isPodStarted(pod *corev1.Pod) bool {
+	return pod.Status.Phase != "" && pod.Status.Phase != corev1.PodPending
+}
+
 func isJobComplete(job *batchv1.Job) bool {
 	return job.Status.Succeeded >= 1
 }
@@ -228,3 +320,28 @@ func prepareWritablePaths(paths []string) error {
 	}
 	return nil
 }
+
+// prepareOwnedFiles copies each file to its target and applies the requested
+// mode and ownership, creating the target directory if needed.
+func prepareOwnedFiles(files []OwnedFile) error {
+	for _, f := range files {
+		content, err := os.ReadFile(f.Source)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", f.Source, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(f.Target), 0755); err != nil {
+			return fmt.Errorf("create directory for %s: %w", f.Target, err)
+		}
+		if err := os.WriteFile(f.Target, content, os.FileMode(f.Mode)); err != nil {
+			return fmt.Errorf("write %s: %w", f.Target, err)
+		}
+		// WriteFile's mode is subject to umask and ignored for existing files
+		if err := os.Chmod(f.Target, os.FileMode(f.Mode)); err != nil {
+			return fmt.Errorf("chmod file %s: %w", f.Target, err)
+		}
+		if err := os.Chown(f.Target, f.UID, f.GID); err != nil {
+			return fmt.Errorf("chown file %s: %w", f.Target, err)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestPrepareWritablePathsCreatesDir(t *testing.T) {
@@ -78,3 +80,68 @@ func TestPrepareWritablePathsRejectsRelative(t *testing.T) {
 		t.Fatal("expected error for relative path, got nil")
 	}
 }
+
+func TestPrepareOwnedFilesCopiesWithMode(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "src", "app.yaml")
+	target := filepath.Join(root, "owned", "config-app", "app.yaml")
+	if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(source, []byte("key: value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Chown to the current user so the test runs unprivileged
+	files := []OwnedFile{{Source: source, Target: target, UID: os.Getuid(), GID: os.Getgid(), Mode: 0440}}
+	if err := prepareOwnedFiles(files); err != nil {
+		t.Fatalf("prepareOwnedFiles failed: %v", err)
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("read target: %v", err)
+	}
+	if string(content) != "key: value\n" {
+		t.Fatalf("unexpected content %q", content)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatalf("stat target: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0440 {
+		t.Fatalf("expected mode 0440, got %04o", mode)
+	}
+}
+
+func TestPrepareOwnedFilesMissingSource(t *testing.T) {
+	root := t.TempDir()
+	files := []OwnedFile{{Source: filepath.Join(root, "missing"), Target: filepath.Join(root, "out"), Mode: 0444}}
+	if err := prepareOwnedFiles(files); err == nil {
+		t.Fatal("expected error for missing source file")
+	}
+}
+
+func TestServiceSelector(t *testing.T) {
+	if got := serviceSelector("myproj", "db"); got != "kappal.io/project=myproj,kappal.io/service=db" {
+		t.Errorf("serviceSelector = %q", got)
+	}
+	if got := serviceSelector("", "db"); got != "kappal.io/service=db" {
+		t.Errorf("serviceSelector without project = %q", got)
+	}
+}
+
+func TestIsPodStarted(t *testing.T) {
+	for phase, want := range map[corev1.PodPhase]bool{
+		"":                  false,
+		corev1.PodPending:   false,
+		corev1.PodRunning:   true,
+		corev1.PodSucceeded: true,
+		corev1.PodFailed:    true,
+	} {
+		pod := &corev1.Pod{Status: corev1.PodStatus{Phase: phase}}
+		if got := isPodStarted(pod); got != want {
+			t.Errorf("isPodStarted(%q) = %v, want %v", phase, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/k8s"
+	"github.com/kappal-app/kappal/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	attachIndex   int
+	attachNoStdin bool
+)
+
+var attachCmd = &cobra.Command{
+	Use:   "attach [OPTIONS] SERVICE",
+	Short: "Attach to a running service container's main process",
+	Long: `Attach local standard input and output to a service container's main
+process.
+
+This is similar to 'docker compose attach'. Unlike 'kappal exec', no new
+process is started: you interact with the container's own command (e.g. a
+REPL or a console app). Output is streamed until the process exits or you
+disconnect.
+
+Input is only forwarded when the service sets 'stdin_open: true', and a
+terminal is only allocated when it also sets 'tty: true' (the pod container
+gets stdin: true and tty: true). Without them attach is read-only, like
+following the service's output.
+
+Flags:
+  --index <n>    Replica index when the service has several pods (default 0)
+  --no-stdin     Do not forward standard input, even if the service keeps it open
+
+Examples:
+  kappal attach console             # Interact with the console service
+  kappal attach --index 1 worker    # Attach to the second replica
+  kappal attach --no-stdin console  # Only watch the output`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAttach,
+}
+
+func init() {
+	attachCmd.Flags().IntVar(&attachIndex, "index", 0, "Index of the container if service has multiple replicas")
+	attachCmd.Flags().BoolVar(&attachNoStdin, "no-stdin", false, "Do not attach STDIN")
+}
+
+func runAttach(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	serviceName := args[0]
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := project.GetService(serviceName); err != nil {
+		return fmt.Errorf("service %q not found in compose file", serviceName)
+	}
+
+	workspaceDir := filepath.Join(projectDir, ".kappal")
+
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false, Namespace: ns})
+	if err != nil {
+		return fmt.Errorf("failed to discover state: %w", err)
+	}
+
+	if discovered.K3s.Status != "running" {
+		return fmt.Errorf("K3s not running (run 'kappal up' first)")
+	}
+
+	if discovered.Kubeconfig == "" {
+		return fmt.Errorf("kubeconfig not available (run 'kappal up' first)")
+	}
+
+	k8sClient, err := k8s.NewClient(discovered.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	opts := k8s.ExecOptions{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Index:  attachIndex,
+	}
+	if !attachNoStdin {
+		opts.Stdin = os.Stdin
+	}
+
+	return k8sClient.Attach(ctx, ns, project.Name, serviceName, opts)
+}
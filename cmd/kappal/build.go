@@ -3,12 +3,25 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/build"
 	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/docker"
 	"github.com/kappal-app/kappal/pkg/k3s"
+	"github.com/kappal-app/kappal/pkg/k8s"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	buildNoCache  bool
+	buildForce    bool
+	buildPlatform string
 )
 
 var buildCmd = &cobra.Command{
@@ -16,10 +29,21 @@ var buildCmd = &cobra.Command{
 	Short: "Build or rebuild services",
 	Long: `Build images for services that have a build context in the compose file.
 
-For each service with a "build:" section, runs docker build inside the project's
-K3s container so the image is immediately available to Kubernetes (no registry push
-needed). If SERVICE arguments are given, only those services are built; otherwise
-all buildable services are built.
+For each service with a "build:" section, builds the image with the project's
+in-cluster BuildKit builder, straight into K3s's containerd, so it is immediately
+available to Kubernetes (no registry push needed). When the builder is not
+healthy, or for --platform/compose "platform:" builds, kappal falls back to
+docker build and imports the image into K3s. If SERVICE arguments are given, only those services are built; otherwise
+all buildable services are built. Up to 4 images are built in parallel; their
+output lines are prefixed with the service name ("web | ..."), and the first
+failed build cancels the others.
+
+Unchanged services are skipped: kappal records a hash of each build context
+(the files not excluded by .dockerignore, the Dockerfile path and build args)
+in .kappal/runtime, and when it matches and the image is still loaded in K3s,
+neither docker build nor the containerd import runs. --force or --no-cache
+rebuild anyway. After a build, the import into K3s is also skipped when K3s
+already holds an image with the same ID (e.g. every layer came from cache).
 
 Image naming: images are tagged as <project>-<service>:latest. The build context
 path and optional dockerfile are taken from the compose file's build.context and
@@ -29,15 +53,34 @@ K3s must be running (started automatically if not). Images are loaded directly
 into K3s's containerd, bypassing any external registry.
 
 Flags:
-  -f <path>      Compose file path (default: docker-compose.yaml)
+  --no-cache     Rebuild every layer instead of using the Docker build cache
+                 (for stale layers, without pruning the whole cache)
+  --force        Rebuild even if the build context is unchanged
+  --platform <p> Build for this platform (e.g. linux/amd64) instead of the
+                 service's compose "platform:" or the Docker host's own
+                 architecture. Use it for amd64-only base images on Apple
+                 Silicon; running the result needs emulation (binfmt/qemu or
+                 Rosetta, as Docker Desktop provides), otherwise pods fail
+                 with "exec format error".
+  -f <path>      Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
   -p <name>      Override project name
 
 Examples:
   kappal build              Build all services with build contexts
-  kappal build web api      Build only the web and api services`,
+  kappal build web api      Build only the web and api services
+  kappal build --no-cache   Rebuild all services from scratch
+  kappal build --force web  Rebuild web even if nothing changed
+  kappal build --platform linux/amd64
+                            Build amd64 images on an arm64 host`,
 	RunE:  runBuild,
 }
 
+func init() {
+	buildCmd.Flags().BoolVar(&buildNoCache, "no-cache", false, "Do not use cache when building the image")
+	buildCmd.Flags().BoolVar(&buildForce, "force", false, "Rebuild images even if their build context is unchanged")
+	buildCmd.Flags().StringVar(&buildPlatform, "platform", "", "Target platform for builds (e.g. linux/amd64); overrides the compose platform")
+}
+
 func runBuild(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
@@ -46,13 +89,10 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePaths := composeFilePaths(projectDir)
 
-	resolvedName := resolveProjectName(projectName, filepath.Dir(composePath))
-	project, err := compose.Load(composePath, resolvedName)
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
 	if err != nil {
 		return fmt.Errorf("failed to load compose file: %w", err)
 	}
@@ -63,6 +103,8 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create K3s manager: %w", err)
 	}
 	defer func() { _ = k3sManager.Close() }()
+	k3sManager.SetNoCache(buildNoCache)
+	k3sManager.SetForceBuild(buildForce)
 
 	// Ensure K3s is running (for loading images into containerd)
 	if err := k3sManager.EnsureRunning(ctx); err != nil {
@@ -84,6 +126,7 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	var services []types.ServiceConfig
 	for _, name := range servicesToBuild {
 		svc, err := project.GetService(name)
 		if err != nil {
@@ -93,19 +136,78 @@ func runBuild(cmd *cobra.Command, args []string) error {
 			fmt.Printf("Skipping %s (no build context)\n", name)
 			continue
 		}
+		services = append(services, svc)
+	}
 
-		fmt.Printf("Building %s...\n", name)
-		dockerfile := ""
-		if svc.Build.Dockerfile != "" {
-			dockerfile = svc.Build.Dockerfile
-		}
+	useClusterBuilder(k3sManager)
+	return buildServices(ctx, k3sManager, project.Name, services, buildPlatform, os.Stdout)
+}
+
+// useClusterBuilder has k3sManager build with the in-cluster BuildKit
+// builder, which writes images straight into K3s. Without a cluster client
+// builds keep using docker build and import. K3s must be running.
+func useClusterBuilder(k3sManager *k3s.Manager) {
+	if client, err := k8s.NewClient(k3sManager.GetKubeconfigPath()); err == nil {
+		k3sManager.SetClusterBuilder(build.NewEngine(client))
+	}
+}
 
-		// Only pass explicit build.args from compose file
-		if err := k3sManager.BuildImage(ctx, project.Name, name, svc.Build.Context, dockerfile, svc.Build.Args); err != nil {
-			return fmt.Errorf("failed to build %s: %w", name, err)
+// maxParallelBuilds caps how many images are built at once.
+const maxParallelBuilds = 4
+
+// imageBuilder builds a service image and loads it into K3s (k3s.Manager).
+type imageBuilder interface {
+	BuildImage(ctx context.Context, projectName, serviceName, contextDir, dockerfile, platform string, buildArgs map[string]*string, out io.Writer) error
+}
+
+// buildServices builds the images of services that have a build section, up
+// to maxParallelBuilds at a time, and stops at the first failure. A single
+// build is rendered in the builder's progress mode; parallel builds write
+// plain lines prefixed with the service name ("web | ...") so their output
+// stays readable. A non-empty platform overrides each service's compose
+// platform.
+func buildServices(ctx context.Context, builder imageBuilder, projectName string, services []types.ServiceConfig, platform string, out io.Writer) error {
+	width := 0
+	for _, svc := range services {
+		if len(svc.Name) > width {
+			width = len(svc.Name)
 		}
-		fmt.Printf("Built %s\n", name)
 	}
 
-	return nil
+	var mu sync.Mutex
+	printf := func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = fmt.Fprintf(out, format, args...)
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxParallelBuilds)
+	for _, svc := range services {
+		svc := svc
+		g.Go(func() error {
+			printf("Building %s...\n", svc.Name)
+			// Only pass explicit build.args from compose file
+			var buildOut io.Writer
+			var prefixed *docker.PrefixWriter
+			if len(services) > 1 {
+				prefixed = docker.NewPrefixWriter(out, &mu, fmt.Sprintf("%-*s | ", width, svc.Name))
+				buildOut = prefixed
+			}
+			svcPlatform := platform
+			if svcPlatform == "" {
+				svcPlatform = svc.Platform
+			}
+			err := builder.BuildImage(ctx, projectName, svc.Name, svc.Build.Context, svc.Build.Dockerfile, svcPlatform, svc.Build.Args, buildOut)
+			if prefixed != nil {
+				_ = prefixed.Flush()
+			}
+			if err != nil {
+				return fmt.Errorf("failed to build %s: %w", svc.Name, err)
+			}
+			printf("Built %s\n", svc.Name)
+			return nil
+		})
+	}
+	return g.Wait()
 }
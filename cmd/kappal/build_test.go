@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// fakeBuilder records build concurrency and writes two output lines per build.
+type fakeBuilder struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+	fail    string
+}
+
+func (b *fakeBuilder) BuildImage(ctx context.Context, projectName, serviceName, contextDir, dockerfile, platform string, buildArgs map[string]*string, out io.Writer) error {
+	b.mu.Lock()
+	b.running++
+	if b.running > b.peak {
+		b.peak = b.running
+	}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.running--
+		b.mu.Unlock()
+	}()
+
+	if serviceName == b.fail {
+		return errors.New("exit code 1")
+	}
+	if out == nil {
+		return fmt.Errorf("expected a prefixed writer for parallel builds")
+	}
+	_, _ = fmt.Fprintf(out, "step 1/2 in %s\nstep 2/2", contextDir)
+	select {
+	case <-time.After(20 * time.Millisecond):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	_, _ = fmt.Fprint(out, "\n")
+	return nil
+}
+
+func buildableServices(names ...string) []types.ServiceConfig {
+	var services []types.ServiceConfig
+	for _, name := range names {
+		services = append(services, types.ServiceConfig{Name: name, Build: &types.BuildConfig{Context: "./" + name}})
+	}
+	return services
+}
+
+func TestBuildServicesParallel(t *testing.T) {
+	builder := &fakeBuilder{}
+	var out bytes.Buffer
+	services := buildableServices("api", "web", "worker", "db", "cache", "queue")
+	if err := buildServices(context.Background(), builder, "test", services, "", &out); err != nil {
+		t.Fatalf("buildServices failed: %v", err)
+	}
+	if builder.peak < 2 || builder.peak > maxParallelBuilds {
+		t.Errorf("peak concurrency = %d, want between 2 and %d", builder.peak, maxParallelBuilds)
+	}
+
+	// Every build line carries its service's padded prefix, unbroken
+	for _, svc := range services {
+		for _, want := range []string{
+			fmt.Sprintf("%-6s | step 1/2 in ./%s\n", svc.Name, svc.Name),
+			fmt.Sprintf("%-6s | step 2/2\n", svc.Name),
+			fmt.Sprintf("Built %s\n", svc.Name),
+		} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("output missing %q:\n%s", want, out.String())
+			}
+		}
+	}
+}
+
+func TestBuildServicesFailsFast(t *testing.T) {
+	builder := &fakeBuilder{fail: "web"}
+	var out bytes.Buffer
+	err := buildServices(context.Background(), builder, "test", buildableServices("api", "web"), "", &out)
+	if err == nil || !strings.Contains(err.Error(), "failed to build web: exit code 1") {
+		t.Fatalf("expected web build failure, got %v", err)
+	}
+	if strings.Contains(out.String(), "Built api") {
+		t.Errorf("api build should be cancelled after web failed:\n%s", out.String())
+	}
+}
+
+func TestBuildServicesSingleUsesProgressMode(t *testing.T) {
+	var gotOut io.Writer = &bytes.Buffer{}
+	builder := builderFunc(func(out io.Writer) { gotOut = out })
+	if err := buildServices(context.Background(), builder, "test", buildableServices("web"), "", io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if gotOut != nil {
+		t.Errorf("a single build should render in the progress mode (nil writer), got %T", gotOut)
+	}
+}
+
+func TestBuildServicesPlatform(t *testing.T) {
+	var mu sync.Mutex
+	platforms := map[string]string{}
+	builder := platformBuilderFunc(func(service, platform string) {
+		mu.Lock()
+		defer mu.Unlock()
+		platforms[service] = platform
+	})
+	services := buildableServices("api", "web")
+	services[0].Platform = "linux/arm64"
+
+	if err := buildServices(context.Background(), builder, "test", services, "", io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if platforms["api"] != "linux/arm64" || platforms["web"] != "" {
+		t.Errorf("platforms = %v, want the compose platform for api and none for web", platforms)
+	}
+
+	if err := buildServices(context.Background(), builder, "test", services, "linux/amd64", io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if platforms["api"] != "linux/amd64" || platforms["web"] != "linux/amd64" {
+		t.Errorf("platforms = %v, want --platform to override every service", platforms)
+	}
+}
+
+type builderFunc func(out io.Writer)
+
+func (f builderFunc) BuildImage(ctx context.Context, projectName, serviceName, contextDir, dockerfile, platform string, buildArgs map[string]*string, out io.Writer) error {
+	f(out)
+	return nil
+}
+
+type platformBuilderFunc func(service, platform string)
+
+func (f platformBuilderFunc) BuildImage(ctx context.Context, projectName, serviceName, contextDir, dockerfile, platform string, buildArgs map[string]*string, out io.Writer) error {
+	f(serviceName, platform)
+	return nil
+}
@@ -44,7 +44,7 @@ restore Docker to a pristine (no-kappal) state.
 
 Flags:
   --all            Clean ALL kappal resources across every project
-  -f <path>        Compose file path (default: docker-compose.yaml)
+  -f <path>        Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
   -p <name>        Override project name
 
 Examples:
@@ -155,10 +155,7 @@ func runCleanProject(ctx context.Context) error {
 	workspaceDir := filepath.Join(projectDir, ".kappal")
 
 	// Resolve project name: from -p flag, or directory-based with hash
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePath := composeFilePaths(projectDir)[0]
 	projName := resolveProjectName(projectName, filepath.Dir(composePath))
 
 	// Discover live state via labels (fast path — no K8s query)
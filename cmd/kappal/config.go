@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/transform"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configFormat   string
+	configServices bool
+	configHash     bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Render the resolved compose file",
+	Long: `Parse, merge and interpolate the compose file(s) and print the canonical result.
+
+This is the equivalent of 'docker compose config'. It shows exactly what kappal
+sees after merging every -f file (and docker-compose.override.yaml when no -f is
+given), substituting variables from the environment and .env, resolving env_file
+entries into environment, and expanding relative paths. Use it to debug why a
+variable didn't expand or which override won. Nothing is deployed, and neither
+Docker nor K3s is needed.
+
+--hash prints a SHA-256 fingerprint of the deployable configuration instead:
+the normalized spec kappal generates manifests from, serialized with sorted
+keys. Reformatting the compose file, reordering keys, or switching between
+list and map syntax leaves the hash unchanged; changing an image, port,
+variable or label changes it. CI can compare hashes between commits to detect
+config drift. Absolute host paths (bind mounts, secret files, build contexts)
+are part of the spec, so compare hashes computed from the same checkout path.
+
+Flags:
+  -o, --format <fmt>   Output format: yaml (default), json
+  --services           Print only the service names, one per line (a JSON array
+                       with -o json), sorted
+  --hash               Print a SHA-256 of the normalized configuration
+                       ({"hash": "..."} with -o json)
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>            Override project name (shown as the top-level name)
+
+Examples:
+  kappal config                          Print the merged compose YAML
+  kappal -f base.yaml -f prod.yaml config
+                                         Check how prod.yaml overrides base.yaml
+  kappal config --services               List service names
+  kappal config --hash                   Fingerprint the config for drift detection
+  kappal config -o json | jq '.services.web.environment'`,
+	RunE: runConfig,
+}
+
+func init() {
+	configCmd.Flags().StringVarP(&configFormat, "format", "o", "yaml", "Output format (yaml, json)")
+	configCmd.Flags().BoolVar(&configServices, "services", false, "Print only the service names")
+	configCmd.Flags().BoolVar(&configHash, "hash", false, "Print a SHA-256 of the normalized configuration")
+}
+
+func runConfig(cmd *cobra.Command, args []string) error {
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	if configHash {
+		if configServices {
+			return fmt.Errorf("--hash and --services cannot be used together")
+		}
+		return renderConfigHash(os.Stdout, project, configFormat)
+	}
+	return renderConfig(os.Stdout, project, configFormat, configServices)
+}
+
+// computeConfigHash returns the hex SHA-256 of the project's normalized
+// ComposeSpec. encoding/json sorts map keys and ToSpec orders slices
+// deterministically, so equal configs hash equally regardless of formatting.
+func computeConfigHash(project *types.Project) (string, error) {
+	data, err := json.Marshal(transform.NewTransformer(project).ToSpec())
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal compose spec: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// renderConfigHash writes the project's config hash in the given format.
+func renderConfigHash(out io.Writer, project *types.Project, format string) error {
+	if format != "yaml" && format != "json" {
+		return fmt.Errorf("invalid format %q (valid: yaml, json)", format)
+	}
+	hash, err := computeConfigHash(project)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]string{"hash": hash})
+	}
+	_, err = fmt.Fprintln(out, hash)
+	return err
+}
+
+// renderConfig writes the resolved project, or only its service names, in
+// the given format.
+func renderConfig(out io.Writer, project *types.Project, format string, servicesOnly bool) error {
+	if format != "yaml" && format != "json" {
+		return fmt.Errorf("invalid format %q (valid: yaml, json)", format)
+	}
+
+	if servicesOnly {
+		names := project.ServiceNames()
+		if format == "json" {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(names)
+		}
+		for _, name := range names {
+			if _, err := fmt.Fprintln(out, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var data []byte
+	var err error
+	if format == "json" {
+		data, err = json.MarshalIndent(project, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = project.MarshalYAML()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal compose project: %w", err)
+	}
+	_, err = out.Write(data)
+	return err
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+)
+
+func TestRenderConfig(t *testing.T) {
+	t.Setenv("KAPPAL_TEST_TAG", "1.27")
+	project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: nginx:${KAPPAL_TEST_TAG}
+  db:
+    image: postgres:16
+`), "demo")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+
+	t.Run("yaml shows interpolated values", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := renderConfig(&out, project, "yaml", false); err != nil {
+			t.Fatalf("renderConfig failed: %v", err)
+		}
+		if !strings.Contains(out.String(), "image: nginx:1.27") {
+			t.Errorf("expected interpolated image, got:\n%s", out.String())
+		}
+		if !strings.Contains(out.String(), "name: demo") {
+			t.Errorf("expected project name, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := renderConfig(&out, project, "json", false); err != nil {
+			t.Fatalf("renderConfig failed: %v", err)
+		}
+		var decoded struct {
+			Services map[string]struct {
+				Image string `json:"image"`
+			} `json:"services"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+			t.Fatalf("output is not valid JSON: %v", err)
+		}
+		if decoded.Services["web"].Image != "nginx:1.27" {
+			t.Errorf("expected web image nginx:1.27, got %q", decoded.Services["web"].Image)
+		}
+	})
+
+	t.Run("services lists sorted names", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := renderConfig(&out, project, "yaml", true); err != nil {
+			t.Fatalf("renderConfig failed: %v", err)
+		}
+		if out.String() != "db\nweb\n" {
+			t.Errorf("expected sorted service names, got %q", out.String())
+		}
+
+		out.Reset()
+		if err := renderConfig(&out, project, "json", true); err != nil {
+			t.Fatalf("renderConfig failed: %v", err)
+		}
+		var names []string
+		if err := json.Unmarshal(out.Bytes(), &names); err != nil || len(names) != 2 || names[0] != "db" {
+			t.Errorf("expected JSON array of service names, got %q (err %v)", out.String(), err)
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		if err := renderConfig(&bytes.Buffer{}, project, "toml", false); err == nil {
+			t.Error("expected error for invalid format")
+		}
+	})
+}
+
+func TestComputeConfigHash(t *testing.T) {
+	load := func(content string) string {
+		t.Helper()
+		project, err := compose.LoadFromContent([]byte(content), "demo")
+		if err != nil {
+			t.Fatalf("LoadFromContent failed: %v", err)
+		}
+		hash, err := computeConfigHash(project)
+		if err != nil {
+			t.Fatalf("computeConfigHash failed: %v", err)
+		}
+		return hash
+	}
+
+	base := load(`services:
+  web:
+    image: nginx:1.27
+    ports: ["8080:80"]
+    environment:
+      A: "1"
+      B: "2"
+  db:
+    image: postgres:16
+`)
+	// Same config: services and keys reordered, flow style, list-form environment
+	reformatted := load(`services:
+  db: {image: "postgres:16"}
+  web:
+    environment: [B=2, A=1]
+    ports:
+      - "8080:80"
+    image: nginx:1.27
+`)
+	changed := load(`services:
+  web:
+    image: nginx:1.28
+    ports: ["8080:80"]
+    environment:
+      A: "1"
+      B: "2"
+  db:
+    image: postgres:16
+`)
+
+	if len(base) != 64 {
+		t.Errorf("expected a hex SHA-256, got %q", base)
+	}
+	if reformatted != base {
+		t.Errorf("semantically equal compose files hashed differently: %s vs %s", base, reformatted)
+	}
+	if changed == base {
+		t.Error("changing the image should change the hash")
+	}
+}
+
+func TestRenderConfigHash(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: nginx
+`), "demo")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	hash, err := computeConfigHash(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := renderConfigHash(&out, project, "yaml"); err != nil {
+		t.Fatalf("renderConfigHash failed: %v", err)
+	}
+	if out.String() != hash+"\n" {
+		t.Errorf("text output = %q, want the bare hash", out.String())
+	}
+
+	out.Reset()
+	if err := renderConfigHash(&out, project, "json"); err != nil {
+		t.Fatalf("renderConfigHash failed: %v", err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil || got["hash"] != hash {
+		t.Errorf("json output = %q (%v), want hash %s", out.String(), err, hash)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/k8s"
+	"github.com/kappal-app/kappal/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var cpIndex int
+
+var cpCmd = &cobra.Command{
+	Use:   "cp SERVICE:SRC_PATH DEST_PATH | SRC_PATH SERVICE:DEST_PATH",
+	Short: "Copy files between a service container and the local filesystem",
+	Long: `Copy files or directories between a running service container and the local
+filesystem.
+
+This is the equivalent of 'docker compose cp'. Exactly one side must be a
+container path, written SERVICE:PATH. Container paths should be absolute.
+Files are streamed as a tar archive through the Kubernetes exec API, so the
+container image needs a tar binary (busybox/alpine/debian images have one;
+distroless and scratch images don't).
+
+The destination names the copy itself (as with 'kubectl cp'): copying
+./seed to web:/data/seed creates /data/seed, and its parent directory must
+already exist. File modes are preserved; ownership is not.
+
+Fails if K3s is not running (run 'kappal up' first) or the service has no
+running pod.
+
+Flags:
+  --index <n>          Replica index when the service has several pods (default 0)
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>            Override project name
+  --namespace <ns>     K8s namespace used at 'kappal up' (default: project name)
+
+Examples:
+  kappal cp ./seed.sql db:/tmp/seed.sql        Copy a file into the db container
+  kappal cp ./fixtures web:/app/fixtures       Copy a directory
+  kappal cp web:/var/log/app.log ./app.log     Copy a file out of the container
+  kappal cp --index 1 web:/tmp/dump ./dump     Copy from the second replica`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCp,
+}
+
+func init() {
+	cpCmd.Flags().IntVar(&cpIndex, "index", 0, "Index of the container if service has multiple replicas")
+}
+
+// copySpec is one side of a cp: a local path, or a path in a service's pod.
+type copySpec struct {
+	Service string // empty for a local path
+	Path    string
+}
+
+// parseCopySpec splits SERVICE:PATH. A colon only marks a container path when
+// the text before it names a compose service, so local paths like ./a:b work.
+func parseCopySpec(arg string, project *types.Project) copySpec {
+	if i := strings.Index(arg, ":"); i > 0 {
+		if _, ok := project.Services[arg[:i]]; ok {
+			return copySpec{Service: arg[:i], Path: arg[i+1:]}
+		}
+	}
+	return copySpec{Path: arg}
+}
+
+func runCp(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	src := parseCopySpec(args[0], project)
+	dest := parseCopySpec(args[1], project)
+	if (src.Service == "") == (dest.Service == "") {
+		return fmt.Errorf("exactly one of source and destination must be SERVICE:PATH")
+	}
+	if src.Path == "" || dest.Path == "" {
+		return fmt.Errorf("source and destination paths must not be empty")
+	}
+
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
+	workspaceDir := filepath.Join(projectDir, ".kappal")
+
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false, Namespace: ns})
+	if err != nil {
+		return fmt.Errorf("failed to discover state: %w", err)
+	}
+
+	if discovered.K3s.Status != "running" {
+		return fmt.Errorf("K3s not running (run 'kappal up' first)")
+	}
+
+	if discovered.Kubeconfig == "" {
+		return fmt.Errorf("kubeconfig not available (run 'kappal up' first)")
+	}
+
+	k8sClient, err := k8s.NewClient(discovered.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	opts := k8s.CopyOptions{Index: cpIndex}
+	if dest.Service != "" {
+		if _, err := os.Lstat(src.Path); err != nil {
+			return fmt.Errorf("failed to read %s: %w", src.Path, err)
+		}
+		return k8sClient.CopyToPod(ctx, ns, project.Name, dest.Service, src.Path, dest.Path, opts)
+	}
+	return k8sClient.CopyFromPod(ctx, ns, project.Name, src.Service, src.Path, dest.Path, opts)
+}
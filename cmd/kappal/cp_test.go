@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func TestParseCopySpec(t *testing.T) {
+	project := &types.Project{Services: types.Services{"web": {Name: "web"}}}
+
+	tests := []struct {
+		arg  string
+		want copySpec
+	}{
+		{arg: "web:/app/data", want: copySpec{Service: "web", Path: "/app/data"}},
+		{arg: "./local", want: copySpec{Path: "./local"}},
+		{arg: "notes:today.txt", want: copySpec{Path: "notes:today.txt"}},
+		{arg: ":/root", want: copySpec{Path: ":/root"}},
+	}
+	for _, tt := range tests {
+		if got := parseCopySpec(tt.arg, project); got != tt.want {
+			t.Errorf("parseCopySpec(%q) = %+v, want %+v", tt.arg, got, tt.want)
+		}
+	}
+}
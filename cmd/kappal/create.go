@@ -0,0 +1,69 @@
+package main
+
+import (
+	"github.com/kappal-app/kappal/pkg/k3s"
+	"github.com/kappal-app/kappal/pkg/transform"
+	"github.com/spf13/cobra"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create services without starting them",
+	Long: `Create the project's resources without starting any containers.
+
+This is the equivalent of 'docker compose create' and is the same as
+'kappal up --no-start': K3s is started and every manifest is applied, but
+Deployments get 0 replicas and Jobs are suspended. Use it to pre-provision
+volumes, secrets and images, then start quickly later.
+
+'kappal start' resumes the one-shot services (Jobs) and scales the
+Deployments to their deploy.replicas. The next 'kappal up' re-applies the
+normal manifests.
+
+Flags:
+  --build            Build images (from build.context in compose) first
+  --platform <p>     With --build, build for this platform (e.g. linux/amd64)
+  --no-cache         With --build, rebuild every layer instead of using the Docker build cache
+  --force            Re-apply manifests even if unchanged since the last up/create,
+                     and rebuild images with --build even if their context is unchanged
+  --progress-deadline <secs>
+                     progressDeadlineSeconds of every Deployment (default 600;
+                     see 'kappal up --help')
+  --expose-all       Bind published ports to 0.0.0.0 instead of 127.0.0.1
+  --registry-auth <path>
+                     Docker config.json with private registry credentials
+                     (default: ~/.docker/config.json; see 'kappal up --help')
+  --progress <mode>  Progress output: plain, tty, quiet
+  -o, --format <fmt> Output format: text (default) or json (see 'kappal up --help')
+  --label KEY=VALUE  Add a label to every generated resource (repeatable; see 'kappal up --help')
+  -f <path>          Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>          Override project name
+  --namespace <ns>   K8s namespace for resources (default: project name)
+
+Examples:
+  kappal create                 Provision everything, start nothing
+  kappal create --build         Build images and provision
+  kappal start                  Start the created services`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		upNoStart = true
+		return runUp(cmd, args)
+	},
+}
+
+func init() {
+	createCmd.Flags().BoolVar(&upBuild, "build", false, "Build images before creating containers")
+	createCmd.Flags().StringVar(&upPlatform, "platform", "", "Target platform for --build (e.g. linux/amd64); overrides the compose platform")
+	createCmd.Flags().BoolVar(&upNoCache, "no-cache", false, "Do not use the build cache when building images (requires --build)")
+	createCmd.Flags().IntVar(&upProgressDeadline, "progress-deadline", int(transform.DefaultProgressDeadlineSeconds), "Seconds a Deployment rollout may make no progress before it is reported failed")
+	createCmd.Flags().BoolVar(&upExposeAll, "expose-all", false, "Bind published ports to 0.0.0.0 instead of 127.0.0.1")
+	createCmd.Flags().StringVar(&upK3sMemory, "k3s-memory", "", "Memory limit for the K3s container (e.g. 4g); default unlimited or "+k3s.MemoryEnv)
+	createCmd.Flags().BoolVar(&upEnableIngress, "enable-ingress", false, "Run the K3s Traefik ingress controller and publish host ports 80/443 for kappal.io/ingress-host routing")
+	createCmd.Flags().BoolVar(&upEnableMetrics, "enable-metrics", false, "Run the K3s metrics-server so stats and HorizontalPodAutoscalers get CPU/memory metrics")
+	createCmd.Flags().StringVar(&upK3sCPUs, "k3s-cpus", "", "CPU limit for the K3s container (e.g. 2); default unlimited or "+k3s.CPUsEnv)
+	createCmd.Flags().BoolVar(&upForce, "force", false, "Re-apply manifests even if unchanged since the last up")
+	createCmd.Flags().StringVar(&upRegistryAuth, "registry-auth", "", "Docker config.json with private registry credentials (default: ~/.docker/config.json)")
+	createCmd.Flags().StringVar(&upProgress, "progress", "", "Progress output type (plain, tty, quiet); defaults to tty on a terminal, plain otherwise")
+	createCmd.Flags().StringArrayVar(&upLabels, "label", nil, "Add a label (KEY=VALUE) to every generated resource (repeatable)")
+	createCmd.Flags().StringVarP(&upFormat, "format", "o", "text", "Output format (text, json)")
+}
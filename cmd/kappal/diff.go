@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/kubectl"
+	"github.com/kappal-app/kappal/pkg/state"
+	"github.com/kappal-app/kappal/pkg/transform"
+	"github.com/kappal-app/kappal/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what 'kappal up' would change in the cluster",
+	Long: `Show what 'kappal up' would change, without applying anything.
+
+Regenerates the Kubernetes manifests from the compose file (as 'kappal up'
+does) and compares them with the live objects in the project's K3s cluster
+using 'kubectl diff' (server-side dry run). The output is a unified diff per
+changed object; objects that don't exist yet are shown as fully added.
+
+Exit status follows kubectl diff:
+  0  no changes ('kappal up' would not change any object)
+  1  error (e.g. K3s not running, invalid compose file)
+  2  there are changes
+
+K3s must be running (run 'kappal up' first); diff never starts it. Builds
+are not run, so image content changes under the same tag are not shown.
+Objects that 'up' would not touch, such as resources removed from the
+compose file, are not reported.
+
+Flags:
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>            Override project name
+  --namespace <ns>     K8s namespace used at 'kappal up' (default: project name)
+
+Examples:
+  kappal diff                        Preview changes before 'kappal up'
+  kappal diff && echo up-to-date     Script on the exit status
+  kappal -f prod.yaml diff | less    Review an override's effect`,
+	Args: cobra.NoArgs,
+	RunE: runDiff,
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
+	workspaceDir := filepath.Join(projectDir, ".kappal")
+
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false, Namespace: ns})
+	if err != nil {
+		return fmt.Errorf("failed to discover state: %w", err)
+	}
+
+	if discovered.K3s.Status != "running" {
+		return fmt.Errorf("K3s not running (run 'kappal up' first)")
+	}
+
+	if discovered.Kubeconfig == "" {
+		return fmt.Errorf("kubeconfig not available (run 'kappal up' first)")
+	}
+
+	ws, err := workspace.New(workspaceDir)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	transformer := transform.NewTransformer(project)
+	transformer.SetNamespace(ns)
+	transformer.SetHostDir(os.Getenv("KAPPAL_HOST_DIR"))
+	if err := transformer.Generate(ws); err != nil {
+		return fmt.Errorf("failed to generate workspace: %w", err)
+	}
+
+	diff, err := kubectl.Diff(ctx, ws, discovered.Kubeconfig, kubectl.DiffOpts{})
+	if err != nil {
+		return err
+	}
+
+	if err := writeDiff(os.Stdout, diff); err != nil {
+		// The exit status is the result; don't print an error or usage
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return err
+	}
+	return nil
+}
+
+// writeDiff prints diff and returns exitCodeError{2} when it is not empty.
+func writeDiff(out io.Writer, diff string) error {
+	if diff == "" {
+		return nil
+	}
+	if _, err := io.WriteString(out, diff); err != nil {
+		return err
+	}
+	return exitCodeError{code: 2}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWriteDiffExitCode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeDiff(&buf, ""); err != nil || buf.Len() != 0 {
+		t.Errorf("no changes: err = %v, output %q; want nil and nothing printed", err, buf.String())
+	}
+
+	diff := "--- a/Deployment\n+++ b/Deployment\n-  replicas: 1\n+  replicas: 2\n"
+	err := writeDiff(&buf, diff)
+	var exitErr exitCodeError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &exitErr) || exitErr.code != 2 {
+		t.Errorf("changes: err = %v, want exit code 2", err)
+	}
+	if buf.String() != diff {
+		t.Errorf("output = %q, want the diff", buf.String())
+	}
+}
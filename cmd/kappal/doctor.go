@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/transform"
+	"github.com/kappal-app/kappal/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the compose file for problems before 'kappal up'",
+	Long: `Run kappal's pre-flight checks on the compose file without deploying.
+
+Loads the compose project, runs the same compatibility analysis 'kappal up'
+prints as "Compatibility check: ..." lines, and renders the manifests into a
+temporary directory to catch errors 'up' would hit (missing or oversized
+secret/config files, two services publishing the same container port).
+Neither Docker nor K3s is needed and .kappal/ is not touched.
+
+Output has one line per finding:
+  note:   kappal handles it but behaves differently from Docker Compose,
+          or ignores a setting
+  error:  'kappal up' will fail until it is fixed
+followed by whether the kappal-init image will be needed (writable bind
+mounts, secret/config ownership, depends_on waits).
+
+Exits 0 when there are no errors (notes alone don't fail), 1 otherwise,
+including when the compose file itself doesn't load.
+
+Flags:
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>            Override project name
+  --namespace <ns>     K8s namespace to render into (default: project name)
+
+Examples:
+  kappal doctor                      Check before the first 'kappal up'
+  kappal doctor && kappal up -d      Only deploy a clean compose file`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
+	report := analyzeCompatibility(project)
+	if err := renderCheck(project, ns); err != nil {
+		report.Blocking = append(report.Blocking, err.Error())
+	}
+
+	writeDoctorReport(os.Stdout, project.Name, report)
+	if len(report.Blocking) > 0 {
+		return fmt.Errorf("found %d blocking issue(s)", len(report.Blocking))
+	}
+	return nil
+}
+
+// renderCheck generates the project's manifests into a throwaway workspace,
+// returning the error 'kappal up' would fail with.
+func renderCheck(project *types.Project, ns string) error {
+	tmpDir, err := os.MkdirTemp("", "kappal-doctor-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp workspace: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	ws, err := workspace.New(tmpDir)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+	transformer := transform.NewTransformer(project)
+	transformer.SetNamespace(ns)
+	return transformer.Generate(ws)
+}
+
+func writeDoctorReport(out io.Writer, projectName string, report compatibilityReport) {
+	_, _ = fmt.Fprintf(out, "Project: %s\n", projectName)
+	for _, note := range report.Notes {
+		_, _ = fmt.Fprintf(out, "note:  %s\n", note)
+	}
+	for _, issue := range report.Blocking {
+		_, _ = fmt.Fprintf(out, "error: %s\n", issue)
+	}
+	if report.NeedInitImage {
+		_, _ = fmt.Fprintln(out, "Init image: needed (kappal up loads it into K3s)")
+	} else {
+		_, _ = fmt.Fprintln(out, "Init image: not needed")
+	}
+	_, _ = fmt.Fprintf(out, "%d note(s), %d error(s)\n", len(report.Notes), len(report.Blocking))
+}
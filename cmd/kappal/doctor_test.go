@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func TestAnalyzeCompatibilityDuplicatePortsBlock(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"a": {Name: "a", Ports: []types.ServicePortConfig{{Target: 80, Published: "8080"}}},
+			"b": {Name: "b", Ports: []types.ServicePortConfig{{Target: 80, Published: "8081"}}},
+		},
+	}
+	report := analyzeCompatibility(project)
+	if len(report.Blocking) != 1 || !strings.Contains(report.Blocking[0], "container port 80/tcp") {
+		t.Errorf("expected a blocking duplicate port issue, got %v", report.Blocking)
+	}
+
+	project.Services["b"] = types.ServiceConfig{Name: "b", Ports: []types.ServicePortConfig{{Target: 81, Published: "8081"}}}
+	if report := analyzeCompatibility(project); len(report.Blocking) != 0 {
+		t.Errorf("distinct container ports should not block, got %v", report.Blocking)
+	}
+}
+
+func TestRenderCheckReportsGenerateErrors(t *testing.T) {
+	dir := t.TempDir()
+	project := &types.Project{
+		Name:       "test",
+		WorkingDir: dir,
+		Services:   types.Services{"app": {Name: "app", Image: "app"}},
+		Secrets:    types.Secrets{"key": {File: filepath.Join(dir, "missing.txt")}},
+	}
+	if err := renderCheck(project, "test"); err == nil || !strings.Contains(err.Error(), "missing.txt") {
+		t.Errorf("expected the missing secret file to be reported, got: %v", err)
+	}
+
+	project.Secrets = nil
+	if err := renderCheck(project, "test"); err != nil {
+		t.Errorf("a valid project should render, got: %v", err)
+	}
+}
+
+func TestWriteDoctorReport(t *testing.T) {
+	var buf bytes.Buffer
+	writeDoctorReport(&buf, "demo", compatibilityReport{
+		NeedInitImage: true,
+		Notes:         []string{"service \"app\" uses writable bind mounts"},
+		Blocking:      []string{"two services publish container port 80/tcp"},
+	})
+	out := buf.String()
+	for _, want := range []string{
+		"Project: demo",
+		"note:  service \"app\" uses writable bind mounts",
+		"error: two services publish container port 80/tcp",
+		"Init image: needed",
+		"1 note(s), 1 error(s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/docker"
 	"github.com/kappal-app/kappal/pkg/k3s"
 	"github.com/kappal-app/kappal/pkg/state"
 	"github.com/kappal-app/kappal/pkg/kubectl"
@@ -17,6 +21,8 @@ import (
 var (
 	downVolumes bool
 	downAll     bool
+	downRmi     string
+	downDryRun  bool
 )
 
 var downCmd = &cobra.Command{
@@ -25,13 +31,132 @@ var downCmd = &cobra.Command{
 	Long: `Stop and remove containers, networks, and K3s.
 
 By default, this stops all services and K3s. Volume data is preserved.
-Use --volumes/-v to also remove persistent volume data.`,
+Use --volumes/-v to also remove persistent volume data.
+
+Flags:
+  -v, --volumes    Remove named volumes and K3s data
+  --rmi <type>     Remove images used by services, from both the Docker host and
+                   K3s containerd (removal failures are warnings):
+                     local  images kappal built (<project>-<service>:latest)
+                     all    local images plus the images services pull
+                            (compose image: without build)
+  --dry-run        Print what down would delete (Deployments, Jobs and the
+                   other resource kinds, volumes, images, the K3s container)
+                   and whether volume data is kept, without deleting anything.
+                   Needs Docker to look up the K3s container; lists live
+                   Deployments and Jobs when K3s is running.
+
+Examples:
+  kappal down                Stop services and K3s, keep volumes
+  kappal down -v             Also remove volumes
+  kappal down --rmi local    Also remove images built by 'kappal up --build'
+  kappal down -v --dry-run   Check what -v would delete before running it`,
 	RunE: runDown,
 }
 
 func init() {
 	downCmd.Flags().BoolVarP(&downVolumes, "volumes", "v", false, "Remove named volumes and K3s data")
 	downCmd.Flags().BoolVar(&downAll, "all", false, "Remove everything including K3s (deprecated, now default)")
+	downCmd.Flags().StringVar(&downRmi, "rmi", "", "Remove images used by services (local, all)")
+	downCmd.Flags().BoolVar(&downDryRun, "dry-run", false, "Print what would be deleted without deleting anything")
+}
+
+// imagesToRemove selects the images 'down --rmi' removes. "local" selects the
+// <project>-<service>:latest images kappal builds; "all" adds the images that
+// services without a build context pull. Services with profiles are skipped,
+// as they are never deployed.
+func imagesToRemove(project *types.Project, mode string) ([]string, error) {
+	if mode != "local" && mode != "all" {
+		return nil, fmt.Errorf("invalid --rmi value %q (valid: local, all)", mode)
+	}
+
+	built := map[string]bool{}
+	seen := map[string]bool{}
+	var images []string
+	add := func(image string) {
+		if image != "" && !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+	for _, svc := range project.Services {
+		if !compose.IsActive(project, svc) || svc.Build == nil {
+			continue
+		}
+		add(fmt.Sprintf("%s-%s:latest", project.Name, svc.Name))
+		// The compose image of a built service names the local build, not a pull
+		built[svc.Image] = true
+	}
+	if mode == "all" {
+		for _, svc := range project.Services {
+			if !compose.IsActive(project, svc) || svc.Build != nil || built[svc.Image] {
+				continue
+			}
+			add(svc.Image)
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// downPlan describes, one line per action, what 'down' deletes given the
+// discovered state: the Kubernetes resources, whether volume data is kept,
+// images and the K3s container. Used by --dry-run.
+func downPlan(project *types.Project, ns string, discovered *state.State, volumes bool, images []string) []string {
+	var plan []string
+	var volumeNames []string
+	for name, vol := range project.Volumes {
+		if !bool(vol.External) {
+			volumeNames = append(volumeNames, name)
+		}
+	}
+	sort.Strings(volumeNames)
+
+	if discovered.Kubeconfig == "" {
+		plan = append(plan, "No kubeconfig found: Kubernetes resources are left as they are")
+	} else {
+		shared := ns != project.Name
+		kinds := append([]string(nil), kubectl.DeleteKinds...)
+		switch {
+		case volumes && !shared:
+			plan = append(plan, fmt.Sprintf("Delete namespace %s and everything in it", ns))
+		case shared:
+			if volumes {
+				kinds = append(kinds, "persistentvolumeclaims")
+			}
+			plan = append(plan, fmt.Sprintf("Delete %s labeled kappal.io/project=%s in namespace %s", strings.Join(kinds, ", "), project.Name, ns))
+		default:
+			plan = append(plan, fmt.Sprintf("Delete all %s in namespace %s", strings.Join(kinds, ", "), ns))
+		}
+		var workloads []string
+		for name, svc := range discovered.Services {
+			if svc.Kind != "" {
+				workloads = append(workloads, fmt.Sprintf("  %s/%s", svc.Kind, name))
+			}
+		}
+		sort.Strings(workloads)
+		plan = append(plan, workloads...)
+	}
+
+	if len(volumeNames) > 0 {
+		if volumes {
+			plan = append(plan, fmt.Sprintf("Delete volumes and their data: %s", strings.Join(volumeNames, ", ")))
+		} else {
+			plan = append(plan, fmt.Sprintf("Keep volumes: %s (use -v to delete them)", strings.Join(volumeNames, ", ")))
+		}
+	}
+	for _, image := range images {
+		plan = append(plan, fmt.Sprintf("Remove image %s", image))
+	}
+	if discovered.K3s.Status == "not found" {
+		plan = append(plan, "K3s container not found: nothing to stop")
+	} else {
+		plan = append(plan, fmt.Sprintf("Stop and remove K3s container %s (%s)", discovered.K3s.ContainerName, discovered.K3s.Status))
+	}
+	if volumes {
+		plan = append(plan, "Remove the K3s data volume, network and .kappal/runtime")
+	}
+	return plan
 }
 
 func runDown(cmd *cobra.Command, args []string) error {
@@ -42,36 +167,59 @@ func runDown(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePaths := composeFilePaths(projectDir)
 
-	resolvedName := resolveProjectName(projectName, filepath.Dir(composePath))
-	project, err := compose.Load(composePath, resolvedName)
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
 	if err != nil {
 		return fmt.Errorf("failed to load compose file: %w", err)
 	}
 
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
+	var images []string
+	if downRmi != "" {
+		if images, err = imagesToRemove(project, downRmi); err != nil {
+			return err
+		}
+	}
+
 	workspaceDir := filepath.Join(projectDir, ".kappal")
 	_, err = workspace.Open(workspaceDir)
 	if err != nil {
 		return fmt.Errorf("workspace not found (run 'kappal up' first): %w", err)
 	}
 
-	// Discover live state via labels (fast path — no K8s query)
-	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false})
+	// Discover live state via labels (fast path — no K8s query, except to
+	// list the workloads a dry run would delete)
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: downDryRun, Namespace: ns})
 	if err != nil {
 		return fmt.Errorf("failed to discover state: %w", err)
 	}
 
+	if downDryRun {
+		fmt.Printf("Dry run: 'kappal down' would, for %s:\n", project.Name)
+		for _, line := range downPlan(project, ns, discovered, downVolumes, images) {
+			fmt.Println(line)
+		}
+		return nil
+	}
+
 	// Delete resources via kubectl if kubeconfig available
 	// Continue cleanup even if kubectl delete fails (e.g. stale kubeconfig, K3s unreachable)
 	if discovered.Kubeconfig != "" {
-		if err := kubectl.Delete(ctx, project.Name, discovered.Kubeconfig, kubectl.DeleteOpts{
+		deleteOpts := kubectl.DeleteOpts{
 			AutoApprove:   true,
 			DeleteVolumes: downVolumes,
-		}); err != nil {
+		}
+		if ns != project.Name {
+			// Shared namespace: only remove this project's labeled resources
+			deleteOpts.Project = project.Name
+		}
+		if err := kubectl.Delete(ctx, ns, discovered.Kubeconfig, deleteOpts); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to delete resources (continuing cleanup): %v\n", err)
 		} else {
 			fmt.Printf("Stopped services for %s\n", project.Name)
@@ -85,6 +233,12 @@ func runDown(cmd *cobra.Command, args []string) error {
 	}
 	defer func() { _ = k3sManager.Close() }()
 
+	// Images must leave containerd while K3s is still running; its data
+	// volume (and image store) survives down without -v
+	if len(images) > 0 {
+		removeImages(ctx, k3sManager, images, discovered.K3s.Status == "running")
+	}
+
 	// Always stop and remove K3s on down (matches docker-compose behavior)
 	if err := k3sManager.Stop(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to stop K3s: %v\n", err)
@@ -104,3 +258,27 @@ func runDown(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// removeImages deletes images from K3s containerd (when K3s is running) and
+// the Docker host. Failures are reported as warnings so down still completes.
+func removeImages(ctx context.Context, k3sManager *k3s.Manager, images []string, k3sRunning bool) {
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot remove images: %v\n", err)
+		return
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	for _, image := range images {
+		if k3sRunning {
+			if err := k3sManager.RemoveImage(ctx, image); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+		if err := dockerClient.ImageRemove(ctx, image); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		fmt.Printf("Removed image %s\n", image)
+	}
+}
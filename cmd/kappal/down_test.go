@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/kubectl"
+	"github.com/kappal-app/kappal/pkg/state"
+)
+
+func TestImagesToRemove(t *testing.T) {
+	project := &types.Project{
+		Name: "demo",
+		Services: types.Services{
+			"web":    {Name: "web", Build: &types.BuildConfig{Context: "."}, Image: "registry.example.com/web:1.0"},
+			"worker": {Name: "worker", Image: "registry.example.com/web:1.0"},
+			"db":     {Name: "db", Image: "postgres:16"},
+			"cache":  {Name: "cache", Image: "redis:7"},
+			"debug":  {Name: "debug", Image: "busybox", Profiles: []string{"debug"}},
+			"tool":   {Name: "tool", Build: &types.BuildConfig{Context: "./tool"}, Profiles: []string{"tools"}},
+		},
+	}
+
+	local, err := imagesToRemove(project, "local")
+	if err != nil {
+		t.Fatalf("imagesToRemove(local) failed: %v", err)
+	}
+	if want := []string{"demo-web:latest"}; !reflect.DeepEqual(local, want) {
+		t.Errorf("local = %v, want %v", local, want)
+	}
+
+	all, err := imagesToRemove(project, "all")
+	if err != nil {
+		t.Fatalf("imagesToRemove(all) failed: %v", err)
+	}
+	// worker shares web's built image, so its compose image is not a pull
+	if want := []string{"demo-web:latest", "postgres:16", "redis:7"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("all = %v, want %v", all, want)
+	}
+
+	if _, err := imagesToRemove(project, "none"); err == nil {
+		t.Error("expected error for invalid --rmi value")
+	}
+}
+
+func TestDownPlan(t *testing.T) {
+	project := &types.Project{
+		Name: "demo",
+		Volumes: types.Volumes{
+			"pgdata": {Name: "pgdata"},
+			"cache":  {Name: "cache"},
+			"shared": {Name: "shared", External: true},
+		},
+	}
+	discovered := &state.State{
+		Kubeconfig: "/tmp/kubeconfig.yaml",
+		K3s:        state.K3sInfo{ContainerName: "kappal-demo-k3s", Status: "running"},
+		Services: map[string]*state.ServiceInfo{
+			"web":     {Name: "web", Kind: "Deployment"},
+			"migrate": {Name: "migrate", Kind: "Job"},
+		},
+	}
+	kinds := strings.Join(kubectl.DeleteKinds, ", ")
+
+	got := downPlan(project, "demo", discovered, false, []string{"demo-web:latest"})
+	want := []string{
+		"Delete all " + kinds + " in namespace demo",
+		"  Deployment/web",
+		"  Job/migrate",
+		"Keep volumes: cache, pgdata (use -v to delete them)",
+		"Remove image demo-web:latest",
+		"Stop and remove K3s container kappal-demo-k3s (running)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("downPlan =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	got = downPlan(project, "demo", discovered, true, nil)
+	want = []string{
+		"Delete namespace demo and everything in it",
+		"  Deployment/web",
+		"  Job/migrate",
+		"Delete volumes and their data: cache, pgdata",
+		"Stop and remove K3s container kappal-demo-k3s (running)",
+		"Remove the K3s data volume, network and .kappal/runtime",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("downPlan -v =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// A shared namespace is never deleted; only the project's labeled objects are
+	got = downPlan(project, "team", discovered, true, nil)
+	if want := "Delete " + kinds + ", persistentvolumeclaims labeled kappal.io/project=demo in namespace team"; got[0] != want {
+		t.Errorf("shared namespace plan starts with %q, want %q", got[0], want)
+	}
+
+	stopped := &state.State{K3s: state.K3sInfo{Status: "not found"}}
+	got = downPlan(project, "demo", stopped, false, nil)
+	want = []string{
+		"No kubeconfig found: Kubernetes resources are left as they are",
+		"Keep volumes: cache, pgdata (use -v to delete them)",
+		"K3s container not found: nothing to stop",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("downPlan without K3s =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
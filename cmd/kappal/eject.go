@@ -32,7 +32,7 @@ manifests.
 
 Flags:
   -o, --output <dir>   Output directory (default: "tanka")
-  -f <path>            Compose file path (default: docker-compose.yaml)
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
   -p <name>            Override project name
 
 Examples:
@@ -51,13 +51,10 @@ func runEject(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePaths := composeFilePaths(projectDir)
 
-	resolvedName := resolveProjectName(projectName, filepath.Dir(composePath))
-	project, err := compose.Load(composePath, resolvedName)
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
 	if err != nil {
 		return fmt.Errorf("failed to load compose file: %w", err)
 	}
@@ -74,6 +71,7 @@ func runEject(cmd *cobra.Command, args []string) error {
 	}
 
 	transformer := transform.NewTransformer(project)
+	transformer.SetHostDir(os.Getenv("KAPPAL_HOST_DIR"))
 	if err := transformer.GenerateStandalone(ws); err != nil {
 		return fmt.Errorf("failed to generate workspace: %w", err)
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/kappal-app/kappal/pkg/compose"
 	"github.com/kappal-app/kappal/pkg/k8s"
@@ -16,6 +17,7 @@ var (
 	execInteractive bool
 	execTTY         bool
 	execIndex       int
+	execEnv         []string
 )
 
 var execCmd = &cobra.Command{
@@ -30,7 +32,16 @@ Examples:
   kappal exec web sh                      # Start a shell in web service
   kappal exec -it web bash                # Start interactive bash
   kappal exec web wget -O - http://api    # Run wget in web container
-  kappal exec --index 1 web ps aux        # Run in second replica`,
+  kappal exec --index 1 web ps aux        # Run in second replica
+  kappal exec -e DEBUG=1 web ./manage.py  # Set an env var for the command
+
+Flags:
+  -i, --interactive    Keep STDIN open
+  -t, --tty            Allocate a pseudo-TTY
+  --index <n>          Replica index when the service has several pods (default 0)
+  -e, --env KEY=VALUE  Set an environment variable for the command (repeatable).
+                       The K8s exec API has no env field, so the command is run as
+                       "env KEY=VALUE ... COMMAND"; the container needs an env binary.`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: runExec,
 }
@@ -39,6 +50,7 @@ func init() {
 	execCmd.Flags().BoolVarP(&execInteractive, "interactive", "i", false, "Keep STDIN open")
 	execCmd.Flags().BoolVarP(&execTTY, "tty", "t", false, "Allocate a pseudo-TTY")
 	execCmd.Flags().IntVar(&execIndex, "index", 0, "Index of the container if service has multiple replicas")
+	execCmd.Flags().StringArrayVarP(&execEnv, "env", "e", nil, "Set environment variables (KEY=VALUE, repeatable)")
 	// Disable interspersed flags so flags after SERVICE are passed to the command
 	// This allows: kappal exec app sh -c 'echo hello' (without needing --)
 	execCmd.Flags().SetInterspersed(false)
@@ -48,24 +60,29 @@ func runExec(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
 	serviceName := args[0]
-	command := args[1:]
+	command, err := wrapWithEnv(execEnv, args[1:])
+	if err != nil {
+		return err
+	}
 
 	projectDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePaths := composeFilePaths(projectDir)
 
-	resolvedName := resolveProjectName(projectName, filepath.Dir(composePath))
-	project, err := compose.Load(composePath, resolvedName)
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
 	if err != nil {
 		return fmt.Errorf("failed to load compose file: %w", err)
 	}
 
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
 	// Verify service exists in compose file
 	found := false
 	for _, svc := range project.Services {
@@ -81,7 +98,7 @@ func runExec(cmd *cobra.Command, args []string) error {
 	workspaceDir := filepath.Join(projectDir, ".kappal")
 
 	// Discover live state via labels (fast path — no K8s query needed)
-	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false})
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false, Namespace: ns})
 	if err != nil {
 		return fmt.Errorf("failed to discover state: %w", err)
 	}
@@ -120,5 +137,21 @@ func runExec(cmd *cobra.Command, args []string) error {
 	}
 
 	// Execute command in the service's pod
-	return k8sClient.Exec(ctx, project.Name, serviceName, command, opts)
+	return k8sClient.Exec(ctx, ns, project.Name, serviceName, command, opts)
+}
+
+// wrapWithEnv prefixes command with "env KEY=VALUE ..." for each -e entry,
+// since the K8s exec subresource cannot set environment variables itself.
+func wrapWithEnv(env []string, command []string) ([]string, error) {
+	if len(env) == 0 {
+		return command, nil
+	}
+	wrapped := []string{"env"}
+	for _, e := range env {
+		if strings.Index(e, "=") <= 0 {
+			return nil, fmt.Errorf("invalid environment variable %q: expected KEY=VALUE", e)
+		}
+		wrapped = append(wrapped, e)
+	}
+	return append(wrapped, command...), nil
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWrapWithEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     []string
+		command []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "no env leaves command unchanged",
+			command: []string{"sh", "-c", "echo hi"},
+			want:    []string{"sh", "-c", "echo hi"},
+		},
+		{
+			name:    "env prefixes command in order",
+			env:     []string{"DEBUG=1", "MODE=a=b"},
+			command: []string{"./manage.py", "migrate"},
+			want:    []string{"env", "DEBUG=1", "MODE=a=b", "./manage.py", "migrate"},
+		},
+		{
+			name:    "empty value is allowed",
+			env:     []string{"EMPTY="},
+			command: []string{"printenv"},
+			want:    []string{"env", "EMPTY=", "printenv"},
+		},
+		{name: "missing equals", env: []string{"DEBUG"}, command: []string{"sh"}, wantErr: true},
+		{name: "missing key", env: []string{"=1"}, command: []string{"sh"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := wrapWithEnv(tt.env, tt.command)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/kappal-app/kappal/pkg/compose"
 	"github.com/kappal-app/kappal/pkg/state"
 	"github.com/spf13/cobra"
@@ -25,7 +26,9 @@ the output self-documenting for AI tools and automation.
 
 Output structure:
   _schema        Map of field path → human-readable description
-  project        Compose project name (used as K8s namespace)
+  project        Compose project name
+  namespace      K8s namespace holding the project's resources (project name unless --namespace is set)
+  namespace_status  Live namespace phase (Active, Terminating, NotFound) and blocking conditions
   k3s            K3s container state: container name, status, network
   services[]     Array of services with kind, image, status, replicas, ports, pods
 
@@ -33,30 +36,38 @@ If K3s is not running, services will be an empty array. If K3s is running but th
 API is unreachable, services are listed from the compose file with status "unavailable".
 
 Flags:
-  -f <path>      Compose file path (default: docker-compose.yaml)
+  -f <path>      Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
   -p <name>      Override project name
+  --namespace    K8s namespace to inspect (default: project name)
 
 Examples:
   kappal inspect                          Full project state
   kappal inspect | jq '.services[].name'  List service names
   kappal inspect | jq '.services[] | select(.status=="running") | .ports[].host'
                                           Get host ports of running services
-  kappal inspect | jq '.k3s.status'       Check if K3s is running`,
+  kappal inspect | jq '.k3s.status'       Check if K3s is running
+  kappal inspect | jq '.namespace_status' Check for a namespace stuck Terminating`,
 	RunE: runInspect,
 }
 
 // inspectOutput types for JSON serialization
 type inspectResult struct {
-	Schema   map[string]string `json:"_schema"`
-	Project  string            `json:"project"`
-	K3s      inspectK3s        `json:"k3s"`
-	Services []inspectService  `json:"services"`
+	Schema          map[string]string       `json:"_schema"`
+	Project         string                  `json:"project"`
+	Namespace       string                  `json:"namespace"`
+	NamespaceStatus *inspectNamespaceStatus `json:"namespace_status,omitempty"`
+	K3s             inspectK3s              `json:"k3s"`
+	Services        []inspectService        `json:"services"`
 }
 
 // inspectSchema describes every field in the inspect JSON output.
 // Embedded as _schema so the output is self-documenting for AI tools.
 var inspectSchema = map[string]string{
-	"project":                      "Compose project name, derived from directory name or -p flag. Also used as the K8s namespace.",
+	"project":                      "Compose project name, derived from directory name or -p flag. Used as the kappal.io/project label on all resources.",
+	"namespace":                    "K8s namespace holding the project's resources. Equals the project name unless --namespace is set.",
+	"namespace_status":             "Live state of the namespace from the K8s API. Omitted when K3s is not running or the API is unreachable.",
+	"namespace_status.phase":       "Namespace lifecycle phase. Values: 'Active', 'Terminating' (being deleted; a namespace stuck here after 'kappal down' blocks the next 'kappal up'), 'NotFound' (not created yet or fully deleted).",
+	"namespace_status.conditions":  "Namespace conditions that are true, as 'Type: message' (e.g. 'NamespaceFinalizersRemaining: ...'). Explains why a Terminating namespace is stuck; empty when healthy.",
 	"k3s.container":                "Docker container name running this project's K3s instance (format: kappal-<project>-k3s).",
 	"k3s.status":                   "K3s container state. Values: 'running', 'stopped', 'not found'.",
 	"k3s.network":                  "Docker bridge network isolating this project (format: kappal-<project>-net).",
@@ -84,6 +95,11 @@ var inspectSchema = map[string]string{
 	"services[].pods[].ip":                "Pod's cluster-internal IP address on the K3s overlay network.",
 }
 
+type inspectNamespaceStatus struct {
+	Phase      string   `json:"phase"`
+	Conditions []string `json:"conditions"`
+}
+
 type inspectK3s struct {
 	Container string `json:"container"`
 	Status    string `json:"status"`
@@ -134,28 +150,31 @@ func runInspect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePaths := composeFilePaths(projectDir)
 
-	resolvedName := resolveProjectName(projectName, filepath.Dir(composePath))
-	project, err := compose.Load(composePath, resolvedName)
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
 	if err != nil {
 		return fmt.Errorf("failed to load compose file: %w", err)
 	}
 
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
 	workspaceDir := filepath.Join(projectDir, ".kappal")
 
 	// Discover live state via labels
-	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: true})
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: true, Namespace: ns})
 	if err != nil {
 		return fmt.Errorf("failed to discover state: %w", err)
 	}
 
 	result := inspectResult{
-		Schema:  inspectSchema,
-		Project: project.Name,
+		Schema:    inspectSchema,
+		Project:   project.Name,
+		Namespace: discovered.Namespace,
 		K3s: inspectK3s{
 			Container: discovered.K3s.ContainerName,
 			Status:    discovered.K3s.Status,
@@ -168,7 +187,28 @@ func runInspect(cmd *cobra.Command, args []string) error {
 		return outputJSON(result)
 	}
 
-	// Merge compose definitions with discovered K8s state
+	result.NamespaceStatus = inspectNamespace(discovered)
+	result.Services = inspectServices(discovered, project)
+	return outputJSON(result)
+}
+
+// inspectNamespace returns the namespace's live status, or nil if K8s wasn't
+// queried. Conditions are never nil, so the JSON is always an array.
+func inspectNamespace(discovered *state.State) *inspectNamespaceStatus {
+	if discovered.NamespacePhase == "" {
+		return nil
+	}
+	conditions := discovered.NamespaceConditions
+	if conditions == nil {
+		conditions = []string{}
+	}
+	return &inspectNamespaceStatus{Phase: discovered.NamespacePhase, Conditions: conditions}
+}
+
+// inspectServices merges compose definitions with discovered K8s state into
+// the inspect service model. Never returns nil, so the JSON is always an array.
+func inspectServices(discovered *state.State, project *types.Project) []inspectService {
+	services := []inspectService{}
 	merged := state.MergeCompose(discovered, project)
 	for _, svc := range merged {
 		iSvc := inspectService{
@@ -200,14 +240,9 @@ func runInspect(cmd *cobra.Command, args []string) error {
 				StartPeriod: svc.HealthCheck.StartPeriod,
 			}
 		}
-		result.Services = append(result.Services, iSvc)
+		services = append(services, iSvc)
 	}
-
-	if result.Services == nil {
-		result.Services = []inspectService{}
-	}
-
-	return outputJSON(result)
+	return services
 }
 
 // convertPods converts state.PodInfo to the inspect-specific inspectPod type.
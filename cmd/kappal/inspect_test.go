@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/kappal-app/kappal/pkg/state"
 )
 
 // TestInspectSchemaCompleteness verifies that every JSON field in the inspect
@@ -101,3 +103,26 @@ func collectJSONPaths(t reflect.Type, prefix string) []string {
 	}
 	return paths
 }
+
+func TestInspectNamespace(t *testing.T) {
+	if got := inspectNamespace(&state.State{}); got != nil {
+		t.Errorf("expected no namespace_status when K8s wasn't queried, got %+v", got)
+	}
+
+	got := inspectNamespace(&state.State{
+		NamespacePhase:      "Terminating",
+		NamespaceConditions: []string{"NamespaceFinalizersRemaining: finalizers remaining"},
+	})
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"phase":"Terminating","conditions":["NamespaceFinalizersRemaining: finalizers remaining"]}`; string(data) != want {
+		t.Errorf("namespace_status = %s, want %s", data, want)
+	}
+
+	data, _ = json.Marshal(inspectNamespace(&state.State{NamespacePhase: "Active"}))
+	if want := `{"phase":"Active","conditions":[]}`; string(data) != want {
+		t.Errorf("namespace_status = %s, want %s", data, want)
+	}
+}
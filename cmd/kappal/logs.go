@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/kappal-app/kappal/pkg/compose"
 	"github.com/kappal-app/kappal/pkg/k8s"
@@ -13,8 +14,12 @@ import (
 )
 
 var (
-	logsFollow bool
-	logsTail   int
+	logsFollow   bool
+	logsTail     int
+	logsHead     int
+	logsSince    string
+	logsUntil    string
+	logsSelector string
 )
 
 var logsCmd = &cobra.Command{
@@ -28,49 +33,120 @@ time.
 
 Without --follow, prints the last N lines (default 100) and exits (snapshot mode).
 With --follow, streams new log lines continuously until interrupted (Ctrl+C).
+Transient API errors (e.g. K3s restarting) are retried with backoff for up to
+about 15 seconds; a followed stream that drops reconnects from where it broke.
+An error line is printed for a pod only when retries run out or the pod is gone.
+
+--head N prints the first N lines of each pod's log instead (e.g. startup
+banners). Kubernetes has no head option, so kappal reads each log from the
+start and closes the stream after N lines. --head cannot be combined with --tail.
+
+--since and --until bound the output by time. Both accept a duration relative to
+now (e.g. 10m, 1h30m) or an RFC3339 timestamp (e.g. 2024-05-01T10:00:00Z).
+Kubernetes has no server-side "until", so kappal requests timestamped lines and
+stops each pod's stream at the first line logged after --until. With --follow,
+streaming ends once the --until time has passed.
+
+--selector picks pods by Kubernetes label selector instead of service name,
+e.g. every service on a compose network (kappal.io/network=backend) or with a
+compose label (labels are copied to pods). It is always narrowed to this
+project's pods, and each line is still prefixed with the pod's service.
 
 Flags:
   --follow         Stream logs continuously (like tail -f)
   --tail <n>       Number of historical lines to show (default: 100)
-  -f <path>        Compose file path (default: docker-compose.yaml)
+  --head <n>       Show only the first n lines of each pod's log
+  --since <t>      Only show lines logged at or after t (duration or RFC3339)
+  --until <t>      Stop at the first line logged after t (duration or RFC3339)
+  -l, --selector <s>
+                   Show pods matching a label selector (e.g.
+                   kappal.io/network=backend or "tier in (api,worker)");
+                   cannot be combined with SERVICE arguments
+  -f <path>        Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
   -p <name>        Override project name
+  --namespace <ns> K8s namespace used at 'kappal up' (default: project name)
 
 Examples:
   kappal logs                All services, last 100 lines
   kappal logs api            Logs from the api service only
   kappal logs --follow api   Stream api logs continuously
-  kappal logs --tail 20      Last 20 lines from all services`,
+  kappal logs --tail 20      Last 20 lines from all services
+  kappal logs --head 10 api  First 10 lines of each api pod (startup banner)
+  kappal logs -l kappal.io/network=backend --follow
+                             Stream every service on the backend network
+  kappal logs --since 1h --until 30m api
+                             api logs from between one hour and 30 minutes ago
+  kappal logs --since 2024-05-01T10:00:00Z --until 2024-05-01T11:00:00Z
+                             Logs from a fixed window`,
 	RunE: runLogs,
 }
 
 func init() {
 	logsCmd.Flags().BoolVar(&logsFollow, "follow", false, "Follow log output")
 	logsCmd.Flags().IntVar(&logsTail, "tail", 100, "Number of lines to show from the end")
+	logsCmd.Flags().IntVar(&logsHead, "head", 0, "Number of lines to show from the beginning")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "Show logs since a duration ago (e.g. 10m) or RFC3339 timestamp")
+	logsCmd.Flags().StringVarP(&logsSelector, "selector", "l", "", "Show logs of pods matching a label selector (e.g. kappal.io/network=backend)")
+	logsCmd.Flags().StringVar(&logsUntil, "until", "", "Show logs until a duration ago (e.g. 10m) or RFC3339 timestamp")
+}
+
+// parseLogTime parses a --since/--until value: a duration before now
+// (e.g. "10m") or an RFC3339 timestamp. Empty returns the zero time.
+func parseLogTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use a duration (e.g. 10m) or RFC3339 timestamp", value)
+	}
+	return t, nil
 }
 
 func runLogs(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	if logsHead < 0 {
+		return fmt.Errorf("--head must not be negative")
+	}
+	if logsHead > 0 && cmd.Flags().Changed("tail") {
+		return fmt.Errorf("--head and --tail cannot be used together")
+	}
+	if logsSelector != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("--selector cannot be combined with SERVICE arguments")
+		}
+		// Fail on a bad selector before connecting to K3s
+		if _, err := k8s.ProjectSelector("", logsSelector); err != nil {
+			return err
+		}
+	}
+
 	projectDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePaths := composeFilePaths(projectDir)
 
-	resolvedName := resolveProjectName(projectName, filepath.Dir(composePath))
-	project, err := compose.Load(composePath, resolvedName)
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
 	if err != nil {
 		return fmt.Errorf("failed to load compose file: %w", err)
 	}
 
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
 	workspaceDir := filepath.Join(projectDir, ".kappal")
 
 	// Discover live state via labels (fast path — no K8s query needed)
-	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false})
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false, Namespace: ns})
 	if err != nil {
 		return fmt.Errorf("failed to discover state: %w", err)
 	}
@@ -89,11 +165,28 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create k8s client: %w", err)
 	}
 
+	now := time.Now()
+	since, err := parseLogTime(logsSince, now)
+	if err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	until, err := parseLogTime(logsUntil, now)
+	if err != nil {
+		return fmt.Errorf("--until: %w", err)
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return fmt.Errorf("--until must not be before --since")
+	}
+
 	opts := k8s.LogOptions{
 		Follow:    logsFollow,
 		TailLines: int64(logsTail),
+		HeadLines: int64(logsHead),
 		Services:  args,
+		Selector:  logsSelector,
+		Since:     since,
+		Until:     until,
 	}
 
-	return k8sClient.StreamLogs(ctx, project, opts, os.Stdout)
+	return k8sClient.StreamLogs(ctx, ns, project, opts, os.Stdout)
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLogTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseLogTime("30m", now)
+	if err != nil || !got.Equal(now.Add(-30*time.Minute)) {
+		t.Errorf("parseLogTime(30m) = %v, %v", got, err)
+	}
+
+	got, err = parseLogTime("2024-05-01T10:00:00Z", now)
+	if err != nil || !got.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseLogTime(RFC3339) = %v, %v", got, err)
+	}
+
+	got, err = parseLogTime("", now)
+	if err != nil || !got.IsZero() {
+		t.Errorf("parseLogTime(empty) = %v, %v; want zero time", got, err)
+	}
+
+	if _, err := parseLogTime("yesterday", now); err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
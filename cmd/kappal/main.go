@@ -1,12 +1,28 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 )
 
+// exitCodeError makes the process exit with code without printing an error,
+// for commands whose exit status carries a result (e.g. diff exits 2 when
+// there are changes).
+type exitCodeError struct {
+	code int
+}
+
+func (e exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var portProtocol string
+
+var portCmd = &cobra.Command{
+	Use:   "port SERVICE PRIVATE_PORT",
+	Short: "Print the host address a service port is published on",
+	Long: `Print the public host:port bound to a service's container port.
+
+This is the equivalent of 'docker compose port'. PRIVATE_PORT is the container
+(target) port from the compose ports entry, e.g. 80 for "8082:80". kappal reads
+the Docker port bindings of the K3s container, so the answer reflects what is
+actually bound right now, including the host IP of the binding. Output is a
+single line such as:
+
+  0.0.0.0:8082
+  127.0.0.1:8082     (binding restricted to loopback)
+
+Exits non-zero when the service does not publish PRIVATE_PORT with the given
+protocol, when the binding is missing from the K3s container (run 'kappal up'),
+or when K3s is not running.
+
+Flags:
+  --protocol <proto>   Port protocol: tcp (default), udp
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>            Override project name
+
+Examples:
+  kappal port web 80                   Print e.g. 0.0.0.0:8082
+  kappal port dns 53 --protocol udp    Look up a UDP port
+  curl "http://$(kappal port web 80)/health"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPort,
+}
+
+func init() {
+	portCmd.Flags().StringVar(&portProtocol, "protocol", "tcp", "Port protocol (tcp, udp)")
+}
+
+func runPort(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	serviceName := args[0]
+	privatePort, err := strconv.ParseUint(args[1], 10, 16)
+	if err != nil || privatePort == 0 {
+		return fmt.Errorf("invalid port %q", args[1])
+	}
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	workspaceDir := filepath.Join(projectDir, ".kappal")
+
+	// Port bindings live on the K3s container; no K8s query needed
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false})
+	if err != nil {
+		return fmt.Errorf("failed to discover state: %w", err)
+	}
+
+	if discovered.K3s.Status != "running" {
+		return fmt.Errorf("K3s not running (run 'kappal up' first)")
+	}
+
+	addr, err := publishedAddress(project, serviceName, uint32(privatePort), portProtocol, discovered.PortMap, discovered.PortHostIPs)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(addr)
+	return nil
+}
+
+// publishedAddress returns the host address ("ip:port") bound to a service's
+// container port. portMap and hostIPs are keyed by "containerPort/proto", as
+// in state.State; an empty host IP means Docker bound all interfaces.
+func publishedAddress(project *types.Project, serviceName string, privatePort uint32, protocol string, portMap map[string]int, hostIPs map[string]string) (string, error) {
+	if protocol != "tcp" && protocol != "udp" {
+		return "", fmt.Errorf("invalid protocol %q (valid: tcp, udp)", protocol)
+	}
+
+	svc, ok := project.Services[serviceName]
+	if !ok {
+		return "", fmt.Errorf("service %q not found in compose file", serviceName)
+	}
+
+	declared := false
+	for _, p := range svc.Ports {
+		proto := p.Protocol
+		if proto == "" {
+			proto = "tcp"
+		}
+		if p.Target == privatePort && proto == protocol {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return "", fmt.Errorf("service %q does not publish port %d/%s", serviceName, privatePort, protocol)
+	}
+
+	key := fmt.Sprintf("%d/%s", privatePort, protocol)
+	hostPort, ok := portMap[key]
+	if !ok || hostPort == 0 {
+		return "", fmt.Errorf("port %d/%s of service %q is not bound on the K3s container (run 'kappal up')", privatePort, protocol, serviceName)
+	}
+	hostIP := hostIPs[key]
+	if hostIP == "" {
+		hostIP = "0.0.0.0"
+	}
+	return net.JoinHostPort(hostIP, strconv.Itoa(hostPort)), nil
+}
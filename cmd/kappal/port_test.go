@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func TestPublishedHostPort(t *testing.T) {
+	project := &types.Project{
+		Name: "demo",
+		Services: types.Services{
+			"web": {Name: "web", Ports: []types.ServicePortConfig{{Target: 80, Published: "8082"}}},
+			"dns": {Name: "dns", Ports: []types.ServicePortConfig{{Target: 53, Published: "5353", Protocol: "udp"}}},
+			"api": {Name: "api", Ports: []types.ServicePortConfig{{Target: 9000, Published: "9000"}}},
+		},
+	}
+	portMap := map[string]int{"80/tcp": 8082, "53/udp": 5353}
+	hostIPs := map[string]string{"80/tcp": "127.0.0.1", "53/udp": ""}
+
+	tests := []struct {
+		name     string
+		service  string
+		port     uint32
+		protocol string
+		want     string
+		wantErr  bool
+	}{
+		{name: "loopback binding", service: "web", port: 80, protocol: "tcp", want: "127.0.0.1:8082"},
+		{name: "all interfaces", service: "dns", port: 53, protocol: "udp", want: "0.0.0.0:5353"},
+		{name: "wrong protocol", service: "dns", port: 53, protocol: "tcp", wantErr: true},
+		{name: "port not declared", service: "web", port: 443, protocol: "tcp", wantErr: true},
+		{name: "declared but not bound", service: "api", port: 9000, protocol: "tcp", wantErr: true},
+		{name: "unknown service", service: "nope", port: 80, protocol: "tcp", wantErr: true},
+		{name: "invalid protocol", service: "web", port: 80, protocol: "sctp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := publishedAddress(project, tt.service, tt.port, tt.protocol, portMap, hostIPs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
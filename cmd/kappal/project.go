@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/kappal-app/kappal/pkg/compose"
 )
 
 var nonDNSChars = regexp.MustCompile(`[^a-z0-9-]`)
@@ -67,6 +69,30 @@ func buildProjectName(composeDir string) string {
 	return base + "-" + dirHash(absDir)
 }
 
+// resolveComposePaths makes each -f path absolute relative to projectDir,
+// preserving order so later files override earlier ones when merged.
+func resolveComposePaths(files []string, projectDir string) []string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(projectDir, f)
+		}
+		paths = append(paths, f)
+	}
+	return paths
+}
+
+// composeFilePaths returns the compose files for this invocation. Without
+// explicit -f flags, a docker-compose.override.yaml next to the default file
+// is layered on top, as Docker Compose does.
+func composeFilePaths(projectDir string) []string {
+	paths := resolveComposePaths(composeFiles, projectDir)
+	if !rootCmd.PersistentFlags().Changed("file") {
+		paths = compose.WithOverrideFile(paths)
+	}
+	return paths
+}
+
 // resolveProjectName determines the project name:
 //  1. If the user supplied -p, return that unchanged.
 //  2. Otherwise, build "<sanitised-base>-<8-char-hash>" from the compose directory.
@@ -76,3 +102,16 @@ func resolveProjectName(userProjectName string, composeDir string) string {
 	}
 	return buildProjectName(composeDir)
 }
+
+// resolveNamespace determines the K8s namespace for a project:
+//  1. If the user supplied --namespace, validate and return it.
+//  2. Otherwise, use the project name.
+func resolveNamespace(userNamespace, projectName string) (string, error) {
+	if userNamespace == "" {
+		return projectName, nil
+	}
+	if len(userNamespace) > 63 || sanitizeDNS1123Label(userNamespace) != userNamespace {
+		return "", fmt.Errorf("invalid namespace %q: must be a lowercase RFC 1123 label (a-z, 0-9, '-', at most 63 characters)", userNamespace)
+	}
+	return userNamespace, nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -71,6 +72,24 @@ func TestResolveProjectNameExplicit(t *testing.T) {
 	}
 }
 
+func TestResolveNamespace(t *testing.T) {
+	got, err := resolveNamespace("", "myapp-1a2b3c4d")
+	if err != nil || got != "myapp-1a2b3c4d" {
+		t.Errorf("resolveNamespace without override = %q, %v; want project name", got, err)
+	}
+
+	got, err = resolveNamespace("team-a", "myapp-1a2b3c4d")
+	if err != nil || got != "team-a" {
+		t.Errorf("resolveNamespace with override = %q, %v; want %q", got, err, "team-a")
+	}
+
+	for _, invalid := range []string{"Team_A", "-leading", "has.dot", strings.Repeat("a", 64)} {
+		if _, err := resolveNamespace(invalid, "myapp"); err == nil {
+			t.Errorf("resolveNamespace(%q) should fail", invalid)
+		}
+	}
+}
+
 func TestBuildProjectNameFormat(t *testing.T) {
 	got := buildProjectName("/home/user/myapp")
 	pattern := regexp.MustCompile(`^[a-z0-9][a-z0-9-]*-[0-9a-f]{8}$`)
@@ -151,3 +170,16 @@ func TestBuildProjectNameSymlinkResilience(t *testing.T) {
 		t.Errorf("symlink divergence: real=%q symlink=%q", fromReal, fromLink)
 	}
 }
+
+func TestResolveComposePaths(t *testing.T) {
+	got := resolveComposePaths([]string{"docker-compose.yaml", "/abs/override.yaml", "sub/prod.yaml"}, "/work")
+	want := []string{"/work/docker-compose.yaml", "/abs/override.yaml", "/work/sub/prod.yaml"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("path %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
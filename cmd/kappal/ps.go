@@ -35,8 +35,9 @@ For richer machine-readable output with replicas, pod IPs, and K3s state, use
 
 Flags:
   -o, --format <fmt>   Output format: table (default), json, yaml
-  -f <path>            Compose file path (default: docker-compose.yaml)
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
   -p <name>            Override project name
+  --namespace <ns>     K8s namespace used at 'kappal up' (default: project name)
 
 Examples:
   kappal ps                  Table view of all services
@@ -65,21 +66,23 @@ func runPs(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePaths := composeFilePaths(projectDir)
 
-	resolvedName := resolveProjectName(projectName, filepath.Dir(composePath))
-	project, err := compose.Load(composePath, resolvedName)
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
 	if err != nil {
 		return fmt.Errorf("failed to load compose file: %w", err)
 	}
 
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
 	workspaceDir := filepath.Join(projectDir, ".kappal")
 
 	// Discover live state via labels
-	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: true})
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: true, Namespace: ns})
 	if err != nil {
 		return fmt.Errorf("failed to discover state: %w", err)
 	}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kappal-app/kappal/pkg/k8s"
+	"github.com/spf13/cobra"
+)
+
+var restartTimeout int
+
+var restartCmd = &cobra.Command{
+	Use:   "restart [SERVICE...]",
+	Short: "Restart services and wait for the rollout",
+	Long: `Restart running services without re-applying the compose file.
+
+Each service's Deployment gets a rolling restart (like 'kubectl rollout
+restart'): new pods are started and old ones removed once the new ones are
+ready. kappal then waits for every rollout to complete. If a rollout doesn't
+finish within --timeout, or exceeds its progress deadline, the error names the
+failing pods and why (e.g. CrashLoopBackOff, ImagePullBackOff, an init
+container exiting non-zero), so there's no need to dig through 'kappal ps'.
+
+One-shot services (Jobs) are not restarted; use 'kappal up' to re-run them.
+Services stopped with 'kappal stop' stay at 0 replicas. With no arguments all
+services are restarted; otherwise only the named ones. To apply compose file
+changes, use 'kappal up' instead.
+
+Fails if K3s is not running (run 'kappal up' first).
+
+Flags:
+  --timeout <s>      Seconds to wait for each rollout to complete (default: 300)
+  -f <path>          Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>          Override project name
+  --namespace <ns>   K8s namespace used at 'kappal up' (default: project name)
+
+Examples:
+  kappal restart                  Restart all services
+  kappal restart web              Restart only web
+  kappal restart --timeout 60 api Fail after a minute if api doesn't come back`,
+	RunE: runRestart,
+}
+
+func init() {
+	restartCmd.Flags().IntVar(&restartTimeout, "timeout", 300, "Timeout in seconds waiting for each rollout to complete")
+}
+
+func runRestart(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	if restartTimeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+
+	p, err := loadPausedProject(ctx, args)
+	if err != nil {
+		return err
+	}
+	if len(p.deployments) == 0 {
+		fmt.Println("No services to restart")
+		return nil
+	}
+
+	for _, d := range p.deployments {
+		if err := p.client.RestartDeployment(ctx, p.namespace, d.Name); err != nil {
+			return err
+		}
+	}
+	for _, d := range p.deployments {
+		service := d.Labels["kappal.io/service"]
+		selector := k8s.ServiceSelector(d.Labels["kappal.io/project"], service)
+		if err := p.client.WaitForRollout(ctx, p.namespace, d.Name, selector, time.Duration(restartTimeout)*time.Second); err != nil {
+			return fmt.Errorf("service %s failed to restart: %w", service, err)
+		}
+		fmt.Printf("Restarted %s\n", service)
+	}
+	return nil
+}
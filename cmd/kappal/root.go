@@ -9,9 +9,12 @@ import (
 )
 
 var (
-	composeFile string
-	projectName string
-	runSetup    bool
+	composeFiles []string
+	projectName  string
+	namespace    string
+	runSetup     bool
+	// composeProfiles are the compose profiles enabled with --profile
+	composeProfiles []string
 )
 
 var rootCmd = &cobra.Command{
@@ -32,6 +35,9 @@ just familiar Compose commands.`,
 			"help":    true,
 			"version": true,
 			"clean":   true, // clean should work even without setup
+			"config":  true, // config only reads compose files
+			"show":    true, // show only renders manifests
+			"doctor":  true, // doctor only checks the compose file
 		}
 		if skipCheck[cmd.Name()] {
 			return nil
@@ -60,8 +66,10 @@ just familiar Compose commands.`,
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&composeFile, "file", "f", "docker-compose.yaml", "Compose file path")
+	rootCmd.PersistentFlags().StringArrayVarP(&composeFiles, "file", "f", []string{"docker-compose.yaml"}, "Compose file path (repeat to merge overrides in order)")
 	rootCmd.PersistentFlags().StringVarP(&projectName, "project-name", "p", "", "Project name (defaults to directory name with path hash)")
+	rootCmd.PersistentFlags().StringArrayVar(&composeProfiles, "profile", nil, "Enable services in a compose profile (repeatable; \"*\" enables all)")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "", "K8s namespace for project resources (defaults to the project name)")
 
 	// Add --setup flag
 	rootCmd.Flags().BoolVar(&runSetup, "setup", false, "Set up kappal (pull K3s image, verify Docker)")
@@ -72,6 +80,20 @@ func init() {
 	rootCmd.AddCommand(psCmd)
 	rootCmd.AddCommand(logsCmd)
 	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(attachCmd)
 	rootCmd.AddCommand(ejectCmd)
 	rootCmd.AddCommand(inspectCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(restartCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(portCmd)
+	rootCmd.AddCommand(cpCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(createCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(doctorCmd)
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/k8s"
+	"github.com/kappal-app/kappal/pkg/state"
+	"github.com/kappal-app/kappal/pkg/transform"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runRm         bool
+	runEnv        []string
+	runNoDeps     bool
+	runWorkdir    string
+	runUser       string
+	runEntrypoint string
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [OPTIONS] SERVICE [COMMAND] [ARGS...]",
+	Short: "Run a one-off command for a service",
+	Long: `Run a one-off command for a service.
+
+This is the equivalent of 'docker compose run'. It starts a new pod from the
+service's definition (image, environment, volumes, secrets, configs, user)
+as a K8s Job, streams its output, and waits for it to exit. COMMAND replaces
+the service's compose command; without it the service command is run. The
+exit status is non-zero when the command fails.
+
+The Job is named <service>-run-<suffix> and never retried. Its pod is not
+labelled as part of the service, so it does not receive the service's
+traffic and is ignored by ps, logs and exec. Ports are not published.
+
+The project must be up ('kappal up' first): run uses the images and
+volumes created there and does not build. depends_on waits
+(service_healthy, service_completed_successfully) still apply unless
+--no-deps is given. Ctrl-C stops waiting; with --rm the Job is still deleted.
+
+Flags:
+  --rm                 Delete the Job and its pod after the command exits
+  -e, --env KEY=VALUE  Set an environment variable, overriding the service's (repeatable)
+  --no-deps            Don't wait for the service's depends_on targets
+  -w, --workdir <dir>  Working directory inside the container (must be absolute)
+  -u, --user <uid[:gid]>
+                       Run as this numeric user (and group); user names can't be
+                       resolved by K8s and are rejected
+  --entrypoint <cmd>   Override the image entrypoint. Split on whitespace; use
+                       --entrypoint sh plus COMMAND "-c" "..." for shell scripts
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>            Override project name
+  --namespace <ns>     K8s namespace used at 'kappal up' (default: project name)
+
+Examples:
+  kappal run --rm web rake db:migrate        Run migrations in a throwaway pod
+  kappal run --rm -e DEBUG=1 web ./check.sh  Override an env var for the run
+  kappal run --rm --no-deps worker ls /app   Skip waiting for dependencies
+  kappal run --rm web                        Run the service's own command once
+  kappal run --rm -w /app/db -u 0 web ls     Run as root in another directory
+  kappal run --rm --entrypoint sh web -c 'env | sort'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRun,
+}
+
+func init() {
+	runCmd.Flags().BoolVar(&runRm, "rm", false, "Remove the Job after the command exits")
+	runCmd.Flags().StringArrayVarP(&runEnv, "env", "e", nil, "Set environment variables (KEY=VALUE, repeatable)")
+	runCmd.Flags().BoolVar(&runNoDeps, "no-deps", false, "Don't wait for linked services")
+	runCmd.Flags().StringVarP(&runWorkdir, "workdir", "w", "", "Working directory inside the container")
+	runCmd.Flags().StringVarP(&runUser, "user", "u", "", "Run as numeric uid[:gid]")
+	runCmd.Flags().StringVar(&runEntrypoint, "entrypoint", "", "Override the entrypoint of the image")
+	// Flags after SERVICE belong to the command, as with exec
+	runCmd.Flags().SetInterspersed(false)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	serviceName := args[0]
+	env, err := parseEnvOverrides(runEnv)
+	if err != nil {
+		return err
+	}
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
+	svc, ok := project.Services[serviceName]
+	if !ok {
+		return fmt.Errorf("service %q not found in compose file", serviceName)
+	}
+	if !compose.IsActive(project, svc) {
+		return fmt.Errorf("service %q is in an inactive profile and was not deployed by 'kappal up' (enable it with --profile)", serviceName)
+	}
+
+	workspaceDir := filepath.Join(projectDir, ".kappal")
+
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false, Namespace: ns})
+	if err != nil {
+		return fmt.Errorf("failed to discover state: %w", err)
+	}
+
+	if discovered.K3s.Status != "running" {
+		return fmt.Errorf("K3s not running (run 'kappal up' first)")
+	}
+
+	if discovered.Kubeconfig == "" {
+		return fmt.Errorf("kubeconfig not available (run 'kappal up' first)")
+	}
+
+	transformer := transform.NewTransformer(project)
+	transformer.SetNamespace(ns)
+	transformer.SetHostDir(os.Getenv("KAPPAL_HOST_DIR"))
+	job, err := transformer.RunJob(project.Name, serviceName, transformer.ToSpec().Services, transform.RunOverrides{
+		Command:     args[1:],
+		Environment: env,
+		NoDeps:      runNoDeps,
+		WorkingDir:  runWorkdir,
+		User:        runUser,
+		Entrypoint:  strings.Fields(runEntrypoint),
+	})
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := k8s.NewClient(discovered.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	return k8sClient.RunJob(ctx, job, os.Stdout, k8s.RunOptions{Remove: runRm})
+}
+
+// parseEnvOverrides parses -e KEY=VALUE flags into env entries.
+func parseEnvOverrides(env []string) ([]transform.EnvSpec, error) {
+	var specs []transform.EnvSpec
+	for _, e := range env {
+		i := strings.Index(e, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid environment variable %q: expected KEY=VALUE", e)
+		}
+		specs = append(specs, transform.EnvSpec{Name: e[:i], Value: e[i+1:]})
+	}
+	return specs, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kappal-app/kappal/pkg/transform"
+)
+
+func TestParseEnvOverrides(t *testing.T) {
+	got, err := parseEnvOverrides([]string{"A=1", "B=x=y", "EMPTY="})
+	if err != nil {
+		t.Fatalf("parseEnvOverrides failed: %v", err)
+	}
+	want := []transform.EnvSpec{{Name: "A", Value: "1"}, {Name: "B", Value: "x=y"}, {Name: "EMPTY", Value: ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"NOVALUE", "=1"} {
+		if _, err := parseEnvOverrides([]string{bad}); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
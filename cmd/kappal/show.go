@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/kubectl"
+	"github.com/kappal-app/kappal/pkg/transform"
+	"github.com/kappal-app/kappal/pkg/workspace"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var showService string
+
+var showCmd = &cobra.Command{
+	Use:     "show",
+	Aliases: []string{"render"},
+	Short:   "Print the Kubernetes manifests kappal would apply",
+	Long: `Print the Kubernetes manifests generated from the compose file.
+
+Runs the same transformation as 'kappal up' into a temporary workspace and
+prints the combined multi-document YAML that 'up' would apply: namespace,
+secrets, configmaps, PVCs, network policies, RBAC, and a Deployment or Job
+plus a Service per compose service. Nothing is written to .kappal/ and
+neither Docker nor K3s is needed, so it works anywhere the compose file
+does. Pipe it into other tooling or commit it for review.
+
+Secret and config files are read and inlined, so missing files are an
+error here just as in 'up'.
+
+Flags:
+  --service <name>     Print only this service's workload (Deployment or Job)
+                       and its Service
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>            Override project name
+  --namespace <ns>     K8s namespace to generate into (default: project name)
+
+Examples:
+  kappal show                          Print every manifest
+  kappal render > manifests.yaml       Same, via the alias
+  kappal show --service web            Only web's Deployment and Service
+  kappal show | kubeconform -strict    Validate with external tooling`,
+	Args: cobra.NoArgs,
+	RunE: runShow,
+}
+
+func init() {
+	showCmd.Flags().StringVar(&showService, "service", "", "Print only this service's workload and Service")
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
+	if showService != "" {
+		svc, ok := project.Services[showService]
+		if !ok {
+			return fmt.Errorf("service %q not found in compose file", showService)
+		}
+		if !compose.IsActive(project, svc) {
+			return fmt.Errorf("service %q is in an inactive profile and is not deployed (enable it with --profile)", showService)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "kappal-show-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp workspace: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	ws, err := workspace.New(tmpDir)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	transformer := transform.NewTransformer(project)
+	transformer.SetNamespace(ns)
+	transformer.SetHostDir(os.Getenv("KAPPAL_HOST_DIR"))
+	if err := transformer.Generate(ws); err != nil {
+		return fmt.Errorf("failed to generate manifests: %w", err)
+	}
+
+	manifest, err := kubectl.Show(ctx, ws)
+	if err != nil {
+		return fmt.Errorf("failed to read manifests: %w", err)
+	}
+
+	if showService != "" {
+		if manifest, err = serviceManifests(manifest, showService); err != nil {
+			return err
+		}
+	}
+	_, err = os.Stdout.Write(manifest)
+	return err
+}
+
+// serviceManifests returns the documents of a combined manifest that belong to
+// a compose service (labelled kappal.io/service), keeping the "---" separators.
+func serviceManifests(manifest []byte, service string) ([]byte, error) {
+	var result []byte
+	for _, doc := range strings.Split(string(manifest), "---\n") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var obj struct {
+			Metadata struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if obj.Metadata.Labels["kappal.io/service"] == service {
+			result = append(result, "---\n"...)
+			result = append(result, doc...)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServiceManifests(t *testing.T) {
+	manifest := `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    kappal.io/project: demo
+    kappal.io/service: web
+  name: web
+---
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    kappal.io/project: demo
+    kappal.io/service: web
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    kappal.io/project: demo
+    kappal.io/service: webapp
+  name: webapp
+`
+	got, err := serviceManifests([]byte(manifest), "web")
+	if err != nil {
+		t.Fatalf("serviceManifests failed: %v", err)
+	}
+	out := string(got)
+	if strings.Count(out, "---\n") != 2 {
+		t.Errorf("expected 2 documents for web, got:\n%s", out)
+	}
+	if !strings.Contains(out, "kind: Deployment") || !strings.Contains(out, "kind: Service") {
+		t.Errorf("expected web's Deployment and Service, got:\n%s", out)
+	}
+	if strings.Contains(out, "Namespace") || strings.Contains(out, "name: webapp") {
+		t.Errorf("unrelated objects leaked into the output:\n%s", out)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start [SERVICE...]",
+	Short: "Start services stopped with 'kappal stop'",
+	Long: `Start services previously stopped with 'kappal stop'.
+
+Restores each Deployment to the replica count recorded by 'kappal stop' in
+.kappal/runtime/stopped-replicas.json, then removes it from the record. Jobs
+created suspended by 'kappal create' (or 'up --no-start') are resumed first, so
+one-shot services run too. Services that were not stopped are left untouched.
+Returns once the Deployments are scaled; use 'kappal ps' to watch them become
+ready.
+
+With no arguments all stopped services are started; otherwise only the named
+ones. To apply compose file changes, use 'kappal up' instead.
+
+Fails if K3s is not running (run 'kappal up' first).
+
+Flags:
+  -f <path>          Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>          Override project name
+  --namespace <ns>   K8s namespace used at 'kappal up' (default: project name)
+
+Examples:
+  kappal start         Start all stopped services
+  kappal start web     Start only web`,
+	RunE: runStart,
+}
+
+func runStart(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	p, err := loadPausedProject(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	recorded, err := p.ws.ReadStoppedReplicas()
+	if err != nil {
+		return err
+	}
+
+	started, err := startPaused(ctx, p.client, p, recorded)
+	if err != nil {
+		return err
+	}
+	if started == 0 {
+		fmt.Println("No stopped services to start")
+	}
+	return nil
+}
+
+// pausedStarter resumes Jobs and scales Deployments (k8s.Client).
+type pausedStarter interface {
+	ResumeJob(ctx context.Context, namespace, name string) error
+	ScaleDeployment(ctx context.Context, namespace, name string, replicas int32) error
+}
+
+// startPaused resumes p's suspended Jobs, then scales its Deployments back to
+// their recorded replicas, dropping each from the record as it starts. It
+// returns how many services were started.
+func startPaused(ctx context.Context, client pausedStarter, p *pausedProject, recorded map[string]int32) (int, error) {
+	started := 0
+	for _, j := range p.jobs {
+		if err := client.ResumeJob(ctx, p.namespace, j.Name); err != nil {
+			return started, err
+		}
+		fmt.Printf("Started %s\n", j.Labels["kappal.io/service"])
+		started++
+	}
+	for _, d := range p.deployments {
+		replicas, ok := recorded[d.Name]
+		if !ok {
+			continue
+		}
+		if err := client.ScaleDeployment(ctx, p.namespace, d.Name, replicas); err != nil {
+			return started, err
+		}
+		delete(recorded, d.Name)
+		if err := p.ws.WriteStoppedReplicas(recorded); err != nil {
+			return started, err
+		}
+		fmt.Printf("Started %s\n", d.Labels["kappal.io/service"])
+		started++
+	}
+	return started, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kappal-app/kappal/pkg/workspace"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeStarter records the calls startPaused makes, in order.
+type fakeStarter struct {
+	calls []string
+}
+
+func (s *fakeStarter) ResumeJob(ctx context.Context, namespace, name string) error {
+	s.calls = append(s.calls, "resume "+namespace+"/"+name)
+	return nil
+}
+
+func (s *fakeStarter) ScaleDeployment(ctx context.Context, namespace, name string, replicas int32) error {
+	s.calls = append(s.calls, "scale "+namespace+"/"+name)
+	return nil
+}
+
+func TestStartPausedResumesJobs(t *testing.T) {
+	ws, err := workspace.New(filepath.Join(t.TempDir(), ".kappal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Labels: map[string]string{"kappal.io/service": name}}
+	}
+	p := &pausedProject{
+		namespace:   "demo",
+		ws:          ws,
+		deployments: []appsv1.Deployment{{ObjectMeta: meta("web")}, {ObjectMeta: meta("db")}},
+		jobs:        []batchv1.Job{{ObjectMeta: meta("migrate")}},
+	}
+	// As 'kappal create' records them; db was never stopped
+	recorded := map[string]int32{"web": 2}
+	if err := ws.WriteStoppedReplicas(recorded); err != nil {
+		t.Fatal(err)
+	}
+
+	starter := &fakeStarter{}
+	started, err := startPaused(context.Background(), starter, p, recorded)
+	if err != nil {
+		t.Fatalf("startPaused failed: %v", err)
+	}
+	if started != 2 {
+		t.Errorf("started = %d, want 2 (migrate and web)", started)
+	}
+	// Jobs are resumed before the Deployments that may wait on them scale up
+	if want := []string{"resume demo/migrate", "scale demo/web"}; !reflect.DeepEqual(starter.calls, want) {
+		t.Errorf("calls = %v, want %v", starter.calls, want)
+	}
+	left, err := ws.ReadStoppedReplicas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 0 {
+		t.Errorf("started services should leave the record, got %v", left)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/docker"
+	"github.com/kappal-app/kappal/pkg/k3s"
+	"github.com/kappal-app/kappal/pkg/k8s"
+	"github.com/kappal-app/kappal/pkg/state"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var (
+	statsFormat   string
+	statsWatch    bool
+	statsInterval time.Duration
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Display resource usage of the project's K3s node and pods",
+	Long: `Display a single resource usage sample for the project's K3s container and
+each of the project's pods.
+
+K3s runs with metrics-server disabled unless 'kappal up --enable-metrics' was
+given, so kappal reads usage from two places:
+
+  1. Docker stats for the K3s container, which hosts every pod of the project.
+     These are whole-node numbers: all services plus K3s itself.
+  2. containerd's per-container cgroup stats inside K3s (via crictl, the CRI
+     client K3s bundles next to ctr), summed per pod and limited to this
+     project's pods. If they can't be read, a warning is printed and only the
+     node numbers are shown.
+
+Node table columns:
+  NAME         K3s container name
+  CPU %        CPU usage since the previous sample (100% = one full core)
+  MEM USAGE    Memory in use excluding reclaimable page cache
+  LIMIT        Memory available to the container
+  MEM %        MEM USAGE as a percentage of LIMIT
+  NET RX/TX    Bytes received/sent across the container's networks
+  PIDS         Number of processes in the container
+
+Pod table columns:
+  SERVICE      Compose service the pod belongs to
+  POD          Pod name
+  CPU %        Recent CPU usage of the pod's containers (100% = one full core;
+               containerd reports 0 until it has two samples)
+  MEM USAGE    Working set memory of the pod's containers
+
+JSON fields (-o json): name, cpuPercent, memoryUsage, memoryLimit, memoryPercent,
+networkRx, networkTx, pids for the node, plus pods: [{service, pod, cpuPercent,
+memoryUsage}]. Byte values are raw integers.
+
+With --watch, the pod table is redrawn every --interval until Ctrl-C, like
+'kubectl top pods'. Usage comes from the metrics API (metrics.k8s.io) when the
+cluster serves it; while it doesn't (metrics-server disabled or still
+starting), kappal falls back to containerd's stats and says so in the header.
+Right after metrics-server starts it has no samples yet; the view shows
+"waiting for metrics" until the first scrape.
+
+Fails if K3s is not running (run 'kappal up' first).
+
+Flags:
+  -o, --format <fmt>   Output format: table (default), json
+  --watch              Refresh the per-pod table until interrupted (table format only)
+  --interval <d>       Refresh interval for --watch (default 2s)
+  -f <path>            Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>            Override project name
+  --namespace <ns>     K8s namespace used at 'kappal up' (default: project name)
+
+Examples:
+  kappal stats              Table view of K3s node usage
+  kappal stats -o json      JSON output for scripting
+  kappal stats -o json | jq '.memoryPercent'
+  kappal stats --watch      Live per-pod CPU/memory view
+  kappal stats --watch --interval 5s`,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().StringVarP(&statsFormat, "format", "o", "table", "Output format (table, json)")
+	statsCmd.Flags().BoolVar(&statsWatch, "watch", false, "Refresh the per-pod table until interrupted")
+	statsCmd.Flags().DurationVar(&statsInterval, "interval", 2*time.Second, "Refresh interval for --watch")
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if statsFormat != "table" && statsFormat != "json" {
+		return fmt.Errorf("invalid format %q (valid: table, json)", statsFormat)
+	}
+	if statsWatch && statsFormat != "table" {
+		return fmt.Errorf("--watch only supports the table format")
+	}
+	if statsInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
+	workspaceDir := filepath.Join(projectDir, ".kappal")
+
+	// Docker-level state is enough; pods are listed directly below
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false, Namespace: ns})
+	if err != nil {
+		return fmt.Errorf("failed to discover state: %w", err)
+	}
+
+	if discovered.K3s.Status != "running" {
+		return fmt.Errorf("K3s not running (run 'kappal up' first)")
+	}
+
+	if statsWatch {
+		if discovered.Kubeconfig == "" {
+			return fmt.Errorf("kubeconfig not available (run 'kappal up' first)")
+		}
+		k8sClient, err := k8s.NewClient(discovered.Kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to create k8s client: %w", err)
+		}
+		return watchPodStats(ctx, os.Stdout, statsInterval, docker.StdoutIsTerminal(), func(ctx context.Context) ([]podStatsRow, string, error) {
+			return samplePodStats(ctx, k8sClient, workspaceDir, project.Name, ns, discovered.Kubeconfig)
+		})
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	stats, err := dockerClient.ContainerStats(ctx, discovered.K3s.ContainerName)
+	if err != nil {
+		return err
+	}
+
+	pods, err := projectPodStats(ctx, workspaceDir, project.Name, ns, discovered.Kubeconfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: per-pod stats unavailable: %v\n", err)
+	}
+
+	if statsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statsOutput{ContainerStats: stats, Pods: pods})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tCPU %\tMEM USAGE\tLIMIT\tMEM %\tNET RX/TX\tPIDS")
+	_, _ = fmt.Fprintf(w, "%s\t%.2f%%\t%s\t%s\t%.2f%%\t%s / %s\t%d\n",
+		stats.Name, stats.CPUPercent,
+		formatBytes(stats.MemoryUsage), formatBytes(stats.MemoryLimit), stats.MemoryPercent,
+		formatBytes(stats.NetworkRx), formatBytes(stats.NetworkTx), stats.PIDs)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(pods) == 0 {
+		return nil
+	}
+	fmt.Println()
+	return writePodStatsTable(os.Stdout, pods)
+}
+
+// writePodStatsTable renders the per-pod usage table.
+func writePodStatsTable(out io.Writer, rows []podStatsRow) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVICE\tPOD\tCPU %\tMEM USAGE")
+	for _, p := range rows {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%.2f%%\t%s\n", p.Service, p.Pod, p.CPUPercent, formatBytes(p.MemoryUsage))
+	}
+	return w.Flush()
+}
+
+// Sources of per-pod usage shown in the --watch header.
+const (
+	statsSourceMetrics    = "metrics API"
+	statsSourceContainerd = "containerd (metrics API not available)"
+)
+
+// samplePodStats reads per-pod usage from the metrics API, falling back to
+// containerd's stats while the cluster doesn't serve metrics.k8s.io.
+func samplePodStats(ctx context.Context, k8sClient *k8s.Client, workspaceDir, projectName, ns, kubeconfig string) ([]podStatsRow, string, error) {
+	metrics, err := k8sClient.ListPodMetrics(ctx, ns, "kappal.io/project="+projectName)
+	if err == nil {
+		return podMetricsRows(metrics), statsSourceMetrics, nil
+	}
+	if !errors.Is(err, k8s.ErrMetricsUnavailable) {
+		return nil, statsSourceMetrics, err
+	}
+	rows, err := projectPodStats(ctx, workspaceDir, projectName, ns, kubeconfig)
+	return rows, statsSourceContainerd, err
+}
+
+// podMetricsRows sums container usage per pod, sorted by service and pod.
+// 1000 millicores is one full core, shown as 100%.
+func podMetricsRows(metrics []k8s.PodMetrics) []podStatsRow {
+	rows := []podStatsRow{}
+	for _, m := range metrics {
+		row := podStatsRow{Service: m.Labels["kappal.io/service"], Pod: m.Name}
+		for _, c := range m.Containers {
+			if cpu, ok := c.Usage[corev1.ResourceCPU]; ok {
+				row.CPUPercent += float64(cpu.MilliValue()) / 10
+			}
+			if mem, ok := c.Usage[corev1.ResourceMemory]; ok {
+				row.MemoryUsage += uint64(mem.Value())
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Service != rows[j].Service {
+			return rows[i].Service < rows[j].Service
+		}
+		return rows[i].Pod < rows[j].Pod
+	})
+	return rows
+}
+
+// watchPodStats redraws the pod table every interval until ctx is done.
+// On a terminal the screen is cleared before each frame; otherwise frames
+// are appended, so the output stays readable when piped.
+func watchPodStats(ctx context.Context, out io.Writer, interval time.Duration, clear bool, sample func(context.Context) ([]podStatsRow, string, error)) error {
+	for {
+		rows, source, err := sample(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if clear {
+			_, _ = fmt.Fprint(out, "\033[H\033[2J")
+		}
+		_, _ = fmt.Fprintf(out, "Every %s, usage from %s. Ctrl-C to exit.\n\n", interval, source)
+		switch {
+		case err != nil:
+			_, _ = fmt.Fprintf(out, "Error: %v\n", err)
+		case len(rows) == 0 && source == statsSourceMetrics:
+			_, _ = fmt.Fprintln(out, "Waiting for metrics (no samples yet)...")
+		case len(rows) == 0:
+			_, _ = fmt.Fprintln(out, "No pods found")
+		default:
+			if err := writePodStatsTable(out, rows); err != nil {
+				return err
+			}
+		}
+		if !clear {
+			_, _ = fmt.Fprintln(out)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// statsOutput is the -o json document: the node stats fields plus pods.
+type statsOutput struct {
+	*docker.ContainerStats
+	Pods []podStatsRow `json:"pods"`
+}
+
+// podStatsRow is the usage of one of the project's pods.
+type podStatsRow struct {
+	Service     string  `json:"service"`
+	Pod         string  `json:"pod"`
+	CPUPercent  float64 `json:"cpuPercent"`
+	MemoryUsage uint64  `json:"memoryUsage"`
+}
+
+// projectPodStats reads containerd pod stats and keeps the project's pods,
+// labeled with their service. Pods are listed through the K8s API because
+// containerd only knows pod names, not kappal labels.
+func projectPodStats(ctx context.Context, workspaceDir, projectName, ns, kubeconfig string) ([]podStatsRow, error) {
+	if kubeconfig == "" {
+		return []podStatsRow{}, fmt.Errorf("kubeconfig not available")
+	}
+	k8sClient, err := k8s.NewClient(kubeconfig)
+	if err != nil {
+		return []podStatsRow{}, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	podList, err := k8sClient.ListPods(ctx, ns, "kappal.io/project="+projectName)
+	if err != nil {
+		return []podStatsRow{}, fmt.Errorf("failed to list pods: %w", err)
+	}
+	services := map[string]string{}
+	for _, pod := range podList.Items {
+		services[pod.Name] = pod.Labels["kappal.io/service"]
+	}
+
+	k3sManager, err := k3s.NewManager(workspaceDir, projectName)
+	if err != nil {
+		return []podStatsRow{}, err
+	}
+	defer func() { _ = k3sManager.Close() }()
+
+	stats, err := k3sManager.PodStats(ctx)
+	if err != nil {
+		return []podStatsRow{}, err
+	}
+	return podStatsRows(stats, ns, services), nil
+}
+
+// podStatsRows keeps the stats of pods in ns that appear in services
+// (pod name → service name).
+func podStatsRows(stats []k3s.PodStats, ns string, services map[string]string) []podStatsRow {
+	rows := []podStatsRow{}
+	for _, s := range stats {
+		service, ok := services[s.Pod]
+		if s.Namespace != ns || !ok {
+			continue
+		}
+		rows = append(rows, podStatsRow{Service: service, Pod: s.Pod, CPUPercent: s.CPUPercent, MemoryUsage: s.MemoryUsage})
+	}
+	return rows
+}
+
+// formatBytes renders a byte count with binary units (e.g. "1.5GiB").
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
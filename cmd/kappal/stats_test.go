@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kappal-app/kappal/pkg/k3s"
+	"github.com/kappal-app/kappal/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodStatsRowsKeepsProjectPods(t *testing.T) {
+	stats := []k3s.PodStats{
+		{Namespace: "demo", Pod: "web-6d4b", CPUPercent: 12.5, MemoryUsage: 1024},
+		{Namespace: "demo", Pod: "other-app-1", CPUPercent: 1, MemoryUsage: 1},
+		{Namespace: "kube-system", Pod: "web-6d4b", CPUPercent: 2, MemoryUsage: 2},
+	}
+	services := map[string]string{"web-6d4b": "web"}
+
+	got := podStatsRows(stats, "demo", services)
+	want := []podStatsRow{{Service: "web", Pod: "web-6d4b", CPUPercent: 12.5, MemoryUsage: 1024}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("podStatsRows = %+v, want %+v", got, want)
+	}
+}
+
+func TestPodMetricsTable(t *testing.T) {
+	metrics := []k8s.PodMetrics{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-6d4b", Labels: map[string]string{"kappal.io/service": "web"}},
+			Containers: []k8s.ContainerMetrics{
+				{Name: "web", Usage: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("64Mi"),
+				}},
+				{Name: "sidecar", Usage: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("50m"),
+					corev1.ResourceMemory: resource.MustParse("16Mi"),
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-0", Labels: map[string]string{"kappal.io/service": "db"}},
+			Containers: []k8s.ContainerMetrics{
+				{Name: "db", Usage: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			},
+		},
+	}
+
+	rows := podMetricsRows(metrics)
+	want := []podStatsRow{
+		{Service: "db", Pod: "db-0", CPUPercent: 100, MemoryUsage: 1 << 30},
+		{Service: "web", Pod: "web-6d4b", CPUPercent: 30, MemoryUsage: 80 << 20},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %+v, want %+v", rows, want)
+	}
+
+	var buf bytes.Buffer
+	if err := writePodStatsTable(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "SERVICE") {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+	for i, fields := range [][]string{{"db", "db-0", "100.00%", "1.0GiB"}, {"web", "web-6d4b", "30.00%", "80.0MiB"}} {
+		if got := strings.Fields(lines[i+1]); !reflect.DeepEqual(got, fields) {
+			t.Errorf("row %d = %v, want %v", i, got, fields)
+		}
+	}
+}
+
+func TestWatchPodStatsWaitsForMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	samples := 0
+	sample := func(context.Context) ([]podStatsRow, string, error) {
+		samples++
+		if samples == 1 {
+			return nil, statsSourceMetrics, nil
+		}
+		cancel()
+		return []podStatsRow{{Service: "web", Pod: "web-1", CPUPercent: 5, MemoryUsage: 2048}}, statsSourceMetrics, nil
+	}
+
+	var buf bytes.Buffer
+	if err := watchPodStats(ctx, &buf, time.Millisecond, false, sample); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Waiting for metrics") {
+		t.Errorf("expected a waiting message before the first sample, got:\n%s", out)
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("should not clear the screen when not on a terminal, got %q", out)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/k8s"
+	"github.com/kappal-app/kappal/pkg/state"
+	"github.com/kappal-app/kappal/pkg/workspace"
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+var stopCmd = &cobra.Command{
+	Use:   "stop [SERVICE...]",
+	Short: "Stop services without removing them",
+	Long: `Stop running services without removing them or the K3s cluster.
+
+Each service's Deployment is scaled to 0 replicas. Its previous replica count is
+recorded in .kappal/runtime/stopped-replicas.json so 'kappal start' can restore
+it. Manifests, volumes and the K3s container are kept, so 'kappal start' resumes
+in seconds instead of paying the K3s boot cost. Use 'kappal down' to remove
+everything.
+
+One-shot services (restart: no → Jobs) are left alone. With no arguments all
+services are stopped; otherwise only the named ones. Stopping an already
+stopped service is a no-op. The next 'kappal up' re-applies the manifests and
+clears the stopped state.
+
+Fails if K3s is not running (run 'kappal up' first).
+
+Flags:
+  -f <path>          Compose file path; repeat to merge overrides in order (default: docker-compose.yaml)
+  -p <name>          Override project name
+  --namespace <ns>   K8s namespace used at 'kappal up' (default: project name)
+
+Examples:
+  kappal stop              Stop all services
+  kappal stop web worker   Stop only web and worker
+  kappal start             Resume them`,
+	RunE: runStop,
+}
+
+// pausedProject holds what stop and start need to scale a project's
+// Deployments and resume its suspended Jobs.
+type pausedProject struct {
+	namespace   string
+	ws          *workspace.Workspace
+	client      *k8s.Client
+	deployments []appsv1.Deployment
+	jobs        []batchv1.Job // suspended by 'kappal create' / 'up --no-start'
+}
+
+// loadPausedProject loads the compose project, connects to its running K3s and
+// lists its Deployments and suspended Jobs, restricted to services when any
+// are given.
+func loadPausedProject(ctx context.Context, services []string) (*pausedProject, error) {
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	composePaths := composeFilePaths(projectDir)
+
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compose file: %w", err)
+	}
+
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, name := range services {
+		if _, ok := project.Services[name]; !ok {
+			return nil, fmt.Errorf("service %q not found in compose file", name)
+		}
+		wanted[name] = true
+	}
+
+	workspaceDir := filepath.Join(projectDir, ".kappal")
+	ws, err := workspace.Open(workspaceDir)
+	if err != nil {
+		return nil, fmt.Errorf("workspace not found (run 'kappal up' first): %w", err)
+	}
+
+	discovered, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false, Namespace: ns})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover state: %w", err)
+	}
+	if discovered.K3s.Status != "running" {
+		return nil, fmt.Errorf("K3s not running (run 'kappal up' first)")
+	}
+	if discovered.Kubeconfig == "" {
+		return nil, fmt.Errorf("kubeconfig not available (run 'kappal up' first)")
+	}
+
+	client, err := k8s.NewClient(discovered.Kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	list, err := client.ListDeployments(ctx, ns, "kappal.io/project="+project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	p := &pausedProject{namespace: ns, ws: ws, client: client}
+	for _, d := range list.Items {
+		if len(wanted) > 0 && !wanted[d.Labels["kappal.io/service"]] {
+			continue
+		}
+		p.deployments = append(p.deployments, d)
+	}
+
+	jobs, err := client.ListJobs(ctx, ns, "kappal.io/project="+project.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	for _, j := range jobs.Items {
+		if j.Spec.Suspend == nil || !*j.Spec.Suspend {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[j.Labels["kappal.io/service"]] {
+			continue
+		}
+		p.jobs = append(p.jobs, j)
+	}
+	return p, nil
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	p, err := loadPausedProject(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	recorded, err := p.ws.ReadStoppedReplicas()
+	if err != nil {
+		return err
+	}
+
+	var toStop []appsv1.Deployment
+	for _, d := range p.deployments {
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		if replicas == 0 {
+			continue
+		}
+		recorded[d.Name] = replicas
+		toStop = append(toStop, d)
+	}
+
+	// Record before scaling so a partial failure can still be resumed
+	if err := p.ws.WriteStoppedReplicas(recorded); err != nil {
+		return err
+	}
+	// The cluster no longer matches the last apply; make the next up re-apply
+	_ = os.Remove(filepath.Join(p.ws.GetRuntimeDir(), applyHashFile))
+
+	for _, d := range toStop {
+		if err := p.client.ScaleDeployment(ctx, p.namespace, d.Name, 0); err != nil {
+			return err
+		}
+		fmt.Printf("Stopped %s\n", d.Labels["kappal.io/service"])
+	}
+	if len(toStop) == 0 {
+		fmt.Println("No running services to stop")
+	}
+	return nil
+}
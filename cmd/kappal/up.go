@@ -2,14 +2,23 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/docker"
 	"github.com/kappal-app/kappal/pkg/k3s"
 	"github.com/kappal-app/kappal/pkg/k8s"
 	"github.com/kappal-app/kappal/pkg/state"
@@ -17,14 +26,63 @@ import (
 	"github.com/kappal-app/kappal/pkg/transform"
 	"github.com/kappal-app/kappal/pkg/workspace"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 var (
-	upDetach  bool
-	upBuild   bool
-	upTimeout int
+	upDetach      bool
+	upBuild       bool
+	upNoCache     bool
+	upTimeout     int
+	upProgressDeadline int
+	upProgress    string
+	upForce       bool
+	upShowChanges bool
+	upFormat      string
+	upNoStart     bool
+	upPull        string
+	upLabels      []string
+	upPlatform    string
+	upRegistryAuth string
+	upExposeAll    bool
+	upK3sMemory    string
+	upK3sCPUs      string
+	upEnableIngress bool
+	upEnableMetrics bool
 )
 
+// parseLabels parses repeated --label KEY=VALUE flags, rejecting invalid
+// Kubernetes label keys/values and keys under kappal.io/ (which kappal's own
+// selectors rely on).
+func parseLabels(flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(flags))
+	for _, f := range flags {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --label %q: expected KEY=VALUE", f)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid --label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid --label value %q: %s", value, strings.Join(errs, "; "))
+		}
+		if key == "kappal.io" || strings.HasPrefix(key, "kappal.io/") {
+			return nil, fmt.Errorf("invalid --label key %q: the kappal.io/ prefix is reserved", key)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// applyHashFile records, under the workspace runtime dir, the hash of the
+// last successfully applied manifests and their inputs.
+const applyHashFile = "applied.sha256"
+
 var upCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Create and start containers",
@@ -34,37 +92,187 @@ Parses docker-compose.yaml, generates Kubernetes manifests, ensures a K3s instan
 is running for this project, and applies the manifests via kubectl. Waits up to 5
 minutes for all pods to become ready before returning.
 
-Services with "restart: no" run as one-shot Kubernetes Jobs. Services with
-depends_on condition: service_completed_successfully get init containers that block
-until the dependency Job finishes. Services with profiles are excluded.
+Services with "restart: no" run as one-shot Kubernetes Jobs, as do services
+with "restart: on-failure[:N]" (restartPolicy OnFailure, backoffLimit N or 3).
+Services with depends_on condition: service_completed_successfully get init
+containers that block until the dependency Job finishes. Services with profiles are excluded
+unless a profile is enabled with the global --profile flag; up fails if an active
+service depends_on a service whose profiles are all inactive.
 
 Port chain: compose ports → K3s container port bindings → K8s NodePort services.
-Published ports bind to the Docker host and are accessible via localhost.
+Published ports bind to 127.0.0.1 on the Docker host, so they are reachable
+via localhost but not from other machines. A host IP in the compose port
+("0.0.0.0:8080:80", "192.168.1.5:8080:80") binds that address instead, and
+--expose-all binds every port without one to all interfaces.
 
 Flags:
   -d, --detach       Run in the background (timeout becomes a warning, not an error)
-  --build            Build images (from build.context in compose) before starting
+  --build            Build images (from build.context in compose) before starting,
+                     up to 4 in parallel (output prefixed with the service name).
+                     Services whose build context (non-.dockerignored files,
+                     Dockerfile path, build args) is unchanged since their last
+                     build, and whose image is still in K3s, are skipped.
+  --platform <p>     With --build, build for this platform (e.g. linux/amd64)
+                     instead of each service's compose "platform:" (see
+                     'kappal build --help')
+  --no-cache         With --build, rebuild every layer instead of using the Docker build cache
   --timeout <secs>   Seconds to wait for services to be ready (default 300)
-  -f <path>          Compose file path (default: docker-compose.yaml)
+  --expose-all       Bind published ports without a host IP to 0.0.0.0 (all
+                     interfaces, reachable from the network) instead of
+                     127.0.0.1. Changing it recreates the K3s container.
+  --progress-deadline <secs>
+                     progressDeadlineSeconds of every Deployment (default 600).
+                     A service whose rollout makes no progress for that long
+                     (e.g. pods stuck in CrashLoopBackOff) is marked failed
+                     with ProgressDeadlineExceeded, and up stops waiting and
+                     reports it with its failing pods, even before --timeout.
+  --pull missing     Before starting K3s, check that every image not built by
+                     compose exists in the local Docker image store or in its
+                     registry (manifest lookup, no download), and fail with one
+                     line per missing image instead of waiting for
+                     ImagePullBackOff. The registry lookup uses the same
+                     credentials as the pulls (see --registry-auth).
+  --registry-auth <path>
+                     Docker config.json with credentials for private
+                     registries (default: $DOCKER_CONFIG/config.json or
+                     ~/.docker/config.json, as written by 'docker login';
+                     credsStore/credHelpers such as Docker Desktop's keychain
+                     are queried). For every registry a pulled service image
+                     comes from that has credentials, kappal renders a
+                     kubernetes.io/dockerconfigjson Secret
+                     (kappal-registry-auth-<project>) and adds it as imagePullSecret to
+                     those services' pods. The Secret is also written to
+                     .kappal/manifests, so keep .kappal/ out of version control.
+  --progress <mode>  Progress output: plain (line-buffered, CI-friendly), tty
+                     (in-place updates), quiet (final result only). Defaults
+                     to tty when stdout is a terminal, plain otherwise.
+  --force            Re-apply manifests even if nothing changed since the last up.
+                     Without it, kappal skips kubectl apply (but still waits for
+                     readiness) when the rendered manifests, compose/secret/config
+                     file mtimes and the K3s container are unchanged and every
+                     service still has its Deployment (not scaled to 0) or Job
+                     in the cluster. --build always re-applies. With --build,
+                     also rebuilds images whose build context is unchanged.
+  --show-changes     Apply in-process (server-side apply) instead of via kubectl
+                     and print every object as created, updated or unchanged,
+                     with a field-level diff under each update, e.g.
+                       updated    Deployment/web
+                                    spec.replicas: 1 -> 2
+                     Implies --force.
+  --no-start         Create everything (K3s, namespace, volumes, secrets,
+                     Deployments, Jobs) without starting containers:
+                     Deployments get 0 replicas and Jobs are suspended.
+                     Same as 'kappal create'. 'kappal start' then resumes
+                     the Jobs and scales the Deployments up.
+                     Skips the readiness wait.
+  -o, --format <fmt> Output format: text (default) or json. With json, all
+                     progress goes to stderr and stdout gets one JSON object
+                     once up finishes (also when readiness times out):
+                       {"project", "namespace",
+                        "applied": false when the apply was skipped,
+                        "ready": all pods ready within --timeout,
+                        "error": readiness error, if any,
+                        "services": [same objects as 'kappal inspect']}
+  --label KEY=VALUE  Add a label to every generated resource (namespace,
+                     Deployments, Jobs, pods, Services, Ingresses, PVCs,
+                     Secrets, ConfigMaps), e.g. for cost attribution.
+                     Repeatable. Keys under kappal.io/ are reserved.
+                     Selectors (ps, logs, down, clean) still match only
+                     kappal.io/project and kappal.io/service, so extra
+                     labels never change what kappal finds. A namespace set
+                     with --namespace isn't labeled, since it may be shared.
+  -f <path>          Compose file path; repeat to merge overrides in order.
+                     Default: docker-compose.yaml, plus
+                     docker-compose.override.yaml when it exists.
   -p <name>          Override project name
+  --namespace <ns>   K8s namespace for resources (default: project name).
+                     Labels still use the project name; pass the same
+                     --namespace to down, ps, logs, exec and inspect.
+                     Fails if another project in the namespace already has
+                     resources with the same names (e.g. a shared service name).
 
 Examples:
   kappal up -d                  Start all services
   kappal up --build -d          Build images then start
   kappal up --timeout 600 -d    Wait up to 10 minutes for readiness
-  kappal -p myapp up -d         Start with explicit project name`,
+  kappal up --progress-deadline 120
+                                Give up on a crash-looping service after 2 minutes
+  kappal up --pull missing -d   Fail fast on a mistyped image name
+  kappal up --registry-auth ./ci-docker-config.json -d
+                                Pull private images with CI credentials
+  kappal up --progress plain -d Line-by-line output for CI logs
+  kappal up --force -d          Re-apply manifests even if unchanged
+  kappal up --show-changes -d   Show what each apply changed
+  kappal up -d --label team=payments --label env=dev
+                                Tag every resource for cost attribution
+  kappal up -d -o json | jq '.services[] | {name, status}'
+                                Script a deploy and read the end state
+  kappal -p myapp up -d         Start with explicit project name
+  kappal --profile debug up -d  Also start services in the debug profile`,
 	RunE: runUp,
 }
 
 func init() {
 	upCmd.Flags().BoolVarP(&upDetach, "detach", "d", false, "Run containers in the background")
 	upCmd.Flags().BoolVar(&upBuild, "build", false, "Build images before starting containers")
+	upCmd.Flags().StringVar(&upPlatform, "platform", "", "Target platform for --build (e.g. linux/amd64); overrides the compose platform")
+	upCmd.Flags().BoolVar(&upNoCache, "no-cache", false, "Do not use the build cache when building images (requires --build)")
 	upCmd.Flags().IntVar(&upTimeout, "timeout", 300, "Timeout in seconds waiting for services to be ready")
+	upCmd.Flags().BoolVar(&upExposeAll, "expose-all", false, "Bind published ports to 0.0.0.0 instead of 127.0.0.1")
+	upCmd.Flags().StringVar(&upK3sMemory, "k3s-memory", "", "Memory limit for the K3s container (e.g. 4g); default unlimited or "+k3s.MemoryEnv)
+	upCmd.Flags().BoolVar(&upEnableIngress, "enable-ingress", false, "Run the K3s Traefik ingress controller and publish host ports 80/443 for kappal.io/ingress-host routing")
+	upCmd.Flags().BoolVar(&upEnableMetrics, "enable-metrics", false, "Run the K3s metrics-server so stats and HorizontalPodAutoscalers get CPU/memory metrics")
+	upCmd.Flags().StringVar(&upK3sCPUs, "k3s-cpus", "", "CPU limit for the K3s container (e.g. 2); default unlimited or "+k3s.CPUsEnv)
+	upCmd.Flags().IntVar(&upProgressDeadline, "progress-deadline", int(transform.DefaultProgressDeadlineSeconds), "Seconds a Deployment rollout may make no progress before it is reported failed")
+	upCmd.Flags().BoolVar(&upForce, "force", false, "Re-apply manifests even if unchanged since the last up")
+	upCmd.Flags().BoolVar(&upShowChanges, "show-changes", false, "Print per-object created/updated/unchanged status and field diffs (implies --force)")
+	upCmd.Flags().StringVarP(&upFormat, "format", "o", "text", "Output format (text, json)")
+	upCmd.Flags().BoolVar(&upNoStart, "no-start", false, "Create services without starting them")
+	upCmd.Flags().StringVar(&upRegistryAuth, "registry-auth", "", "Docker config.json with private registry credentials (default: ~/.docker/config.json)")
+	upCmd.Flags().StringVar(&upPull, "pull", "", "Check images before applying: missing (fail fast if an image is neither local nor in its registry)")
+	upCmd.Flags().StringArrayVar(&upLabels, "label", nil, "Add a label (KEY=VALUE) to every generated resource (repeatable)")
+	upCmd.Flags().StringVar(&upProgress, "progress", "", "Progress output type (plain, tty, quiet); defaults to tty on a terminal, plain otherwise")
 }
 
 func runUp(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	if upFormat != "text" && upFormat != "json" {
+		return fmt.Errorf("invalid format %q (valid: text, json)", upFormat)
+	}
+	if upNoCache && !upBuild {
+		return fmt.Errorf("--no-cache requires --build")
+	}
+	if upPlatform != "" && !upBuild {
+		return fmt.Errorf("--platform requires --build")
+	}
+	if upProgressDeadline <= 0 {
+		return fmt.Errorf("--progress-deadline must be a positive number of seconds")
+	}
+	extraLabels, err := parseLabels(upLabels)
+	if err != nil {
+		return err
+	}
+	k3sResources, err := k3s.ParseResources(upK3sMemory, upK3sCPUs)
+	if err != nil {
+		return err
+	}
+	jsonOut := io.Writer(nil)
+	if upFormat == "json" {
+		// Progress, build and kubectl output all write to os.Stdout; send them
+		// to stderr so stdout carries only the final JSON document
+		jsonOut = os.Stdout
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+	}
+
+	progress, err := docker.ParseProgressMode(upProgress, docker.StdoutIsTerminal())
+	if err != nil {
+		return err
+	}
+	out := progress.Writer()
+
 	// Get project directory
 	projectDir, err := os.Getwd()
 	if err != nil {
@@ -72,23 +280,57 @@ func runUp(cmd *cobra.Command, args []string) error {
 	}
 
 	// Resolve compose file path
-	composePath := composeFile
-	if !filepath.IsAbs(composePath) {
-		composePath = filepath.Join(projectDir, composePath)
-	}
+	composePaths := composeFilePaths(projectDir)
 
 	// Load compose file
-	resolvedName := resolveProjectName(projectName, filepath.Dir(composePath))
-	project, err := compose.Load(composePath, resolvedName)
+	resolvedName := resolveProjectName(projectName, filepath.Dir(composePaths[0]))
+	project, err := compose.Load(composePaths, resolvedName, composeProfiles...)
 	if err != nil {
 		return fmt.Errorf("failed to load compose file: %w", err)
 	}
 
+	ns, err := resolveNamespace(namespace, project.Name)
+	if err != nil {
+		return err
+	}
+
 	compat := analyzeCompatibility(project)
 
-	fmt.Printf("Project: %s\n", project.Name)
+	fmt.Fprintf(out, "Project: %s\n", project.Name)
+	if ns != project.Name {
+		fmt.Fprintf(out, "Namespace: %s\n", ns)
+	}
 	for _, note := range compat.Notes {
-		fmt.Printf("Compatibility check: %s\n", note)
+		fmt.Fprintf(out, "Compatibility check: %s\n", note)
+	}
+	if len(compat.Blocking) > 0 {
+		return fmt.Errorf("compatibility check failed: %s", strings.Join(compat.Blocking, "; "))
+	}
+
+	// Fail fast if the daemon is down instead of erroring deep inside K3s startup
+	if err := docker.CheckDaemon(ctx); err != nil {
+		return err
+	}
+
+	auths, err := registryAuth(upRegistryAuth, project)
+	if err != nil {
+		return err
+	}
+
+	if upPull != "" {
+		if upPull != "missing" {
+			return fmt.Errorf("invalid --pull %q (valid: missing)", upPull)
+		}
+		dockerClient, err := docker.NewClient()
+		if err != nil {
+			return err
+		}
+		dockerClient.SetRegistryAuth(auths)
+		err = checkImages(ctx, dockerClient, project)
+		_ = dockerClient.Close()
+		if err != nil {
+			return err
+		}
 	}
 
 	// Create workspace directory
@@ -100,16 +342,22 @@ func runUp(cmd *cobra.Command, args []string) error {
 
 	// Transform compose to Kubernetes manifests
 	transformer := transform.NewTransformer(project)
+	transformer.SetNamespace(ns)
+	transformer.SetHostDir(os.Getenv("KAPPAL_HOST_DIR"))
+	transformer.SetNoStart(upNoStart)
+	transformer.SetExtraLabels(extraLabels)
+	transformer.SetProgressDeadline(int32(upProgressDeadline))
+	transformer.SetRegistryAuth(auths)
 	if err := transformer.Generate(ws); err != nil {
 		return fmt.Errorf("failed to generate workspace: %w", err)
 	}
 
-	fmt.Println("Generated Kappal workspace in .kappal/")
+	fmt.Fprintln(out, "Generated Kappal workspace in .kappal/")
 
 	// Discover existing state (if any) for awareness
 	discovered, _ := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false})
 	if discovered != nil && discovered.K3s.Status == "running" {
-		fmt.Println("K3s already running (discovered via labels)")
+		fmt.Fprintln(out, "K3s already running (discovered via labels)")
 	}
 
 	// Ensure K3s is running (ONLY Docker command - starts the container)
@@ -118,34 +366,41 @@ func runUp(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create K3s manager: %w", err)
 	}
 	defer func() { _ = k3sManager.Close() }()
+	k3sManager.SetProgress(progress)
+	k3sManager.SetNoCache(upNoCache)
+	k3sManager.SetForceBuild(upForce)
+
+	// driver_opts of the default network (e.g. MTU) apply to the K3s bridge network
+	k3sManager.SetNetworkOptions(project.Networks["default"].DriverOpts)
 
 	// Extract published ports from compose project for K3s port forwarding
-	var ports []k3s.PublishedPort
-	for _, svc := range project.Services {
-		if len(svc.Profiles) > 0 {
-			continue
-		}
-		for _, p := range svc.Ports {
-			published := p.Target
-			if p.Published != "" {
-				if v, err := strconv.ParseUint(p.Published, 10, 32); err == nil {
-					published = uint32(v)
-				}
-			}
-			proto := p.Protocol
-			if proto == "" {
-				proto = "tcp"
-			}
-			ports = append(ports, k3s.PublishedPort{
-				HostPort:      uint32(published),
-				ContainerPort: uint32(p.Target),
-				Protocol:      proto,
-			})
+	ports, err := publishedPorts(project)
+	if err != nil {
+		return err
+	}
+	if upEnableIngress {
+		if err := k3s.CheckIngressPorts(ports); err != nil {
+			return err
 		}
+		ports = append(ports, k3s.IngressPorts...)
+		k3sManager.SetIngress(true)
 	}
 	if err := k3sManager.SetPublishedPorts(ports); err != nil {
 		return err
 	}
+	if upExposeAll {
+		k3sManager.SetBindAddress("0.0.0.0")
+	}
+	k3sManager.SetResources(k3sResources)
+	k3sManager.SetMetrics(upEnableMetrics)
+	if low := privilegedPorts(ports); len(low) > 0 {
+		rootless := false
+		if dockerClient, err := docker.NewClient(); err == nil {
+			rootless, _ = dockerClient.Rootless(ctx)
+			_ = dockerClient.Close()
+		}
+		fmt.Fprintln(os.Stderr, privilegedPortWarning(low, rootless))
+	}
 
 	if err := k3sManager.EnsureRunning(ctx); err != nil {
 		return fmt.Errorf("failed to start K3s: %w", err)
@@ -155,23 +410,17 @@ func runUp(cmd *cobra.Command, args []string) error {
 
 	// Build images if requested
 	if upBuild {
+		var services []types.ServiceConfig
 		for _, svc := range project.Services {
-			if len(svc.Profiles) > 0 {
-				continue
-			}
-			if svc.Build != nil {
-				fmt.Printf("Building %s...\n", svc.Name)
-				dockerfile := ""
-				if svc.Build.Dockerfile != "" {
-					dockerfile = svc.Build.Dockerfile
-				}
-
-				// Only pass explicit build.args from compose file
-				if err := k3sManager.BuildImage(ctx, project.Name, svc.Name, svc.Build.Context, dockerfile, svc.Build.Args); err != nil {
-					return fmt.Errorf("failed to build %s: %w", svc.Name, err)
-				}
+			if compose.IsActive(project, svc) && svc.Build != nil {
+				services = append(services, svc)
 			}
 		}
+		sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+		useClusterBuilder(k3sManager)
+		if err := buildServices(ctx, k3sManager, project.Name, services, upPlatform, out); err != nil {
+			return err
+		}
 	}
 
 	if compat.NeedInitImage {
@@ -180,16 +429,68 @@ func runUp(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Delete existing Jobs before re-applying (Jobs are immutable in K8s)
-	deleteCtx, deleteCancel := context.WithTimeout(ctx, 10*time.Second)
-	defer deleteCancel()
-	if k8sClient, err := k8s.NewClient(kubeconfigPath); err == nil {
-		_ = k8sClient.DeleteJobs(deleteCtx, project.Name)
+	// Skip re-applying identical manifests to the same K3s instance
+	hashPath := filepath.Join(ws.GetRuntimeDir(), applyHashFile)
+	applyHash := ""
+	if current, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: false}); err == nil && current.K3s.ContainerID != "" {
+		applyHash, _ = computeApplyHash(filepath.Join(ws.GetManifestDir(), "all.yaml"), applyInputFiles(project), current.K3s.ContainerID)
 	}
 
-	// Apply manifests via kubectl (uses kubeconfig, NOT docker exec)
-	if err := kubectl.Apply(ctx, ws, kubeconfigPath, kubectl.ApplyOpts{AutoApprove: true}); err != nil {
-		return fmt.Errorf("failed to apply: %w", err)
+	forceApply := upForce || upBuild || upShowChanges
+	hashMatches := appliedHashMatches(hashPath, applyHash)
+	var live *state.State
+	if !forceApply && hashMatches {
+		// The hash only covers kappal's inputs; confirm the workloads it
+		// applied are still in the cluster (kubectl delete, manual scale)
+		live, _ = state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: true, Namespace: ns})
+	}
+
+	applied := false
+	if skipApply(forceApply, hashMatches, live, project) {
+		fmt.Fprintln(out, "Manifests unchanged since last up; skipping apply (use --force to re-apply)")
+	} else {
+		if ns != project.Name {
+			if err := checkSharedNamespace(ctx, ws, kubeconfigPath, ns, project.Name); err != nil {
+				return err
+			}
+		}
+
+		// Delete changed Jobs before re-applying (Jobs are immutable in K8s);
+		// unchanged ones are kept so they don't run again
+		deleteCtx, deleteCancel := context.WithTimeout(ctx, 10*time.Second)
+		defer deleteCancel()
+		if k8sClient, err := k8s.NewClient(kubeconfigPath); err == nil {
+			if manifest, err := os.ReadFile(filepath.Join(ws.GetManifestDir(), "all.yaml")); err == nil {
+				_ = k8sClient.DeleteJobs(deleteCtx, ns, project.Name, manifest, transform.ConfigChecksumAnnotation)
+			}
+		}
+
+		if upShowChanges {
+			if err := applyShowingChanges(ctx, ws, kubeconfigPath, out); err != nil {
+				_ = os.Remove(hashPath)
+				return fmt.Errorf("failed to apply: %w", err)
+			}
+		} else if err := kubectl.Apply(ctx, ws, kubeconfigPath, kubectl.ApplyOpts{AutoApprove: true, Quiet: progress == docker.ProgressQuiet}); err != nil {
+			// Apply manifests via kubectl (uses kubeconfig, NOT docker exec)
+			_ = os.Remove(hashPath)
+			return fmt.Errorf("failed to apply: %w", err)
+		}
+		applied = true
+		// Applying restores every Deployment's replicas, undoing 'kappal stop'
+		if err := ws.WriteStoppedReplicas(nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if applyHash != "" {
+			if err := os.WriteFile(hashPath, []byte(applyHash+"\n"), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not record applied manifest hash: %v\n", err)
+			}
+		}
+	}
+	if upNoStart {
+		// Record the replica counts so 'kappal start' can scale the services up
+		if err := ws.WriteStoppedReplicas(transformer.DeploymentReplicas()); err != nil {
+			return err
+		}
 	}
 
 	// Wait for pods via client-go (NOT docker exec kubectl)
@@ -198,9 +499,36 @@ func runUp(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create k8s client: %w", err)
 	}
 
-	fmt.Println("Waiting for services to be ready...")
-	labelSelector := fmt.Sprintf("kappal.io/project=%s", project.Name)
-	if err := k8sClient.WaitForPodsReady(ctx, project.Name, labelSelector, time.Duration(upTimeout)*time.Second); err != nil {
+	var readyErr error
+	if !upNoStart {
+		fmt.Fprintln(out, "Waiting for services to be ready...")
+		deadline := time.Now().Add(time.Duration(upTimeout) * time.Second)
+		// Jobs first: a failed migration Job fails up early instead of
+		// leaving the services that wait on it to time out
+		for _, name := range jobServices(project) {
+			if readyErr = k8sClient.WaitForJobComplete(ctx, ns, name, time.Until(deadline)); readyErr != nil {
+				break
+			}
+		}
+		if readyErr == nil {
+			labelSelector := fmt.Sprintf("kappal.io/project=%s", project.Name)
+			readyErr = k8sClient.WaitForPodsReady(ctx, ns, labelSelector, time.Until(deadline))
+		}
+	}
+	if jsonOut != nil {
+		final, err := state.Discover(ctx, project.Name, workspaceDir, state.DiscoverOpts{QueryK8s: true, Namespace: ns})
+		if err != nil {
+			return fmt.Errorf("failed to discover state: %w", err)
+		}
+		if err := writeUpResult(jsonOut, newUpResult(final, project, applied, readyErr)); err != nil {
+			return err
+		}
+	}
+	if upNoStart {
+		fmt.Println("Services created but not started. Use 'kappal start' to start them.")
+		return nil
+	}
+	if err := readyErr; err != nil {
 		if upDetach {
 			fmt.Fprintf(os.Stderr, "Warning: %v (services may still be starting)\n", err)
 			fmt.Println("Services starting in background. Use 'kappal ps' to check status.")
@@ -214,9 +542,332 @@ func runUp(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// checkSharedNamespace fails when applying into a --namespace would take over
+// objects another kappal project already created there under the same names.
+func checkSharedNamespace(ctx context.Context, ws *workspace.Workspace, kubeconfigPath, ns, projectName string) error {
+	manifest, err := os.ReadFile(filepath.Join(ws.GetManifestDir(), "all.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to read manifests: %w", err)
+	}
+	client, err := k8s.NewClient(kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	conflicts, err := client.ProjectConflicts(ctx, manifest, projectName)
+	if err != nil {
+		return fmt.Errorf("failed to check namespace %s: %w", ns, err)
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("namespace %s is already used by another project with the same resource names: %s; rename the services or choose another --namespace", ns, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// completionDependents returns the sorted services that wait for name with
+// service_completed_successfully.
+func completionDependents(project *types.Project, name string) []string {
+	var waiting []string
+	for _, svc := range project.Services {
+		if dep, ok := svc.DependsOn[name]; ok && dep.Condition == types.ServiceConditionCompletedSuccessfully {
+			waiting = append(waiting, svc.Name)
+		}
+	}
+	sort.Strings(waiting)
+	return waiting
+}
+
+// jobServices returns the names of the active services deployed as Jobs,
+// sorted.
+func jobServices(project *types.Project) []string {
+	var names []string
+	for _, name := range project.ServiceNames() {
+		if svc := project.Services[name]; compose.IsActive(project, svc) && compose.IsOneShot(svc) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// registryAuth loads the credentials for the registries the project's
+// pulled images come from, from the Docker config at path. An empty path
+// uses the default Docker config, which need not exist.
+func registryAuth(path string, project *types.Project) (map[string]registry.AuthConfig, error) {
+	configPath := path
+	if configPath == "" {
+		configPath = docker.DefaultConfigPath()
+	}
+	var hosts []string
+	for _, name := range project.ServiceNames() {
+		svc := project.Services[name]
+		if compose.IsActive(project, svc) && svc.Build == nil && svc.Image != "" {
+			hosts = append(hosts, docker.RegistryHost(svc.Image))
+		}
+	}
+	if len(hosts) == 0 || configPath == "" {
+		return nil, nil
+	}
+	auths, err := docker.LoadRegistryAuth(configPath, hosts)
+	if err != nil {
+		if os.IsNotExist(err) && path == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load registry credentials: %w", err)
+	}
+	return auths, nil
+}
+
+// imageChecker looks up images locally and in their registries.
+type imageChecker interface {
+	ImageExists(ctx context.Context, imageName string) bool
+	ImageInRegistry(ctx context.Context, imageName string) error
+}
+
+// checkImages returns an error naming every active service whose image is
+// neither in the local image store nor in its registry. Images built by a
+// service in the project are skipped.
+func checkImages(ctx context.Context, images imageChecker, project *types.Project) error {
+	built := map[string]bool{}
+	for _, svc := range project.Services {
+		if svc.Build != nil && svc.Image != "" {
+			built[svc.Image] = true
+		}
+	}
+
+	var missing []string
+	for _, name := range project.ServiceNames() {
+		svc := project.Services[name]
+		if !compose.IsActive(project, svc) || svc.Build != nil || svc.Image == "" || built[svc.Image] {
+			continue
+		}
+		if images.ImageExists(ctx, svc.Image) {
+			continue
+		}
+		if err := images.ImageInRegistry(ctx, svc.Image); err != nil {
+			missing = append(missing, fmt.Sprintf("service %q: image %q not found locally or in its registry (%v)", name, svc.Image, err))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("image check failed:\n  %s", strings.Join(missing, "\n  "))
+	}
+	return nil
+}
+
+// upResult is the -o json document printed when up finishes.
+type upResult struct {
+	Project   string           `json:"project"`
+	Namespace string           `json:"namespace"`
+	Applied   bool             `json:"applied"`
+	Ready     bool             `json:"ready"`
+	Error     string           `json:"error,omitempty"`
+	Services  []inspectService `json:"services"`
+}
+
+// newUpResult describes the end state of an up from the state discovered
+// after the readiness wait.
+func newUpResult(final *state.State, project *types.Project, applied bool, readyErr error) upResult {
+	result := upResult{
+		Project:   project.Name,
+		Namespace: final.Namespace,
+		Applied:   applied,
+		Ready:     readyErr == nil,
+		Services:  inspectServices(final, project),
+	}
+	if readyErr != nil {
+		result.Error = readyErr.Error()
+	}
+	return result
+}
+
+func writeUpResult(out io.Writer, result upResult) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return fmt.Errorf("failed to write JSON output: %w", err)
+	}
+	return nil
+}
+
+// skipApply reports whether up can skip re-applying manifests: nothing forces
+// an apply, the recorded hash matches, and live (queried from K8s) still has
+// a workload for every active service, with Deployments not scaled to zero.
+func skipApply(force, hashMatches bool, live *state.State, project *types.Project) bool {
+	if force || !hashMatches || live == nil || !live.K8sAvailable {
+		return false
+	}
+	for _, svc := range project.Services {
+		if !compose.IsActive(project, svc) {
+			continue
+		}
+		info, ok := live.Services[svc.Name]
+		if !ok {
+			return false
+		}
+		if info.Kind == "Deployment" && (info.Replicas == nil || info.Replicas.Desired == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyShowingChanges applies the workspace manifest in-process and prints
+// what happened to each object.
+func applyShowingChanges(ctx context.Context, ws *workspace.Workspace, kubeconfigPath string, out io.Writer) error {
+	manifest, err := os.ReadFile(filepath.Join(ws.GetManifestDir(), "all.yaml"))
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	k8sClient, err := k8s.NewClient(kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to create k8s client: %w", err)
+	}
+
+	results, err := k8sClient.ApplyManifest(ctx, manifest)
+	// Report what was applied even when a later object failed
+	writeApplyResults(out, results)
+	return err
+}
+
+// writeApplyResults prints one line per applied object, followed by the
+// indented field diff of updated objects.
+func writeApplyResults(out io.Writer, results []k8s.ApplyResult) {
+	for _, r := range results {
+		_, _ = fmt.Fprintf(out, "%-10s %s/%s\n", r.Action, r.Kind, r.Name)
+		for _, line := range r.Diff {
+			_, _ = fmt.Fprintf(out, "             %s\n", line)
+		}
+	}
+}
+
+// applyInputFiles lists the files whose changes invalidate a previous apply:
+// the compose files plus file-backed secrets and configs.
+func applyInputFiles(project *types.Project) []string {
+	files := append([]string{}, project.ComposeFiles...)
+	for _, s := range project.Secrets {
+		if s.File != "" {
+			files = append(files, s.File)
+		}
+	}
+	for _, c := range project.Configs {
+		if c.File != "" {
+			files = append(files, c.File)
+		}
+	}
+	for i, f := range files {
+		if !filepath.IsAbs(f) {
+			files[i] = filepath.Join(project.WorkingDir, f)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// computeApplyHash hashes the rendered manifest, the mtimes of its input
+// files and the K3s container ID, so a recreated cluster always re-applies.
+func computeApplyHash(manifestPath string, inputs []string, k3sContainerID string) (string, error) {
+	manifest, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	h := sha256.New()
+	h.Write(manifest)
+	fmt.Fprintf(h, "\nk3s=%s\n", k3sContainerID)
+	for _, f := range inputs {
+		mtime := "missing"
+		if info, err := os.Stat(f); err == nil {
+			mtime = strconv.FormatInt(info.ModTime().UnixNano(), 10)
+		}
+		fmt.Fprintf(h, "%s=%s\n", f, mtime)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// appliedHashMatches reports whether hash equals the one recorded at hashPath
+// by the last successful apply. An empty hash never matches.
+func appliedHashMatches(hashPath, hash string) bool {
+	if hash == "" {
+		return false
+	}
+	recorded, err := os.ReadFile(hashPath)
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(recorded)) == hash
+}
+
+// publishedPorts lists the ports of active services to publish on the K3s
+// container. compose-go has already expanded port ranges to one entry per port.
+func publishedPorts(project *types.Project) ([]k3s.PublishedPort, error) {
+	var ports []k3s.PublishedPort
+	for _, svc := range project.Services {
+		if !compose.IsActive(project, svc) {
+			continue
+		}
+		for _, p := range svc.Ports {
+			published, err := transform.PublishedPort(p)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", svc.Name, err)
+			}
+			proto := p.Protocol
+			if proto == "" {
+				proto = "tcp"
+			}
+			ports = append(ports, k3s.PublishedPort{
+				HostIP:        p.HostIP,
+				HostPort:      published,
+				ContainerPort: uint32(p.Target),
+				Protocol:      proto,
+			})
+		}
+	}
+	return ports, nil
+}
+
+// privilegedPorts returns the sorted, distinct published host ports below
+// 1024, which need elevated privileges to bind.
+func privilegedPorts(ports []k3s.PublishedPort) []uint32 {
+	seen := map[uint32]bool{}
+	var low []uint32
+	for _, p := range ports {
+		if p.HostPort == 0 || p.HostPort >= 1024 || seen[p.HostPort] {
+			continue
+		}
+		seen[p.HostPort] = true
+		low = append(low, p.HostPort)
+	}
+	sort.Slice(low, func(i, j int) bool { return low[i] < low[j] })
+	return low
+}
+
+// privilegedPortWarning explains that publishing low ports may fail, with
+// the rootless-Docker fix when the daemon runs rootless.
+func privilegedPortWarning(ports []uint32, rootless bool) string {
+	list := make([]string, len(ports))
+	for i, p := range ports {
+		list[i] = strconv.FormatUint(uint64(p), 10)
+	}
+	msg := fmt.Sprintf("Warning: published host port(s) %s are below 1024 and need elevated privileges to bind", strings.Join(list, ", "))
+	if rootless {
+		return msg + ".\n  Docker is running rootless, so starting K3s will likely fail. Either publish a port >= 1024\n  (e.g. \"8080:80\") or allow low ports with 'sudo sysctl net.ipv4.ip_unprivileged_port_start=" + list[0] + "'."
+	}
+	return msg + "; if starting K3s fails to bind them, publish a port >= 1024 instead (e.g. \"8080:80\")."
+}
+
+// hasCPURequest reports whether a service's container gets a CPU request,
+// i.e. deploy.resources sets cpus in its limits or reservations.
+func hasCPURequest(svc types.ServiceConfig) bool {
+	if svc.Deploy == nil {
+		return false
+	}
+	r := svc.Deploy.Resources
+	return (r.Limits != nil && r.Limits.NanoCPUs > 0) || (r.Reservations != nil && r.Reservations.NanoCPUs > 0)
+}
+
 type compatibilityReport struct {
 	NeedInitImage bool
 	Notes         []string
+	// Blocking lists problems that make 'kappal up' fail
+	Blocking []string
 }
 
 func analyzeCompatibility(project *types.Project) compatibilityReport {
@@ -230,8 +881,14 @@ func analyzeCompatibility(project *types.Project) compatibilityReport {
 		report.Notes = append(report.Notes, msg)
 	}
 
+	if ports, err := publishedPorts(project); err != nil {
+		report.Blocking = append(report.Blocking, err.Error())
+	} else if err := k3s.CheckPublishedPorts(ports); err != nil {
+		report.Blocking = append(report.Blocking, err.Error())
+	}
+
 	for _, svc := range project.Services {
-		if len(svc.Profiles) > 0 {
+		if !compose.IsActive(project, svc) {
 			continue
 		}
 
@@ -247,26 +904,203 @@ func analyzeCompatibility(project *types.Project) compatibilityReport {
 			addNote(fmt.Sprintf("service %q uses writable bind mounts; enabling compatibility init for permissions", svc.Name))
 		}
 
+		if compose.IsOneShot(svc) && svc.Deploy != nil && svc.Deploy.Replicas != nil && *svc.Deploy.Replicas > 1 {
+			addNote(fmt.Sprintf("service %q has restart: %s with deploy.replicas=%d; it runs once as a Job and replicas are ignored", svc.Name, svc.Restart, *svc.Deploy.Replicas))
+		}
+
+		extraHosts := svc.ExtraHosts.AsList("=")
+		sort.Strings(extraHosts)
+		for _, entry := range extraHosts {
+			host, ip, _ := strings.Cut(entry, "=")
+			if net.ParseIP(strings.Trim(ip, "[]")) == nil {
+				addNote(fmt.Sprintf("service %q extra_hosts entry %s=%s is not an IP address (host-gateway is Docker-only) and will be ignored", svc.Name, host, ip))
+			}
+		}
+
+		if _, ok := transform.NodeSelector(svc); !ok {
+			addNote(fmt.Sprintf("service %q %s is not a map of node label to value and will be ignored", svc.Name, transform.NodeSelectorExtension))
+		}
+
+		sysctls := make([]string, 0, len(svc.Sysctls))
+		for name := range svc.Sysctls {
+			sysctls = append(sysctls, name)
+		}
+		sort.Strings(sysctls)
+		for _, name := range sysctls {
+			switch safe, namespaced := transform.ClassifySysctl(name); {
+			case !namespaced:
+				addNote(fmt.Sprintf("service %q sysctl %s is node-level and can't be set per pod; it will be ignored (set it on the Docker host, e.g. sysctl -w %s=%s)", svc.Name, name, name, svc.Sysctls[name]))
+			case !safe:
+				addNote(fmt.Sprintf("service %q sysctl %s is unsafe; the kubelet rejects the pod (SysctlForbidden) unless K3s allows it via --allowed-unsafe-sysctls", svc.Name, name))
+			}
+		}
+
+		if len(svc.DNS) > 0 {
+			addNote(fmt.Sprintf("service %q sets dns; its pods use only those servers (dnsPolicy: None), so other services' names won't resolve unless the servers forward to cluster DNS", svc.Name))
+		}
+
+		if svc.NetworkMode == "host" {
+			addNote(fmt.Sprintf("service %q uses network_mode: host; it shares the K3s node's network (not the Docker host's) and gets no Service, so other services can't reach it by name", svc.Name))
+			if svc.Deploy != nil && svc.Deploy.Replicas != nil && *svc.Deploy.Replicas > 1 {
+				addNote(fmt.Sprintf("service %q uses network_mode: host with deploy.replicas=%d; replicas share the node's ports and all but one may fail to bind", svc.Name, *svc.Deploy.Replicas))
+			}
+		}
+		if svc.NetworkMode == "none" {
+			if len(svc.Ports) > 0 {
+				report.Blocking = append(report.Blocking, fmt.Sprintf("service %q uses network_mode: none but publishes ports (Docker rejects this too); remove ports or network_mode", svc.Name))
+			}
+			addNote(fmt.Sprintf("service %q uses network_mode: none; it gets no Service and joins no network, but K8s pods always have a network interface, so it is not fully isolated without a deny-all NetworkPolicy", svc.Name))
+		}
+		if svc.Platform != "" && svc.Build == nil {
+			addNote(fmt.Sprintf("service %q sets platform: %s but is not built by kappal; K3s pulls the image variant for its own architecture", svc.Name, svc.Platform))
+		}
+
+		if value, ok := svc.Labels[transform.EphemeralStorageLabel]; ok {
+			if _, err := resource.ParseQuantity(value); err != nil {
+				addNote(fmt.Sprintf("service %q label %s=%q is not a valid quantity (e.g. 2Gi) and will be ignored", svc.Name, transform.EphemeralStorageLabel, value))
+			}
+		}
+
+		if svc.Deploy != nil && svc.Deploy.Resources.Limits != nil && svc.Deploy.Resources.Reservations != nil {
+			limits, reservations := svc.Deploy.Resources.Limits, svc.Deploy.Resources.Reservations
+			if limits.NanoCPUs > 0 && reservations.NanoCPUs > limits.NanoCPUs {
+				report.Blocking = append(report.Blocking, fmt.Sprintf("service %q reserves %v cpus but is limited to %v; Kubernetes rejects a request above its limit", svc.Name, reservations.NanoCPUs, limits.NanoCPUs))
+			}
+			if limits.MemoryBytes > 0 && reservations.MemoryBytes > limits.MemoryBytes {
+				report.Blocking = append(report.Blocking, fmt.Sprintf("service %q reserves %d bytes of memory but is limited to %d; Kubernetes rejects a request above its limit", svc.Name, reservations.MemoryBytes, limits.MemoryBytes))
+			}
+		}
+
+		if warning := transform.ThrottledProbeWarning(svc); warning != "" {
+			addNote(warning)
+		}
+
+		if _, ok := svc.Labels[transform.HPAMaxReplicasLabel]; ok && !compose.IsOneShot(svc) {
+			addNote(fmt.Sprintf("service %q autoscales with a HorizontalPodAutoscaler; K3s runs without metrics-server unless up --enable-metrics is given, so it stays at its minimum replicas until CPU metrics are available", svc.Name))
+			if _, err := strconv.ParseInt(svc.Labels[transform.HPATargetCPULabel], 10, 32); (svc.Labels[transform.HPATargetCPULabel] == "" || err == nil) && !hasCPURequest(svc) {
+				addNote(fmt.Sprintf("service %q autoscales on CPU utilization, which is relative to a CPU request it doesn't have; set deploy.resources cpus or use an absolute target such as %s: 250m", svc.Name, transform.HPATargetCPULabel))
+			}
+		}
+
+		if host, ok := svc.Labels[transform.IngressHostLabel]; ok {
+			if compose.IsOneShot(svc) {
+				addNote(fmt.Sprintf("service %q is a one-shot Job; label %s=%q will be ignored", svc.Name, transform.IngressHostLabel, host))
+			} else {
+				addNote(fmt.Sprintf("service %q gets an Ingress for host %q; K3s runs without the Traefik ingress controller unless up --enable-ingress is given, so the host isn't routed until one is installed", svc.Name, host))
+			}
+		}
+
+		if value, ok := svc.Labels[transform.JobTTLLabel]; ok && value != "never" {
+			if waiting := completionDependents(project, svc.Name); len(waiting) > 0 {
+				addNote(fmt.Sprintf("service %q label %s=%q will be ignored; %s waits for it with service_completed_successfully, so its Job is kept until 'kappal down'", svc.Name, transform.JobTTLLabel, value, strings.Join(waiting, ", ")))
+			} else if ttl, err := strconv.ParseInt(value, 10, 32); err != nil || ttl < 0 {
+				addNote(fmt.Sprintf("service %q label %s=%q is not a number of seconds or \"never\" and will be ignored; finished Jobs are deleted after %ds", svc.Name, transform.JobTTLLabel, value, transform.DefaultJobTTL))
+			}
+		}
+
+		if value, ok := svc.Labels[transform.FSGroupLabel]; ok {
+			if gid, err := strconv.ParseInt(value, 10, 64); err != nil || gid < 0 {
+				addNote(fmt.Sprintf("service %q label %s=%q is not a numeric GID and will be ignored; fsGroup falls back to the user/group_add gid or %d", svc.Name, transform.FSGroupLabel, value, transform.DefaultFSGroup))
+			}
+		}
+
+		if svc.Deploy != nil && svc.Deploy.Resources.Reservations != nil {
+			for _, r := range svc.Deploy.Resources.Reservations.GenericResources {
+				if r.DiscreteResourceSpec == nil || strings.Contains(r.DiscreteResourceSpec.Kind, "/") {
+					continue
+				}
+				addNote(fmt.Sprintf("service %q generic resource %q is not a domain-qualified K8s extended resource name (e.g. example.com/%s); the API server may reject it", svc.Name, r.DiscreteResourceSpec.Kind, r.DiscreteResourceSpec.Kind))
+			}
+		}
+
+		if svc.MemSwapLimit != 0 {
+			addNote(fmt.Sprintf("service %q sets memswap_limit, which is not supported and will be ignored; K8s has no per-container swap limit (node swap support is alpha and off by default)", svc.Name))
+		}
+
+		if svc.User != "" {
+			if _, _, err := transform.ParseUser(svc.User); err != nil {
+				addNote(fmt.Sprintf("service %q user %q is not numeric and will be ignored; K8s runs containers by numeric uid[:gid]", svc.Name, svc.User))
+			}
+		}
+
+		for _, group := range svc.GroupAdd {
+			if _, err := strconv.ParseInt(group, 10, 64); err != nil {
+				addNote(fmt.Sprintf("service %q group_add %q is not a numeric GID and will be ignored; use the numeric group ID instead", svc.Name, group))
+			}
+		}
+
+		var fileRefs []types.FileReferenceConfig
+		for _, s := range svc.Secrets {
+			fileRefs = append(fileRefs, types.FileReferenceConfig(s))
+		}
+		for _, c := range svc.Configs {
+			fileRefs = append(fileRefs, types.FileReferenceConfig(c))
+		}
+		for _, ref := range fileRefs {
+			if ref.UID == "" && ref.GID == "" {
+				continue
+			}
+			report.NeedInitImage = true
+			addNote(fmt.Sprintf("service %q sets uid/gid on %q; enabling compatibility init to set file ownership", svc.Name, ref.Source))
+			for _, id := range []string{ref.UID, ref.GID} {
+				if _, err := strconv.ParseInt(id, 10, 64); id != "" && err != nil {
+					addNote(fmt.Sprintf("service %q uid/gid %q on %q is not numeric and will be ignored", svc.Name, id, ref.Source))
+				}
+			}
+		}
+
 		for depName, depConfig := range svc.DependsOn {
 			depSvc, ok := project.Services[depName]
+			if !ok {
+				if disabled, isDisabled := project.DisabledServices[depName]; isDisabled {
+					depSvc, ok = disabled, true
+				}
+			}
 			if !ok {
 				addNote(fmt.Sprintf("service %q depends_on %q which is not defined in compose", svc.Name, depName))
 				continue
 			}
-			if len(depSvc.Profiles) > 0 {
-				addNote(fmt.Sprintf("service %q depends_on profiled service %q; enable matching profile(s) if needed", svc.Name, depName))
+			if !compose.IsActive(project, depSvc) || isDisabledService(project, depName) {
+				// The dependency is never deployed, so its init wait would block forever
+				report.Blocking = append(report.Blocking, fmt.Sprintf("service %q depends_on %q, which is only enabled by profile(s) %s; pass --profile %s, label %q with %s: \"true\" or drop the dependency",
+					svc.Name, depName, strings.Join(depSvc.Profiles, ", "), depSvc.Profiles[0], depName, compose.AlwaysOnLabel))
 				continue
 			}
 
 			switch depConfig.Condition {
 			case "service_completed_successfully":
-				if depSvc.Restart == "no" {
+				if compose.IsOneShot(depSvc) {
 					report.NeedInitImage = true
 				}
 			case "service_healthy":
-				if depSvc.Restart != "no" {
+				if !compose.IsOneShot(depSvc) {
 					report.NeedInitImage = true
 				}
+			case "service_started":
+				report.NeedInitImage = true
+			}
+		}
+	}
+
+	for _, kind := range []string{"secret", "config"} {
+		labels := map[string]types.Labels{}
+		if kind == "secret" {
+			for name, s := range project.Secrets {
+				labels[name] = s.Labels
+			}
+		} else {
+			for name, c := range project.Configs {
+				labels[name] = c.Labels
+			}
+		}
+		names := make([]string, 0, len(labels))
+		for name := range labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := transform.Interpolate(labels[name]); !ok {
+				addNote(fmt.Sprintf("%s %q has an invalid %s label %q (expected true or false); its content is not interpolated", kind, name, transform.InterpolateLabel, labels[name][transform.InterpolateLabel]))
 			}
 		}
 	}
@@ -274,9 +1108,17 @@ func analyzeCompatibility(project *types.Project) compatibilityReport {
 	return report
 }
 
+// isDisabledService reports whether compose-go disabled the service because
+// none of its profiles is active.
+func isDisabledService(project *types.Project, name string) bool {
+	_, ok := project.DisabledServices[name]
+	return ok
+}
+
 // shouldLoadInitImage returns true when any active service needs kappal-init:
 // - dependency waits (service_completed_successfully/service_healthy)
 // - writable bind mount preparation for non-root workloads
+// - secret/config files with a compose uid/gid
 func shouldLoadInitImage(project *types.Project) bool {
 	return analyzeCompatibility(project).NeedInitImage
 }
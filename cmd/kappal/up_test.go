@@ -1,10 +1,25 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/k3s"
+	"github.com/kappal-app/kappal/pkg/k8s"
+	"github.com/kappal-app/kappal/pkg/state"
+	"github.com/kappal-app/kappal/pkg/transform"
 )
 
 func TestShouldLoadInitImage(t *testing.T) {
@@ -24,6 +39,21 @@ func TestShouldLoadInitImage(t *testing.T) {
 		}
 	})
 
+	t.Run("service_started dependency", func(t *testing.T) {
+		project := &types.Project{
+			Services: types.Services{
+				"db": {Name: "db"},
+				"app": {
+					Name:      "app",
+					DependsOn: types.DependsOnConfig{"db": {Condition: "service_started"}},
+				},
+			},
+		}
+		if !shouldLoadInitImage(project) {
+			t.Fatal("expected init image load for a service_started dependency")
+		}
+	})
+
 	t.Run("read-only bind mount only", func(t *testing.T) {
 		project := &types.Project{
 			Services: types.Services{
@@ -80,6 +110,22 @@ func TestShouldLoadInitImage(t *testing.T) {
 		}
 	})
 
+	t.Run("config ownership requires init image", func(t *testing.T) {
+		project := &types.Project{
+			Services: types.Services{
+				"app": {
+					Name: "app",
+					Configs: []types.ServiceConfigObjConfig{
+						{Source: "app_config", Target: "/etc/app/config.yaml", UID: "1000"},
+					},
+				},
+			},
+		}
+		if !shouldLoadInitImage(project) {
+			t.Fatal("expected init image load for config with uid")
+		}
+	})
+
 	t.Run("dependency on profiled service is ignored", func(t *testing.T) {
 		project := &types.Project{
 			Services: types.Services{
@@ -131,10 +177,832 @@ func TestAnalyzeCompatibilityNotes(t *testing.T) {
 	if !strings.Contains(joined, `service "app" uses writable bind mounts`) {
 		t.Fatalf("expected writable bind mount note, got: %s", joined)
 	}
-	if !strings.Contains(joined, `service "app" depends_on profiled service "db"`) {
-		t.Fatalf("expected profiled dependency note, got: %s", joined)
+	if strings.Contains(joined, `"db"`) {
+		t.Fatalf("profiled dependency should be blocking, not a note, got: %s", joined)
+	}
+	if len(report.Blocking) != 1 || !strings.Contains(report.Blocking[0], `service "app" depends_on "db", which is only enabled by profile(s) manual`) {
+		t.Fatalf("expected a blocking profiled dependency issue, got: %v", report.Blocking)
 	}
 	if !strings.Contains(joined, `service "app" depends_on "missing"`) {
 		t.Fatalf("expected missing dependency note, got: %s", joined)
 	}
 }
+
+func TestAnalyzeCompatibilityGenericResourceName(t *testing.T) {
+	reservations := func(kind string) *types.DeployConfig {
+		return &types.DeployConfig{Resources: types.Resources{Reservations: &types.Resource{
+			GenericResources: []types.GenericResource{{DiscreteResourceSpec: &types.DiscreteGenericResource{Kind: kind, Value: 1}}},
+		}}}
+	}
+	project := &types.Project{
+		Services: types.Services{
+			"app":    {Name: "app", Deploy: reservations("licenses")},
+			"worker": {Name: "worker", Deploy: reservations("example.com/licenses")},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "app" generic resource "licenses" is not a domain-qualified`) {
+		t.Errorf("expected note for unqualified generic resource, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "worker" generic resource`) {
+		t.Errorf("expected no note for a domain-qualified generic resource, got: %s", joined)
+	}
+}
+
+func TestApplyHashSkipsUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "all.yaml")
+	composeFile := filepath.Join(dir, "docker-compose.yaml")
+	hashPath := filepath.Join(dir, applyHashFile)
+	for _, f := range []string{manifest, composeFile} {
+		if err := os.WriteFile(f, []byte("kind: Deployment\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inputs := []string{composeFile}
+
+	first, err := computeApplyHash(manifest, inputs, "k3s-id")
+	if err != nil {
+		t.Fatalf("computeApplyHash failed: %v", err)
+	}
+	if appliedHashMatches(hashPath, first) {
+		t.Fatal("expected no match before any apply was recorded")
+	}
+	if err := os.WriteFile(hashPath, []byte(first+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := computeApplyHash(manifest, inputs, "k3s-id")
+	if err != nil {
+		t.Fatalf("computeApplyHash failed: %v", err)
+	}
+	if !appliedHashMatches(hashPath, second) {
+		t.Error("expected unchanged manifests and inputs to skip the apply")
+	}
+
+	if recreated, _ := computeApplyHash(manifest, inputs, "new-k3s-id"); appliedHashMatches(hashPath, recreated) {
+		t.Error("expected a recreated K3s container to force a re-apply")
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(composeFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if touched, _ := computeApplyHash(manifest, inputs, "k3s-id"); appliedHashMatches(hashPath, touched) {
+		t.Error("expected a modified input file to force a re-apply")
+	}
+
+	if appliedHashMatches(hashPath, "") {
+		t.Error("expected an empty hash to never match")
+	}
+}
+
+func TestAnalyzeCompatibilityJobReplicas(t *testing.T) {
+	three := 3
+	project := &types.Project{
+		Services: types.Services{
+			"migrate": {Name: "migrate", Restart: "no", Deploy: &types.DeployConfig{Replicas: &three}},
+			"web":     {Name: "web", Deploy: &types.DeployConfig{Replicas: &three}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "migrate" has restart: no with deploy.replicas=3`) {
+		t.Errorf("expected note for replicated one-shot service, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "web" has restart: no`) {
+		t.Errorf("expected no note for a replicated Deployment, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityUser(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"db":  {Name: "db", User: "postgres"},
+			"app": {Name: "app", User: "1000:1000"},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "db" user "postgres" is not numeric`) {
+		t.Errorf("expected note for named user, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "app" user`) {
+		t.Errorf("expected no note for numeric user, got: %s", joined)
+	}
+}
+
+func TestWriteApplyResults(t *testing.T) {
+	var out bytes.Buffer
+	writeApplyResults(&out, []k8s.ApplyResult{
+		{Kind: "Namespace", Name: "demo", Action: k8s.ApplyUnchanged},
+		{Kind: "Deployment", Namespace: "demo", Name: "web", Action: k8s.ApplyUpdated, Diff: []string{"spec.replicas: 1 -> 2"}},
+		{Kind: "ConfigMap", Namespace: "demo", Name: "settings", Action: k8s.ApplyCreated},
+	})
+
+	want := "unchanged  Namespace/demo\n" +
+		"updated    Deployment/web\n" +
+		"             spec.replicas: 1 -> 2\n" +
+		"created    ConfigMap/settings\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestSkipApply(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web":     {Name: "web"},
+			"migrate": {Name: "migrate", Restart: "no"},
+			"debug":   {Name: "debug", Profiles: []string{"debug"}},
+		},
+	}
+	healthy := func() *state.State {
+		return &state.State{
+			K8sAvailable: true,
+			Services: map[string]*state.ServiceInfo{
+				"web":     {Name: "web", Kind: "Deployment", Replicas: &state.Replicas{Ready: 1, Desired: 1}},
+				"migrate": {Name: "migrate", Kind: "Job"},
+			},
+		}
+	}
+
+	if !skipApply(false, true, healthy(), project) {
+		t.Error("expected skip when hash matches and every workload is live")
+	}
+	if skipApply(true, true, healthy(), project) {
+		t.Error("expected --force/--build/--show-changes to bypass the skip")
+	}
+	if skipApply(false, false, healthy(), project) {
+		t.Error("expected a changed hash to re-apply")
+	}
+	if skipApply(false, true, nil, project) {
+		t.Error("expected re-apply when live state is unknown")
+	}
+
+	unavailable := healthy()
+	unavailable.K8sAvailable = false
+	if skipApply(false, true, unavailable, project) {
+		t.Error("expected re-apply when the K8s API is unreachable")
+	}
+
+	deleted := healthy()
+	delete(deleted.Services, "migrate")
+	if skipApply(false, true, deleted, project) {
+		t.Error("expected re-apply when a Job was deleted outside kappal")
+	}
+
+	scaled := healthy()
+	scaled.Services["web"].Replicas.Desired = 0
+	if skipApply(false, true, scaled, project) {
+		t.Error("expected re-apply when a Deployment was scaled to 0 outside kappal")
+	}
+}
+
+func TestAnalyzeCompatibilityMemSwapLimit(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"db":  {Name: "db", MemSwapLimit: types.UnitBytes(2 << 30)},
+			"app": {Name: "app"},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "db" sets memswap_limit, which is not supported`) {
+		t.Errorf("expected note for memswap_limit, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "app" sets memswap_limit`) {
+		t.Errorf("expected no note without memswap_limit, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityDisabledDependency(t *testing.T) {
+	// An always-on service is re-enabled after compose-go disabled it, while
+	// its profiled dependency stays in DisabledServices
+	project := &types.Project{
+		Services: types.Services{
+			"app": {
+				Name:      "app",
+				Profiles:  []string{"debug"},
+				Labels:    types.Labels{compose.AlwaysOnLabel: "true"},
+				DependsOn: types.DependsOnConfig{"db": {Condition: "service_started"}},
+			},
+		},
+		DisabledServices: types.Services{
+			"db": {Name: "db", Profiles: []string{"debug"}},
+		},
+	}
+
+	report := analyzeCompatibility(project)
+	if len(report.Blocking) != 1 || !strings.Contains(report.Blocking[0], `service "app" depends_on "db", which is only enabled by profile(s) debug`) {
+		t.Fatalf("expected a blocking disabled dependency issue, got: %v", report.Blocking)
+	}
+
+	project.Services["db"] = types.ServiceConfig{Name: "db"}
+	delete(project.DisabledServices, "db")
+	if report := analyzeCompatibility(project); len(report.Blocking) != 0 {
+		t.Errorf("active dependency should not block, got: %v", report.Blocking)
+	}
+}
+
+func TestAnalyzeCompatibilityHPA(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web": {Name: "web", Labels: types.Labels{transform.HPAMaxReplicasLabel: "4"}},
+			"api": {Name: "api", Labels: types.Labels{transform.HPAMaxReplicasLabel: "4", transform.HPATargetCPULabel: "250m"}},
+			"worker": {Name: "worker", Labels: types.Labels{transform.HPAMaxReplicasLabel: "4"},
+				Deploy: &types.DeployConfig{Resources: types.Resources{Limits: &types.Resource{NanoCPUs: 0.5}}}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	for _, want := range []string{
+		`service "web" autoscales with a HorizontalPodAutoscaler; K3s runs without metrics-server`,
+		`service "web" autoscales on CPU utilization`,
+		`service "api" autoscales with a HorizontalPodAutoscaler`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected note %q, got: %s", want, joined)
+		}
+	}
+	if strings.Contains(joined, `service "api" autoscales on CPU utilization`) {
+		t.Errorf("absolute target should not get the utilization note, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "worker" autoscales on CPU utilization`) {
+		t.Errorf("a CPU limit is also the request, so no utilization note, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityResourceReservations(t *testing.T) {
+	resources := func(limit, reservation types.Resource) *types.DeployConfig {
+		return &types.DeployConfig{Resources: types.Resources{Limits: &limit, Reservations: &reservation}}
+	}
+	project := &types.Project{
+		Services: types.Services{
+			"ok":     {Name: "ok", Deploy: resources(types.Resource{NanoCPUs: 1, MemoryBytes: 512 << 20}, types.Resource{NanoCPUs: 0.25, MemoryBytes: 256 << 20})},
+			"greedy": {Name: "greedy", Deploy: resources(types.Resource{NanoCPUs: 0.5, MemoryBytes: 256 << 20}, types.Resource{NanoCPUs: 1, MemoryBytes: 512 << 20})},
+		},
+	}
+
+	blocking := strings.Join(analyzeCompatibility(project).Blocking, "\n")
+	for _, want := range []string{`service "greedy" reserves 1 cpus but is limited to 0.5`, `service "greedy" reserves 536870912 bytes of memory`} {
+		if !strings.Contains(blocking, want) {
+			t.Errorf("expected blocking %q, got: %s", want, blocking)
+		}
+	}
+	if strings.Contains(blocking, `"ok"`) {
+		t.Errorf("reservations within limits should not block, got: %s", blocking)
+	}
+}
+
+func TestAnalyzeCompatibilityIngressHost(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web":     {Name: "web", Labels: types.Labels{transform.IngressHostLabel: "app.localhost"}},
+			"migrate": {Name: "migrate", Restart: "no", Labels: types.Labels{transform.IngressHostLabel: "migrate.localhost"}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	for _, want := range []string{
+		`service "web" gets an Ingress for host "app.localhost"`,
+		`service "migrate" is a one-shot Job; label kappal.io/ingress-host="migrate.localhost" will be ignored`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected note %q, got: %s", want, joined)
+		}
+	}
+}
+
+func TestAnalyzeCompatibilityHostNetwork(t *testing.T) {
+	replicas := 2
+	project := &types.Project{
+		Services: types.Services{
+			"agent":  {Name: "agent", NetworkMode: "host"},
+			"mdns":   {Name: "mdns", NetworkMode: "host", Deploy: &types.DeployConfig{Replicas: &replicas}},
+			"normal": {Name: "normal"},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	for _, want := range []string{
+		`service "agent" uses network_mode: host; it shares the K3s node's network`,
+		`service "mdns" uses network_mode: host with deploy.replicas=2`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected note %q, got: %s", want, joined)
+		}
+	}
+	if strings.Contains(joined, `service "agent" uses network_mode: host with`) || strings.Contains(joined, `service "normal"`) {
+		t.Errorf("unexpected note, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityNetworkNone(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"batch":  {Name: "batch", NetworkMode: "none"},
+			"leaky":  {Name: "leaky", NetworkMode: "none", Ports: []types.ServicePortConfig{{Target: 80, Published: "8080"}}},
+			"normal": {Name: "normal"},
+		},
+	}
+
+	report := analyzeCompatibility(project)
+	joined := strings.Join(report.Notes, "\n")
+	if !strings.Contains(joined, `service "batch" uses network_mode: none; it gets no Service`) {
+		t.Errorf("expected network_mode: none note, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "normal"`) {
+		t.Errorf("unexpected note, got: %s", joined)
+	}
+	blocking := strings.Join(report.Blocking, "\n")
+	if !strings.Contains(blocking, `service "leaky" uses network_mode: none but publishes ports`) || strings.Contains(blocking, `"batch"`) {
+		t.Errorf("expected only leaky to block, got: %s", blocking)
+	}
+}
+
+func TestAnalyzeCompatibilityPlatform(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"legacy": {Name: "legacy", Image: "legacy:1", Platform: "linux/amd64"},
+			"app":    {Name: "app", Platform: "linux/amd64", Build: &types.BuildConfig{Context: "."}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "legacy" sets platform: linux/amd64 but is not built by kappal`) {
+		t.Errorf("expected platform note for the pulled image, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "app"`) {
+		t.Errorf("built services honor platform and need no note, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityExtraHosts(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"app": {Name: "app", ExtraHosts: types.HostsList{
+				"db":                   {"10.0.0.5"},
+				"host.docker.internal": {"host-gateway"},
+			}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "app" extra_hosts entry host.docker.internal=host-gateway is not an IP address`) {
+		t.Errorf("expected note for host-gateway, got: %s", joined)
+	}
+	if strings.Contains(joined, "db=10.0.0.5") {
+		t.Errorf("expected no note for a valid entry, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityDNS(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"app":    {Name: "app", DNS: types.StringList{"10.0.0.2"}},
+			"search": {Name: "search", DNSSearch: types.StringList{"corp.example.com"}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "app" sets dns; its pods use only those servers`) {
+		t.Errorf("expected note for custom dns, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "search"`) {
+		t.Errorf("dns_search alone keeps cluster DNS and needs no note, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityNodeSelector(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"gpu":    {Name: "gpu", Extensions: types.Extensions{transform.NodeSelectorExtension: map[string]any{"gpu": "true"}}},
+			"broken": {Name: "broken", Extensions: types.Extensions{transform.NodeSelectorExtension: []any{"gpu=true"}}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "broken" x-kappal-node-selector is not a map of node label to value`) {
+		t.Errorf("expected note for invalid node selector, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "gpu"`) {
+		t.Errorf("expected no note for a valid node selector, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilitySysctls(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"search": {Name: "search", Sysctls: types.Mapping{
+				"vm.max_map_count":        "262144",
+				"net.core.somaxconn":      "1024",
+				"net.ipv4.tcp_syncookies": "1",
+			}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	for _, want := range []string{
+		`service "search" sysctl vm.max_map_count is node-level and can't be set per pod; it will be ignored (set it on the Docker host, e.g. sysctl -w vm.max_map_count=262144)`,
+		`service "search" sysctl net.core.somaxconn is unsafe`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected note %q, got: %s", want, joined)
+		}
+	}
+	if strings.Contains(joined, "tcp_syncookies") {
+		t.Errorf("expected no note for a safe sysctl, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityInterpolateLabel(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{"web": {Name: "web"}},
+		Configs: types.Configs{
+			"good": {File: "a.conf", Labels: types.Labels{transform.InterpolateLabel: "true"}},
+			"bad":  {File: "b.conf", Labels: types.Labels{transform.InterpolateLabel: "yes"}},
+		},
+		Secrets: types.Secrets{
+			"token": {File: "t.txt", Labels: types.Labels{transform.InterpolateLabel: "on"}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	for _, want := range []string{
+		`config "bad" has an invalid kappal.io/interpolate label "yes"`,
+		`secret "token" has an invalid kappal.io/interpolate label "on"`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("expected note %q, got: %s", want, joined)
+		}
+	}
+	if strings.Contains(joined, `"good"`) {
+		t.Errorf("unexpected note for a valid label, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityJobTTLLabel(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"migrate": {Name: "migrate", Restart: "no", Labels: types.Labels{transform.JobTTLLabel: "1h"}},
+			"seed":    {Name: "seed", Restart: "no", Labels: types.Labels{transform.JobTTLLabel: "never"}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "migrate" label kappal.io/job-ttl="1h" is not a number of seconds`) {
+		t.Errorf("expected note for invalid job-ttl label, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "seed"`) {
+		t.Errorf("expected no note for job-ttl never, got: %s", joined)
+	}
+
+	// A TTL on a Job others wait for is ignored
+	project.Services["migrate"] = types.ServiceConfig{Name: "migrate", Restart: "no", Labels: types.Labels{transform.JobTTLLabel: "60"}}
+	project.Services["web"] = types.ServiceConfig{Name: "web", DependsOn: types.DependsOnConfig{
+		"migrate": {Condition: types.ServiceConditionCompletedSuccessfully},
+	}}
+	joined = strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "migrate" label kappal.io/job-ttl="60" will be ignored; web waits for it`) {
+		t.Errorf("expected note for a ttl on an awaited Job, got: %s", joined)
+	}
+}
+
+func TestAnalyzeCompatibilityFSGroupLabel(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"db":  {Name: "db", Labels: types.Labels{transform.FSGroupLabel: "postgres"}},
+			"app": {Name: "app", Labels: types.Labels{transform.FSGroupLabel: "1000"}},
+		},
+	}
+
+	joined := strings.Join(analyzeCompatibility(project).Notes, "\n")
+	if !strings.Contains(joined, `service "db" label kappal.io/fs-group="postgres" is not a numeric GID`) {
+		t.Errorf("expected note for invalid fs-group label, got: %s", joined)
+	}
+	if strings.Contains(joined, `service "app" label kappal.io/fs-group`) {
+		t.Errorf("expected no note for numeric fs-group label, got: %s", joined)
+	}
+}
+
+func TestUpResultJSON(t *testing.T) {
+	project := &types.Project{
+		Name: "demo",
+		Services: types.Services{
+			"web":     {Name: "web", Image: "nginx"},
+			"migrate": {Name: "migrate", Image: "app", Restart: "no"},
+		},
+	}
+	final := &state.State{
+		Project:      "demo",
+		Namespace:    "demo-ns",
+		K8sAvailable: true,
+		Services: map[string]*state.ServiceInfo{
+			"web": {Name: "web", Kind: "Deployment", Image: "nginx", Status: "waiting", Replicas: &state.Replicas{Ready: 0, Desired: 1}},
+		},
+	}
+
+	var buf bytes.Buffer
+	result := newUpResult(final, project, true, errors.New("timeout waiting for pods to be ready"))
+	if err := writeUpResult(&buf, result); err != nil {
+		t.Fatalf("writeUpResult failed: %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	for key, want := range map[string]interface{}{
+		"project":   "demo",
+		"namespace": "demo-ns",
+		"applied":   true,
+		"ready":     false,
+		"error":     "timeout waiting for pods to be ready",
+	} {
+		if doc[key] != want {
+			t.Errorf("%s = %v, want %v", key, doc[key], want)
+		}
+	}
+	services, ok := doc["services"].([]interface{})
+	if !ok || len(services) != 2 {
+		t.Fatalf("services = %v, want 2 entries", doc["services"])
+	}
+	statuses := map[string]interface{}{}
+	for _, s := range services {
+		svc := s.(map[string]interface{})
+		statuses[svc["name"].(string)] = svc["status"]
+	}
+	if statuses["web"] != "waiting" || statuses["migrate"] != "missing" {
+		t.Errorf("statuses = %v, want web waiting and migrate missing", statuses)
+	}
+
+	buf.Reset()
+	if err := writeUpResult(&buf, newUpResult(final, project, false, nil)); err != nil {
+		t.Fatalf("writeUpResult failed: %v", err)
+	}
+	if strings.Contains(buf.String(), `"error"`) || !strings.Contains(buf.String(), `"ready": true`) {
+		t.Errorf("a ready up should omit error, got:\n%s", buf.String())
+	}
+}
+
+// fakeImages is an imageChecker with fixed local and registry images.
+type fakeImages struct {
+	local    map[string]bool
+	registry map[string]bool
+	lookups  []string
+}
+
+func (f *fakeImages) ImageExists(ctx context.Context, imageName string) bool {
+	return f.local[imageName]
+}
+
+func (f *fakeImages) ImageInRegistry(ctx context.Context, imageName string) error {
+	f.lookups = append(f.lookups, imageName)
+	if !f.registry[imageName] {
+		return fmt.Errorf("manifest unknown")
+	}
+	return nil
+}
+
+func TestCheckImages(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: ngnix:latest
+  db:
+    image: postgres:16
+  cache:
+    image: my-cache:dev
+  api:
+    build: .
+    image: myorg/api:dev
+  worker:
+    image: myorg/api:dev
+  debug:
+    image: missing/debug
+    profiles: [debug]
+`), "demo")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	images := &fakeImages{
+		local:    map[string]bool{"my-cache:dev": true},
+		registry: map[string]bool{"postgres:16": true},
+	}
+
+	err = checkImages(context.Background(), images, project)
+	if err == nil {
+		t.Fatal("expected an error for the missing image")
+	}
+	if !strings.Contains(err.Error(), `service "web": image "ngnix:latest" not found locally or in its registry (manifest unknown)`) {
+		t.Errorf("error should name the service and image, got: %v", err)
+	}
+	for _, ok := range []string{"postgres", "my-cache", "myorg/api", "missing/debug"} {
+		if strings.Contains(err.Error(), ok) {
+			t.Errorf("error should not mention %s, got: %v", ok, err)
+		}
+	}
+	if !reflect.DeepEqual(images.lookups, []string{"postgres:16", "ngnix:latest"}) {
+		t.Errorf("registry lookups = %v, want only images missing locally", images.lookups)
+	}
+
+	images.registry["ngnix:latest"] = true
+	if err := checkImages(context.Background(), images, project); err != nil {
+		t.Errorf("expected no error once every image is available, got: %v", err)
+	}
+}
+
+func TestPrivilegedPortWarning(t *testing.T) {
+	ports := []k3s.PublishedPort{
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostPort: 443, ContainerPort: 443, Protocol: "tcp"},
+		{HostPort: 53, ContainerPort: 53, Protocol: "udp"},
+		{HostPort: 53, ContainerPort: 53, Protocol: "tcp"},
+		{HostPort: 1024, ContainerPort: 1024, Protocol: "tcp"},
+	}
+	low := privilegedPorts(ports)
+	if !reflect.DeepEqual(low, []uint32{53, 443}) {
+		t.Fatalf("privilegedPorts = %v, want [53 443]", low)
+	}
+	if got := privilegedPorts(ports[:1]); len(got) != 0 {
+		t.Errorf("expected no privileged ports, got %v", got)
+	}
+
+	msg := privilegedPortWarning(low, false)
+	if !strings.Contains(msg, "53, 443 are below 1024") || strings.Contains(msg, "rootless") {
+		t.Errorf("unexpected warning: %s", msg)
+	}
+	msg = privilegedPortWarning(low, true)
+	if !strings.Contains(msg, "rootless") || !strings.Contains(msg, "ip_unprivileged_port_start=53") {
+		t.Errorf("rootless warning lacks guidance: %s", msg)
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	got, err := parseLabels([]string{"team=payments", "example.com/cost-center=42", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"team": "payments", "example.com/cost-center": "42", "empty": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLabels = %v, want %v", got, want)
+	}
+	if got, err := parseLabels(nil); got != nil || err != nil {
+		t.Errorf("parseLabels(nil) = %v, %v", got, err)
+	}
+
+	for flag, wantErr := range map[string]string{
+		"team":                   "expected KEY=VALUE",
+		"bad key=x":              "invalid --label key",
+		"team=not valid":         "invalid --label value",
+		"kappal.io/project=evil": "reserved",
+	} {
+		if _, err := parseLabels([]string{flag}); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("parseLabels(%q) error = %v, want %q", flag, err, wantErr)
+		}
+	}
+}
+
+func TestRegistryAuth(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"api":   {Name: "api", Image: "ghcr.io/org/api:1"},
+			"built": {Name: "built", Image: "registry.example.com/app", Build: &types.BuildConfig{Context: "."}},
+		},
+	}
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	// No Docker config yet: no credentials, no error
+	auths, err := registryAuth("", project)
+	if err != nil || len(auths) != 0 {
+		t.Fatalf("registryAuth without a config = %v, %v", auths, err)
+	}
+	if _, err := registryAuth(filepath.Join(dir, "missing.json"), project); err == nil {
+		t.Error("an explicit --registry-auth file that doesn't exist should fail")
+	}
+
+	config := `{"auths":{"ghcr.io":{"auth":"b2N0b2NhdDp0b2tlbg=="},"registry.example.com":{"auth":"dTpw"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	auths, err = registryAuth("", project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(auths) != 1 || auths["ghcr.io"].Username != "octocat" || auths["ghcr.io"].Password != "token" {
+		t.Errorf("registryAuth = %+v, want only ghcr.io (built images are not pulled)", auths)
+	}
+}
+
+func TestPublishedPortsHostIP(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+      - "127.0.0.1:8081:81"
+      - "0.0.0.0:8443:443"
+      - "192.168.1.5:5353:53/udp"
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	got, err := publishedPorts(project)
+	if err != nil {
+		t.Fatalf("publishedPorts failed: %v", err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].HostPort < got[j].HostPort })
+	want := []k3s.PublishedPort{
+		{HostIP: "192.168.1.5", HostPort: 5353, ContainerPort: 53, Protocol: "udp"},
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostIP: "127.0.0.1", HostPort: 8081, ContainerPort: 81, Protocol: "tcp"},
+		{HostIP: "0.0.0.0", HostPort: 8443, ContainerPort: 443, Protocol: "tcp"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("publishedPorts = %+v, want %+v", got, want)
+	}
+}
+
+func TestPublishedPortRanges(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  ftp:
+    image: ftp
+    ports: ["127.0.0.1:30000-30002:30000-30002"]
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	got, err := publishedPorts(project)
+	if err != nil {
+		t.Fatalf("publishedPorts failed: %v", err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].HostPort < got[j].HostPort })
+	want := []k3s.PublishedPort{
+		{HostIP: "127.0.0.1", HostPort: 30000, ContainerPort: 30000, Protocol: "tcp"},
+		{HostIP: "127.0.0.1", HostPort: 30001, ContainerPort: 30001, Protocol: "tcp"},
+		{HostIP: "127.0.0.1", HostPort: 30002, ContainerPort: 30002, Protocol: "tcp"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("publishedPorts = %+v, want %+v", got, want)
+	}
+
+	t.Run("duplicates are detected inside a range", func(t *testing.T) {
+		project, err := compose.LoadFromContent([]byte(`services:
+  ftp:
+    image: ftp
+    ports: ["30000-30002:30000-30002"]
+  other:
+    image: other
+    ports: ["9001:30001"]
+`), "test")
+		if err != nil {
+			t.Fatalf("LoadFromContent failed: %v", err)
+		}
+		ports, err := publishedPorts(project)
+		if err != nil {
+			t.Fatalf("publishedPorts failed: %v", err)
+		}
+		if err := k3s.CheckPublishedPorts(ports); err == nil || !strings.Contains(err.Error(), "30001/tcp") {
+			t.Errorf("expected a duplicate container port 30001/tcp error, got: %v", err)
+		}
+	})
+
+	t.Run("host range onto a single port is rejected", func(t *testing.T) {
+		project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: nginx
+    ports: ["8000-8010:80"]
+`), "test")
+		if err != nil {
+			t.Fatalf("LoadFromContent failed: %v", err)
+		}
+		if _, err := publishedPorts(project); err == nil || !strings.Contains(err.Error(), "11 ports") {
+			t.Errorf("expected a range length mismatch error, got: %v", err)
+		}
+	})
+}
+
+func TestJobServices(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: nginx
+  seed:
+    image: app
+    restart: "no"
+  migrate:
+    image: app
+    restart: on-failure:2
+  debug:
+    image: app
+    restart: "no"
+    profiles: [debug]
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	if got := jobServices(project); !reflect.DeepEqual(got, []string{"migrate", "seed"}) {
+		t.Errorf("jobServices = %v, want the active one-shot services [migrate seed]", got)
+	}
+}
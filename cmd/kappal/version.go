@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/kappal-app/kappal/pkg/docker"
+	"github.com/kappal-app/kappal/pkg/k3s"
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, injected at build time:
+//
+//	go build -ldflags "-X main.version=v1.2.3 -X main.gitCommit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	gitCommit = ""
+	buildDate = ""
+)
+
+var versionFormat string
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the kappal version",
+	Long: `Show the kappal version and build metadata.
+
+Prints the kappal version, the git commit and date it was built from, the
+K3s image kappal runs, and the API version of the Docker daemon. Include
+this output in bug reports.
+
+The commit falls back to the VCS revision Go records in the binary when it
+was not injected at build time. When Docker is not reachable the Docker API
+version is reported as unavailable; the command still succeeds.
+
+Flags:
+  -o, --format <fmt>   Output format: text (default), json
+
+Examples:
+  kappal version              Human-readable versions
+  kappal version -o json      JSON output, e.g. for bug report templates`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
+}
+
+func init() {
+	versionCmd.Flags().StringVarP(&versionFormat, "format", "o", "text", "Output format (text, json)")
+}
+
+// versionInfo is the version command's output.
+type versionInfo struct {
+	Version          string `json:"version"`
+	GitCommit        string `json:"git_commit"`
+	BuildDate        string `json:"build_date"`
+	GoVersion        string `json:"go_version"`
+	K3sImage         string `json:"k3s_image"`
+	DockerAPIVersion string `json:"docker_api_version,omitempty"`
+	DockerError      string `json:"docker_error,omitempty"`
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	if versionFormat != "text" && versionFormat != "json" {
+		return fmt.Errorf("invalid format %q (valid: text, json)", versionFormat)
+	}
+
+	info := buildVersionInfo()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if dockerClient, err := docker.NewClient(); err != nil {
+		info.DockerError = err.Error()
+	} else {
+		defer func() { _ = dockerClient.Close() }()
+		if v, err := dockerClient.ServerAPIVersion(ctx); err != nil {
+			info.DockerError = err.Error()
+		} else {
+			info.DockerAPIVersion = v
+		}
+	}
+
+	return writeVersion(os.Stdout, info, versionFormat)
+}
+
+// buildVersionInfo collects everything except the Docker version.
+func buildVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		GitCommit: gitCommit,
+		BuildDate: buildDate,
+		K3sImage:  k3s.K3sImage,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = bi.GoVersion
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.GitCommit == "":
+				info.GitCommit = s.Value
+			case s.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = s.Value
+			}
+		}
+	}
+	if info.GitCommit == "" {
+		info.GitCommit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+func writeVersion(out io.Writer, info versionInfo, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	dockerAPI := info.DockerAPIVersion
+	if dockerAPI == "" {
+		dockerAPI = "unavailable (" + info.DockerError + ")"
+	}
+	_, err := fmt.Fprintf(out, "Version:     %s\nGit commit:  %s\nBuilt:       %s\nGo version:  %s\nK3s image:   %s\nDocker API:  %s\n",
+		info.Version, info.GitCommit, info.BuildDate, info.GoVersion, info.K3sImage, dockerAPI)
+	return err
+}
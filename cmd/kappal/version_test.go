@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kappal-app/kappal/pkg/k3s"
+)
+
+func TestWriteVersion(t *testing.T) {
+	info := versionInfo{
+		Version:          "v1.2.3",
+		GitCommit:        "abc123",
+		BuildDate:        "2026-01-02T03:04:05Z",
+		GoVersion:        "go1.22.12",
+		K3sImage:         k3s.K3sImage,
+		DockerAPIVersion: "1.43",
+	}
+
+	var buf bytes.Buffer
+	if err := writeVersion(&buf, info, "json"); err != nil {
+		t.Fatalf("writeVersion failed: %v", err)
+	}
+	var got versionInfo
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if got != info {
+		t.Errorf("round trip = %+v, want %+v", got, info)
+	}
+	if strings.Contains(buf.String(), "docker_error") {
+		t.Errorf("docker_error should be omitted when Docker answered:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	info.DockerAPIVersion = ""
+	info.DockerError = "cannot connect"
+	if err := writeVersion(&buf, info, "text"); err != nil {
+		t.Fatalf("writeVersion failed: %v", err)
+	}
+	for _, want := range []string{"v1.2.3", "abc123", k3s.K3sImage, "Docker API:  unavailable (cannot connect)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestBuildVersionInfoDefaults(t *testing.T) {
+	info := buildVersionInfo()
+	if info.Version != version || info.K3sImage != k3s.K3sImage {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.GitCommit == "" || info.BuildDate == "" {
+		t.Errorf("commit and date should never be empty, got %+v", info)
+	}
+}
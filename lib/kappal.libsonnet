@@ -188,13 +188,13 @@
       },
     },
     spec: {
-      accessModes: ['ReadWriteOnce'],
+      accessModes: [$.get(volumeSpec, 'access_mode', 'ReadWriteOnce')],
       resources: {
         requests: {
-          storage: '1Gi',
+          storage: $.get(volumeSpec, 'size', '1Gi'),
         },
       },
-      storageClassName: 'local-path',
+      storageClassName: $.get(volumeSpec, 'storage_class', 'local-path'),
     },
   },
 
@@ -246,7 +246,7 @@
           'kappal.io/network': networkName,
         },
       },
-      policyTypes: ['Ingress'],
+      policyTypes: ['Ingress', 'Egress'],
       ingress: [{
         from: [{
           podSelector: {
@@ -256,6 +256,44 @@
           },
         }],
       }],
+      // Same-network pods, cluster DNS and anything outside the cluster
+      egress: [
+        {
+          to: [{
+            podSelector: {
+              matchLabels: {
+                'kappal.io/network': networkName,
+              },
+            },
+          }],
+        },
+        {
+          to: [{
+            namespaceSelector: {
+              matchLabels: {
+                'kubernetes.io/metadata.name': 'kube-system',
+              },
+            },
+            podSelector: {
+              matchLabels: {
+                'k8s-app': 'kube-dns',
+              },
+            },
+          }],
+          ports: [
+            { protocol: 'UDP', port: 53 },
+            { protocol: 'TCP', port: 53 },
+          ],
+        },
+        {
+          to: [{
+            ipBlock: {
+              cidr: '0.0.0.0/0',
+              except: ['10.42.0.0/16'],
+            },
+          }],
+        },
+      ],
     },
   },
 
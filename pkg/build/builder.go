@@ -3,6 +3,7 @@ package build
 import (
 	"context"
 	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -17,6 +18,13 @@ const (
 	BuildKitImage     = "moby/buildkit:v0.12.0"
 )
 
+// BuilderSelector selects the BuildKit builder's pods.
+const BuilderSelector = "kappal.io/component=builder"
+
+// containerdRoot is K3s's containerd root directory; the containerd worker
+// mounts snapshots from it, so it is shared with the builder pod.
+const containerdRoot = "/var/lib/rancher/k3s/agent/containerd"
+
 // Builder manages the in-cluster BuildKit deployment
 type Builder struct {
 	clientset *kubernetes.Clientset
@@ -103,6 +111,8 @@ func (b *Builder) createDeployment() *appsv1.Deployment {
 							Name:  "buildkitd",
 							Image: BuildKitImage,
 							Args: []string{
+								"--addr=unix:///run/buildkit/buildkitd.sock",
+								"--addr=tcp://0.0.0.0:1234",
 								"--oci-worker=false",
 								"--containerd-worker=true",
 								"--containerd-worker-addr=/run/k3s/containerd/containerd.sock",
@@ -111,11 +121,23 @@ func (b *Builder) createDeployment() *appsv1.Deployment {
 							SecurityContext: &corev1.SecurityContext{
 								Privileged: &privileged,
 							},
+							// Healthy once buildkitd answers and has its containerd worker
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									Exec: &corev1.ExecAction{Command: []string{"buildctl", "debug", "workers"}},
+								},
+								PeriodSeconds: 2,
+							},
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "k3s-socket",
 									MountPath: "/run/k3s/containerd/containerd.sock",
 								},
+								{
+									Name:             "containerd-root",
+									MountPath:        containerdRoot,
+									MountPropagation: mountPropagationPtr(corev1.MountPropagationBidirectional),
+								},
 							},
 							Ports: []corev1.ContainerPort{
 								{
@@ -136,6 +158,15 @@ func (b *Builder) createDeployment() *appsv1.Deployment {
 								},
 							},
 						},
+						{
+							Name: "containerd-root",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: containerdRoot,
+									Type: hostPathTypePtr(corev1.HostPathDirectory),
+								},
+							},
+						},
 					},
 				},
 			},
@@ -147,6 +178,72 @@ func hostPathTypePtr(t corev1.HostPathType) *corev1.HostPathType {
 	return &t
 }
 
+func mountPropagationPtr(m corev1.MountPropagationMode) *corev1.MountPropagationMode {
+	return &m
+}
+
+// ReadyPod returns the name of a running, ready builder pod.
+func (b *Builder) ReadyPod(ctx context.Context) (string, error) {
+	pods, err := b.clientset.CoreV1().Pods(BuilderNamespace).List(ctx, metav1.ListOptions{LabelSelector: BuilderSelector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list builder pods: %w", err)
+	}
+	var pullErr error
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if w := cs.State.Waiting; w != nil && (w.Reason == "ErrImagePull" || w.Reason == "ImagePullBackOff") {
+				pullErr = &pullError{image: BuildKitImage, message: w.Message}
+			}
+		}
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+				return pod.Name, nil
+			}
+		}
+	}
+	if pullErr != nil {
+		return "", pullErr
+	}
+	return "", fmt.Errorf("no ready builder pod in %s", BuilderNamespace)
+}
+
+// pullError reports that the builder's image can't be pulled, which
+// waiting longer won't fix (e.g. offline).
+type pullError struct {
+	image, message string
+}
+
+func (e *pullError) Error() string {
+	return fmt.Sprintf("cannot pull %s: %s", e.image, e.message)
+}
+
+// WaitReady waits up to timeout for a ready builder pod (the first start
+// pulls the BuildKit image) and returns its name. It gives up early when
+// the image can't be pulled.
+func (b *Builder) WaitReady(ctx context.Context, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		pod, err := b.ReadyPod(ctx)
+		if err == nil {
+			return pod, nil
+		}
+		if _, ok := err.(*pullError); ok {
+			return "", err
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("builder not ready after %s: %w", timeout, err)
+		case <-ticker.C:
+		}
+	}
+}
+
 // RemoveBuilder removes the BuildKit deployment
 func (b *Builder) RemoveBuilder(ctx context.Context) error {
 	err := b.clientset.AppsV1().Deployments(BuilderNamespace).Delete(ctx, BuilderName, metav1.DeleteOptions{})
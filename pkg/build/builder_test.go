@@ -0,0 +1,82 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestReadyPod(t *testing.T) {
+	pods := &corev1.PodList{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/"+BuilderNamespace+"/pods" || r.URL.Query().Get("labelSelector") != BuilderSelector {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(pods)
+	}))
+	defer server.Close()
+	cs, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBuilder(cs)
+
+	pod := func(name string, phase corev1.PodPhase, ready corev1.ConditionStatus) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{
+				Phase:      phase,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+
+	pods.Items = []corev1.Pod{pod("pending", corev1.PodPending, corev1.ConditionFalse), pod("starting", corev1.PodRunning, corev1.ConditionFalse)}
+	if name, err := b.ReadyPod(context.Background()); err == nil {
+		t.Errorf("expected no ready pod, got %s", name)
+	}
+
+	// A pull failure ends the wait instead of running into the timeout
+	pulling := pod("pulling", corev1.PodPending, corev1.ConditionFalse)
+	pulling.Status.ContainerStatuses = []corev1.ContainerStatus{{State: corev1.ContainerState{
+		Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "no route to host"},
+	}}}
+	pods.Items = append(pods.Items, pulling)
+	if _, err := b.WaitReady(context.Background(), time.Minute); err == nil || !strings.Contains(err.Error(), "no route to host") {
+		t.Errorf("expected WaitReady to fail fast on the pull error, got: %v", err)
+	}
+
+	pods.Items = append(pods.Items, pod("ready", corev1.PodRunning, corev1.ConditionTrue))
+	name, err := b.ReadyPod(context.Background())
+	if err != nil || name != "ready" {
+		t.Errorf("ReadyPod = %q, %v, want ready", name, err)
+	}
+}
+
+func TestBuilderDeployment(t *testing.T) {
+	d := (&Builder{}).createDeployment()
+	c := d.Spec.Template.Spec.Containers[0]
+	if c.ReadinessProbe == nil || c.ReadinessProbe.Exec == nil {
+		t.Fatal("builder should only be ready once buildctl can reach buildkitd")
+	}
+	var shared bool
+	for _, m := range c.VolumeMounts {
+		if m.MountPath == containerdRoot && m.MountPropagation != nil && *m.MountPropagation == corev1.MountPropagationBidirectional {
+			shared = true
+		}
+	}
+	if !shared {
+		t.Errorf("containerd root must be shared with the containerd worker, mounts: %+v", c.VolumeMounts)
+	}
+}
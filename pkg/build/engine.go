@@ -1,58 +1,145 @@
 package build
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/distribution/reference"
 	"github.com/kappal-app/kappal/pkg/docker"
+	"github.com/kappal-app/kappal/pkg/k8s"
 )
 
-// Engine handles image building
+// builderReadyTimeout bounds the wait for the BuildKit builder before
+// falling back to docker build. The first start pulls the BuildKit image.
+const builderReadyTimeout = 2 * time.Minute
+
+// buildContextDir is where build contexts are unpacked in the builder pod,
+// one subdirectory per image so parallel builds don't collide.
+const buildContextDir = "/tmp/kappal-build"
+
+// Engine builds images with the in-cluster BuildKit builder, whose
+// containerd worker writes them straight into K3s's containerd (no docker
+// save/ctr import round-trip). It implements k3s.ClusterBuilder; the K3s
+// manager falls back to docker build when Ready fails.
 type Engine struct {
-	projectName string
-	docker      *docker.Client
+	cluster *k8s.Client
+	pod     string // ready builder pod, set by Ready
 }
 
-// NewEngine creates a new build engine
-func NewEngine(projectName string) (*Engine, error) {
-	dockerClient, err := docker.NewClient()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create docker client: %w", err)
-	}
-	return &Engine{
-		projectName: projectName,
-		docker:      dockerClient,
-	}, nil
+// NewEngine creates a build engine for the K3s cluster behind client.
+func NewEngine(client *k8s.Client) *Engine {
+	return &Engine{cluster: client}
 }
 
-// Close closes the Docker client
-func (e *Engine) Close() error {
-	if e.docker != nil {
-		return e.docker.Close()
+// Ready starts the builder Deployment if needed and waits for a healthy pod.
+func (e *Engine) Ready(ctx context.Context) error {
+	pod, err := e.readyBuilder(ctx, NewBuilder(e.cluster.Clientset()))
+	if err != nil {
+		return err
 	}
+	e.pod = pod
 	return nil
 }
 
-// Build builds an image for a service
-func (e *Engine) Build(ctx context.Context, serviceName, contextDir, dockerfile string, buildArgs map[string]*string) (string, error) {
-	imageName := fmt.Sprintf("%s-%s:latest", e.projectName, serviceName)
+// Build builds dockerfile (relative to contextDir) as imageName in the
+// builder pod found by Ready. Output goes to opts.Output when set, else per
+// progress; in quiet mode it is only shown when the build fails.
+func (e *Engine) Build(ctx context.Context, imageName, contextDir, dockerfile string, opts docker.BuildOptions, progress docker.ProgressMode) error {
+	if e.pod == "" {
+		return fmt.Errorf("buildkit builder is not ready")
+	}
+	dir := path.Join(buildContextDir, strings.NewReplacer("/", "-", ":", "-").Replace(imageName))
+	args, err := buildctlArgs(dir, dockerfile, imageName, opts)
+	if err != nil {
+		return err
+	}
+
+	tarCtx, err := docker.BuildContextTar(contextDir, dockerfile)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tarCtx.Close() }()
+
+	out := opts.Output
+	var quiet bytes.Buffer
+	if out == nil {
+		out = progress.Writer()
+		if progress == docker.ProgressQuiet {
+			out = &quiet
+		}
+	}
 
-	// If no dockerfile specified, use default "Dockerfile"
-	dockerfilePath := dockerfile
-	if dockerfilePath == "" {
-		dockerfilePath = "Dockerfile"
+	// Unpack the context streamed on stdin, build, and clean up
+	err = e.cluster.ExecInPod(ctx, BuilderNamespace, e.pod, buildkitScript(dir, args), k8s.ExecOptions{
+		Stdin:  tarCtx,
+		Stdout: out,
+		Stderr: out,
+	})
+	if err != nil {
+		_, _ = io.Copy(os.Stderr, &quiet)
+		return fmt.Errorf("buildkit build failed for %s: %w", imageName, err)
 	}
+	return nil
+}
 
-	if err := e.docker.ImageBuild(ctx, contextDir, dockerfilePath, imageName, buildArgs); err != nil {
-		return "", fmt.Errorf("build failed: %w", err)
+// readyBuilder ensures the builder Deployment exists and returns a ready pod.
+func (e *Engine) readyBuilder(ctx context.Context, builder *Builder) (string, error) {
+	if err := builder.EnsureBuilder(ctx); err != nil {
+		return "", err
 	}
+	return builder.WaitReady(ctx, builderReadyTimeout)
+}
 
-	return imageName, nil
+// buildctlArgs returns the buildctl command that builds the Dockerfile in
+// contextDir and stores the image, unpacked, in containerd under the name
+// the kubelet resolves imageName to (e.g. docker.io/library/app:latest).
+// Build args without a value are skipped, as the builder can't see the
+// caller's environment. Progress is always plain: the exec has no TTY.
+func buildctlArgs(contextDir, dockerfile, imageName string, opts docker.BuildOptions) ([]string, error) {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image name %s: %w", imageName, err)
+	}
+	args := []string{
+		"buildctl", "build",
+		"--progress", "plain",
+		"--frontend", "dockerfile.v0",
+		"--local", "context=" + contextDir,
+		"--local", "dockerfile=" + contextDir,
+		"--opt", "filename=" + path.Clean(strings.TrimPrefix(dockerfile, "./")),
+	}
+	if opts.NoCache {
+		args = append(args, "--no-cache")
+	}
+	names := make([]string, 0, len(opts.BuildArgs))
+	for name := range opts.BuildArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v := opts.BuildArgs[name]; v != nil {
+			args = append(args, "--opt", fmt.Sprintf("build-arg:%s=%s", name, *v))
+		}
+	}
+	args = append(args, "--output", fmt.Sprintf("type=image,name=%s,unpack=true", reference.TagNameOnly(named).String()))
+	return args, nil
 }
 
-// BuildWithBuildKit builds using BuildKit (for future enhancement)
-func (e *Engine) BuildWithBuildKit(ctx context.Context, serviceName, contextDir, dockerfile string, buildArgs map[string]*string) (string, error) {
-	// For now, use standard docker build
-	// TODO: Integrate BuildKit client directly for in-cluster builds
-	return e.Build(ctx, serviceName, contextDir, dockerfile, buildArgs)
+// buildkitScript wraps buildctl args in a shell command that unpacks the
+// tarred build context from stdin into dir, builds, and removes dir.
+func buildkitScript(dir string, args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	script := fmt.Sprintf("rm -rf %[1]s && mkdir -p %[1]s && tar -x -C %[1]s && %[2]s; rc=$?; rm -rf %[1]s; exit $rc",
+		dir, strings.Join(quoted, " "))
+	return []string{"sh", "-c", script}
 }
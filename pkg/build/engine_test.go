@@ -0,0 +1,77 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kappal-app/kappal/pkg/docker"
+)
+
+func TestBuildctlArgs(t *testing.T) {
+	version := "1.2"
+	args, err := buildctlArgs("/tmp/kappal-build/demo-web", "./docker/Dockerfile.prod", "demo-web:latest", docker.BuildOptions{
+		BuildArgs: map[string]*string{"VERSION": &version, "UNSET": nil},
+		NoCache:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"buildctl", "build",
+		"--progress", "plain",
+		"--frontend", "dockerfile.v0",
+		"--local", "context=/tmp/kappal-build/demo-web",
+		"--local", "dockerfile=/tmp/kappal-build/demo-web",
+		"--opt", "filename=docker/Dockerfile.prod",
+		"--no-cache",
+		"--opt", "build-arg:VERSION=1.2",
+		"--output", "type=image,name=docker.io/library/demo-web:latest,unpack=true",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("buildctlArgs =\n%q\nwant\n%q", args, want)
+	}
+
+	if _, err := buildctlArgs("/tmp/x", "Dockerfile", "Not A Valid:Name", docker.BuildOptions{}); err == nil {
+		t.Error("expected an invalid image name error")
+	}
+}
+
+func TestBuildkitScript(t *testing.T) {
+	if _, err := exec.LookPath("tar"); err != nil {
+		t.Skip("tar not available")
+	}
+	dir := filepath.Join(t.TempDir(), "ctx")
+	out := filepath.Join(t.TempDir(), "args")
+
+	// Stand-in for buildctl: record the arguments and the unpacked context
+	cmd := buildkitScript(dir, []string{"sh", "-c", `printf '%s\n' "$@" > "$0"; ls "$1" >> "$0"`, out, dir, "it's quoted"})
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "Dockerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tarCmd := exec.Command("tar", "-c", "-C", src, ".")
+	stdin, err := tarCmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := exec.Command(cmd[0], cmd[1:]...)
+	run.Stdin = strings.NewReader(string(stdin))
+	if output, err := run.CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, output)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := dir + "\nit's quoted\nDockerfile\n"; string(got) != want {
+		t.Errorf("recorded %q, want %q", got, want)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("the build context directory should be removed afterwards")
+	}
+}
@@ -2,23 +2,38 @@ package compose
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/compose-spec/compose-go/v2/cli"
 	"github.com/compose-spec/compose-go/v2/types"
 )
 
-// Load parses a docker-compose.yaml file and returns a Project
-func Load(path string, projectName string) (*types.Project, error) {
-	// Convert compose file path to absolute for reliable loading
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return nil, err
+// Load parses one or more compose files and returns the merged Project.
+// Later files override earlier ones, as with `docker compose -f a -f b`.
+// The first file's directory is the project working directory. Services
+// whose profiles include one of profiles ("*" for all) are enabled alongside
+// those without profiles; the rest end up in DisabledServices.
+func Load(paths []string, projectName string, profiles ...string) (*types.Project, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no compose file given")
+	}
+
+	// Convert compose file paths to absolute for reliable loading
+	absPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		absPaths = append(absPaths, absPath)
 	}
 
-	// Get the directory containing the compose file
-	absDir := filepath.Dir(absPath)
+	// Get the directory containing the first compose file
+	absDir := filepath.Dir(absPaths[0])
 
 	// Check if .env file exists in the compose file's directory
 	envFile := filepath.Join(absDir, ".env")
@@ -32,6 +47,7 @@ func Load(path string, projectName string) (*types.Project, error) {
 	opts := []cli.ProjectOptionsFn{
 		cli.WithWorkingDirectory(absDir),
 		cli.WithOsEnv,
+		cli.WithProfiles(profiles),
 	}
 
 	// Add env files if .env exists, then WithDotEnv to load them
@@ -47,17 +63,115 @@ func Load(path string, projectName string) (*types.Project, error) {
 		opts = append(opts, cli.WithName(filepath.Base(absDir)))
 	}
 
-	// Pass absolute path to ensure compose-go finds the file correctly
-	options, err := cli.NewProjectOptions([]string{absPath}, opts...)
+	// Pass absolute paths to ensure compose-go finds the files correctly.
+	// Per-service env_file entries are resolved relative to absDir and merged
+	// into each service's Environment by compose-go during LoadProject, so
+	// environment resolution must stay enabled here.
+	options, err := cli.NewProjectOptions(absPaths, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := options.LoadProject(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	return options.LoadProject(context.Background())
+	return enableAlwaysOn(project)
+}
+
+// AlwaysOnLabel marks a profiled service as active by default. Setting it to
+// "true" (or listing an empty-string profile) keeps the service deployed by a
+// plain 'kappal up' while it stays tagged with its profiles.
+const AlwaysOnLabel = "kappal.io/always-on"
+
+// IsAlwaysOn reports whether a service opts out of profile gating via
+// AlwaysOnLabel or an empty-string entry in profiles.
+func IsAlwaysOn(svc types.ServiceConfig) bool {
+	if on, err := strconv.ParseBool(svc.Labels[AlwaysOnLabel]); err == nil && on {
+		return true
+	}
+	for _, p := range svc.Profiles {
+		if p == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether a service is deployed: it has no profiles, it is
+// always-on, or one of its profiles is among the project's active profiles
+// (set by Load from --profile).
+func IsActive(project *types.Project, svc types.ServiceConfig) bool {
+	return svc.HasProfile(project.Profiles) || IsAlwaysOn(svc)
+}
+
+// IsOneShot reports whether a service runs to completion rather than being
+// kept running: restart "no", or "on-failure[:N]", which doesn't restart a
+// container that exited successfully. One-shot services become K8s Jobs.
+func IsOneShot(svc types.ServiceConfig) bool {
+	_, onFailure := OnFailureRetries(svc.Restart)
+	return svc.Restart == "no" || onFailure
+}
+
+// OnFailureRetries parses a restart policy of the form "on-failure[:N]",
+// returning N (0 when omitted or invalid, which Docker treats as no limit)
+// and whether the policy is on-failure at all.
+func OnFailureRetries(restart string) (int, bool) {
+	policy, count, _ := strings.Cut(restart, ":")
+	if policy != "on-failure" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return 0, true
+	}
+	return n, true
+}
+
+// enableAlwaysOn moves always-on services back from DisabledServices, where
+// compose-go puts every service whose profiles are not activated.
+func enableAlwaysOn(project *types.Project) (*types.Project, error) {
+	enabled := false
+	for name, svc := range project.DisabledServices {
+		if !IsAlwaysOn(svc) {
+			continue
+		}
+		if project.Services == nil {
+			project.Services = types.Services{}
+		}
+		project.Services[name] = svc
+		delete(project.DisabledServices, name)
+		enabled = true
+	}
+	if !enabled {
+		return project, nil
+	}
+	// compose-go only resolves env_file entries for enabled services
+	return project.WithServicesEnvironmentResolved(false)
+}
+
+// WithOverrideFile appends the override file that Docker Compose layers
+// automatically on top of the base file (e.g. docker-compose.override.yaml
+// or .yml next to docker-compose.yaml) when one exists. Callers should only
+// use it when the user did not pass explicit -f flags.
+func WithOverrideFile(paths []string) []string {
+	if len(paths) != 1 {
+		return paths
+	}
+	base := paths[0]
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	for _, candidate := range []string{stem + ".override.yaml", stem + ".override.yml"} {
+		if _, err := os.Stat(candidate); err == nil {
+			return []string{base, candidate}
+		}
+	}
+	return paths
 }
 
 // LoadFromContent parses compose content from a byte slice
-func LoadFromContent(content []byte, projectName string) (*types.Project, error) {
+func LoadFromContent(content []byte, projectName string, profiles ...string) (*types.Project, error) {
 	// Write to temp file and load
 	tmpDir, err := os.MkdirTemp("", "kappal-compose-*")
 	if err != nil {
@@ -70,7 +184,7 @@ func LoadFromContent(content []byte, projectName string) (*types.Project, error)
 		return nil, err
 	}
 
-	return Load(tmpFile, projectName)
+	return Load([]string{tmpFile}, projectName, profiles...)
 }
 
 // GetServiceNames returns all service names in the project
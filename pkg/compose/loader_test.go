@@ -0,0 +1,216 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadMergesFilesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "docker-compose.yaml")
+	override := filepath.Join(dir, "prod.yaml")
+	writeFile(t, base, `services:
+  web:
+    image: nginx:1.25
+    environment:
+      MODE: dev
+      KEEP: "yes"
+  db:
+    image: postgres:16
+`)
+	writeFile(t, override, `services:
+  web:
+    image: nginx:1.27
+    environment:
+      MODE: prod
+`)
+
+	project, err := Load([]string{base, override}, "test")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	web := project.Services["web"]
+	if web.Image != "nginx:1.27" {
+		t.Errorf("expected override image nginx:1.27, got %s", web.Image)
+	}
+	if v := web.Environment["MODE"]; v == nil || *v != "prod" {
+		t.Errorf("expected override MODE=prod, got %v", v)
+	}
+	if v := web.Environment["KEEP"]; v == nil || *v != "yes" {
+		t.Errorf("expected base KEEP=yes to survive the merge, got %v", v)
+	}
+	if _, ok := project.Services["db"]; !ok {
+		t.Error("expected db service from the base file")
+	}
+	if project.WorkingDir != dir {
+		t.Errorf("expected working dir %s, got %s", dir, project.WorkingDir)
+	}
+}
+
+func TestLoadRequiresFile(t *testing.T) {
+	if _, err := Load(nil, "test"); err == nil {
+		t.Error("expected error when no compose file is given")
+	}
+}
+
+func TestWithOverrideFileAppliesPortOverride(t *testing.T) {
+	base := filepath.Join("..", "..", "testdata", "override", "docker-compose.yaml")
+	paths := WithOverrideFile([]string{base})
+	if len(paths) != 2 || filepath.Base(paths[1]) != "docker-compose.override.yaml" {
+		t.Fatalf("expected override file to be detected, got %v", paths)
+	}
+
+	project, err := Load(paths, "test")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	ports := project.Services["web"].Ports
+	if len(ports) != 1 || ports[0].Published != "8082" {
+		t.Errorf("expected override port 8082 to win, got %+v", ports)
+	}
+}
+
+func TestWithOverrideFileYmlAndMissing(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "docker-compose.yml")
+	writeFile(t, base, "services: {}\n")
+
+	if paths := WithOverrideFile([]string{base}); len(paths) != 1 {
+		t.Errorf("expected no override without an override file, got %v", paths)
+	}
+
+	override := filepath.Join(dir, "docker-compose.override.yml")
+	writeFile(t, override, "services: {}\n")
+	if paths := WithOverrideFile([]string{base}); len(paths) != 2 || paths[1] != override {
+		t.Errorf("expected .yml override to be detected, got %v", paths)
+	}
+
+	explicit := []string{base, filepath.Join(dir, "prod.yaml")}
+	if paths := WithOverrideFile(explicit); len(paths) != 2 || paths[1] != explicit[1] {
+		t.Errorf("expected multiple files to be left untouched, got %v", paths)
+	}
+}
+
+func TestIsOneShot(t *testing.T) {
+	for restart, want := range map[string]bool{
+		"no":           true,
+		"on-failure":   true,
+		"on-failure:5": true,
+		"always":       false,
+		"":             false,
+	} {
+		if got := IsOneShot(types.ServiceConfig{Restart: restart}); got != want {
+			t.Errorf("IsOneShot(%q) = %v, want %v", restart, got, want)
+		}
+	}
+
+	if n, ok := OnFailureRetries("on-failure:5"); n != 5 || !ok {
+		t.Errorf("OnFailureRetries(on-failure:5) = %d, %v", n, ok)
+	}
+	if n, ok := OnFailureRetries("on-failure"); n != 0 || !ok {
+		t.Errorf("OnFailureRetries(on-failure) = %d, %v", n, ok)
+	}
+	if _, ok := OnFailureRetries("unless-stopped"); ok {
+		t.Error("unless-stopped is not on-failure")
+	}
+}
+
+func TestAlwaysOnKeepsProfiledServiceActive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "docker-compose.yaml")
+	writeFile(t, path, `services:
+  web:
+    image: nginx
+  metrics:
+    image: prom/node-exporter
+    profiles: ["monitoring"]
+    labels:
+      kappal.io/always-on: "true"
+  sidecar:
+    image: busybox
+    profiles: ["", "debug"]
+    env_file: sidecar.env
+  debug:
+    image: busybox
+    profiles: ["debug"]
+`)
+	writeFile(t, filepath.Join(dir, "sidecar.env"), "LEVEL=info\n")
+
+	project, err := Load([]string{path}, "test")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	for _, name := range []string{"web", "metrics", "sidecar"} {
+		svc, ok := project.Services[name]
+		if !ok {
+			t.Errorf("expected %s to be active", name)
+			continue
+		}
+		if !IsActive(project, svc) {
+			t.Errorf("expected IsActive(%s) to be true", name)
+		}
+	}
+	if _, ok := project.Services["debug"]; ok {
+		t.Error("expected profiled debug service to stay disabled")
+	}
+	if _, ok := project.DisabledServices["debug"]; !ok {
+		t.Error("expected debug in DisabledServices")
+	}
+	if v := project.Services["sidecar"].Environment["LEVEL"]; v == nil || *v != "info" {
+		t.Errorf("expected env_file resolved for re-enabled service, got %v", v)
+	}
+}
+
+func TestLoadWithProfiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "docker-compose.yaml")
+	writeFile(t, path, `services:
+  web:
+    image: nginx
+  adminer:
+    image: adminer
+    profiles: ["debug"]
+  grafana:
+    image: grafana/grafana
+    profiles: ["monitoring"]
+`)
+
+	project, err := Load([]string{path}, "test", "debug")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	for _, name := range []string{"web", "adminer"} {
+		svc, ok := project.Services[name]
+		if !ok || !IsActive(project, svc) {
+			t.Errorf("expected %s to be active with --profile debug", name)
+		}
+	}
+	if _, ok := project.DisabledServices["grafana"]; !ok {
+		t.Error("expected grafana to stay disabled without its profile")
+	}
+
+	project, err = Load([]string{path}, "test", "*")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(project.Services) != 3 {
+		t.Errorf("expected every service enabled with \"*\", got %v", project.ServiceNames())
+	}
+
+	// Without the project's profiles, a profiled service is inactive
+	if IsActive(&types.Project{}, types.ServiceConfig{Name: "adminer", Profiles: []string{"debug"}}) {
+		t.Error("expected a profiled service to be inactive with no active profiles")
+	}
+}
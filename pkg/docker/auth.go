@@ -0,0 +1,175 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// dockerHubServer is the server key Docker uses for Docker Hub credentials
+// in config.json and credential helpers.
+const dockerHubServer = "https://index.docker.io/v1/"
+
+// DefaultConfigPath returns the Docker CLI config file: $DOCKER_CONFIG/config.json
+// or ~/.docker/config.json.
+func DefaultConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// RegistryHost returns the registry an image is pulled from, e.g. "ghcr.io"
+// for ghcr.io/org/app and "docker.io" for nginx. Unparseable references
+// return "".
+func RegistryHost(image string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
+
+// RegistryServer returns the key under which Docker (and the kubelet) look
+// up credentials for a registry host.
+func RegistryServer(host string) string {
+	if host == "docker.io" {
+		return dockerHubServer
+	}
+	return host
+}
+
+// dockerConfig is the part of the Docker CLI config.json holding credentials.
+type dockerConfig struct {
+	Auths       map[string]dockerConfigAuth `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+type dockerConfigAuth struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// credentialHelper runs docker-credential-<helper> get for a server and
+// returns the username and secret. A variable so tests can stub it.
+var credentialHelper = func(helper, server string) (string, string, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("docker-credential-%s get %s failed: %w", helper, server, err)
+	}
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return "", "", fmt.Errorf("failed to parse docker-credential-%s output: %w", helper, err)
+	}
+	return creds.Username, creds.Secret, nil
+}
+
+// LoadRegistryAuth returns the username/password credentials that the
+// Docker config at configPath holds for each of the given registry hosts
+// (see RegistryHost), keyed by host. Credentials come from the host's
+// credHelpers entry or the credsStore (e.g. Docker Desktop's keychain),
+// falling back to the base64 "auth" in auths. Hosts without credentials
+// are left out. A missing config file returns an os.IsNotExist error.
+func LoadRegistryAuth(configPath string, hosts []string) (map[string]registry.AuthConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+
+	auths := map[string]registry.AuthConfig{}
+	for _, host := range hosts {
+		if _, done := auths[host]; done || host == "" {
+			continue
+		}
+		server := RegistryServer(host)
+		helper := config.CredHelpers[host]
+		if helper == "" {
+			helper = config.CredsStore
+		}
+		if helper != "" {
+			if user, secret, err := credentialHelper(helper, server); err == nil && secret != "" {
+				auths[host] = registry.AuthConfig{Username: user, Password: secret, ServerAddress: server}
+				continue
+			}
+		}
+		if auth, ok := config.lookupAuth(host); ok {
+			auth.ServerAddress = server
+			auths[host] = auth
+		}
+	}
+	return auths, nil
+}
+
+// lookupAuth finds a host's entry in auths, which may be keyed by bare
+// host, URL, or (for Docker Hub) the legacy index URL.
+func (c dockerConfig) lookupAuth(host string) (registry.AuthConfig, bool) {
+	keys := []string{host, "https://" + host, "http://" + host}
+	if host == "docker.io" {
+		keys = append([]string{dockerHubServer, "index.docker.io", "https://index.docker.io"}, keys...)
+	}
+	for _, key := range keys {
+		entry, ok := c.Auths[key]
+		if !ok {
+			continue
+		}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				continue
+			}
+			user, password, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				continue
+			}
+			return registry.AuthConfig{Username: user, Password: password}, true
+		}
+		if entry.Username != "" && entry.Password != "" {
+			return registry.AuthConfig{Username: entry.Username, Password: entry.Password}, true
+		}
+	}
+	return registry.AuthConfig{}, false
+}
+
+// SetRegistryAuth makes ImagePull and ImageInRegistry authenticate to the
+// registries in auths (keyed by RegistryHost) instead of pulling anonymously.
+func (c *Client) SetRegistryAuth(auths map[string]registry.AuthConfig) {
+	c.registryAuth = auths
+}
+
+// encodedRegistryAuth returns the X-Registry-Auth value for an image, or ""
+// to access its registry anonymously.
+func (c *Client) encodedRegistryAuth(image string) string {
+	auth, ok := c.registryAuth[RegistryHost(image)]
+	if !ok {
+		return ""
+	}
+	encoded, err := registry.EncodeAuthConfig(auth)
+	if err != nil {
+		return ""
+	}
+	return encoded
+}
@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+)
+
+func TestRegistryHost(t *testing.T) {
+	for image, want := range map[string]string{
+		"nginx":                  "docker.io",
+		"myorg/api:1.2":          "docker.io",
+		"ghcr.io/org/app:latest": "ghcr.io",
+		"localhost:5000/app":     "localhost:5000",
+		"123.dkr.ecr.us-east-1.amazonaws.com/app@sha256:" + strings.Repeat("a", 64): "123.dkr.ecr.us-east-1.amazonaws.com",
+		"Not Valid": "",
+	} {
+		if got := RegistryHost(image); got != want {
+			t.Errorf("RegistryHost(%q) = %q, want %q", image, got, want)
+		}
+	}
+	if RegistryServer("docker.io") != "https://index.docker.io/v1/" || RegistryServer("ghcr.io") != "ghcr.io" {
+		t.Error("Docker Hub credentials are keyed by the legacy index URL")
+	}
+}
+
+func TestLoadRegistryAuth(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	config := map[string]interface{}{
+		"auths": map[string]interface{}{
+			"https://index.docker.io/v1/":  map[string]string{"auth": encode("hubuser:hubpass")},
+			"ghcr.io":                      map[string]string{"auth": encode("octocat:ghp_token")},
+			"https://registry.example.com": map[string]string{"username": "alice", "password": "s3cret"},
+			"keychain.example.com":         map[string]string{},
+		},
+		"credHelpers": map[string]string{"123.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"},
+		"credsStore":  "desktop",
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var helperCalls []string
+	saved := credentialHelper
+	defer func() { credentialHelper = saved }()
+	credentialHelper = func(helper, server string) (string, string, error) {
+		helperCalls = append(helperCalls, helper+" "+server)
+		switch server {
+		case "123.dkr.ecr.us-east-1.amazonaws.com":
+			return "AWS", "ecr-token", nil
+		case "keychain.example.com":
+			return "bob", "from-keychain", nil
+		}
+		return "", "", errors.New("credentials not found in native keychain")
+	}
+
+	auths, err := LoadRegistryAuth(path, []string{"docker.io", "ghcr.io", "registry.example.com", "keychain.example.com", "123.dkr.ecr.us-east-1.amazonaws.com", "quay.io", "ghcr.io"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]registry.AuthConfig{
+		"docker.io":                           {Username: "hubuser", Password: "hubpass", ServerAddress: "https://index.docker.io/v1/"},
+		"ghcr.io":                             {Username: "octocat", Password: "ghp_token", ServerAddress: "ghcr.io"},
+		"registry.example.com":                {Username: "alice", Password: "s3cret", ServerAddress: "registry.example.com"},
+		"keychain.example.com":                {Username: "bob", Password: "from-keychain", ServerAddress: "keychain.example.com"},
+		"123.dkr.ecr.us-east-1.amazonaws.com": {Username: "AWS", Password: "ecr-token", ServerAddress: "123.dkr.ecr.us-east-1.amazonaws.com"},
+	}
+	if !reflect.DeepEqual(auths, want) {
+		t.Errorf("LoadRegistryAuth =\n%+v\nwant\n%+v", auths, want)
+	}
+	if helperCalls[0] != "desktop https://index.docker.io/v1/" {
+		t.Errorf("the credsStore should be asked first, calls: %v", helperCalls)
+	}
+	for _, call := range helperCalls {
+		if strings.Contains(call, "amazonaws") && !strings.HasPrefix(call, "ecr-login ") {
+			t.Errorf("credHelpers should take precedence over credsStore, calls: %v", helperCalls)
+		}
+	}
+
+	if _, err := LoadRegistryAuth(filepath.Join(t.TempDir(), "missing.json"), []string{"ghcr.io"}); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error for a missing config, got: %v", err)
+	}
+}
+
+func TestImagePullRegistryAuth(t *testing.T) {
+	headers := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers[r.URL.Query().Get("fromImage")] = r.Header.Get("X-Registry-Auth")
+		_, _ = w.Write([]byte(`{"status":"done"}` + "\n"))
+	}))
+	defer server.Close()
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://")
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithVersion("1.43"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{cli: cli}
+	c.SetRegistryAuth(map[string]registry.AuthConfig{"ghcr.io": {Username: "octocat", Password: "ghp_token", ServerAddress: "ghcr.io"}})
+
+	for _, image := range []string{"ghcr.io/org/app:1", "nginx:latest"} {
+		if err := c.ImagePull(context.Background(), image, ""); err != nil {
+			t.Fatalf("ImagePull(%s) failed: %v", image, err)
+		}
+	}
+	decoded, err := base64.URLEncoding.DecodeString(headers["ghcr.io/org/app"])
+	if err != nil || !strings.Contains(string(decoded), `"username":"octocat"`) {
+		t.Errorf("ghcr.io pull should send its credentials, got header %q", headers["ghcr.io/org/app"])
+	}
+	if headers["nginx"] != "" {
+		t.Errorf("Docker Hub pull should be anonymous without credentials, got %q", headers["nginx"])
+	}
+}
@@ -1,12 +1,18 @@
 package docker
 
 import (
+	"archive/tar"
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,6 +20,7 @@ import (
 	"github.com/docker/docker/api/types/container"
 	dockerfilters "github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
@@ -33,6 +40,8 @@ func filtersArgs(key, value string) dockerfilters.Args {
 // Client wraps the Docker SDK client
 type Client struct {
 	cli *client.Client
+	// registryAuth holds credentials per registry host (see SetRegistryAuth)
+	registryAuth map[string]registry.AuthConfig
 }
 
 // NewClient creates a Docker client from environment
@@ -52,6 +61,44 @@ func (c *Client) Close() error {
 	return c.cli.Close()
 }
 
+// ErrDaemonNotRunning is returned by Ping when the Docker daemon can't be reached.
+var ErrDaemonNotRunning = errors.New("Docker daemon is not running — start Docker Desktop/dockerd")
+
+// Ping checks that the Docker daemon is reachable. NewClient connects lazily,
+// so without this a stopped daemon only surfaces later as an obscure API error.
+func (c *Client) Ping(ctx context.Context) error {
+	if _, err := c.cli.Ping(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrDaemonNotRunning, err)
+	}
+	return nil
+}
+
+// CheckDaemon creates a client from the environment and pings the daemon.
+func CheckDaemon(ctx context.Context) error {
+	c, err := NewClient()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+	return c.Ping(ctx)
+}
+
+// Rootless reports whether the Docker daemon runs in rootless mode, where
+// binding host ports below 1024 fails unless the host lowers
+// net.ipv4.ip_unprivileged_port_start.
+func (c *Client) Rootless(ctx context.Context) (bool, error) {
+	info, err := c.cli.Info(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get docker info: %w", err)
+	}
+	for _, opt := range info.SecurityOptions {
+		if strings.Contains(opt, "name=rootless") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // ContainerState returns (exists, running, error) for a container
 func (c *Client) ContainerState(ctx context.Context, name string) (exists bool, running bool, err error) {
 	inspect, err := c.cli.ContainerInspect(ctx, name)
@@ -106,6 +153,34 @@ func (c *Client) ContainerStart(ctx context.Context, containerID string) error {
 	return nil
 }
 
+// ContainerWriteFile writes a file, and any missing parent directories, into
+// a created (not necessarily started) container. path must be absolute.
+func (c *Client) ContainerWriteFile(ctx context.Context, containerID, path string, content []byte, mode int64) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	rel := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/") + "/"
+		if err := tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: rel, Typeflag: tar.TypeReg, Mode: mode, Size: int64(len(content))}); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := c.cli.CopyToContainer(ctx, containerID, "/", &buf, types.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("failed to copy %s into container %s: %w", path, containerID, err)
+	}
+	return nil
+}
+
 // ContainerRun creates and starts a container (like docker run -d)
 func (c *Client) ContainerRun(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, name string) error {
 	containerID, err := c.ContainerCreate(ctx, config, hostConfig, name)
@@ -213,15 +288,18 @@ func (c *Client) ContainerExecStream(ctx context.Context, name string, cmd []str
 
 // NetworkCreate creates a Docker bridge network. Idempotent - returns nil if network already exists.
 func (c *Client) NetworkCreate(ctx context.Context, name string) error {
-	return c.NetworkCreateWithLabels(ctx, name, nil)
+	return c.NetworkCreateWithLabels(ctx, name, nil, nil)
 }
 
-// NetworkCreateWithLabels creates a Docker bridge network with labels. Idempotent.
-func (c *Client) NetworkCreateWithLabels(ctx context.Context, name string, labels map[string]string) error {
+// NetworkCreateWithLabels creates a Docker bridge network with labels and
+// bridge driver options (e.g. com.docker.network.driver.mtu). Idempotent; an
+// existing network keeps the options it was created with.
+func (c *Client) NetworkCreateWithLabels(ctx context.Context, name string, labels, options map[string]string) error {
 	_, err := c.cli.NetworkCreate(ctx, name, types.NetworkCreate{
 		Driver:         "bridge",
 		CheckDuplicate: true,
 		Labels:         labels,
+		Options:        options,
 	})
 	if err != nil {
 		// If network already exists, treat as success
@@ -254,6 +332,15 @@ func (c *Client) ContainerInspectPorts(ctx context.Context, name string) (nat.Po
 	return inspect.HostConfig.PortBindings, nil
 }
 
+// ContainerInspectCmd returns the command a container was created with.
+func (c *Client) ContainerInspectCmd(ctx context.Context, name string) ([]string, error) {
+	inspect, err := c.cli.ContainerInspect(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", name, err)
+	}
+	return inspect.Config.Cmd, nil
+}
+
 // ContainerCreate creates a container without starting it, optionally connected to a network
 func (c *Client) ContainerCreateWithNetwork(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkName string, name string) (string, error) {
 	var networkingConfig *network.NetworkingConfig
@@ -305,12 +392,27 @@ func readDockerignore(contextDir string) ([]string, error) {
 	return excludes, scanner.Err()
 }
 
-// ImageBuild builds an image from context directory
-func (c *Client) ImageBuild(ctx context.Context, contextDir, dockerfile, imageName string, buildArgs map[string]*string) error {
+// BuildOptions configures an image build
+type BuildOptions struct {
+	BuildArgs map[string]*string
+	// NoCache rebuilds every layer instead of reusing the Docker build cache
+	NoCache bool
+	// Platform (e.g. linux/amd64) builds for another architecture than the
+	// daemon's; empty builds for the daemon's own.
+	Platform string
+	// Output, when set, receives the build output as plain lines instead of
+	// it being rendered per the progress mode (used for parallel builds).
+	Output io.Writer
+}
+
+// BuildContextTar tars contextDir as it is sent to the daemon (or to an
+// in-cluster BuildKit): .dockerignore patterns are excluded, but the
+// Dockerfile is always kept.
+func BuildContextTar(contextDir, dockerfile string) (io.ReadCloser, error) {
 	// Read .dockerignore patterns
 	excludes, err := readDockerignore(contextDir)
 	if err != nil {
-		return fmt.Errorf("failed to read .dockerignore: %w", err)
+		return nil, fmt.Errorf("failed to read .dockerignore: %w", err)
 	}
 
 	// Force-include the Dockerfile even if .dockerignore would exclude it.
@@ -326,7 +428,63 @@ func (c *Client) ImageBuild(ctx context.Context, contextDir, dockerfile, imageNa
 		ExcludePatterns: excludes,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create build context tar: %w", err)
+		return nil, fmt.Errorf("failed to create build context tar: %w", err)
+	}
+	return tarCtx, nil
+}
+
+// BuildContextHash returns a SHA-256 over what a build would send to the
+// daemon: the paths, modes and contents of the context files that aren't
+// .dockerignored, plus the Dockerfile path, target platform and build args.
+// Timestamps are ignored, so touching a file doesn't change the hash.
+func BuildContextHash(contextDir, dockerfile, platform string, buildArgs map[string]*string) (string, error) {
+	tarCtx, err := BuildContextTar(contextDir, dockerfile)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tarCtx.Close() }()
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "dockerfile %s\n", dockerfile)
+	if platform != "" {
+		_, _ = fmt.Fprintf(h, "platform %s\n", platform)
+	}
+	args := make([]string, 0, len(buildArgs))
+	for name := range buildArgs {
+		args = append(args, name)
+	}
+	sort.Strings(args)
+	for _, name := range args {
+		if v := buildArgs[name]; v != nil {
+			_, _ = fmt.Fprintf(h, "arg %s=%s\n", name, *v)
+		} else {
+			_, _ = fmt.Fprintf(h, "arg %s\n", name)
+		}
+	}
+
+	tr := tar.NewReader(tarCtx)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read build context: %w", err)
+		}
+		_, _ = fmt.Fprintf(h, "%s %c %o %d %s\n", hdr.Name, hdr.Typeflag, hdr.Mode, hdr.Size, hdr.Linkname)
+		if _, err := io.Copy(h, tr); err != nil {
+			return "", fmt.Errorf("failed to read build context: %w", err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ImageBuild builds an image from context directory. Build output is rendered
+// according to progress (see ProgressMode).
+func (c *Client) ImageBuild(ctx context.Context, contextDir, dockerfile, imageName string, buildOpts BuildOptions, progress ProgressMode) error {
+	tarCtx, err := BuildContextTar(contextDir, dockerfile)
+	if err != nil {
+		return err
 	}
 	defer func() { _ = tarCtx.Close() }()
 
@@ -334,7 +492,9 @@ func (c *Client) ImageBuild(ctx context.Context, contextDir, dockerfile, imageNa
 		Tags:       []string{imageName},
 		Dockerfile: dockerfile,
 		Remove:     true,
-		BuildArgs:  buildArgs,
+		BuildArgs:  buildOpts.BuildArgs,
+		NoCache:    buildOpts.NoCache,
+		Platform:   buildOpts.Platform,
 	}
 
 	resp, err := c.cli.ImageBuild(ctx, tarCtx, opts)
@@ -344,7 +504,11 @@ func (c *Client) ImageBuild(ctx context.Context, contextDir, dockerfile, imageNa
 	defer func() { _ = resp.Body.Close() }()
 
 	// Stream build output and check for errors
-	err = jsonmessage.DisplayJSONMessagesStream(resp.Body, os.Stdout, 0, false, nil)
+	if buildOpts.Output != nil {
+		err = jsonmessage.DisplayJSONMessagesStream(resp.Body, buildOpts.Output, 0, false, nil)
+	} else {
+		err = displayJSONMessages(resp.Body, progress)
+	}
 	if err != nil {
 		return fmt.Errorf("build failed for image %s: %w", imageName, err)
 	}
@@ -374,6 +538,19 @@ func (c *Client) ImageLoad(ctx context.Context, input io.Reader) error {
 	return nil
 }
 
+// ImageRemove removes an image from the Docker host. Idempotent - returns nil
+// if the image doesn't exist.
+func (c *Client) ImageRemove(ctx context.Context, imageName string) error {
+	_, err := c.cli.ImageRemove(ctx, imageName, types.ImageRemoveOptions{PruneChildren: true})
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil // Idempotent
+		}
+		return fmt.Errorf("failed to remove image %s: %w", imageName, err)
+	}
+	return nil
+}
+
 // VolumeRemove removes a volume. Idempotent - returns nil if volume doesn't exist.
 func (c *Client) VolumeRemove(ctx context.Context, name string) error {
 	err := c.cli.VolumeRemove(ctx, name, true)
@@ -574,13 +751,34 @@ func (c *Client) ImageTag(ctx context.Context, source, target string) error {
 
 // ImageExists checks if an image exists locally
 func (c *Client) ImageExists(ctx context.Context, imageName string) bool {
-	_, _, err := c.cli.ImageInspectWithRaw(ctx, imageName)
+	_, err := c.ImageInspect(ctx, imageName)
 	return err == nil
 }
 
-// ImagePull pulls an image from a registry
-func (c *Client) ImagePull(ctx context.Context, imageName string) error {
-	reader, err := c.cli.ImagePull(ctx, imageName, types.ImagePullOptions{})
+// ImageInspect returns the local image's metadata. The error satisfies
+// errdefs.IsNotFound when the image isn't present locally.
+func (c *Client) ImageInspect(ctx context.Context, imageName string) (types.ImageInspect, error) {
+	inspect, _, err := c.cli.ImageInspectWithRaw(ctx, imageName)
+	if err != nil {
+		return types.ImageInspect{}, fmt.Errorf("failed to inspect image %s: %w", imageName, err)
+	}
+	return inspect, nil
+}
+
+// ImageInRegistry checks that an image's manifest can be fetched from its
+// registry, without pulling it. Registries without credentials set by
+// SetRegistryAuth are accessed anonymously, so only public images pass.
+func (c *Client) ImageInRegistry(ctx context.Context, imageName string) error {
+	if _, err := c.cli.DistributionInspect(ctx, imageName, c.encodedRegistryAuth(imageName)); err != nil {
+		return fmt.Errorf("failed to find image %s in its registry: %w", imageName, err)
+	}
+	return nil
+}
+
+// ImagePull pulls an image from a registry. A non-empty platform (e.g.
+// linux/amd64) pulls that variant of a multi-platform image.
+func (c *Client) ImagePull(ctx context.Context, imageName, platform string) error {
+	reader, err := c.cli.ImagePull(ctx, imageName, types.ImagePullOptions{Platform: platform, RegistryAuth: c.encodedRegistryAuth(imageName)})
 	if err != nil {
 		return fmt.Errorf("failed to pull image %s: %w", imageName, err)
 	}
@@ -594,3 +792,12 @@ func (c *Client) ImagePull(ctx context.Context, imageName string) error {
 
 	return nil
 }
+
+// ServerAPIVersion returns the API version of the Docker daemon
+func (c *Client) ServerAPIVersion(ctx context.Context) (string, error) {
+	v, err := c.cli.ServerVersion(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get docker version: %w", err)
+	}
+	return v.APIVersion, nil
+}
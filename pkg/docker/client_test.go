@@ -1,9 +1,21 @@
 package docker
 
 import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
 )
 
 func TestReadDockerignore(t *testing.T) {
@@ -143,3 +155,172 @@ func TestReadDockerignorePermissionError(t *testing.T) {
 		t.Error("readDockerignore() should error for unreadable file")
 	}
 }
+
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/_ping") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("API-Version", "1.43")
+		_, _ = w.Write([]byte("OK"))
+	}))
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://")
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithVersion("1.43"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{cli: cli}
+
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping against a running daemon failed: %v", err)
+	}
+
+	// A stopped daemon refuses the connection
+	server.Close()
+	err = c.Ping(context.Background())
+	if !errors.Is(err, ErrDaemonNotRunning) {
+		t.Fatalf("Ping against a stopped daemon = %v, want ErrDaemonNotRunning", err)
+	}
+	if !strings.Contains(err.Error(), "start Docker Desktop/dockerd") {
+		t.Errorf("error should tell the user how to start Docker, got: %v", err)
+	}
+}
+
+func TestImageBuildNoCache(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/build") {
+			http.NotFound(w, r)
+			return
+		}
+		queries = append(queries, r.URL.Query().Get("nocache"))
+		_, _ = w.Write([]byte(`{"stream":"done\n"}` + "\n"))
+	}))
+	defer server.Close()
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://")
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithVersion("1.43"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{cli: cli}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, noCache := range []bool{false, true} {
+		if err := c.ImageBuild(context.Background(), dir, "Dockerfile", "app:latest", BuildOptions{NoCache: noCache}, ProgressQuiet); err != nil {
+			t.Fatalf("ImageBuild(NoCache=%v) failed: %v", noCache, err)
+		}
+	}
+	// The client only sends nocache when it is set
+	if len(queries) != 2 || queries[0] != "" || queries[1] != "1" {
+		t.Errorf("nocache query params = %q, want [\"\" \"1\"]", queries)
+	}
+}
+
+func TestBuildContextHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Dockerfile", "FROM alpine\nCOPY . /app\n")
+	write("src/main.go", "package main\n")
+	write("node_modules/dep.js", "v1")
+	write(".dockerignore", "node_modules\n")
+
+	version := "1"
+	args := map[string]*string{"VERSION": &version}
+	platform := ""
+	hash := func() string {
+		t.Helper()
+		h, err := BuildContextHash(dir, "Dockerfile", platform, args)
+		if err != nil {
+			t.Fatalf("BuildContextHash failed: %v", err)
+		}
+		return h
+	}
+
+	base := hash()
+	if hash() != base {
+		t.Fatal("hash is not stable")
+	}
+
+	// Ignored files and timestamps don't count
+	write("node_modules/dep.js", "v2")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "src/main.go"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if hash() != base {
+		t.Error("changing a .dockerignored file or a timestamp should not change the hash")
+	}
+
+	// Build args and context contents do
+	version = "2"
+	if hash() == base {
+		t.Error("changing a build arg should change the hash")
+	}
+	version = "1"
+	platform = "linux/amd64"
+	if hash() == base {
+		t.Error("changing the target platform should change the hash")
+	}
+	platform = ""
+	write("src/main.go", "package main // edited\n")
+	if hash() == base {
+		t.Error("editing a context file should change the hash")
+	}
+}
+
+func TestContainerWriteFile(t *testing.T) {
+	entries := map[string]string{}
+	var target string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || !strings.HasSuffix(r.URL.Path, "/containers/abc123/archive") {
+			http.NotFound(w, r)
+			return
+		}
+		target = r.URL.Query().Get("path")
+		tr := tar.NewReader(r.Body)
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				break
+			}
+			content, _ := io.ReadAll(tr)
+			entries[hdr.Name] = fmt.Sprintf("%c %o %s", hdr.Typeflag, hdr.Mode, content)
+		}
+	}))
+	defer server.Close()
+	host := "tcp://" + strings.TrimPrefix(server.URL, "http://")
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithVersion("1.43"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{cli: cli}
+
+	if err := c.ContainerWriteFile(context.Background(), "abc123", "/etc/rancher/k3s/registries.yaml", []byte("mirrors: {}\n"), 0600); err != nil {
+		t.Fatalf("ContainerWriteFile failed: %v", err)
+	}
+	if target != "/" {
+		t.Errorf("archive extracted at %q, want /", target)
+	}
+	want := map[string]string{
+		"etc/":                            "5 755 ",
+		"etc/rancher/":                    "5 755 ",
+		"etc/rancher/k3s/":                "5 755 ",
+		"etc/rancher/k3s/registries.yaml": "0 600 mirrors: {}\n",
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("archive entries = %q, want %q", entries, want)
+	}
+}
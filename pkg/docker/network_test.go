@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+func TestNetworkCreateWithLabelsPassesOptions(t *testing.T) {
+	var got types.NetworkCreateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/networks/create") {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"Id":"abc"}`))
+	}))
+	defer server.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.43"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{cli: cli}
+
+	options := map[string]string{"com.docker.network.driver.mtu": "1400"}
+	if err := c.NetworkCreateWithLabels(context.Background(), "kappal-demo-net", map[string]string{"kappal.io/project": "demo"}, options); err != nil {
+		t.Fatalf("NetworkCreateWithLabels failed: %v", err)
+	}
+	if got.Name != "kappal-demo-net" || got.Driver != "bridge" {
+		t.Errorf("created %q with driver %q, want kappal-demo-net/bridge", got.Name, got.Driver)
+	}
+	if got.Options["com.docker.network.driver.mtu"] != "1400" {
+		t.Errorf("options = %v, want the MTU to reach the create call", got.Options)
+	}
+	if got.Labels["kappal.io/project"] != "demo" {
+		t.Errorf("labels = %v, want the project label", got.Labels)
+	}
+}
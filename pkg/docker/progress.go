@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/moby/term"
+)
+
+// ProgressMode controls how build and startup progress is rendered,
+// mirroring the BuildKit/compose --progress modes.
+type ProgressMode string
+
+const (
+	// ProgressPlain prints line-buffered output, suitable for CI logs.
+	ProgressPlain ProgressMode = "plain"
+	// ProgressTTY updates progress in place on an attached terminal.
+	ProgressTTY ProgressMode = "tty"
+	// ProgressQuiet suppresses intermediate output and prints only final results.
+	ProgressQuiet ProgressMode = "quiet"
+)
+
+// ParseProgressMode validates a --progress flag value. An empty value selects
+// tty when isTerminal is true and plain otherwise.
+func ParseProgressMode(value string, isTerminal bool) (ProgressMode, error) {
+	switch ProgressMode(value) {
+	case "":
+		if isTerminal {
+			return ProgressTTY, nil
+		}
+		return ProgressPlain, nil
+	case ProgressPlain, ProgressTTY, ProgressQuiet:
+		return ProgressMode(value), nil
+	}
+	return "", fmt.Errorf("invalid progress mode %q (valid: plain, tty, quiet)", value)
+}
+
+// StdoutIsTerminal reports whether os.Stdout is attached to a terminal.
+func StdoutIsTerminal() bool {
+	_, isTerminal := term.GetFdInfo(os.Stdout)
+	return isTerminal
+}
+
+// Writer returns the destination for intermediate progress output:
+// io.Discard in quiet mode, os.Stdout otherwise.
+func (p ProgressMode) Writer() io.Writer {
+	if p == ProgressQuiet {
+		return io.Discard
+	}
+	return os.Stdout
+}
+
+// displayJSONMessages renders a Docker JSON message stream (build/pull output)
+// in the given progress mode. Errors embedded in the stream are returned
+// regardless of mode.
+func displayJSONMessages(in io.Reader, progress ProgressMode) error {
+	switch progress {
+	case ProgressTTY:
+		fd, isTerminal := term.GetFdInfo(os.Stdout)
+		return jsonmessage.DisplayJSONMessagesStream(in, os.Stdout, fd, isTerminal, nil)
+	case ProgressQuiet:
+		return jsonmessage.DisplayJSONMessagesStream(in, io.Discard, 0, false, nil)
+	default:
+		return jsonmessage.DisplayJSONMessagesStream(in, os.Stdout, 0, false, nil)
+	}
+}
+
+// PrefixWriter prefixes each line written to it, like the "web  | " prefixes
+// of 'docker compose' output. Writers sharing a mutex emit whole lines, so
+// concurrent builds don't interleave mid-line. Call Flush to write a final
+// unterminated line.
+type PrefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+// NewPrefixWriter returns a PrefixWriter writing to out under mu.
+func NewPrefixWriter(out io.Writer, mu *sync.Mutex, prefix string) *PrefixWriter {
+	return &PrefixWriter{mu: mu, out: out, prefix: prefix}
+}
+
+// Write buffers p and writes every complete line with the prefix.
+func (w *PrefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	var lines []byte
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, w.prefix...)
+		lines = append(lines, w.buf[:i+1]...)
+		w.buf = w.buf[i+1:]
+	}
+	if len(lines) > 0 {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if _, err := w.out.Write(lines); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes any buffered partial line, terminated with a newline.
+func (w *PrefixWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.Write([]byte("\n"))
+	return err
+}
@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestParseProgressMode(t *testing.T) {
+	tests := []struct {
+		value      string
+		isTerminal bool
+		expected   ProgressMode
+	}{
+		{"", true, ProgressTTY},
+		{"", false, ProgressPlain},
+		{"plain", true, ProgressPlain},
+		{"tty", false, ProgressTTY},
+		{"quiet", true, ProgressQuiet},
+		{"quiet", false, ProgressQuiet},
+	}
+
+	for _, tt := range tests {
+		mode, err := ParseProgressMode(tt.value, tt.isTerminal)
+		if err != nil {
+			t.Errorf("ParseProgressMode(%q, %v) returned error: %v", tt.value, tt.isTerminal, err)
+			continue
+		}
+		if mode != tt.expected {
+			t.Errorf("ParseProgressMode(%q, %v) = %q, want %q", tt.value, tt.isTerminal, mode, tt.expected)
+		}
+	}
+}
+
+func TestParseProgressModeInvalid(t *testing.T) {
+	for _, value := range []string{"auto", "TTY", "verbose"} {
+		if _, err := ParseProgressMode(value, true); err == nil {
+			t.Errorf("ParseProgressMode(%q) should return an error", value)
+		}
+	}
+}
+
+func TestProgressModeWriterQuiet(t *testing.T) {
+	n, err := ProgressQuiet.Writer().Write([]byte("discarded"))
+	if err != nil || n != len("discarded") {
+		t.Errorf("quiet writer should discard output, got n=%d err=%v", n, err)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	web := NewPrefixWriter(&out, &mu, "web | ")
+	api := NewPrefixWriter(&out, &mu, "api | ")
+
+	_, _ = web.Write([]byte("Step 1/2\nSte"))
+	_, _ = api.Write([]byte("Step 1/1\n"))
+	_, _ = web.Write([]byte("p 2/2\ndone"))
+	if err := web.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	_ = api.Flush()
+
+	want := "web | Step 1/2\napi | Step 1/1\nweb | Step 2/2\nweb | done\n"
+	if out.String() != want {
+		t.Errorf("output =\n%q\nwant\n%q", out.String(), want)
+	}
+}
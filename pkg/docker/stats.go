@@ -0,0 +1,94 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+)
+
+// ContainerStats is a point-in-time resource usage summary for a container,
+// computed the same way as `docker stats`.
+type ContainerStats struct {
+	Name          string  `json:"name"`
+	CPUPercent    float64 `json:"cpuPercent"`
+	MemoryUsage   uint64  `json:"memoryUsage"` // bytes, excluding page cache
+	MemoryLimit   uint64  `json:"memoryLimit"` // bytes
+	MemoryPercent float64 `json:"memoryPercent"`
+	NetworkRx     uint64  `json:"networkRx"` // bytes received, summed over interfaces
+	NetworkTx     uint64  `json:"networkTx"` // bytes sent, summed over interfaces
+	PIDs          uint64  `json:"pids"`
+}
+
+// ContainerStats returns a single resource usage sample for a container.
+func (c *Client) ContainerStats(ctx context.Context, name string) (*ContainerStats, error) {
+	resp, err := c.cli.ContainerStats(ctx, name, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats for %s: %w", name, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	return parseStats(resp.Body)
+}
+
+// parseStats decodes a Docker stats JSON document into a ContainerStats.
+func parseStats(r io.Reader) (*ContainerStats, error) {
+	var raw types.StatsJSON
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %w", err)
+	}
+
+	stats := &ContainerStats{
+		Name:        trimContainerName(raw.Name),
+		CPUPercent:  cpuPercent(raw.Stats),
+		MemoryUsage: memoryUsage(raw.MemoryStats),
+		MemoryLimit: raw.MemoryStats.Limit,
+		PIDs:        raw.PidsStats.Current,
+	}
+	if stats.MemoryLimit > 0 {
+		stats.MemoryPercent = float64(stats.MemoryUsage) / float64(stats.MemoryLimit) * 100
+	}
+	for _, n := range raw.Networks {
+		stats.NetworkRx += n.RxBytes
+		stats.NetworkTx += n.TxBytes
+	}
+	return stats, nil
+}
+
+// cpuPercent computes CPU usage between the previous and current sample,
+// scaled by the number of online CPUs (100% per fully used core).
+func cpuPercent(s types.Stats) float64 {
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	onlineCPUs := float64(s.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// memoryUsage subtracts reclaimable page cache from the raw usage:
+// inactive_file on cgroup v2, total_inactive_file on cgroup v1.
+func memoryUsage(m types.MemoryStats) uint64 {
+	cache, ok := m.Stats["inactive_file"]
+	if !ok {
+		cache = m.Stats["total_inactive_file"]
+	}
+	if cache > m.Usage {
+		return m.Usage
+	}
+	return m.Usage - cache
+}
+
+// trimContainerName strips the leading "/" Docker puts on container names.
+func trimContainerName(name string) string {
+	if len(name) > 0 && name[0] == '/' {
+		return name[1:]
+	}
+	return name
+}
@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseStats(t *testing.T) {
+	input := `{
+  "name": "/kappal-myapp-1a2b3c4d-k3s",
+  "pids_stats": {"current": 312},
+  "cpu_stats": {
+    "cpu_usage": {"total_usage": 3000000000},
+    "system_cpu_usage": 20000000000,
+    "online_cpus": 4
+  },
+  "precpu_stats": {
+    "cpu_usage": {"total_usage": 2000000000},
+    "system_cpu_usage": 10000000000
+  },
+  "memory_stats": {
+    "usage": 1073741824,
+    "limit": 4294967296,
+    "stats": {"inactive_file": 268435456}
+  },
+  "networks": {
+    "eth0": {"rx_bytes": 1000, "tx_bytes": 2000},
+    "eth1": {"rx_bytes": 500, "tx_bytes": 250}
+  }
+}`
+
+	stats, err := parseStats(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseStats failed: %v", err)
+	}
+
+	if stats.Name != "kappal-myapp-1a2b3c4d-k3s" {
+		t.Errorf("expected leading slash trimmed from name, got %q", stats.Name)
+	}
+	// 1e9 cpu delta / 1e10 system delta * 4 CPUs * 100
+	if math.Abs(stats.CPUPercent-40) > 0.001 {
+		t.Errorf("expected CPU 40%%, got %f", stats.CPUPercent)
+	}
+	if stats.MemoryUsage != 805306368 {
+		t.Errorf("expected memory usage excluding inactive_file (805306368), got %d", stats.MemoryUsage)
+	}
+	if stats.MemoryLimit != 4294967296 {
+		t.Errorf("expected memory limit 4294967296, got %d", stats.MemoryLimit)
+	}
+	if math.Abs(stats.MemoryPercent-18.75) > 0.001 {
+		t.Errorf("expected memory 18.75%%, got %f", stats.MemoryPercent)
+	}
+	if stats.NetworkRx != 1500 || stats.NetworkTx != 2250 {
+		t.Errorf("expected network rx/tx summed to 1500/2250, got %d/%d", stats.NetworkRx, stats.NetworkTx)
+	}
+	if stats.PIDs != 312 {
+		t.Errorf("expected 312 pids, got %d", stats.PIDs)
+	}
+}
+
+func TestParseStatsNoPreviousSample(t *testing.T) {
+	input := `{"cpu_stats": {"cpu_usage": {"total_usage": 100}, "system_cpu_usage": 1000, "online_cpus": 2},
+"memory_stats": {"usage": 2048, "stats": {"total_inactive_file": 4096}}}`
+
+	stats, err := parseStats(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseStats failed: %v", err)
+	}
+	// Without precpu the whole counter is the delta, as with docker stats
+	if math.Abs(stats.CPUPercent-20) > 0.001 {
+		t.Errorf("expected CPU 20%%, got %f", stats.CPUPercent)
+	}
+	if stats.MemoryUsage != 2048 {
+		t.Errorf("expected usage kept when cache exceeds it, got %d", stats.MemoryUsage)
+	}
+	if stats.MemoryPercent != 0 {
+		t.Errorf("expected 0%% memory without a limit, got %f", stats.MemoryPercent)
+	}
+}
+
+func TestParseStatsInvalidJSON(t *testing.T) {
+	if _, err := parseStats(strings.NewReader("not json")); err == nil {
+		t.Error("expected error for invalid stats JSON")
+	}
+}
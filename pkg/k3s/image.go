@@ -0,0 +1,51 @@
+package k3s
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/distribution/reference"
+)
+
+// K3sImageEnv overrides the K3s image (e.g. docker.io/rancher/k3s:v1.30.4-k3s1)
+// for projects that need a newer or older Kubernetes API than K3sImage.
+const K3sImageEnv = "KAPPAL_K3S_IMAGE"
+
+// ImageFromEnv returns the K3s image named by K3sImageEnv, or K3sImage when
+// it is unset. The image must be a valid reference with a tag or digest.
+func ImageFromEnv(getenv func(string) string) (string, error) {
+	image := getenv(K3sImageEnv)
+	if image == "" {
+		return K3sImage, nil
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %w", K3sImageEnv, image, err)
+	}
+	if reference.IsNameOnly(named) {
+		return "", fmt.Errorf("invalid %s %q: pin a version tag (e.g. rancher/k3s:v1.30.4-k3s1)", K3sImageEnv, image)
+	}
+	return image, nil
+}
+
+// selectImage returns the K3s image a project runs: K3sImageEnv when set,
+// else the image 'kappal --setup' recorded in the workspace's setup.json,
+// else K3sImage.
+func selectImage(workspaceDir string, getenv func(string) string) (string, error) {
+	if getenv(K3sImageEnv) != "" {
+		return ImageFromEnv(getenv)
+	}
+	data, err := os.ReadFile(filepath.Join(workspaceDir, "setup.json"))
+	if err != nil {
+		return K3sImage, nil
+	}
+	var metadata struct {
+		K3sImage string `json:"k3s_image"`
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil || metadata.K3sImage == "" {
+		return K3sImage, nil
+	}
+	return metadata.K3sImage, nil
+}
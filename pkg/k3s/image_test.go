@@ -0,0 +1,56 @@
+package k3s
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImageFromEnv(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+
+	if image, err := ImageFromEnv(getenv); err != nil || image != K3sImage {
+		t.Errorf("ImageFromEnv without %s = %q, %v; want %q", K3sImageEnv, image, err, K3sImage)
+	}
+
+	env[K3sImageEnv] = "rancher/k3s:v1.30.4-k3s1"
+	if image, err := ImageFromEnv(getenv); err != nil || image != "rancher/k3s:v1.30.4-k3s1" {
+		t.Errorf("ImageFromEnv = %q, %v", image, err)
+	}
+
+	for _, bad := range []string{"rancher/k3s", "Rancher/K3S:latest", "rancher/k3s:v1 .30"} {
+		env[K3sImageEnv] = bad
+		if _, err := ImageFromEnv(getenv); err == nil {
+			t.Errorf("ImageFromEnv(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSelectImage(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+	dir := t.TempDir()
+
+	if image, err := selectImage(dir, getenv); err != nil || image != K3sImage {
+		t.Errorf("selectImage without setup.json = %q, %v; want %q", image, err, K3sImage)
+	}
+
+	setup := `{"version": "1.0.0", "k3s_image": "docker.io/rancher/k3s:v1.28.9-k3s1"}`
+	if err := os.WriteFile(filepath.Join(dir, "setup.json"), []byte(setup), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if image, err := selectImage(dir, getenv); err != nil || image != "docker.io/rancher/k3s:v1.28.9-k3s1" {
+		t.Errorf("selectImage = %q, %v; want the image recorded by setup", image, err)
+	}
+
+	// The environment wins over setup metadata
+	env[K3sImageEnv] = "rancher/k3s:v1.30.4-k3s1"
+	if image, err := selectImage(dir, getenv); err != nil || image != "rancher/k3s:v1.30.4-k3s1" {
+		t.Errorf("selectImage = %q, %v; want %s", image, err, K3sImageEnv)
+	}
+	env[K3sImageEnv] = "rancher/k3s"
+	if _, err := selectImage(dir, getenv); err == nil {
+		t.Error("selectImage should reject an untagged image")
+	}
+}
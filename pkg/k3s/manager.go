@@ -5,14 +5,20 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/distribution/reference"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/go-connections/nat"
@@ -21,24 +27,61 @@ import (
 )
 
 const (
+	// K3sImage is the default K3s image; see K3sImageEnv to override it.
 	K3sImage = "docker.io/rancher/k3s:v1.29.0-k3s1"
 )
 
+// DefaultBindAddress is the host address published ports bind to unless the
+// compose port names one (e.g. "0.0.0.0:8080:80") or SetBindAddress changes
+// it, so dev services aren't reachable from the rest of the network.
+const DefaultBindAddress = "127.0.0.1"
+
 // PublishedPort represents a port to publish from the K3s container to the host.
 type PublishedPort struct {
+	HostIP        string // empty binds the manager's bind address
 	HostPort      uint32
 	ContainerPort uint32
 	Protocol      string // "tcp" or "udp"
 }
 
+// IngressPorts are the K3s container ports Traefik serves HTTP and HTTPS
+// on; with ingress enabled they are published on the same host ports.
+var IngressPorts = []PublishedPort{
+	{HostPort: 80, ContainerPort: 80, Protocol: "tcp"},
+	{HostPort: 443, ContainerPort: 443, Protocol: "tcp"},
+}
+
 // Manager handles K3s lifecycle only (Docker start/stop)
 // All Kubernetes operations go through client-go after K3s is running
 type Manager struct {
 	workspaceDir   string
 	runtimeDir     string
 	projectName    string
+	image          string // K3s image new containers are created from
+	apiPort        uint32 // pinned K3s API host port (KAPPAL_API_PORT); 0 derives it from the project name
 	publishedPorts []PublishedPort
+	bindAddress    string // host address for published ports without a host IP
+	networkOptions map[string]string
+	resources      Resources // K3s container limits; zero is unlimited
+	ingress        bool      // run the bundled Traefik ingress controller
+	metrics        bool      // run the bundled metrics-server
+	noCache        bool
+	forceBuild     bool
+	progress       docker.ProgressMode
 	docker         *docker.Client
+
+	clusterBuilder      ClusterBuilder // in-cluster image builder; nil builds with docker
+	clusterBuilderOnce  sync.Once
+	clusterBuilderReady bool
+}
+
+// ClusterBuilder builds images inside K3s, straight into its containerd,
+// without BuildImage's docker save/ctr import round-trip (build.Engine).
+type ClusterBuilder interface {
+	// Ready starts the builder if needed and waits until it is healthy.
+	Ready(ctx context.Context) error
+	// Build builds dockerfile (relative to contextDir) as imageName.
+	Build(ctx context.Context, imageName, contextDir, dockerfile string, opts docker.BuildOptions, progress docker.ProgressMode) error
 }
 
 var sanitizeRe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
@@ -50,6 +93,18 @@ func sanitize(name string) string {
 
 // NewManager creates a new K3s manager for the given project.
 func NewManager(workspaceDir string, projectName string) (*Manager, error) {
+	image, err := selectImage(workspaceDir, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	apiPort, err := apiPortFromEnv(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := resourcesFromEnv(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
 	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create docker client: %w", err)
@@ -58,14 +113,117 @@ func NewManager(workspaceDir string, projectName string) (*Manager, error) {
 		workspaceDir: workspaceDir,
 		runtimeDir:   filepath.Join(workspaceDir, "runtime"),
 		projectName:  projectName,
+		image:        image,
+		apiPort:      apiPort,
+		resources:    resources,
+		bindAddress:  DefaultBindAddress,
+		progress:     docker.ProgressPlain,
 		docker:       dockerClient,
 	}, nil
 }
 
+// SetProgress sets how startup and build progress is rendered.
+// Defaults to docker.ProgressPlain.
+func (m *Manager) SetProgress(mode docker.ProgressMode) {
+	m.progress = mode
+}
+
+// logf prints an intermediate progress message unless progress is quiet.
+func (m *Manager) logf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(m.progress.Writer(), format, args...)
+}
+
+// SetNoCache makes BuildImage ignore the Docker build cache. It also
+// rebuilds images whose build context is unchanged.
+func (m *Manager) SetNoCache(noCache bool) {
+	m.noCache = noCache
+}
+
+// SetForceBuild makes BuildImage rebuild images even when their build
+// context is unchanged since the last build.
+func (m *Manager) SetForceBuild(force bool) {
+	m.forceBuild = force
+}
+
+// SetNetworkOptions sets the driver options (compose driver_opts of the
+// default network, e.g. com.docker.network.driver.mtu) used when creating the
+// project's bridge network. Must be called before EnsureRunning; they only
+// apply when the network is created.
+func (m *Manager) SetNetworkOptions(options map[string]string) {
+	m.networkOptions = options
+}
+
+// SetBindAddress sets the host address published ports without a compose
+// host IP bind to (default DefaultBindAddress; "0.0.0.0" for all
+// interfaces). Must be called before EnsureRunning.
+func (m *Manager) SetBindAddress(addr string) {
+	m.bindAddress = addr
+}
+
+// SetResources caps the K3s container's memory and CPUs, overriding
+// MemoryEnv and CPUsEnv for each non-zero limit. Must be called before
+// EnsureRunning; limits only apply when the container is created.
+func (m *Manager) SetResources(r Resources) {
+	if r.Memory != 0 {
+		m.resources.Memory = r.Memory
+	}
+	if r.NanoCPUs != 0 {
+		m.resources.NanoCPUs = r.NanoCPUs
+	}
+}
+
+// SetIngress keeps the Traefik ingress controller K3s bundles, which is
+// disabled by default to stay lightweight. Callers publish IngressPorts
+// alongside the compose ports. Must be called before EnsureRunning.
+func (m *Manager) SetIngress(enabled bool) {
+	m.ingress = enabled
+}
+
+// SetClusterBuilder makes BuildImage build with b once it is healthy,
+// falling back to docker build and import when it isn't. Builds for an
+// explicit platform always use docker, which can emulate other
+// architectures. Must be called before BuildImage.
+func (m *Manager) SetClusterBuilder(b ClusterBuilder) {
+	m.clusterBuilder = b
+}
+
+// useClusterBuilder reports whether builds go through the cluster builder,
+// checking its health once per manager.
+func (m *Manager) useClusterBuilder(ctx context.Context, logOut io.Writer) bool {
+	if m.clusterBuilder == nil {
+		return false
+	}
+	m.clusterBuilderOnce.Do(func() {
+		if err := m.clusterBuilder.Ready(ctx); err != nil {
+			_, _ = fmt.Fprintf(logOut, "BuildKit builder unavailable (%v), falling back to docker build\n", err)
+			return
+		}
+		m.clusterBuilderReady = true
+	})
+	return m.clusterBuilderReady
+}
+
+// SetMetrics keeps the metrics-server K3s bundles, which serves the
+// metrics.k8s.io API read by stats and HorizontalPodAutoscalers. It is
+// disabled by default to save memory. Must be called before EnsureRunning.
+func (m *Manager) SetMetrics(enabled bool) {
+	m.metrics = enabled
+}
+
 // SetPublishedPorts sets the compose service ports to publish on the K3s container.
 // Must be called before EnsureRunning. Returns an error if duplicate container
 // port/protocol combinations are found.
 func (m *Manager) SetPublishedPorts(ports []PublishedPort) error {
+	if err := CheckPublishedPorts(ports); err != nil {
+		return err
+	}
+	m.publishedPorts = ports
+	return nil
+}
+
+// CheckPublishedPorts returns an error if two ports share a container
+// port/protocol, which the K3s port chain cannot route.
+func CheckPublishedPorts(ports []PublishedPort) error {
 	seen := make(map[string]bool)
 	for _, p := range ports {
 		proto := p.Protocol
@@ -78,10 +236,44 @@ func (m *Manager) SetPublishedPorts(ports []PublishedPort) error {
 		}
 		seen[key] = true
 	}
-	m.publishedPorts = ports
 	return nil
 }
 
+// CheckIngressPorts returns an error if a compose port publishes one of the
+// IngressPorts, which Traefik's load balancer holds when ingress is enabled.
+func CheckIngressPorts(ports []PublishedPort) error {
+	for _, p := range ports {
+		for _, ingress := range IngressPorts {
+			if p.ContainerPort == ingress.ContainerPort && (p.Protocol == "" || p.Protocol == ingress.Protocol) {
+				return fmt.Errorf("container port %d/tcp is used by the ingress controller; route the service through a kappal.io/ingress-host label instead of publishing it, or drop --enable-ingress",
+					p.ContainerPort)
+			}
+		}
+	}
+	return nil
+}
+
+// serverArgs returns the K3s server command line. Traefik and
+// metrics-server are disabled unless SetIngress and SetMetrics enabled them.
+func (m *Manager) serverArgs() []string {
+	args := []string{"server"}
+	if !m.ingress {
+		args = append(args, "--disable=traefik")
+	}
+	if !m.metrics {
+		args = append(args, "--disable=metrics-server")
+	}
+	return append(args,
+		"--flannel-backend=host-gw",
+		"--kube-apiserver-arg=watch-cache=false",
+		"--kube-controller-manager-arg=terminated-pod-gc-threshold=10",
+		"--etcd-disable-snapshots",
+		"--bind-address=0.0.0.0",
+		"--tls-san=0.0.0.0",
+		"--tls-san=127.0.0.1",
+	)
+}
+
 // containerName returns the Docker container name for this project's K3s instance.
 func (m *Manager) containerName() string {
 	return fmt.Sprintf("kappal-%s-k3s", sanitize(m.projectName))
@@ -102,9 +294,30 @@ func (m *Manager) NetworkName() string {
 	return m.networkName()
 }
 
-// apiHostPort returns a deterministic host port for the K3s API server,
-// derived from the project name. Range: 16443–26442.
+// APIPortEnv pins the host port the K3s API server is published on, for a
+// stable, firewall-friendly port or to resolve a collision between projects.
+const APIPortEnv = "KAPPAL_API_PORT"
+
+// apiPortFromEnv returns the port set in APIPortEnv, or 0 when it is unset.
+func apiPortFromEnv(getenv func(string) string) (uint32, error) {
+	value := getenv(APIPortEnv)
+	if value == "" {
+		return 0, nil
+	}
+	port, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid %s %q (must be a port number 1-65535)", APIPortEnv, value)
+	}
+	return uint32(port), nil
+}
+
+// apiHostPort returns the host port for the K3s API server: APIPortEnv when
+// set, else a deterministic port derived from the project name. Range:
+// 16443–26442.
 func (m *Manager) apiHostPort() uint32 {
+	if m.apiPort != 0 {
+		return m.apiPort
+	}
 	h := sha256.Sum256([]byte(m.projectName))
 	offset := binary.BigEndian.Uint16(h[:2]) % 10000
 	return 16443 + uint32(offset)
@@ -151,14 +364,22 @@ func (m *Manager) buildExpectedPortBindings() nat.PortMap {
 	}
 
 	// Compose published ports
+	bindAddress := m.bindAddress
+	if bindAddress == "" {
+		bindAddress = DefaultBindAddress
+	}
 	for _, p := range m.publishedPorts {
 		proto := p.Protocol
 		if proto == "" {
 			proto = "tcp"
 		}
+		hostIP := p.HostIP
+		if hostIP == "" {
+			hostIP = bindAddress
+		}
 		containerPort, _ := nat.NewPort(proto, fmt.Sprintf("%d", p.ContainerPort))
 		portBindings[containerPort] = []nat.PortBinding{
-			{HostIP: "0.0.0.0", HostPort: fmt.Sprintf("%d", p.HostPort)},
+			{HostIP: hostIP, HostPort: fmt.Sprintf("%d", p.HostPort)},
 		}
 	}
 
@@ -176,7 +397,7 @@ func portBindingsMatch(running, expected nat.PortMap) bool {
 			return false
 		}
 		for i, eb := range expectedBindings {
-			if runningBindings[i].HostPort != eb.HostPort {
+			if runningBindings[i].HostPort != eb.HostPort || runningBindings[i].HostIP != eb.HostIP {
 				return false
 			}
 		}
@@ -185,7 +406,8 @@ func portBindingsMatch(running, expected nat.PortMap) bool {
 }
 
 // EnsureRunning starts K3s if not already running.
-// If the container is running but port bindings have changed, it recreates K3s.
+// If the container is running but port bindings or server arguments have
+// changed, it recreates K3s.
 func (m *Manager) EnsureRunning(ctx context.Context) error {
 	containerName := m.containerName()
 
@@ -201,9 +423,21 @@ func (m *Manager) EnsureRunning(ctx context.Context) error {
 			return fmt.Errorf("failed to inspect container ports: %w", err)
 		}
 
+		currentCmd, err := m.docker.ContainerInspectCmd(ctx, containerName)
+		if err != nil {
+			return fmt.Errorf("failed to inspect container command: %w", err)
+		}
+
 		expectedPorts := m.buildExpectedPortBindings()
-		if !portBindingsMatch(currentPorts, expectedPorts) {
-			fmt.Println("Port config changed, recreating K3s...")
+		changed := ""
+		switch {
+		case !portBindingsMatch(currentPorts, expectedPorts):
+			changed = "Port"
+		case !slices.Equal(currentCmd, m.serverArgs()):
+			changed = "Server"
+		}
+		if changed != "" {
+			m.logf("%s config changed, recreating K3s...\n", changed)
 			if err := m.docker.ContainerStop(ctx, containerName, 10*time.Second); err != nil {
 				return fmt.Errorf("failed to stop K3s: %w", err)
 			}
@@ -213,7 +447,7 @@ func (m *Manager) EnsureRunning(ctx context.Context) error {
 			return m.start(ctx)
 		}
 
-		fmt.Println("K3s already running")
+		m.logf("K3s already running\n")
 		return m.waitForReady(ctx)
 	}
 
@@ -233,9 +467,14 @@ func (m *Manager) checkPortAvailability() error {
 	// Check API port
 	apiPort := m.apiHostPort()
 	if err := checkTCPPort(apiPort); err != nil {
+		if m.apiPort != 0 {
+			return fmt.Errorf("FATAL: K3s API port %d (%s) is already in use.\n"+
+				"Set %s to a free port, or unset it to derive the port from the project name", apiPort, APIPortEnv, APIPortEnv)
+		}
 		return fmt.Errorf("FATAL: K3s API port %d is already in use.\n"+
 			"This port is auto-assigned from your project name. Another kappal project has\n"+
-			"the same assignment. Use -p <different-name> to pick a different project name", apiPort)
+			"the same assignment. Set %s=<free port> to pin a different port, or use\n"+
+			"-p <different-name> to pick a different project name", apiPort, APIPortEnv)
 	}
 
 	// Check compose published ports
@@ -282,18 +521,24 @@ func checkUDPPort(port uint32) error {
 }
 
 func (m *Manager) start(ctx context.Context) error {
-	fmt.Println("Starting K3s...")
+	m.logf("Starting K3s...\n")
 
 	// Create runtime directory if it doesn't exist
 	if err := os.MkdirAll(m.runtimeDir, 0755); err != nil {
 		return fmt.Errorf("failed to create runtime directory: %w", err)
 	}
 
+	// Registry mirrors must be in place before K3s starts containerd
+	registries, err := registriesConfig(os.Getenv)
+	if err != nil {
+		return err
+	}
+
 	// Create bridge network for isolation (with project label for discovery)
 	networkLabels := map[string]string{
 		"kappal.io/project": m.projectName,
 	}
-	if err := m.docker.NetworkCreateWithLabels(ctx, m.networkName(), networkLabels); err != nil {
+	if err := m.docker.NetworkCreateWithLabels(ctx, m.networkName(), networkLabels, m.networkOptions); err != nil {
 		return fmt.Errorf("failed to create network: %w", err)
 	}
 
@@ -302,6 +547,14 @@ func (m *Manager) start(ctx context.Context) error {
 		return err
 	}
 
+	// A KAPPAL_K3S_IMAGE chosen after setup hasn't been pulled yet
+	if !m.docker.ImageExists(ctx, m.image) {
+		m.logf("Pulling K3s image (%s)...\n", m.image)
+		if err := m.docker.ImagePull(ctx, m.image, ""); err != nil {
+			return fmt.Errorf("failed to pull K3s image: %w", err)
+		}
+	}
+
 	// Use a named Docker volume for K3s data persistence.
 	k3sDataVolume := m.getK3sDataVolumeName()
 
@@ -317,19 +570,8 @@ func (m *Manager) start(ctx context.Context) error {
 	// Build container config
 	config := &container.Config{
 		Hostname: m.containerName(), // Stable hostname ensures K3s node name persists across container recreation
-		Image:    K3sImage,
-		Cmd: []string{
-			"server",
-			"--disable=traefik",
-			"--disable=metrics-server",
-			"--flannel-backend=host-gw",
-			"--kube-apiserver-arg=watch-cache=false",
-			"--kube-controller-manager-arg=terminated-pod-gc-threshold=10",
-			"--etcd-disable-snapshots",
-			"--bind-address=0.0.0.0",
-			"--tls-san=0.0.0.0",
-			"--tls-san=127.0.0.1",
-		},
+		Image:    m.image,
+		Cmd:      m.serverArgs(),
 		Env: []string{
 			"K3S_KUBECONFIG_MODE=644",
 			"GOMEMLIMIT=500MiB",
@@ -347,6 +589,10 @@ func (m *Manager) start(ctx context.Context) error {
 		NetworkMode:   container.NetworkMode(m.networkName()),
 		RestartPolicy: container.RestartPolicy{Name: "unless-stopped"},
 		PortBindings:  portBindings,
+		Resources: container.Resources{
+			Memory:   m.resources.Memory,
+			NanoCPUs: m.resources.NanoCPUs,
+		},
 		Mounts: []mount.Mount{
 			{
 				Type:   mount.TypeVolume,
@@ -356,7 +602,18 @@ func (m *Manager) start(ctx context.Context) error {
 		},
 	}
 
-	if err := m.docker.ContainerRunWithNetwork(ctx, config, hostConfig, m.networkName(), m.containerName()); err != nil {
+	containerID, err := m.docker.ContainerCreateWithNetwork(ctx, config, hostConfig, m.networkName(), m.containerName())
+	if err != nil {
+		return fmt.Errorf("failed to start K3s: %w", err)
+	}
+	if registries != nil {
+		m.logf("Writing K3s registry configuration (%s)\n", registriesPath)
+		if err := m.docker.ContainerWriteFile(ctx, containerID, registriesPath, registries, 0600); err != nil {
+			_ = m.docker.ContainerRemove(ctx, m.containerName())
+			return fmt.Errorf("failed to configure K3s registries: %w", err)
+		}
+	}
+	if err := m.docker.ContainerStart(ctx, containerID); err != nil {
 		return fmt.Errorf("failed to start K3s: %w", err)
 	}
 
@@ -392,11 +649,28 @@ func replaceServerURL(kubeconfig, target string) string {
 	return serverURLRegexp.ReplaceAllString(kubeconfig, "${1}"+target)
 }
 
+// publishedAPIPort returns the host port the K3s container publishes its
+// API server (6443/tcp) on, or 0 if it isn't published.
+func publishedAPIPort(ports nat.PortMap) uint32 {
+	for _, binding := range ports[nat.Port("6443/tcp")] {
+		if port, err := strconv.ParseUint(binding.HostPort, 10, 16); err == nil && port != 0 {
+			return uint32(port)
+		}
+	}
+	return 0
+}
+
 // resolveAPIEndpoint determines the correct API server host and port for the
 // current execution context, and connects to the bridge network if running in Docker.
 func (m *Manager) resolveAPIEndpoint(ctx context.Context) (host string, port uint32) {
 	host = "127.0.0.1"
 	port = m.apiHostPort()
+	// The container may have been created with a different KAPPAL_API_PORT
+	if ports, err := m.docker.ContainerInspectPorts(ctx, m.containerName()); err == nil {
+		if published := publishedAPIPort(ports); published != 0 {
+			port = published
+		}
+	}
 
 	if isInsideDocker() {
 		selfID := getSelfContainerID()
@@ -444,7 +718,11 @@ func (m *Manager) EnsureKubeconfig(ctx context.Context) error {
 
 // waitForReady waits for K3s to be ready and extracts the kubeconfig
 func (m *Manager) waitForReady(ctx context.Context) error {
-	fmt.Print("Waiting for K3s to be ready")
+	if m.progress == docker.ProgressTTY {
+		m.logf("Waiting for K3s to be ready")
+	} else {
+		m.logf("Waiting for K3s to be ready...\n")
+	}
 
 	// Ensure runtime directory exists
 	if err := os.MkdirAll(m.runtimeDir, 0755); err != nil {
@@ -475,13 +753,19 @@ func (m *Manager) waitForReady(ctx context.Context) error {
 			client, err := k8s.NewClient(kubeconfigPath)
 			if err == nil {
 				if err := client.CheckConnection(ctx); err == nil {
-					fmt.Println(" Ready!")
+					if m.progress == docker.ProgressTTY {
+						m.logf(" Ready!\n")
+					} else {
+						m.logf("K3s is ready\n")
+					}
 					return nil
 				}
 			}
 		}
 
-		fmt.Print(".")
+		if m.progress == docker.ProgressTTY {
+			m.logf(".")
+		}
 		time.Sleep(2 * time.Second)
 	}
 
@@ -509,13 +793,22 @@ func (m *Manager) Remove(ctx context.Context) error {
 	return m.docker.ContainerRemove(ctx, m.containerName())
 }
 
-// BuildImage builds an image and loads it into K3s containerd
+// BuildImage builds an image and loads it into K3s containerd, directly with
+// the cluster builder when one is set and healthy (see SetClusterBuilder).
 // dockerfile is the path to the Dockerfile relative to contextDir (empty string for default "Dockerfile")
-func (m *Manager) BuildImage(ctx context.Context, projectName, serviceName, contextDir, dockerfile string, buildArgs map[string]*string) error {
+// If out is non-nil, all build and import output goes to it as plain lines
+// (e.g. a prefixed writer for parallel builds); otherwise it is rendered in
+// the manager's progress mode. A non-empty platform (e.g. linux/amd64) builds
+// and imports the image for that architecture.
+func (m *Manager) BuildImage(ctx context.Context, projectName, serviceName, contextDir, dockerfile, platform string, buildArgs map[string]*string, out io.Writer) error {
 	imageName := fmt.Sprintf("%s-%s:latest", projectName, serviceName)
+	logOut := out
+	if logOut == nil {
+		logOut = m.progress.Writer()
+	}
 
 	// Build with docker SDK
-	fmt.Printf("Building image %s from %s\n", imageName, contextDir)
+	_, _ = fmt.Fprintf(logOut, "Building image %s from %s\n", imageName, contextDir)
 
 	// If no dockerfile specified, use default "Dockerfile"
 	dockerfilePath := dockerfile
@@ -523,12 +816,43 @@ func (m *Manager) BuildImage(ctx context.Context, projectName, serviceName, cont
 		dockerfilePath = "Dockerfile"
 	}
 
-	if err := m.docker.ImageBuild(ctx, contextDir, dockerfilePath, imageName, buildArgs); err != nil {
+	// Skip the build and import when nothing in the context changed and the
+	// image is still in both Docker and K3s. A failed hash just rebuilds.
+	hash, err := docker.BuildContextHash(contextDir, dockerfilePath, platform, buildArgs)
+	if err != nil {
+		hash = ""
+	}
+	hashPath := m.buildHashPath(serviceName)
+	if hash != "" && !m.noCache && !m.forceBuild && m.imageUpToDate(ctx, imageName, hashPath, hash) {
+		_, _ = fmt.Fprintf(logOut, "Image %s is up to date (build context unchanged), skipping build\n", imageName)
+		return nil
+	}
+	_ = os.Remove(hashPath)
+
+	buildOpts := docker.BuildOptions{BuildArgs: buildArgs, NoCache: m.noCache, Platform: platform, Output: out}
+	if platform == "" && m.useClusterBuilder(ctx, logOut) {
+		_, _ = fmt.Fprintf(logOut, "Building with the in-cluster BuildKit builder\n")
+		if err := m.clusterBuilder.Build(ctx, imageName, contextDir, dockerfilePath, buildOpts, m.progress); err != nil {
+			return err
+		}
+		m.recordBuildHash(hashPath, hash)
+		return nil
+	}
+
+	if err := m.docker.ImageBuild(ctx, contextDir, dockerfilePath, imageName, buildOpts, m.progress); err != nil {
 		return fmt.Errorf("docker build failed: %w", err)
 	}
 
+	// A rebuild often produces the identical image (every layer cached);
+	// don't stream hundreds of MB into containerd again
+	if m.imageLoaded(ctx, imageName) {
+		_, _ = fmt.Fprintf(logOut, "Image %s already loaded in K3s, skipping import\n", imageName)
+		m.recordBuildHash(hashPath, hash)
+		return nil
+	}
+
 	// Save and load into k3s containerd (uses pipe to avoid tarball on disk)
-	fmt.Printf("Loading image into K3s...\n")
+	_, _ = fmt.Fprintf(logOut, "Loading image into K3s...\n")
 
 	// Get image as tar stream
 	imageTar, err := m.docker.ImageSave(ctx, imageName)
@@ -539,13 +863,113 @@ func (m *Manager) BuildImage(ctx context.Context, projectName, serviceName, cont
 
 	containerName := m.containerName()
 
-	// Import into K3s containerd via docker exec
+	// Import into K3s containerd via docker exec. ctr only imports the
+	// node's own platform unless told otherwise.
+	importCmd := []string{"ctr", "images", "import"}
+	if platform != "" {
+		importCmd = append(importCmd, "--platform", platform)
+	}
 	if err := m.docker.ContainerExecStream(ctx, containerName,
-		[]string{"ctr", "images", "import", "-"},
-		imageTar, os.Stdout, os.Stderr); err != nil {
+		append(importCmd, "-"),
+		imageTar, logOut, os.Stderr); err != nil {
 		return fmt.Errorf("ctr import failed: %w", err)
 	}
 
+	m.recordBuildHash(hashPath, hash)
+	return nil
+}
+
+// recordBuildHash records the build context hash of a successful build and
+// import. Failing to record it only costs a rebuild next time.
+func (m *Manager) recordBuildHash(hashPath, hash string) {
+	if hash == "" {
+		return
+	}
+	if err := os.MkdirAll(m.runtimeDir, 0755); err == nil {
+		_ = os.WriteFile(hashPath, []byte(hash+"\n"), 0644)
+	}
+}
+
+// buildHashPath is where the build context hash of a service's last
+// successful build is recorded.
+func (m *Manager) buildHashPath(serviceName string) string {
+	return filepath.Join(m.runtimeDir, "build-"+sanitize(serviceName)+".sha256")
+}
+
+// imageUpToDate reports whether the last build of imageName used a context
+// with the given hash and K3s containerd still has the image Docker holds
+// (K3s loses it when its container is recreated). Images from the cluster
+// builder never reach Docker, so with one set K3s holding the tag suffices.
+func (m *Manager) imageUpToDate(ctx context.Context, imageName, hashPath, hash string) bool {
+	recorded, err := os.ReadFile(hashPath)
+	if err != nil || strings.TrimSpace(string(recorded)) != hash {
+		return false
+	}
+	if m.imageLoaded(ctx, imageName) {
+		return true
+	}
+	return m.clusterBuilder != nil && m.k3sImageID(ctx, imageName) != ""
+}
+
+// imageLoaded reports whether K3s containerd has the same image (by image
+// ID, the config digest) as the local Docker image store.
+func (m *Manager) imageLoaded(ctx context.Context, imageName string) bool {
+	inspect, err := m.docker.ImageInspect(ctx, imageName)
+	if err != nil || inspect.ID == "" {
+		return false
+	}
+	return m.k3sImageID(ctx, imageName) == inspect.ID
+}
+
+// k3sImageID returns the ID of imageName in K3s containerd, or "".
+func (m *Manager) k3sImageID(ctx context.Context, imageName string) string {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return ""
+	}
+	out, err := m.docker.ContainerExec(ctx, m.containerName(), []string{"crictl", "images", "-o", "json"})
+	if err != nil {
+		return ""
+	}
+	return criImageID(out, reference.TagNameOnly(named).String())
+}
+
+// criImageID returns the image ID (e.g. "sha256:...") that `crictl images -o
+// json` output lists for ref, or "" if ref isn't there.
+func criImageID(output []byte, ref string) string {
+	var list struct {
+		Images []struct {
+			ID       string   `json:"id"`
+			RepoTags []string `json:"repoTags"`
+		} `json:"images"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return ""
+	}
+	for _, img := range list.Images {
+		for _, tag := range img.RepoTags {
+			if tag == ref {
+				return img.ID
+			}
+		}
+	}
+	return ""
+}
+
+// RemoveImage removes an image from K3s containerd. Short names are
+// normalized (e.g. "app:latest" → "docker.io/library/app:latest") to match
+// the references ctr import and image pulls create.
+func (m *Manager) RemoveImage(ctx context.Context, imageName string) error {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return fmt.Errorf("invalid image reference %s: %w", imageName, err)
+	}
+	ref := reference.TagNameOnly(named).String()
+	if err := m.docker.ContainerExecStream(ctx, m.containerName(),
+		[]string{"ctr", "images", "rm", ref},
+		nil, io.Discard, io.Discard); err != nil {
+		return fmt.Errorf("ctr images rm %s failed: %w", ref, err)
+	}
 	return nil
 }
 
@@ -611,7 +1035,7 @@ func (m *Manager) LoadInitImage(ctx context.Context, imageName string) error {
 	}
 
 	// Build the minimal init image
-	if err := m.docker.ImageBuild(ctx, tmpDir, "Dockerfile", imageName, nil); err != nil {
+	if err := m.docker.ImageBuild(ctx, tmpDir, "Dockerfile", imageName, docker.BuildOptions{}, docker.ProgressQuiet); err != nil {
 		return fmt.Errorf("failed to build init image: %w", err)
 	}
 
@@ -0,0 +1,228 @@
+package k3s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+
+	"github.com/kappal-app/kappal/pkg/docker"
+)
+
+func TestCRIImageID(t *testing.T) {
+	output := []byte(`{
+  "images": [
+    {"id": "sha256:aaa", "repoTags": ["docker.io/rancher/mirrored-pause:3.6"], "repoDigests": []},
+    {"id": "sha256:bbb", "repoTags": ["docker.io/library/demo-web:latest", "docker.io/library/demo-web:v1"]},
+    {"id": "sha256:ccc", "repoTags": []}
+  ]
+}`)
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"docker.io/library/demo-web:latest", "sha256:bbb"},
+		{"docker.io/library/demo-web:v1", "sha256:bbb"},
+		{"docker.io/library/demo-api:latest", ""},
+	}
+	for _, tt := range tests {
+		if got := criImageID(output, tt.ref); got != tt.want {
+			t.Errorf("criImageID(%s) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+	if got := criImageID([]byte("not json"), "docker.io/library/demo-web:latest"); got != "" {
+		t.Errorf("expected no ID for invalid output, got %q", got)
+	}
+}
+
+func TestAPIHostPort(t *testing.T) {
+	m := &Manager{projectName: "demo-1a2b3c4d"}
+	hashed := m.apiHostPort()
+	if hashed < 16443 || hashed > 26442 {
+		t.Errorf("hashed API port %d outside 16443-26442", hashed)
+	}
+	if again := (&Manager{projectName: "demo-1a2b3c4d"}).apiHostPort(); again != hashed {
+		t.Errorf("API port not deterministic: %d then %d", hashed, again)
+	}
+
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+	if port, err := apiPortFromEnv(getenv); port != 0 || err != nil {
+		t.Errorf("apiPortFromEnv without %s = %d, %v", APIPortEnv, port, err)
+	}
+	env[APIPortEnv] = "6443"
+	port, err := apiPortFromEnv(getenv)
+	if err != nil || port != 6443 {
+		t.Fatalf("apiPortFromEnv = %d, %v; want 6443", port, err)
+	}
+	m.apiPort = port
+	if got := m.apiHostPort(); got != 6443 {
+		t.Errorf("pinned apiHostPort = %d, want 6443", got)
+	}
+	for _, bad := range []string{"0", "65536", "-1", "api"} {
+		env[APIPortEnv] = bad
+		if _, err := apiPortFromEnv(getenv); err == nil {
+			t.Errorf("apiPortFromEnv(%q) should fail", bad)
+		}
+	}
+}
+
+func TestPublishedAPIPort(t *testing.T) {
+	ports := nat.PortMap{
+		"80/tcp":   {{HostIP: "0.0.0.0", HostPort: "8080"}},
+		"6443/tcp": {{HostIP: "0.0.0.0", HostPort: "17001"}},
+	}
+	if got := publishedAPIPort(ports); got != 17001 {
+		t.Errorf("publishedAPIPort = %d, want 17001", got)
+	}
+	if got := publishedAPIPort(nat.PortMap{}); got != 0 {
+		t.Errorf("publishedAPIPort without the API port = %d, want 0", got)
+	}
+}
+
+func TestBuildExpectedPortBindings(t *testing.T) {
+	m := &Manager{projectName: "demo", bindAddress: DefaultBindAddress, publishedPorts: []PublishedPort{
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostIP: "0.0.0.0", HostPort: 8443, ContainerPort: 443, Protocol: "tcp"},
+	}}
+	bindings := m.buildExpectedPortBindings()
+	if got := bindings["80/tcp"]; len(got) != 1 || got[0].HostIP != "127.0.0.1" || got[0].HostPort != "8080" {
+		t.Errorf("80/tcp bound to %+v, want 127.0.0.1:8080", got)
+	}
+	if got := bindings["443/tcp"]; len(got) != 1 || got[0].HostIP != "0.0.0.0" {
+		t.Errorf("443/tcp bound to %+v, want the compose host IP 0.0.0.0", got)
+	}
+
+	m.SetBindAddress("0.0.0.0")
+	exposed := m.buildExpectedPortBindings()
+	if got := exposed["80/tcp"]; len(got) != 1 || got[0].HostIP != "0.0.0.0" {
+		t.Errorf("with bind address 0.0.0.0, 80/tcp bound to %+v", got)
+	}
+	// A changed bind address must recreate the container
+	if portBindingsMatch(bindings, exposed) {
+		t.Error("port bindings on different host IPs should not match")
+	}
+	if !portBindingsMatch(exposed, m.buildExpectedPortBindings()) {
+		t.Error("identical port bindings should match")
+	}
+}
+
+func TestBuildExpectedPortBindingsHostIP(t *testing.T) {
+	tests := []struct {
+		hostIP string
+		want   string
+	}{
+		{"127.0.0.1", "127.0.0.1"},
+		{"0.0.0.0", "0.0.0.0"},
+		{"", DefaultBindAddress},
+	}
+	for _, tt := range tests {
+		m := &Manager{projectName: "demo", publishedPorts: []PublishedPort{
+			{HostIP: tt.hostIP, HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		}}
+		got := m.buildExpectedPortBindings()["80/tcp"]
+		if len(got) != 1 || got[0].HostIP != tt.want || got[0].HostPort != "8080" {
+			t.Errorf("host IP %q: bound to %+v, want %s:8080", tt.hostIP, got, tt.want)
+		}
+	}
+}
+
+func TestIngress(t *testing.T) {
+	m := &Manager{projectName: "demo"}
+	if !slices.Contains(m.serverArgs(), "--disable=traefik") {
+		t.Errorf("Traefik should be disabled by default, got %v", m.serverArgs())
+	}
+	m.SetIngress(true)
+	if slices.Contains(m.serverArgs(), "--disable=traefik") {
+		t.Errorf("SetIngress(true) should keep Traefik, got %v", m.serverArgs())
+	}
+
+	m.publishedPorts = append([]PublishedPort{{HostPort: 8080, ContainerPort: 8080, Protocol: "tcp"}}, IngressPorts...)
+	bindings := m.buildExpectedPortBindings()
+	for _, port := range []string{"80/tcp", "443/tcp"} {
+		if got := bindings[nat.Port(port)]; len(got) != 1 || got[0].HostIP != DefaultBindAddress || got[0].HostPort+"/tcp" != port {
+			t.Errorf("%s bound to %+v, want %s on the same host port", port, got, DefaultBindAddress)
+		}
+	}
+
+	if err := CheckIngressPorts([]PublishedPort{{HostPort: 8080, ContainerPort: 8080, Protocol: "tcp"}}); err != nil {
+		t.Errorf("unrelated port should not conflict with ingress, got: %v", err)
+	}
+	if err := CheckIngressPorts([]PublishedPort{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}}); err == nil {
+		t.Error("a service on container port 80 should conflict with the ingress controller")
+	}
+	if err := CheckIngressPorts([]PublishedPort{{HostPort: 8443, ContainerPort: 443, Protocol: "udp"}}); err != nil {
+		t.Errorf("UDP 443 should not conflict with the ingress controller, got: %v", err)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := &Manager{projectName: "demo"}
+	defaults := m.serverArgs()
+	if !slices.Contains(defaults, "--disable=metrics-server") {
+		t.Errorf("metrics-server should be disabled by default, got %v", defaults)
+	}
+	m.SetMetrics(true)
+	args := m.serverArgs()
+	if slices.Contains(args, "--disable=metrics-server") {
+		t.Errorf("SetMetrics(true) should keep metrics-server, got %v", args)
+	}
+	// A changed command line is what makes EnsureRunning recreate K3s
+	if slices.Equal(args, defaults) {
+		t.Error("toggling metrics should change the server arguments")
+	}
+}
+
+type fakeClusterBuilder struct {
+	readyErr error
+	readies  int
+}
+
+func (f *fakeClusterBuilder) Ready(context.Context) error {
+	f.readies++
+	return f.readyErr
+}
+
+func (f *fakeClusterBuilder) Build(context.Context, string, string, string, docker.BuildOptions, docker.ProgressMode) error {
+	return nil
+}
+
+func TestUseClusterBuilder(t *testing.T) {
+	ctx := context.Background()
+	if (&Manager{}).useClusterBuilder(ctx, io.Discard) {
+		t.Error("without a cluster builder, builds should use docker")
+	}
+
+	healthy := &fakeClusterBuilder{}
+	m := &Manager{}
+	m.SetClusterBuilder(healthy)
+	for i := 0; i < 2; i++ {
+		if !m.useClusterBuilder(ctx, io.Discard) {
+			t.Error("a healthy cluster builder should be used")
+		}
+	}
+	if healthy.readies != 1 {
+		t.Errorf("Ready called %d times, want once per manager", healthy.readies)
+	}
+
+	broken := &fakeClusterBuilder{readyErr: errors.New("image pull failed")}
+	m = &Manager{}
+	m.SetClusterBuilder(broken)
+	var out bytes.Buffer
+	for i := 0; i < 2; i++ {
+		if m.useClusterBuilder(ctx, &out) {
+			t.Error("an unhealthy cluster builder should fall back to docker")
+		}
+	}
+	if broken.readies != 1 {
+		t.Errorf("Ready called %d times, want once per manager", broken.readies)
+	}
+	if strings.Count(out.String(), "falling back to docker build") != 1 {
+		t.Errorf("fallback should be reported once, got %q", out.String())
+	}
+}
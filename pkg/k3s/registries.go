@@ -0,0 +1,72 @@
+package k3s
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RegistryMirrorEnv lists pull-through mirror endpoints for Docker Hub,
+	// comma-separated (e.g. https://mirror.corp.example.com). K3s falls back
+	// to Docker Hub when no mirror answers.
+	RegistryMirrorEnv = "KAPPAL_REGISTRY_MIRROR"
+	// RegistriesFileEnv points to a complete K3s registries.yaml (mirrors
+	// for other registries, auth, TLS). It takes precedence over
+	// RegistryMirrorEnv.
+	RegistriesFileEnv = "KAPPAL_REGISTRIES_FILE"
+)
+
+// registriesPath is where K3s reads its containerd registry configuration
+// at startup.
+const registriesPath = "/etc/rancher/k3s/registries.yaml"
+
+// registriesConfig returns the registries.yaml to write into a new K3s
+// container, from RegistriesFileEnv or RegistryMirrorEnv, or nil when
+// neither is set.
+func registriesConfig(getenv func(string) string) ([]byte, error) {
+	if path := getenv(RegistriesFileEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", RegistriesFileEnv, err)
+		}
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", RegistriesFileEnv, path, err)
+		}
+		return data, nil
+	}
+
+	value := getenv(RegistryMirrorEnv)
+	if value == "" {
+		return nil, nil
+	}
+	var endpoints []string
+	for _, endpoint := range strings.Split(value, ",") {
+		endpoint = strings.TrimSpace(endpoint)
+		if endpoint == "" {
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s endpoint %q: expected an http(s) URL such as https://mirror.example.com", RegistryMirrorEnv, endpoint)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+	config := map[string]interface{}{
+		"mirrors": map[string]interface{}{
+			"docker.io": map[string]interface{}{"endpoint": endpoints},
+		},
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render registries.yaml: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,59 @@
+package k3s
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistriesConfig(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+
+	if data, err := registriesConfig(getenv); data != nil || err != nil {
+		t.Fatalf("expected no config without env vars, got %q, %v", data, err)
+	}
+
+	env[RegistryMirrorEnv] = "https://mirror.corp.example.com, http://10.0.0.5:5000"
+	data, err := registriesConfig(getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `mirrors:
+  docker.io:
+    endpoint:
+    - https://mirror.corp.example.com
+    - http://10.0.0.5:5000
+`
+	if string(data) != want {
+		t.Errorf("registries.yaml =\n%s\nwant\n%s", data, want)
+	}
+
+	env[RegistryMirrorEnv] = "mirror.corp.example.com"
+	if _, err := registriesConfig(getenv); err == nil || !strings.Contains(err.Error(), "expected an http(s) URL") {
+		t.Errorf("expected an invalid endpoint error, got: %v", err)
+	}
+
+	// A full registries.yaml wins over the mirror shorthand
+	path := filepath.Join(t.TempDir(), "registries.yaml")
+	custom := "mirrors:\n  ghcr.io:\n    endpoint: [\"https://ghcr-mirror.example.com\"]\n"
+	if err := os.WriteFile(path, []byte(custom), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env[RegistriesFileEnv] = path
+	if data, err := registriesConfig(getenv); err != nil || string(data) != custom {
+		t.Errorf("registriesConfig = %q, %v, want the file verbatim", data, err)
+	}
+
+	if err := os.WriteFile(path, []byte("mirrors: [unclosed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registriesConfig(getenv); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+	env[RegistriesFileEnv] = filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := registriesConfig(getenv); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
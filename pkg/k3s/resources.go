@@ -0,0 +1,54 @@
+package k3s
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/docker/go-units"
+)
+
+const (
+	// MemoryEnv caps the K3s container's memory (e.g. 4g, 512m), and with it
+	// every workload kappal runs. Unset means unlimited.
+	MemoryEnv = "KAPPAL_K3S_MEMORY"
+	// CPUsEnv caps the K3s container's CPUs (e.g. 2, 1.5). Unset means
+	// unlimited.
+	CPUsEnv = "KAPPAL_K3S_CPUS"
+)
+
+// Resources caps the K3s container the way docker run --memory and --cpus
+// do. Zero fields are unlimited.
+type Resources struct {
+	Memory   int64 // bytes
+	NanoCPUs int64
+}
+
+// ParseResources parses a memory size and a CPU count in docker run's
+// --memory/--cpus syntax. An empty value leaves that limit unset.
+func ParseResources(memory, cpus string) (Resources, error) {
+	var r Resources
+	if memory != "" {
+		bytes, err := units.RAMInBytes(memory)
+		if err != nil || bytes <= 0 {
+			return Resources{}, fmt.Errorf("invalid K3s memory limit %q (e.g. 4g, 512m)", memory)
+		}
+		r.Memory = bytes
+	}
+	if cpus != "" {
+		n, err := strconv.ParseFloat(cpus, 64)
+		if err != nil || n <= 0 {
+			return Resources{}, fmt.Errorf("invalid K3s CPU limit %q (e.g. 2, 1.5)", cpus)
+		}
+		r.NanoCPUs = int64(n * 1e9)
+	}
+	return r, nil
+}
+
+// resourcesFromEnv returns the limits set in MemoryEnv and CPUsEnv.
+func resourcesFromEnv(getenv func(string) string) (Resources, error) {
+	r, err := ParseResources(getenv(MemoryEnv), getenv(CPUsEnv))
+	if err != nil {
+		return Resources{}, fmt.Errorf("%w; check %s and %s", err, MemoryEnv, CPUsEnv)
+	}
+	return r, nil
+}
@@ -0,0 +1,58 @@
+package k3s
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseResources(t *testing.T) {
+	tests := []struct {
+		memory, cpus string
+		want         Resources
+	}{
+		{"", "", Resources{}},
+		{"4g", "", Resources{Memory: 4 << 30}},
+		{"512m", "1.5", Resources{Memory: 512 << 20, NanoCPUs: 1500000000}},
+		{"", "2", Resources{NanoCPUs: 2000000000}},
+	}
+	for _, tt := range tests {
+		got, err := ParseResources(tt.memory, tt.cpus)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseResources(%q, %q) = %+v, %v; want %+v", tt.memory, tt.cpus, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range [][2]string{{"lots", ""}, {"-1g", ""}, {"", "0"}, {"", "two"}} {
+		if _, err := ParseResources(bad[0], bad[1]); err == nil {
+			t.Errorf("ParseResources(%q, %q) should fail", bad[0], bad[1])
+		}
+	}
+}
+
+func TestResourcesFromEnv(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+
+	if r, err := resourcesFromEnv(getenv); err != nil || r != (Resources{}) {
+		t.Errorf("resourcesFromEnv without env = %+v, %v; want unlimited", r, err)
+	}
+
+	env[MemoryEnv] = "2g"
+	env[CPUsEnv] = "1"
+	r, err := resourcesFromEnv(getenv)
+	if err != nil || r != (Resources{Memory: 2 << 30, NanoCPUs: 1000000000}) {
+		t.Errorf("resourcesFromEnv = %+v, %v", r, err)
+	}
+
+	// Flags override the env per limit
+	m := &Manager{resources: r}
+	m.SetResources(Resources{NanoCPUs: 500000000})
+	if m.resources != (Resources{Memory: 2 << 30, NanoCPUs: 500000000}) {
+		t.Errorf("SetResources kept %+v, want the env memory and the flag CPUs", m.resources)
+	}
+
+	env[CPUsEnv] = "many"
+	if _, err := resourcesFromEnv(getenv); err == nil || !strings.Contains(err.Error(), CPUsEnv) {
+		t.Errorf("expected an error naming %s, got: %v", CPUsEnv, err)
+	}
+}
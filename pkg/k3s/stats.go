@@ -0,0 +1,93 @@
+package k3s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// PodStats is the resource usage of one pod, summed over its running
+// containers, as read from containerd's cgroup stats inside K3s.
+type PodStats struct {
+	Namespace   string  `json:"namespace"`
+	Pod         string  `json:"pod"`
+	CPUPercent  float64 `json:"cpuPercent"`  // 100% = one full core
+	MemoryUsage uint64  `json:"memoryUsage"` // working set bytes
+}
+
+// PodStats returns per-pod CPU and memory usage from `crictl stats`, the CRI
+// client bundled with K3s next to ctr. It works without metrics-server.
+func (m *Manager) PodStats(ctx context.Context) ([]PodStats, error) {
+	output, err := m.docker.ContainerExec(ctx, m.containerName(), []string{"crictl", "stats", "-o", "json"})
+	if err != nil {
+		return nil, fmt.Errorf("crictl stats failed: %w", err)
+	}
+	return parseCRIStats(bytes.NewReader(output))
+}
+
+// criValue is a CRI UInt64Value; crictl renders uint64 as a JSON string.
+type criValue struct {
+	Value json.Number `json:"value"`
+}
+
+func (v *criValue) uint64() uint64 {
+	if v == nil {
+		return 0
+	}
+	n, _ := strconv.ParseUint(v.Value.String(), 10, 64)
+	return n
+}
+
+// parseCRIStats decodes `crictl stats -o json` output and sums the container
+// stats of each pod. Pods are sorted by namespace, then name.
+func parseCRIStats(r io.Reader) ([]PodStats, error) {
+	var raw struct {
+		Stats []struct {
+			Attributes struct {
+				Labels map[string]string `json:"labels"`
+			} `json:"attributes"`
+			CPU struct {
+				UsageNanoCores *criValue `json:"usageNanoCores"`
+			} `json:"cpu"`
+			Memory struct {
+				WorkingSetBytes *criValue `json:"workingSetBytes"`
+			} `json:"memory"`
+		} `json:"stats"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode crictl stats: %w", err)
+	}
+
+	byPod := map[string]*PodStats{}
+	for _, s := range raw.Stats {
+		ns := s.Attributes.Labels["io.kubernetes.pod.namespace"]
+		pod := s.Attributes.Labels["io.kubernetes.pod.name"]
+		if pod == "" {
+			continue
+		}
+		key := ns + "/" + pod
+		ps, ok := byPod[key]
+		if !ok {
+			ps = &PodStats{Namespace: ns, Pod: pod}
+			byPod[key] = ps
+		}
+		ps.CPUPercent += float64(s.CPU.UsageNanoCores.uint64()) / 1e7
+		ps.MemoryUsage += s.Memory.WorkingSetBytes.uint64()
+	}
+
+	pods := make([]PodStats, 0, len(byPod))
+	for _, ps := range byPod {
+		pods = append(pods, *ps)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Pod < pods[j].Pod
+	})
+	return pods, nil
+}
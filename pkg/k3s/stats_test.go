@@ -0,0 +1,57 @@
+package k3s
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseCRIStats(t *testing.T) {
+	input := `{"stats": [
+  {
+    "attributes": {"labels": {"io.kubernetes.pod.name": "web-6d4b", "io.kubernetes.pod.namespace": "demo", "io.kubernetes.container.name": "web"}},
+    "cpu": {"usageNanoCores": {"value": "250000000"}},
+    "memory": {"workingSetBytes": {"value": "1048576"}}
+  },
+  {
+    "attributes": {"labels": {"io.kubernetes.pod.name": "web-6d4b", "io.kubernetes.pod.namespace": "demo", "io.kubernetes.container.name": "sidecar"}},
+    "cpu": {"usageNanoCores": {"value": 50000000}},
+    "memory": {"workingSetBytes": {"value": "1048576"}}
+  },
+  {
+    "attributes": {"labels": {"io.kubernetes.pod.name": "coredns-1", "io.kubernetes.pod.namespace": "kube-system"}},
+    "cpu": {},
+    "memory": {"workingSetBytes": {"value": "2048"}}
+  }
+]}`
+
+	pods, err := parseCRIStats(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseCRIStats failed: %v", err)
+	}
+	if len(pods) != 2 {
+		t.Fatalf("expected 2 pods, got %d: %+v", len(pods), pods)
+	}
+
+	web := pods[0]
+	if web.Namespace != "demo" || web.Pod != "web-6d4b" {
+		t.Fatalf("expected demo/web-6d4b first, got %s/%s", web.Namespace, web.Pod)
+	}
+	// 0.25 + 0.05 cores summed over both containers
+	if math.Abs(web.CPUPercent-30) > 0.001 {
+		t.Errorf("expected CPU 30%%, got %f", web.CPUPercent)
+	}
+	if web.MemoryUsage != 2097152 {
+		t.Errorf("expected memory 2097152, got %d", web.MemoryUsage)
+	}
+
+	if pods[1].CPUPercent != 0 || pods[1].MemoryUsage != 2048 {
+		t.Errorf("expected missing CPU read as 0, got %+v", pods[1])
+	}
+}
+
+func TestParseCRIStatsInvalidJSON(t *testing.T) {
+	if _, err := parseCRIStats(strings.NewReader("not json")); err == nil {
+		t.Error("expected error for invalid crictl output")
+	}
+}
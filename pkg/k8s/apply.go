@@ -0,0 +1,282 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// FieldManager is the server-side apply field manager kappal applies as.
+const FieldManager = "kappal"
+
+// Apply actions reported in ApplyResult.Action.
+const (
+	ApplyCreated   = "created"
+	ApplyUpdated   = "updated"
+	ApplyUnchanged = "unchanged"
+)
+
+// maxDiffValue bounds how much of a changed value a diff line shows.
+const maxDiffValue = 60
+
+// ApplyResult describes what applying one manifest object did.
+type ApplyResult struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Action    string   // ApplyCreated, ApplyUpdated or ApplyUnchanged
+	Diff      []string // For updates: "path: old -> new" lines, sorted by path
+}
+
+// ApplyManifest applies every object of a multi-document YAML manifest with
+// server-side apply, in order, and reports per object whether it was created,
+// updated or left unchanged. The live object is read before each apply so
+// updates carry a short field-level diff against the previous state.
+func (c *Client) ApplyManifest(ctx context.Context, manifest []byte) ([]ApplyResult, error) {
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ApplyResult
+	for _, obj := range objs {
+		result, err := c.applyObject(ctx, obj)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (c *Client) applyObject(ctx context.Context, obj *unstructured.Unstructured) (ApplyResult, error) {
+	gvk := obj.GroupVersionKind()
+	result := ApplyResult{Kind: gvk.Kind, Name: obj.GetName()}
+
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return result, fmt.Errorf("failed to map %s: %w", gvk.Kind, err)
+	}
+
+	resource := c.dynamic.Resource(mapping.Resource)
+	var client dynamic.ResourceInterface = resource
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns := obj.GetNamespace()
+		if ns == "" {
+			ns = metav1.NamespaceDefault
+		}
+		result.Namespace = ns
+		client = resource.Namespace(ns)
+	}
+
+	prev, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := client.Create(ctx, obj, metav1.CreateOptions{FieldManager: FieldManager}); err != nil {
+			return result, fmt.Errorf("failed to create %s %s: %w", gvk.Kind, obj.GetName(), err)
+		}
+		result.Action = ApplyCreated
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	applied, err := client.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: FieldManager, Force: true})
+	if err != nil {
+		return result, fmt.Errorf("failed to apply %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	var redact []string
+	if gvk.Kind == "Secret" {
+		// Like kubectl diff, never print secret values
+		redact = []string{"data", "stringData"}
+	}
+	result.Diff = objectDiff(prev.Object, applied.Object, redact...)
+	result.Action = ApplyUnchanged
+	if len(result.Diff) > 0 {
+		result.Action = ApplyUpdated
+	}
+	return result, nil
+}
+
+// ProjectConflicts returns the namespaced objects of a manifest that already
+// exist labeled as another kappal project's, as "Kind/name". Applying would
+// take them over, so a namespace shared via --namespace must not reuse names.
+func (c *Client) ProjectConflicts(ctx context.Context, manifest []byte, projectName string) ([]string, error) {
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to map %s: %w", gvk.Kind, err)
+		}
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			continue
+		}
+		live, err := c.dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace()).Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s %s: %w", gvk.Kind, obj.GetName(), err)
+		}
+		if owner := live.GetLabels()["kappal.io/project"]; owner != "" && owner != projectName {
+			conflicts = append(conflicts, fmt.Sprintf("%s/%s (project %s)", gvk.Kind, obj.GetName(), owner))
+		}
+	}
+	return conflicts, nil
+}
+
+// decodeManifest splits a multi-document YAML manifest into objects,
+// skipping empty documents.
+func decodeManifest(manifest []byte) ([]*unstructured.Unstructured, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifest)))
+	var objs []*unstructured.Unstructured
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		data, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 || string(bytes.TrimSpace(data)) == "null" {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(data); err != nil {
+			return nil, fmt.Errorf("failed to decode manifest object: %w", err)
+		}
+		objs = append(objs, obj)
+	}
+}
+
+// ignoredDiffPaths are server-maintained fields that change on every write
+// and say nothing about the applied configuration.
+var ignoredDiffPaths = map[string]bool{
+	"status":                   true,
+	"metadata.managedFields":   true,
+	"metadata.resourceVersion": true,
+	"metadata.generation":      true,
+}
+
+// objectDiff lists the fields that differ between two versions of an object
+// as "path: old -> new" lines. Lists of equal length are compared element by
+// element; otherwise the whole list is reported as one change. Keys of the
+// maps at the redact paths are reported as added, removed or changed
+// without their values.
+func objectDiff(prev, next map[string]interface{}, redact ...string) []string {
+	redacted := map[string]bool{}
+	for _, p := range redact {
+		redacted[p] = true
+	}
+	var diff []string
+	diffValues("", prev, next, redacted, &diff)
+	sort.Strings(diff)
+	return diff
+}
+
+func diffValues(path string, prev, next interface{}, redacted map[string]bool, diff *[]string) {
+	if ignoredDiffPaths[path] {
+		return
+	}
+	if redacted[path] {
+		diffRedacted(path, prev, next, diff)
+		return
+	}
+
+	prevMap, prevIsMap := prev.(map[string]interface{})
+	nextMap, nextIsMap := next.(map[string]interface{})
+	if prevIsMap && nextIsMap {
+		keys := map[string]bool{}
+		for k := range prevMap {
+			keys[k] = true
+		}
+		for k := range nextMap {
+			keys[k] = true
+		}
+		for k := range keys {
+			diffValues(joinPath(path, k), prevMap[k], nextMap[k], redacted, diff)
+		}
+		return
+	}
+
+	prevList, prevIsList := prev.([]interface{})
+	nextList, nextIsList := next.([]interface{})
+	if prevIsList && nextIsList && len(prevList) == len(nextList) {
+		for i := range prevList {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), prevList[i], nextList[i], redacted, diff)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(prev, next) {
+		return
+	}
+	*diff = append(*diff, fmt.Sprintf("%s: %s -> %s", path, formatDiffValue(prev), formatDiffValue(next)))
+}
+
+// diffRedacted reports which keys of a map of sensitive values changed.
+func diffRedacted(path string, prev, next interface{}, diff *[]string) {
+	prevMap, _ := prev.(map[string]interface{})
+	nextMap, _ := next.(map[string]interface{})
+	for k, v := range prevMap {
+		if _, ok := nextMap[k]; !ok {
+			*diff = append(*diff, joinPath(path, k)+": (removed)")
+		} else if !reflect.DeepEqual(v, nextMap[k]) {
+			*diff = append(*diff, joinPath(path, k)+": (changed)")
+		}
+	}
+	for k := range nextMap {
+		if _, ok := prevMap[k]; !ok {
+			*diff = append(*diff, joinPath(path, k)+": (added)")
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// formatDiffValue renders a value compactly as JSON, truncated for display.
+func formatDiffValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	s := string(data)
+	if len(s) > maxDiffValue {
+		s = s[:maxDiffValue-3] + "..."
+	}
+	return strings.TrimSpace(s)
+}
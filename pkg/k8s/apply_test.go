@@ -0,0 +1,276 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// fakeResource is an in-memory dynamic resource client. Apply replaces the
+// stored object, which is enough to exercise ApplyManifest's classification.
+type fakeResource struct {
+	dynamic.NamespaceableResourceInterface
+	objects map[string]*unstructured.Unstructured // "namespace/name" -> object
+	ns      string
+}
+
+func (f *fakeResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &fakeResource{objects: f.objects, ns: ns}
+}
+
+func (f *fakeResource) Get(_ context.Context, name string, _ metav1.GetOptions, _ ...string) (*unstructured.Unstructured, error) {
+	obj, ok := f.objects[f.ns+"/"+name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{}, name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+func (f *fakeResource) Create(_ context.Context, obj *unstructured.Unstructured, _ metav1.CreateOptions, _ ...string) (*unstructured.Unstructured, error) {
+	f.objects[f.ns+"/"+obj.GetName()] = obj.DeepCopy()
+	return obj, nil
+}
+
+func (f *fakeResource) Apply(_ context.Context, name string, obj *unstructured.Unstructured, _ metav1.ApplyOptions, _ ...string) (*unstructured.Unstructured, error) {
+	f.objects[f.ns+"/"+name] = obj.DeepCopy()
+	return obj.DeepCopy(), nil
+}
+
+type fakeDynamic struct {
+	resources map[schema.GroupVersionResource]*fakeResource
+}
+
+func (f *fakeDynamic) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	r, ok := f.resources[gvr]
+	if !ok {
+		r = &fakeResource{objects: map[string]*unstructured.Unstructured{}}
+		f.resources[gvr] = r
+	}
+	return r
+}
+
+func newFakeApplyClient(objects ...*unstructured.Unstructured) *Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	dyn := &fakeDynamic{resources: map[schema.GroupVersionResource]*fakeResource{}}
+	for _, obj := range objects {
+		mapping, err := mapper.RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+		if err != nil {
+			panic(err)
+		}
+		r := dyn.Resource(mapping.Resource).(*fakeResource)
+		r.objects[obj.GetNamespace()+"/"+obj.GetName()] = obj
+	}
+	return &Client{dynamic: dyn, mapper: mapper}
+}
+
+func TestApplyManifestClassifiesChanges(t *testing.T) {
+	existingDeployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "demo"},
+		"spec":       map[string]interface{}{"replicas": int64(1)},
+	}}
+	existingService := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "demo"},
+		"spec":       map[string]interface{}{"selector": map[string]interface{}{"app": "web"}},
+	}}
+	client := newFakeApplyClient(existingDeployment, existingService)
+
+	manifest := []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: demo
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: demo
+spec:
+  selector:
+    app: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: demo
+data:
+  mode: prod
+`)
+
+	results, err := client.ApplyManifest(context.Background(), manifest)
+	if err != nil {
+		t.Fatalf("ApplyManifest failed: %v", err)
+	}
+
+	want := []ApplyResult{
+		{Kind: "Namespace", Name: "demo", Action: ApplyCreated},
+		{Kind: "Deployment", Namespace: "demo", Name: "web", Action: ApplyUpdated, Diff: []string{"spec.replicas: 1 -> 2"}},
+		{Kind: "Service", Namespace: "demo", Name: "web", Action: ApplyUnchanged},
+		{Kind: "ConfigMap", Namespace: "demo", Name: "settings", Action: ApplyCreated},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results =\n%+v\nwant\n%+v", results, want)
+	}
+}
+
+func TestApplyManifestRedactsSecretValues(t *testing.T) {
+	existing := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "db", "namespace": "demo"},
+		"data":       map[string]interface{}{"password": "b2xk", "user": "YWRtaW4=", "legacy": "eA=="},
+		"type":       "Opaque",
+	}}
+	client := newFakeApplyClient(existing)
+
+	manifest := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: demo
+  labels:
+    tier: db
+data:
+  password: bmV3
+  user: YWRtaW4=
+  token: dG9rZW4=
+stringData:
+  extra: plaintext
+type: Opaque
+`)
+	results, err := client.ApplyManifest(context.Background(), manifest)
+	if err != nil {
+		t.Fatalf("ApplyManifest failed: %v", err)
+	}
+	want := []string{
+		"data.legacy: (removed)",
+		"data.password: (changed)",
+		"data.token: (added)",
+		"metadata.labels: <none> -> {\"tier\":\"db\"}",
+		"stringData.extra: (added)",
+	}
+	if len(results) != 1 || !reflect.DeepEqual(results[0].Diff, want) {
+		t.Fatalf("diff = %q, want %q", results[0].Diff, want)
+	}
+	for _, line := range results[0].Diff {
+		for _, value := range []string{"b2xk", "bmV3", "dG9rZW4=", "eA==", "plaintext"} {
+			if strings.Contains(line, value) {
+				t.Errorf("diff line %q leaks secret value %q", line, value)
+			}
+		}
+	}
+}
+
+func TestProjectConflicts(t *testing.T) {
+	labeled := func(kind, apiVersion, name, project string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name, "namespace": "shared"},
+		}}
+		if project != "" {
+			obj.SetLabels(map[string]string{"kappal.io/project": project})
+		}
+		return obj
+	}
+	client := newFakeApplyClient(
+		labeled("Deployment", "apps/v1", "web", "shop"),
+		labeled("Service", "v1", "web", "shop"),
+		labeled("ConfigMap", "v1", "settings", "blog"),
+		labeled("ConfigMap", "v1", "unowned", ""),
+	)
+
+	manifest := []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: shared
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shared
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: shared
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: shared
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unowned
+  namespace: shared
+`)
+
+	conflicts, err := client.ProjectConflicts(context.Background(), manifest, "blog")
+	if err != nil {
+		t.Fatalf("ProjectConflicts failed: %v", err)
+	}
+	// The blog project's own ConfigMap, unlabeled objects, new names and the
+	// cluster-scoped Namespace don't conflict
+	if want := []string{"Deployment/web (project shop)"}; !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("conflicts = %v, want %v", conflicts, want)
+	}
+}
+
+func TestObjectDiff(t *testing.T) {
+	prev := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "resourceVersion": "1", "labels": map[string]interface{}{"tier": "front"}},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "web", "image": "nginx:1.25"}},
+			"ports":      []interface{}{int64(80)},
+		},
+		"status": map[string]interface{}{"readyReplicas": int64(1)},
+	}
+	next := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "resourceVersion": "2"},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "web", "image": "nginx:1.27"}},
+			"ports":      []interface{}{int64(80), int64(443)},
+		},
+		"status": map[string]interface{}{"readyReplicas": int64(0)},
+	}
+
+	want := []string{
+		`metadata.labels: {"tier":"front"} -> <none>`,
+		`spec.containers[0].image: "nginx:1.25" -> "nginx:1.27"`,
+		`spec.ports: [80] -> [80,443]`,
+	}
+	if got := objectDiff(prev, next); !reflect.DeepEqual(got, want) {
+		t.Errorf("objectDiff =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -9,10 +9,18 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -20,6 +28,8 @@ import (
 type Client struct {
 	clientset  *kubernetes.Clientset
 	restConfig *rest.Config
+	dynamic    dynamic.Interface
+	mapper     meta.RESTMapper
 }
 
 // NewClient creates a new Kubernetes client from a kubeconfig file
@@ -34,7 +44,15 @@ func NewClient(kubeconfigPath string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
 
-	return &Client{clientset: clientset, restConfig: config}, nil
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	// Discovery is deferred until the first manifest is applied
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+
+	return &Client{clientset: clientset, restConfig: config, dynamic: dynamicClient, mapper: mapper}, nil
 }
 
 // RESTConfig returns the REST config for the client
@@ -53,6 +71,27 @@ func (c *Client) CheckConnection(ctx context.Context) error {
 	return err
 }
 
+// ServiceSelector returns the label selector for one service's objects. The
+// project label keeps a namespace shared by several projects from matching a
+// same-named service of another project.
+func ServiceSelector(projectName, serviceName string) string {
+	return fmt.Sprintf("kappal.io/project=%s,kappal.io/service=%s", projectName, serviceName)
+}
+
+// ProjectSelector validates a user-supplied label selector (e.g.
+// "kappal.io/network=backend") and narrows it to one project's objects.
+func ProjectSelector(projectName, selector string) (string, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid label selector %q: %w", selector, err)
+	}
+	project, err := labels.NewRequirement("kappal.io/project", selection.Equals, []string{projectName})
+	if err != nil {
+		return "", fmt.Errorf("invalid project name %q: %w", projectName, err)
+	}
+	return sel.Add(*project).String(), nil
+}
+
 // ListPods returns pods matching the given label selector in a namespace
 func (c *Client) ListPods(ctx context.Context, namespace, labelSelector string) (*corev1.PodList, error) {
 	return c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
@@ -87,11 +126,57 @@ func (c *Client) NamespaceExists(ctx context.Context, name string) (bool, error)
 	return true, nil
 }
 
-// WaitForPodsReady waits for all pods matching the selector to be ready
+// NamespaceStatus is a namespace's lifecycle phase plus the conditions that
+// explain a stuck deletion.
+type NamespaceStatus struct {
+	Phase      string   // "Active", "Terminating", or "NotFound"
+	Conditions []string // true conditions as "Type: message", e.g. finalizers remaining
+}
+
+// GetNamespaceStatus returns the phase and conditions of a namespace. A
+// missing namespace is reported with phase "NotFound" rather than an error.
+func (c *Client) GetNamespaceStatus(ctx context.Context, name string) (*NamespaceStatus, error) {
+	ns, err := c.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return &NamespaceStatus{Phase: "NotFound"}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+	return NamespaceStatusOf(ns), nil
+}
+
+// NamespaceStatusOf summarizes a namespace's status. Only conditions with
+// status True are kept: those are the ones blocking deletion.
+func NamespaceStatusOf(ns *corev1.Namespace) *NamespaceStatus {
+	status := &NamespaceStatus{Phase: string(ns.Status.Phase)}
+	if status.Phase == "" {
+		status.Phase = string(corev1.NamespaceActive)
+	}
+	for _, cond := range ns.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		desc := string(cond.Type)
+		if cond.Message != "" {
+			desc += ": " + cond.Message
+		}
+		status.Conditions = append(status.Conditions, desc)
+	}
+	return status
+}
+
+// WaitForPodsReady waits for all pods matching the selector to be ready.
+// It fails early when a matching Deployment exceeds its progress deadline
+// (e.g. its pods keep crashing).
 func (c *Client) WaitForPodsReady(ctx context.Context, namespace, labelSelector string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
 
 	for time.Now().Before(deadline) {
+		if err := c.failedRollout(ctx, namespace, labelSelector); err != nil {
+			return err
+		}
+
 		pods, err := c.ListPods(ctx, namespace, labelSelector)
 		if err != nil {
 			time.Sleep(2 * time.Second)
@@ -136,14 +221,44 @@ func (c *Client) WaitForPodsReady(ctx context.Context, namespace, labelSelector
 	return fmt.Errorf("timeout waiting for pods to be ready")
 }
 
-// DeleteJobs deletes all Jobs in a namespace with the kappal project label.
-// Jobs are immutable in K8s, so they must be deleted before re-applying.
-func (c *Client) DeleteJobs(ctx context.Context, namespace string) error {
+// DeleteJobs deletes the Jobs in a namespace with the kappal project label
+// that applying manifest would change. Jobs are immutable in K8s, so a
+// changed Job must be deleted before re-applying; it then runs again. A Job
+// is kept when manifest renders it with the same checksumAnnotation value,
+// so unchanged Jobs don't re-run on every up, unless it failed: failed Jobs
+// are always deleted so up retries them. 'kappal run' Jobs (labelled
+// kappal.io/run) aren't rendered by up and are left alone.
+func (c *Client) DeleteJobs(ctx context.Context, namespace, projectName string, manifest []byte, checksumAnnotation string) error {
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+	rendered := map[string]string{}
+	for _, obj := range objs {
+		if obj.GetKind() == "Job" {
+			rendered[obj.GetName()] = obj.GetAnnotations()[checksumAnnotation]
+		}
+	}
+
+	jobs, err := c.ListJobs(ctx, namespace, "kappal.io/project="+projectName)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
 	propagation := metav1.DeletePropagationBackground
-	return c.clientset.BatchV1().Jobs(namespace).DeleteCollection(ctx,
-		metav1.DeleteOptions{PropagationPolicy: &propagation},
-		metav1.ListOptions{LabelSelector: "kappal.io/project=" + namespace},
-	)
+	for _, job := range jobs.Items {
+		if _, run := job.Labels["kappal.io/run"]; run {
+			continue
+		}
+		checksum, ok := rendered[job.Name]
+		if _, failed := jobFinished(&job); ok && checksum != "" && job.Annotations[checksumAnnotation] == checksum && !failed {
+			continue
+		}
+		err := c.clientset.BatchV1().Jobs(namespace).Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete job %s: %w", job.Name, err)
+		}
+	}
+	return nil
 }
 
 // GetNodes returns the list of nodes in the cluster
@@ -165,6 +280,28 @@ func (c *Client) ListDeployments(ctx context.Context, namespace, labelSelector s
 	})
 }
 
+// ScaleDeployment sets the replica count of a Deployment via its scale subresource.
+func (c *Client) ScaleDeployment(ctx context.Context, namespace, name string, replicas int32) error {
+	scale, err := c.clientset.AppsV1().Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get scale of deployment %s: %w", name, err)
+	}
+	scale.Spec.Replicas = replicas
+	if _, err := c.clientset.AppsV1().Deployments(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to scale deployment %s: %w", name, err)
+	}
+	return nil
+}
+
+// ResumeJob clears a Job's spec.suspend so its pods are created.
+func (c *Client) ResumeJob(ctx context.Context, namespace, name string) error {
+	patch := []byte(`{"spec":{"suspend":false}}`)
+	if _, err := c.clientset.BatchV1().Jobs(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to resume job %s: %w", name, err)
+	}
+	return nil
+}
+
 // ListJobs returns jobs matching the given label selector in a namespace
 func (c *Client) ListJobs(ctx context.Context, namespace, labelSelector string) (*batchv1.JobList, error) {
 	return c.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
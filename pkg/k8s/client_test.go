@@ -0,0 +1,192 @@
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestServiceSelectorIncludesProject(t *testing.T) {
+	if got := ServiceSelector("myproj", "web"); got != "kappal.io/project=myproj,kappal.io/service=web" {
+		t.Errorf("ServiceSelector = %q", got)
+	}
+}
+
+func TestProjectSelector(t *testing.T) {
+	got, err := ProjectSelector("myproj", "kappal.io/network=backend,tier in (api,worker)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "kappal.io/network=backend,kappal.io/project=myproj,tier in (api,worker)"; got != want {
+		t.Errorf("ProjectSelector = %q, want %q", got, want)
+	}
+	for _, bad := range []string{"tier in (api", "=x", "a==b==c"} {
+		if _, err := ProjectSelector("myproj", bad); err == nil {
+			t.Errorf("ProjectSelector(%q) should fail", bad)
+		}
+	}
+}
+
+func TestGetNamespaceStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/namespaces/stuck":
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"stuck"},
+"status":{"phase":"Terminating","conditions":[
+  {"type":"NamespaceDeletionDiscoveryFailure","status":"False","reason":"ResourcesDiscovered"},
+  {"type":"NamespaceFinalizersRemaining","status":"True","message":"Some content in the namespace has finalizers remaining: kubernetes.io/pvc-protection in 1 resource instances"}]}}`))
+		case "/api/v1/namespaces/live":
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"live"},"status":{"phase":"Active"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+		}
+	}))
+	defer server.Close()
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{clientset: clientset}
+
+	tests := []struct {
+		name string
+		want NamespaceStatus
+	}{
+		{"stuck", NamespaceStatus{Phase: "Terminating", Conditions: []string{
+			"NamespaceFinalizersRemaining: Some content in the namespace has finalizers remaining: kubernetes.io/pvc-protection in 1 resource instances",
+		}}},
+		{"live", NamespaceStatus{Phase: "Active"}},
+		{"gone", NamespaceStatus{Phase: "NotFound"}},
+	}
+	for _, tt := range tests {
+		got, err := c.GetNamespaceStatus(context.Background(), tt.name)
+		if err != nil {
+			t.Fatalf("GetNamespaceStatus(%s) failed: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("GetNamespaceStatus(%s) = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+func TestDeleteJobsKeepsUnchanged(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/batch/v1/namespaces/demo/jobs":
+			if got := r.URL.Query().Get("labelSelector"); got != "kappal.io/project=demo" {
+				t.Errorf("jobs listed with selector %q, want the project's", got)
+			}
+			_, _ = w.Write([]byte(`{"apiVersion":"batch/v1","kind":"JobList","items":[
+  {"metadata":{"name":"seed","annotations":{"kappal.io/config-checksum":"aaa"}}},
+  {"metadata":{"name":"migrate","annotations":{"kappal.io/config-checksum":"old"}}},
+  {"metadata":{"name":"retry","annotations":{"kappal.io/config-checksum":"ccc"}},
+   "status":{"conditions":[{"type":"Failed","status":"True"}]}},
+  {"metadata":{"name":"legacy"}},
+  {"metadata":{"name":"removed","annotations":{"kappal.io/config-checksum":"ddd"}}},
+  {"metadata":{"name":"web-run-x7k2p","labels":{"kappal.io/project":"demo","kappal.io/run":"web"}}}]}`))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/apis/batch/v1/namespaces/demo/jobs/"):
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/apis/batch/v1/namespaces/demo/jobs/"))
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Success"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{clientset: clientset}
+
+	manifest := []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: seed
+  annotations:
+    kappal.io/config-checksum: aaa
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  annotations:
+    kappal.io/config-checksum: new
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: retry
+  annotations:
+    kappal.io/config-checksum: ccc
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: legacy
+  annotations:
+    kappal.io/config-checksum: eee
+`)
+	if err := c.DeleteJobs(context.Background(), "demo", "demo", manifest, "kappal.io/config-checksum"); err != nil {
+		t.Fatalf("DeleteJobs failed: %v", err)
+	}
+	// seed is unchanged and kept; migrate changed, retry failed, legacy
+	// predates the checksum and removed is no longer rendered; the
+	// 'kappal run' Job isn't up's to delete
+	if want := []string{"migrate", "retry", "legacy", "removed"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+}
+
+func TestWaitForPodsReadyProgressDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/apps/v1/namespaces/demo/deployments":
+			_, _ = w.Write([]byte(`{"apiVersion":"apps/v1","kind":"DeploymentList","items":[
+  {"metadata":{"name":"db","generation":1},"spec":{"selector":{"matchLabels":{"kappal.io/service":"db"}}},"status":{"observedGeneration":1,"replicas":1,"updatedReplicas":1,"availableReplicas":1}},
+  {"metadata":{"name":"web","generation":1},"spec":{"selector":{"matchLabels":{"kappal.io/service":"web"}}},"status":{"observedGeneration":1,"replicas":1,"updatedReplicas":1,
+    "conditions":[{"type":"Progressing","status":"False","reason":"ProgressDeadlineExceeded"}]}}]}`))
+		case "/api/v1/namespaces/demo/pods":
+			if got := r.URL.Query().Get("labelSelector"); got != "kappal.io/service=web" {
+				t.Errorf("pods listed with selector %q, want the failed Deployment's", got)
+			}
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"PodList","items":[
+  {"metadata":{"name":"web-abc"},"status":{"phase":"Running","containerStatuses":[
+    {"name":"web","state":{"waiting":{"reason":"CrashLoopBackOff"}}}]}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{clientset: clientset}
+
+	err = c.WaitForPodsReady(context.Background(), "demo", "kappal.io/project=demo", time.Minute)
+	if err == nil {
+		t.Fatal("WaitForPodsReady should fail when a rollout exceeded its progress deadline")
+	}
+	for _, want := range []string{"deployment web exceeded its progress deadline", "web-abc: container web CrashLoopBackOff"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should contain %q", err, want)
+		}
+	}
+}
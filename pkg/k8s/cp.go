@@ -0,0 +1,231 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CopyOptions configures a copy between the local filesystem and a pod
+type CopyOptions struct {
+	Index int // Index of pod if multiple replicas
+}
+
+// CopyToPod copies a local file or directory to remotePath in a service's
+// pod, like `kubectl cp`: remotePath names the copy itself, not the directory
+// it goes into. The container needs a tar binary.
+func (c *Client) CopyToPod(ctx context.Context, namespace, projectName, serviceName, localPath, remotePath string, opts CopyOptions) error {
+	pod, err := c.servicePod(ctx, namespace, projectName, serviceName, opts.Index)
+	if err != nil {
+		return err
+	}
+
+	remotePath = path.Clean(remotePath)
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTar(pw, localPath, path.Base(remotePath)))
+	}()
+
+	var stderr bytes.Buffer
+	command := []string{"tar", "-xmf", "-", "-C", path.Dir(remotePath)}
+	if err := c.execInPod(ctx, namespace, pod, command, ExecOptions{Stdin: pr, Stdout: io.Discard, Stderr: &stderr}); err != nil {
+		_ = pr.CloseWithError(err)
+		return fmt.Errorf("failed to copy to %s:%s: %w%s", serviceName, remotePath, err, stderrSuffix(&stderr))
+	}
+	return nil
+}
+
+// CopyFromPod copies remotePath from a service's pod to localPath, which
+// names the copy itself. The container needs a tar binary.
+func (c *Client) CopyFromPod(ctx context.Context, namespace, projectName, serviceName, remotePath, localPath string, opts CopyOptions) error {
+	pod, err := c.servicePod(ctx, namespace, projectName, serviceName, opts.Index)
+	if err != nil {
+		return err
+	}
+
+	remotePath = path.Clean(remotePath)
+	pr, pw := io.Pipe()
+	var stderr bytes.Buffer
+	go func() {
+		command := []string{"tar", "-cf", "-", "-C", path.Dir(remotePath), path.Base(remotePath)}
+		pw.CloseWithError(c.execInPod(ctx, namespace, pod, command, ExecOptions{Stdout: pw, Stderr: &stderr}))
+	}()
+
+	if err := extractTar(pr, path.Base(remotePath), localPath); err != nil {
+		_ = pr.CloseWithError(err)
+		return fmt.Errorf("failed to copy from %s:%s: %w%s", serviceName, remotePath, err, stderrSuffix(&stderr))
+	}
+	return nil
+}
+
+func stderrSuffix(stderr *bytes.Buffer) string {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return " (" + msg + ")"
+	}
+	return ""
+}
+
+// writeTar archives src (a file or directory tree) with its root renamed to
+// name. Symlinks are stored as links, not followed.
+func writeTar(w io.Writer, src, name string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(name, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extractTar unpacks an archive whose entries live under root, writing root
+// itself to dest. Entries outside root or escaping dest are rejected, as are
+// symlinks pointing outside dest and entries written through a symlink, so a
+// hostile pod can't redirect writes (CVE-2019-11246).
+func extractTar(r io.Reader, root, dest string) error {
+	tr := tar.NewReader(r)
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(hdr.Name)
+		var rel string
+		switch {
+		case name == root:
+			rel = "."
+		case strings.HasPrefix(name, root+"/"):
+			rel = strings.TrimPrefix(name, root+"/")
+		default:
+			return fmt.Errorf("unexpected archive entry %q", hdr.Name)
+		}
+		if rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+			return fmt.Errorf("archive entry %q escapes the destination", hdr.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+		found = true
+		if err := checkNoSymlinkParent(dest, rel); err != nil {
+			return fmt.Errorf("archive entry %q: %w", hdr.Name, err)
+		}
+
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if !linkWithin(dest, target, hdr.Linkname) {
+				return fmt.Errorf("archive entry %q links to %q outside the destination", hdr.Name, hdr.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			_ = os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("no such file or directory")
+	}
+	return nil
+}
+
+// linkWithin reports whether a symlink at target pointing to linkname stays
+// inside dest. Absolute link targets are never allowed.
+func linkWithin(dest, target, linkname string) bool {
+	if linkname == "" || filepath.IsAbs(filepath.FromSlash(linkname)) {
+		return false
+	}
+	resolved := filepath.Join(filepath.Dir(target), filepath.FromSlash(linkname))
+	rel, err := filepath.Rel(dest, resolved)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkNoSymlinkParent fails when a directory between dest and the entry rel
+// is a symlink, which would send the write wherever the link points.
+func checkNoSymlinkParent(dest, rel string) error {
+	dir := dest
+	parts := strings.Split(rel, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("parent %s is a symlink", dir)
+		}
+	}
+	return nil
+}
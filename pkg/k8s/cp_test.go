@@ -0,0 +1,139 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarRoundTripRenamesRoot(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "seed")
+	if err := os.MkdirAll(filepath.Join(src, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "nested", "data.sql"), []byte("select 1;"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTar(&buf, src, "fixtures"); err != nil {
+		t.Fatalf("writeTar failed: %v", err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "copy")
+	if err := extractTar(&buf, "fixtures", dest); err != nil {
+		t.Fatalf("extractTar failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dest, "nested", "data.sql"))
+	if err != nil {
+		t.Fatalf("expected copied file: %v", err)
+	}
+	if string(data) != "select 1;" {
+		t.Errorf("unexpected content %q", data)
+	}
+	info, err := os.Stat(filepath.Join(dest, "nested", "data.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected mode 0640 preserved, got %o", info.Mode().Perm())
+	}
+}
+
+func TestTarSingleFile(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(src, []byte("line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTar(&buf, src, "app.log"); err != nil {
+		t.Fatalf("writeTar failed: %v", err)
+	}
+	dest := filepath.Join(t.TempDir(), "local.log")
+	if err := extractTar(&buf, "app.log", dest); err != nil {
+		t.Fatalf("extractTar failed: %v", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "line\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestExtractTarRejectsEscapingEntries(t *testing.T) {
+	for _, name := range []string{"other/file", "/etc/passwd", "root/../../evil"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		_ = tw.Close()
+
+		if err := extractTar(&buf, "root", t.TempDir()); err == nil {
+			t.Errorf("expected entry %q to be rejected", name)
+		}
+	}
+}
+
+func TestExtractTarRejectsSymlinkEscapes(t *testing.T) {
+	type entry struct {
+		name, link string
+	}
+	for name, entries := range map[string][]entry{
+		"absolute link":         {{name: "root/x", link: "/home/user"}, {name: "root/x/.bashrc"}},
+		"relative link outside": {{name: "root/x", link: "../../outside"}, {name: "root/x/.bashrc"}},
+		// Even a link that stays inside dest isn't written through
+		"write through link": {{name: "root/in", link: "sub"}, {name: "root/in/.bashrc"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for _, e := range entries {
+				hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg}
+				if e.link != "" {
+					hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, e.link
+				}
+				if err := tw.WriteHeader(hdr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_ = tw.Close()
+
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "copy")
+			if err := extractTar(&buf, "root", dest); err == nil {
+				t.Error("expected the archive to be rejected")
+			}
+			if _, err := os.Lstat(filepath.Join(parent, "outside")); err == nil {
+				t.Error("archive wrote outside the destination")
+			}
+		})
+	}
+}
+
+func TestExtractTarKeepsInternalSymlinks(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "root/data", Mode: 0755, Typeflag: tar.TypeDir})
+	_ = tw.WriteHeader(&tar.Header{Name: "root/data/current.sql", Mode: 0644, Typeflag: tar.TypeReg})
+	_ = tw.WriteHeader(&tar.Header{Name: "root/latest.sql", Typeflag: tar.TypeSymlink, Linkname: "data/current.sql"})
+	_ = tw.Close()
+
+	dest := filepath.Join(t.TempDir(), "copy")
+	if err := extractTar(&buf, "root", dest); err != nil {
+		t.Fatalf("extractTar failed: %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join(dest, "latest.sql")); err != nil || link != "data/current.sql" {
+		t.Errorf("latest.sql -> %q, %v", link, err)
+	}
+}
+
+func TestExtractTarEmptyArchive(t *testing.T) {
+	var buf bytes.Buffer
+	_ = tar.NewWriter(&buf).Close()
+	if err := extractTar(&buf, "missing", t.TempDir()); err == nil {
+		t.Error("expected error when the archive has no entries")
+	}
+}
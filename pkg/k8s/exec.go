@@ -6,6 +6,7 @@ import (
 	"io"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -28,19 +29,29 @@ func GetConfig(kubeconfigPath string) (*rest.Config, error) {
 }
 
 // Exec executes a command in a service's pod
-func (c *Client) Exec(ctx context.Context, namespace, serviceName string, command []string, opts ExecOptions) error {
+func (c *Client) Exec(ctx context.Context, namespace, projectName, serviceName string, command []string, opts ExecOptions) error {
+	pod, err := c.servicePod(ctx, namespace, projectName, serviceName, opts.Index)
+	if err != nil {
+		return err
+	}
+	return c.execInPod(ctx, namespace, pod, command, opts)
+}
+
+// servicePod returns the name of a service's running pod, selected by index
+// (out-of-range indexes fall back to the first pod).
+func (c *Client) servicePod(ctx context.Context, namespace, projectName, serviceName string, index int) (string, error) {
 	// Find pods for this service
-	pods, err := c.ListPods(ctx, namespace, fmt.Sprintf("kappal.io/service=%s", serviceName))
+	pods, err := c.ListPods(ctx, namespace, ServiceSelector(projectName, serviceName))
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+		return "", fmt.Errorf("failed to list pods: %w", err)
 	}
 
 	if len(pods.Items) == 0 {
-		return fmt.Errorf("no running container for service %s", serviceName)
+		return "", fmt.Errorf("no running container for service %s", serviceName)
 	}
 
 	// Select pod by index (default to first)
-	podIndex := opts.Index
+	podIndex := index
 	if podIndex < 0 || podIndex >= len(pods.Items) {
 		podIndex = 0
 	}
@@ -48,10 +59,16 @@ func (c *Client) Exec(ctx context.Context, namespace, serviceName string, comman
 
 	// Check if pod is running
 	if pod.Status.Phase != corev1.PodRunning {
-		return fmt.Errorf("pod %s is not running (status: %s)", pod.Name, pod.Status.Phase)
+		return "", fmt.Errorf("pod %s is not running (status: %s)", pod.Name, pod.Status.Phase)
 	}
 
-	return c.execInPod(ctx, namespace, pod.Name, command, opts)
+	return pod.Name, nil
+}
+
+// ExecInPod executes a command in a named pod, for pods that no service
+// selector matches (e.g. the in-cluster BuildKit builder)
+func (c *Client) ExecInPod(ctx context.Context, namespace, podName string, command []string, opts ExecOptions) error {
+	return c.execInPod(ctx, namespace, podName, command, opts)
 }
 
 // execInPod executes a command in a specific pod
@@ -96,3 +113,78 @@ func (c *Client) execInPod(ctx context.Context, namespace, podName string, comma
 	// Execute command
 	return exec.StreamWithContext(ctx, streamOpts)
 }
+
+// Attach connects to the main process of a service's pod, like 'docker
+// attach'. As with Docker, stdin is only forwarded when the container was
+// started with stdin_open and a TTY is used when it was started with tty, so
+// opts.TTY and opts.Interactive are ignored.
+func (c *Client) Attach(ctx context.Context, namespace, projectName, serviceName string, opts ExecOptions) error {
+	podName, err := c.servicePod(ctx, namespace, projectName, serviceName, opts.Index)
+	if err != nil {
+		return err
+	}
+	pod, err := c.clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod %s: %w", podName, err)
+	}
+	container, err := attachContainer(pod, serviceName)
+	if err != nil {
+		return err
+	}
+	attachOpts := AttachOptionsFor(container, opts.Stdin != nil)
+
+	req := c.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("attach").
+		VersionedParams(attachOpts, scheme.ParameterCodec)
+
+	config := c.RESTConfig()
+	if config == nil {
+		return fmt.Errorf("REST config not available")
+	}
+
+	attach, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+
+	streamOpts := remotecommand.StreamOptions{
+		Stdout: opts.Stdout,
+		Tty:    attachOpts.TTY,
+	}
+	if attachOpts.Stdin {
+		streamOpts.Stdin = opts.Stdin
+	}
+	if attachOpts.Stderr {
+		streamOpts.Stderr = opts.Stderr
+	}
+	return attach.StreamWithContext(ctx, streamOpts)
+}
+
+// AttachOptionsFor returns the attach request for a container: stdin only
+// when the container keeps it open (and withStdin is set), and a TTY only
+// alongside stdin when the container has one, as kubectl does. A TTY merges
+// stderr into stdout, so stderr is only requested without one.
+func AttachOptionsFor(container *corev1.Container, withStdin bool) *corev1.PodAttachOptions {
+	stdin := withStdin && container.Stdin
+	tty := stdin && container.TTY
+	return &corev1.PodAttachOptions{
+		Container: container.Name,
+		Stdin:     stdin,
+		Stdout:    true,
+		Stderr:    !tty,
+		TTY:       tty,
+	}
+}
+
+// attachContainer returns the service's app container in pod.
+func attachContainer(pod *corev1.Pod, serviceName string) (*corev1.Container, error) {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == serviceName {
+			return &pod.Spec.Containers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pod %s has no container for service %s", pod.Name, serviceName)
+}
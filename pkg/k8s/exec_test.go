@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAttachOptionsFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		container corev1.Container
+		withStdin bool
+		want      corev1.PodAttachOptions
+	}{
+		{
+			name:      "stdin_open and tty",
+			container: corev1.Container{Name: "console", Stdin: true, TTY: true},
+			withStdin: true,
+			want:      corev1.PodAttachOptions{Container: "console", Stdin: true, Stdout: true, TTY: true},
+		},
+		{
+			name:      "stdin_open without tty keeps stderr separate",
+			container: corev1.Container{Name: "console", Stdin: true},
+			withStdin: true,
+			want:      corev1.PodAttachOptions{Container: "console", Stdin: true, Stdout: true, Stderr: true},
+		},
+		{
+			name:      "--no-stdin drops the TTY too",
+			container: corev1.Container{Name: "console", Stdin: true, TTY: true},
+			want:      corev1.PodAttachOptions{Container: "console", Stdout: true, Stderr: true},
+		},
+		{
+			name:      "container without stdin is output only",
+			container: corev1.Container{Name: "web"},
+			withStdin: true,
+			want:      corev1.PodAttachOptions{Container: "web", Stdout: true, Stderr: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AttachOptionsFor(&tt.container, tt.withStdin); *got != tt.want {
+				t.Errorf("AttachOptionsFor = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachContainer(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "console-abc"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "wait-for-deps"}},
+			Containers:     []corev1.Container{{Name: "console", Stdin: true}},
+		},
+	}
+	c, err := attachContainer(pod, "console")
+	if err != nil || c.Name != "console" || !c.Stdin {
+		t.Errorf("attachContainer = %+v, %v", c, err)
+	}
+	if _, err := attachContainer(pod, "web"); err == nil {
+		t.Error("expected error for a service with no container in the pod")
+	}
+}
@@ -5,21 +5,39 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// logRetryBackoff is the wait before each reconnect of a pod's log stream
+// after a transient error (e.g. the K3s API restarting). Once it is
+// exhausted the error is printed and the pod's stream ends.
+var logRetryBackoff = []time.Duration{
+	500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+}
+
 // LogOptions configures log streaming
 type LogOptions struct {
 	Follow    bool
 	TailLines int64
+	HeadLines int64 // If set, print only the first HeadLines lines of each pod, ignoring TailLines
 	Services  []string
+	Selector  string    // If set, stream the project's pods matching this label selector instead of Services
+	Since     time.Time // If set, only show lines logged at or after this time
+	Until     time.Time // If set, stop at the first line logged after this time
 }
 
 // StreamLogs streams logs from services in a project
-func (c *Client) StreamLogs(ctx context.Context, project *types.Project, opts LogOptions, out io.Writer) error {
+func (c *Client) StreamLogs(ctx context.Context, namespace string, project *types.Project, opts LogOptions, out io.Writer) error {
+	if opts.Selector != "" {
+		return c.streamSelectorLogs(ctx, namespace, project.Name, opts, out)
+	}
 	services := opts.Services
 	if len(services) == 0 {
 		for _, svc := range project.Services {
@@ -34,24 +52,29 @@ func (c *Client) StreamLogs(ctx context.Context, project *types.Project, opts Lo
 		wg.Add(1)
 		go func(service string) {
 			defer wg.Done()
-			if err := c.streamServiceLogs(ctx, project.Name, service, opts, out); err != nil {
+			if err := c.streamServiceLogs(ctx, namespace, project.Name, service, opts, out); err != nil {
 				errChan <- fmt.Errorf("%s: %w", service, err)
 			}
 		}(svcName)
 	}
 
 	// Wait for all streams to finish
+	done := make(chan struct{})
 	go func() {
 		wg.Wait()
 		close(errChan)
+		close(done)
 	}()
 
 	// If not following, wait for completion
 	if !opts.Follow {
 		wg.Wait()
 	} else {
-		// Block until context is cancelled
-		<-ctx.Done()
+		// Block until context is cancelled or every stream has ended (--until reached)
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
 	}
 
 	// Check for errors
@@ -62,9 +85,9 @@ func (c *Client) StreamLogs(ctx context.Context, project *types.Project, opts Lo
 	return nil
 }
 
-func (c *Client) streamServiceLogs(ctx context.Context, namespace, serviceName string, opts LogOptions, out io.Writer) error {
+func (c *Client) streamServiceLogs(ctx context.Context, namespace, projectName, serviceName string, opts LogOptions, out io.Writer) error {
 	// Find pods for this service
-	pods, err := c.ListPods(ctx, namespace, fmt.Sprintf("kappal.io/service=%s", serviceName))
+	pods, err := c.ListPods(ctx, namespace, ServiceSelector(projectName, serviceName))
 	if err != nil {
 		return err
 	}
@@ -87,29 +110,170 @@ func (c *Client) streamServiceLogs(ctx context.Context, namespace, serviceName s
 	return nil
 }
 
+// streamSelectorLogs streams the logs of the project's pods matching
+// opts.Selector, each line prefixed with the pod's service.
+func (c *Client) streamSelectorLogs(ctx context.Context, namespace, projectName string, opts LogOptions, out io.Writer) error {
+	selector, err := ProjectSelector(projectName, opts.Selector)
+	if err != nil {
+		return err
+	}
+	pods, err := c.ListPods(ctx, namespace, selector)
+	if err != nil {
+		return err
+	}
+
+	if len(pods.Items) == 0 {
+		_, _ = fmt.Fprintf(out, "No pods match %s\n", opts.Selector)
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for _, pod := range pods.Items {
+		service := pod.Labels["kappal.io/service"]
+		if service == "" {
+			service = pod.Name
+		}
+		wg.Add(1)
+		go func(podName, service string) {
+			defer wg.Done()
+			c.streamPodLogs(ctx, namespace, podName, service, opts, out)
+		}(pod.Name, service)
+	}
+
+	wg.Wait()
+	return nil
+}
+
 func (c *Client) streamPodLogs(ctx context.Context, namespace, podName, serviceName string, opts LogOptions, out io.Writer) {
 	logOpts := &corev1.PodLogOptions{
 		Follow: opts.Follow,
 	}
 
-	if opts.TailLines > 0 {
+	if opts.TailLines > 0 && opts.HeadLines <= 0 {
 		logOpts.TailLines = &opts.TailLines
 	}
+	if !opts.Since.IsZero() {
+		logOpts.SinceTime = &metav1.Time{Time: opts.Since}
+	}
+	if !opts.Until.IsZero() {
+		// K8s has no server-side until; request timestamps to cut off client-side
+		logOpts.Timestamps = true
+		if opts.Follow {
+			// Stop waiting for new lines once the until time has passed
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, opts.Until)
+			defer cancel()
+		}
+	}
 
-	stream, err := c.GetPodLogs(ctx, namespace, podName, logOpts)
-	if err != nil {
+	open := func(ctx context.Context, logOpts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		return c.GetPodLogs(ctx, namespace, podName, logOpts)
+	}
+	if err := copyLogsWithRetry(ctx, open, logOpts, serviceName, opts, out, logRetryBackoff); err != nil {
 		_, _ = fmt.Fprintf(out, "%s | Error: %v\n", serviceName, err)
-		return
 	}
-	defer func() { _ = stream.Close() }()
+}
+
+// logOpener opens a pod's log stream with the given options.
+type logOpener func(ctx context.Context, logOpts *corev1.PodLogOptions) (io.ReadCloser, error)
+
+// copyLogsWithRetry copies a pod's log stream to out, reconnecting after
+// transient errors with the waits in backoff. Opening the stream is retried
+// in every mode; a followed stream that breaks mid-way is reopened from the
+// time it broke, so lines already printed are not replayed. Permanent errors
+// (e.g. the pod is gone) and the error after the last retry are returned.
+func copyLogsWithRetry(ctx context.Context, open logOpener, logOpts *corev1.PodLogOptions, serviceName string, opts LogOptions, out io.Writer, backoff []time.Duration) error {
+	head := opts.HeadLines
+	for attempt := 0; ; attempt++ {
+		stream, err := open(ctx, logOpts)
+		if err == nil {
+			// Closing the stream stops reading once --head lines are printed
+			written, readErr := writeLogLines(ctx, stream, serviceName, opts.Until, head, out)
+			_ = stream.Close()
+			if readErr == nil || !opts.Follow || ctx.Err() != nil {
+				return readErr
+			}
+			if head > 0 {
+				if head -= written; head <= 0 {
+					return nil
+				}
+			}
+			if written > 0 {
+				attempt = 0
+			}
+			resume := metav1.Now()
+			logOpts.TailLines = nil
+			logOpts.SinceTime = &resume
+			err = readErr
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !isTransientLogError(err) || attempt >= len(backoff) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff[attempt]):
+		}
+	}
+}
 
+// isTransientLogError reports whether a log stream error is worth retrying.
+// Errors saying the pod is gone or access is denied won't heal on their own.
+func isTransientLogError(err error) bool {
+	return !apierrors.IsNotFound(err) &&
+		!apierrors.IsForbidden(err) &&
+		!apierrors.IsUnauthorized(err) &&
+		!apierrors.IsInvalid(err)
+}
+
+// writeLogLines copies log lines to out, prefixed with the service name.
+// When until is set, lines are expected to carry the RFC3339 timestamp prefix
+// added by PodLogOptions.Timestamps: copying stops at the first line stamped
+// after until, and the prefix is stripped from printed lines. When head is
+// positive, copying stops after head lines (K8s has no server-side head).
+// It returns the number of lines written and the error that broke the
+// stream, if any.
+func writeLogLines(ctx context.Context, stream io.Reader, serviceName string, until time.Time, head int64, out io.Writer) (int64, error) {
 	scanner := bufio.NewScanner(stream)
+	var written int64
 	for scanner.Scan() {
+		line := scanner.Text()
+		if !until.IsZero() {
+			if ts, rest, ok := splitLogTimestamp(line); ok {
+				if ts.After(until) {
+					return written, nil
+				}
+				line = rest
+			}
+		}
 		select {
 		case <-ctx.Done():
-			return
+			return written, nil
 		default:
-			_, _ = fmt.Fprintf(out, "%s | %s\n", serviceName, scanner.Text())
+			_, _ = fmt.Fprintf(out, "%s | %s\n", serviceName, line)
+			written++
+			// Return now rather than wait for a line past head, which a
+			// followed stream may never send
+			if head > 0 && written >= head {
+				return written, nil
+			}
 		}
 	}
+	return written, scanner.Err()
+}
+
+// splitLogTimestamp splits a "<RFC3339Nano> <message>" log line.
+func splitLogTimestamp(line string) (time.Time, string, bool) {
+	stamp, rest, found := strings.Cut(line, " ")
+	if !found {
+		stamp, rest = line, ""
+	}
+	ts, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return time.Time{}, line, false
+	}
+	return ts, rest, true
 }
@@ -0,0 +1,257 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestWriteLogLinesUntilCutoff(t *testing.T) {
+	stream := strings.NewReader(strings.Join([]string{
+		"2024-05-01T10:00:00.000000000Z starting",
+		"2024-05-01T10:00:05.500000000Z ready",
+		"2024-05-01T10:00:10.000000001Z too late",
+		"2024-05-01T10:00:11.000000000Z also too late",
+	}, "\n"))
+	until := time.Date(2024, 5, 1, 10, 0, 10, 0, time.UTC)
+
+	var out bytes.Buffer
+	writeLogLines(context.Background(), stream, "api", until, 0, &out)
+
+	want := "api | starting\napi | ready\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteLogLinesWithoutUntil(t *testing.T) {
+	stream := strings.NewReader("2024-05-01T10:00:00Z kept as-is\nplain line\n")
+
+	var out bytes.Buffer
+	writeLogLines(context.Background(), stream, "api", time.Time{}, 0, &out)
+
+	want := "api | 2024-05-01T10:00:00Z kept as-is\napi | plain line\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestSplitLogTimestamp(t *testing.T) {
+	ts, rest, ok := splitLogTimestamp("2024-05-01T10:00:00.123Z hello world")
+	if !ok || rest != "hello world" || !ts.Equal(time.Date(2024, 5, 1, 10, 0, 0, 123000000, time.UTC)) {
+		t.Errorf("splitLogTimestamp = %v, %q, %v", ts, rest, ok)
+	}
+
+	if _, rest, ok := splitLogTimestamp("no timestamp here"); ok || rest != "no timestamp here" {
+		t.Errorf("line without timestamp should be returned unchanged, got %q, %v", rest, ok)
+	}
+}
+
+func TestWriteLogLinesHeadCutoff(t *testing.T) {
+	stream := strings.NewReader("banner\nlistening on :8080\nrequest 1\nrequest 2\n")
+
+	var out bytes.Buffer
+	writeLogLines(context.Background(), stream, "api", time.Time{}, 2, &out)
+
+	want := "api | banner\napi | listening on :8080\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteLogLinesHeadFollow(t *testing.T) {
+	// A followed stream that sends two lines and then stays open
+	stream, w := io.Pipe()
+	defer w.Close()
+	go func() { _, _ = w.Write([]byte("banner\nlistening on :8080\n")) }()
+
+	var out bytes.Buffer
+	done := make(chan int64, 1)
+	go func() {
+		written, _ := writeLogLines(context.Background(), stream, "api", time.Time{}, 2, &out)
+		done <- written
+	}()
+	select {
+	case written := <-done:
+		if written != 2 {
+			t.Errorf("written = %d, want 2", written)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("writeLogLines waited for a line past --head on a followed stream")
+	}
+	want := "api | banner\napi | listening on :8080\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+// brokenReader returns its content, then a read error instead of EOF.
+type brokenReader struct {
+	r io.Reader
+}
+
+func (b *brokenReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, err
+}
+
+func TestCopyLogsWithRetryTransientThenSuccess(t *testing.T) {
+	calls := 0
+	open := func(ctx context.Context, logOpts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		calls++
+		if calls == 1 {
+			return nil, apierrors.NewServiceUnavailable("apiserver restarting")
+		}
+		return io.NopCloser(strings.NewReader("hello\nworld\n")), nil
+	}
+
+	var out bytes.Buffer
+	err := copyLogsWithRetry(context.Background(), open, &corev1.PodLogOptions{}, "api", LogOptions{}, &out, []time.Duration{time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected success after retry, got: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("open called %d times, want 2", calls)
+	}
+	if want := "api | hello\napi | world\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestCopyLogsWithRetryPermanentError(t *testing.T) {
+	calls := 0
+	open := func(ctx context.Context, logOpts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		calls++
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "api-1")
+	}
+
+	err := copyLogsWithRetry(context.Background(), open, &corev1.PodLogOptions{}, "api", LogOptions{}, io.Discard, []time.Duration{time.Millisecond, time.Millisecond})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the not found error, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("open called %d times, want 1 (no retry for a deleted pod)", calls)
+	}
+}
+
+func TestCopyLogsWithRetryGivesUp(t *testing.T) {
+	calls := 0
+	open := func(ctx context.Context, logOpts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	}
+
+	err := copyLogsWithRetry(context.Background(), open, &corev1.PodLogOptions{}, "api", LogOptions{}, io.Discard, []time.Duration{time.Millisecond, time.Millisecond})
+	if err == nil || calls != 3 {
+		t.Errorf("expected an error after 3 attempts, got %v after %d", err, calls)
+	}
+}
+
+func TestCopyLogsWithRetryResumesFollow(t *testing.T) {
+	var reopened *corev1.PodLogOptions
+	calls := 0
+	open := func(ctx context.Context, logOpts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		calls++
+		if calls == 1 {
+			return io.NopCloser(&brokenReader{strings.NewReader("first\n")}), nil
+		}
+		reopened = logOpts.DeepCopy()
+		return io.NopCloser(strings.NewReader("second\n")), nil
+	}
+
+	tail := int64(100)
+	var out bytes.Buffer
+	err := copyLogsWithRetry(context.Background(), open, &corev1.PodLogOptions{Follow: true, TailLines: &tail}, "api", LogOptions{Follow: true}, &out, []time.Duration{time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected the stream to resume, got: %v", err)
+	}
+	if want := "api | first\napi | second\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if reopened == nil || reopened.TailLines != nil || reopened.SinceTime == nil {
+		t.Errorf("expected the stream to resume from the break time without a tail, got %+v", reopened)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for the concurrent per-pod writers.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStreamLogsSelector(t *testing.T) {
+	var listSelector string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/demo/pods":
+			listSelector = r.URL.Query().Get("labelSelector")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"PodList","items":[
+  {"metadata":{"name":"api-1","labels":{"kappal.io/project":"demo","kappal.io/service":"api"}}},
+  {"metadata":{"name":"worker-1","labels":{"kappal.io/project":"demo","kappal.io/service":"worker"}}}]}`))
+		case "/api/v1/namespaces/demo/pods/api-1/log":
+			_, _ = w.Write([]byte("listening\n"))
+		case "/api/v1/namespaces/demo/pods/worker-1/log":
+			_, _ = w.Write([]byte("polling\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{clientset: clientset}
+	project := &types.Project{Name: "demo", Services: types.Services{"db": {Name: "db"}}}
+
+	var out lockedBuffer
+	opts := LogOptions{Selector: "kappal.io/network=backend"}
+	if err := c.StreamLogs(context.Background(), "demo", project, opts, &out); err != nil {
+		t.Fatalf("StreamLogs failed: %v", err)
+	}
+	if listSelector != "kappal.io/network=backend,kappal.io/project=demo" {
+		t.Errorf("pods listed with selector %q, want it narrowed to the project", listSelector)
+	}
+	for _, want := range []string{"api | listening\n", "worker | polling\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "db") {
+		t.Errorf("services outside the selector should not be streamed:\n%s", out.String())
+	}
+
+	if err := c.StreamLogs(context.Background(), "demo", project, LogOptions{Selector: "tier in (api"}, &out); err == nil || !strings.Contains(err.Error(), "invalid label selector") {
+		t.Errorf("expected an invalid selector error, got: %v", err)
+	}
+}
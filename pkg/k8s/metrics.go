@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrMetricsUnavailable is returned by ListPodMetrics when the cluster does
+// not serve the metrics.k8s.io API (metrics-server disabled or still starting).
+var ErrMetricsUnavailable = errors.New("metrics API not available")
+
+// PodMetrics is a pod's resource usage from metrics.k8s.io/v1beta1. Only the
+// fields kappal reads are decoded; k8s.io/metrics is not a dependency.
+type PodMetrics struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Timestamp         metav1.Time        `json:"timestamp"`
+	Window            metav1.Duration    `json:"window"`
+	Containers        []ContainerMetrics `json:"containers"`
+}
+
+// ContainerMetrics is the usage of one container of a pod.
+type ContainerMetrics struct {
+	Name  string              `json:"name"`
+	Usage corev1.ResourceList `json:"usage"`
+}
+
+// ListPodMetrics lists the usage of pods in namespace matching selector.
+func (c *Client) ListPodMetrics(ctx context.Context, namespace, selector string) ([]PodMetrics, error) {
+	raw, err := c.clientset.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods").
+		Param("labelSelector", selector).
+		Do(ctx).Raw()
+	if err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) {
+			return nil, ErrMetricsUnavailable
+		}
+		return nil, fmt.Errorf("failed to list pod metrics: %w", err)
+	}
+	var list struct {
+		Items []PodMetrics `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode pod metrics: %w", err)
+	}
+	return list.Items, nil
+}
+
+// ResourceUsage is the summed CPU and memory usage of a service's pods.
+type ResourceUsage struct {
+	CPUMillis   int64 // 1000 is one full core
+	MemoryBytes int64
+	Pods        int
+}
+
+// ServiceUsage returns the usage of a project's pods keyed by compose
+// service. It returns ErrMetricsUnavailable unless K3s runs metrics-server
+// (up --enable-metrics).
+func (c *Client) ServiceUsage(ctx context.Context, namespace, projectName string) (map[string]ResourceUsage, error) {
+	metrics, err := c.ListPodMetrics(ctx, namespace, "kappal.io/project="+projectName)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[string]ResourceUsage)
+	for _, m := range metrics {
+		service := m.Labels["kappal.io/service"]
+		u := usage[service]
+		for _, container := range m.Containers {
+			if cpu, ok := container.Usage[corev1.ResourceCPU]; ok {
+				u.CPUMillis += cpu.MilliValue()
+			}
+			if mem, ok := container.Usage[corev1.ResourceMemory]; ok {
+				u.MemoryBytes += mem.Value()
+			}
+		}
+		u.Pods++
+		usage[service] = u
+	}
+	return usage, nil
+}
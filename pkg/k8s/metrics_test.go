@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestServiceUsage(t *testing.T) {
+	const body = `{"kind":"PodMetricsList","apiVersion":"metrics.k8s.io/v1beta1","items":[
+		{"metadata":{"name":"web-1","labels":{"kappal.io/service":"web"}},
+		 "containers":[{"name":"web","usage":{"cpu":"150m","memory":"64Mi"}},{"name":"sidecar","usage":{"cpu":"2500000n","memory":"8Mi"}}]},
+		{"metadata":{"name":"web-2","labels":{"kappal.io/service":"web"}},
+		 "containers":[{"name":"web","usage":{"cpu":"50m","memory":"32Mi"}}]},
+		{"metadata":{"name":"db-0","labels":{"kappal.io/service":"db"}},
+		 "containers":[{"name":"db","usage":{"cpu":"1","memory":"1Gi"}}]}
+	]}`
+
+	available := true
+	var selector string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/metrics.k8s.io/v1beta1/namespaces/myapp/pods" {
+			http.NotFound(w, r)
+			return
+		}
+		if !available {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"ServiceUnavailable","code":503}`))
+			return
+		}
+		selector = r.URL.Query().Get("labelSelector")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("NewForConfig: %v", err)
+	}
+	c := &Client{clientset: clientset}
+
+	usage, err := c.ServiceUsage(context.Background(), "myapp", "myapp")
+	if err != nil {
+		t.Fatalf("ServiceUsage: %v", err)
+	}
+	if selector != "kappal.io/project=myapp" {
+		t.Errorf("labelSelector = %q, want kappal.io/project=myapp", selector)
+	}
+	want := map[string]ResourceUsage{
+		"web": {CPUMillis: 203, MemoryBytes: 104 << 20, Pods: 2},
+		"db":  {CPUMillis: 1000, MemoryBytes: 1 << 30, Pods: 1},
+	}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("ServiceUsage = %+v, want %+v", usage, want)
+	}
+
+	// Without metrics-server the API is not served
+	available = false
+	if _, err := c.ServiceUsage(context.Background(), "myapp", "myapp"); !errors.Is(err, ErrMetricsUnavailable) {
+		t.Errorf("expected ErrMetricsUnavailable, got: %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// restartedAtAnnotation is the pod template annotation 'kubectl rollout
+// restart' sets; changing it rolls out new pods.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// RestartDeployment triggers a rolling restart of a Deployment, like
+// 'kubectl rollout restart'.
+func (c *Client) RestartDeployment(ctx context.Context, namespace, name string) error {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		restartedAtAnnotation, time.Now().Format(time.RFC3339))
+	_, err := c.clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to restart deployment %s: %w", name, err)
+	}
+	return nil
+}
+
+// RolloutStatus reports whether a Deployment's latest rollout has completed,
+// using the same checks as 'kubectl rollout status'. It returns an error if
+// the rollout exceeded its progress deadline.
+func RolloutStatus(d *appsv1.Deployment) (bool, error) {
+	if d.Generation > d.Status.ObservedGeneration {
+		return false, nil
+	}
+	for _, cond := range d.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return false, fmt.Errorf("deployment %s exceeded its progress deadline", d.Name)
+		}
+	}
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	s := d.Status
+	return s.UpdatedReplicas >= desired && s.Replicas == s.UpdatedReplicas && s.AvailableReplicas >= s.UpdatedReplicas, nil
+}
+
+// WaitForRollout polls a Deployment until its rollout completes. On timeout
+// the error names the service's failing pods and why, when any are found.
+func (c *Client) WaitForRollout(ctx context.Context, namespace, name, labelSelector string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		d, err := c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get deployment %s: %w", name, err)
+		}
+		var rolloutErr error
+		if err == nil {
+			var done bool
+			done, rolloutErr = RolloutStatus(d)
+			if done {
+				return nil
+			}
+		}
+		if rolloutErr != nil || !time.Now().Before(deadline) {
+			if rolloutErr == nil {
+				rolloutErr = fmt.Errorf("timeout waiting for deployment %s to roll out", name)
+			}
+			return c.withPodFailures(ctx, namespace, labelSelector, rolloutErr)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// jobLogLines is how many trailing log lines of a failed Job's pod
+// WaitForJobComplete reports.
+const jobLogLines = 20
+
+// WaitForJobComplete polls a Job until it completes. It fails early when the
+// Job has failed for good (e.g. its backoffLimit is exhausted), reporting the
+// reason and the tail of its last pod's logs; on timeout the error names the
+// Job's failing pods, when any are found. A Job already removed by its
+// ttlSecondsAfterFinished has finished, so it counts as complete.
+func (c *Client) WaitForJobComplete(ctx context.Context, namespace, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	selector := "job-name=" + name
+
+	for {
+		job, err := c.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get job %s: %w", name, err)
+		}
+		if done, failed := jobFinished(job); done {
+			if !failed {
+				return nil
+			}
+			return c.withJobLogs(ctx, namespace, selector, jobFailure(job))
+		}
+		if !time.Now().Before(deadline) {
+			return c.withPodFailures(ctx, namespace, selector, fmt.Errorf("timeout waiting for job %s to complete", name))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// jobFailure describes a failed Job from its Failed condition, e.g. "job
+// migrate failed: BackoffLimitExceeded: Job has reached the specified
+// backoff limit".
+func jobFailure(job *batchv1.Job) error {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type != batchv1.JobFailed || cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		desc := cond.Reason
+		if cond.Message != "" {
+			desc += ": " + cond.Message
+		}
+		if desc != "" {
+			return fmt.Errorf("job %s failed: %s", job.Name, desc)
+		}
+	}
+	return fmt.Errorf("job %s failed", job.Name)
+}
+
+// withJobLogs appends the last jobLogLines log lines of the most recently
+// created pod matching the selector to err, when they can be read.
+func (c *Client) withJobLogs(ctx context.Context, namespace, labelSelector string, err error) error {
+	pods, listErr := c.ListPods(ctx, namespace, labelSelector)
+	if listErr != nil || len(pods.Items) == 0 {
+		return err
+	}
+	last := pods.Items[0]
+	for _, pod := range pods.Items[1:] {
+		if last.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			last = pod
+		}
+	}
+	tail := int64(jobLogLines)
+	opts := &corev1.PodLogOptions{TailLines: &tail}
+	if len(last.Spec.Containers) > 0 {
+		opts.Container = last.Spec.Containers[0].Name
+	}
+	stream, logErr := c.GetPodLogs(ctx, namespace, last.Name, opts)
+	if logErr != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+	logs, readErr := io.ReadAll(stream)
+	if readErr != nil || len(bytes.TrimSpace(logs)) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w\nlast logs of %s:\n%s", err, last.Name, bytes.TrimRight(logs, "\n"))
+}
+
+// failedRollout returns the error for the first Deployment matching the
+// selector whose rollout exceeded its progress deadline, naming its failing
+// pods, or nil if none has.
+func (c *Client) failedRollout(ctx context.Context, namespace, labelSelector string) error {
+	deployments, err := c.ListDeployments(ctx, namespace, labelSelector)
+	if err != nil {
+		return nil
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if _, err := RolloutStatus(d); err != nil {
+			selector := labelSelector
+			if d.Spec.Selector != nil {
+				selector = labels.SelectorFromSet(d.Spec.Selector.MatchLabels).String()
+			}
+			return c.withPodFailures(ctx, namespace, selector, err)
+		}
+	}
+	return nil
+}
+
+// withPodFailures appends the failures (see PodFailures) of the pods
+// matching the selector to err, when there are any.
+func (c *Client) withPodFailures(ctx context.Context, namespace, labelSelector string, err error) error {
+	if pods, listErr := c.ListPods(ctx, namespace, labelSelector); listErr == nil {
+		if failures := PodFailures(pods.Items); len(failures) > 0 {
+			return fmt.Errorf("%w: %s", err, strings.Join(failures, "; "))
+		}
+	}
+	return err
+}
+
+// failingReasons are container waiting reasons that won't resolve by waiting.
+var failingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+}
+
+// PodFailures describes the init and app containers of pods that are stuck
+// (e.g. "web-5d9c7-abcde: container web CrashLoopBackOff: back-off 10s"),
+// sorted by pod name.
+func PodFailures(pods []corev1.Pod) []string {
+	sorted := append([]corev1.Pod(nil), pods...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var failures []string
+	for _, pod := range sorted {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, cs := range pod.Status.InitContainerStatuses {
+			if desc := containerFailure(cs); desc != "" {
+				failures = append(failures, fmt.Sprintf("%s: init container %s %s", pod.Name, cs.Name, desc))
+			}
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if desc := containerFailure(cs); desc != "" {
+				failures = append(failures, fmt.Sprintf("%s: container %s %s", pod.Name, cs.Name, desc))
+			}
+		}
+	}
+	return failures
+}
+
+// containerFailure describes why a container is failing, or returns "".
+func containerFailure(cs corev1.ContainerStatus) string {
+	if w := cs.State.Waiting; w != nil && failingReasons[w.Reason] {
+		if w.Message != "" {
+			return w.Reason + ": " + w.Message
+		}
+		return w.Reason
+	}
+	if t := cs.State.Terminated; t != nil && t.ExitCode != 0 {
+		return fmt.Sprintf("exited with code %d", t.ExitCode)
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestRolloutStatus(t *testing.T) {
+	replicas := int32(2)
+	deployment := func(status appsv1.DeploymentStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Generation: 3},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     status,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		status  appsv1.DeploymentStatus
+		done    bool
+		wantErr bool
+	}{
+		{"complete", appsv1.DeploymentStatus{ObservedGeneration: 3, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}, true, false},
+		{"not observed yet", appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}, false, false},
+		{"old pods remain", appsv1.DeploymentStatus{ObservedGeneration: 3, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2}, false, false},
+		{"new pods unavailable", appsv1.DeploymentStatus{ObservedGeneration: 3, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1}, false, false},
+		{"deadline exceeded", appsv1.DeploymentStatus{ObservedGeneration: 3, Conditions: []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"},
+		}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, err := RolloutStatus(deployment(tt.status))
+			if done != tt.done || (err != nil) != tt.wantErr {
+				t.Errorf("RolloutStatus = %v, %v; want %v, error %v", done, err, tt.done, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPodFailures(t *testing.T) {
+	waiting := func(name, reason, message string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: name, State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message},
+		}}
+	}
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-b"},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				waiting("web", "ImagePullBackOff", `Back-off pulling image "web:typo"`),
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-a"},
+			Status: corev1.PodStatus{
+				InitContainerStatuses: []corev1.ContainerStatus{{Name: "wait-for-deps", State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+				}}},
+				ContainerStatuses: []corev1.ContainerStatus{waiting("web", "PodInitializing", "")},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-c"},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				waiting("web", "CrashLoopBackOff", ""),
+			}},
+		},
+		{
+			// Healthy and still-starting pods aren't failures
+			ObjectMeta: metav1.ObjectMeta{Name: "web-d"},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "web", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				waiting("sidecar", "ContainerCreating", ""),
+			}},
+		},
+	}
+
+	want := []string{
+		"web-a: init container wait-for-deps exited with code 1",
+		`web-b: container web ImagePullBackOff: Back-off pulling image "web:typo"`,
+		"web-c: container web CrashLoopBackOff",
+	}
+	if got := PodFailures(pods); !reflect.DeepEqual(got, want) {
+		t.Errorf("PodFailures =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestWaitForJobComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/batch/v1/namespaces/demo/jobs/seed":
+			_, _ = w.Write([]byte(`{"apiVersion":"batch/v1","kind":"Job","metadata":{"name":"seed"},
+"status":{"succeeded":1,"conditions":[{"type":"Complete","status":"True"}]}}`))
+		case "/apis/batch/v1/namespaces/demo/jobs/migrate":
+			_, _ = w.Write([]byte(`{"apiVersion":"batch/v1","kind":"Job","metadata":{"name":"migrate"},
+"status":{"failed":4,"conditions":[{"type":"Failed","status":"True","reason":"BackoffLimitExceeded","message":"Job has reached the specified backoff limit"}]}}`))
+		case "/api/v1/namespaces/demo/pods":
+			if got := r.URL.Query().Get("labelSelector"); got != "job-name=migrate" {
+				t.Errorf("pods listed with selector %q, want the Job's", got)
+			}
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"PodList","items":[
+  {"metadata":{"name":"migrate-old","creationTimestamp":"2024-01-01T00:00:00Z"},"spec":{"containers":[{"name":"migrate"}]},"status":{"phase":"Failed"}},
+  {"metadata":{"name":"migrate-new","creationTimestamp":"2024-01-01T00:01:00Z"},"spec":{"containers":[{"name":"migrate"}]},"status":{"phase":"Failed"}}]}`))
+		case "/api/v1/namespaces/demo/pods/migrate-new/log":
+			if got := r.URL.Query().Get("tailLines"); got != "20" {
+				t.Errorf("logs read with tailLines=%q, want 20", got)
+			}
+			_, _ = w.Write([]byte("applying 0002_users\nERROR: relation \"users\" already exists\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`))
+		}
+	}))
+	defer server.Close()
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{clientset: clientset}
+	ctx := context.Background()
+
+	if err := c.WaitForJobComplete(ctx, "demo", "seed", time.Minute); err != nil {
+		t.Errorf("completed Job should not fail, got: %v", err)
+	}
+	if err := c.WaitForJobComplete(ctx, "demo", "expired", time.Minute); err != nil {
+		t.Errorf("a Job removed by its TTL has finished, got: %v", err)
+	}
+
+	err = c.WaitForJobComplete(ctx, "demo", "migrate", time.Minute)
+	if err == nil {
+		t.Fatal("WaitForJobComplete should fail when the Job's backoff limit is exhausted")
+	}
+	for _, want := range []string{
+		"job migrate failed: BackoffLimitExceeded: Job has reached the specified backoff limit",
+		"last logs of migrate-new",
+		`ERROR: relation "users" already exists`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// runPollInterval is how often RunJob checks the Job and its pod.
+const runPollInterval = time.Second
+
+// RunOptions configures RunJob
+type RunOptions struct {
+	// Remove deletes the Job and its pod once it has finished
+	Remove bool
+}
+
+// RunJob creates job, streams the logs of its first container to out until
+// it exits, and waits for the Job to finish. It returns an error when the Job
+// fails, including the container's exit code when known.
+func (c *Client) RunJob(ctx context.Context, job *batchv1.Job, out io.Writer, opts RunOptions) error {
+	created, err := c.clientset.BatchV1().Jobs(job.Namespace).Create(ctx, job, metav1.CreateOptions{FieldManager: FieldManager})
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	if opts.Remove {
+		defer func() {
+			// The run context may already be cancelled (Ctrl-C); still clean up
+			propagation := metav1.DeletePropagationBackground
+			_ = c.clientset.BatchV1().Jobs(created.Namespace).Delete(context.Background(), created.Name,
+				metav1.DeleteOptions{PropagationPolicy: &propagation})
+		}()
+	}
+
+	container := created.Spec.Template.Spec.Containers[0].Name
+	pod, err := c.waitForJobPod(ctx, created.Namespace, created.Name)
+	if err != nil {
+		return err
+	}
+
+	stream, err := c.GetPodLogs(ctx, created.Namespace, pod, &corev1.PodLogOptions{Container: container, Follow: true})
+	if err != nil {
+		return fmt.Errorf("failed to stream logs of %s: %w", pod, err)
+	}
+	_, _ = io.Copy(out, stream)
+	_ = stream.Close()
+
+	for {
+		current, err := c.clientset.BatchV1().Jobs(created.Namespace).Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get job %s: %w", created.Name, err)
+		}
+		if done, failed := jobFinished(current); done {
+			if !failed {
+				return nil
+			}
+			if p, err := c.GetPod(ctx, created.Namespace, pod); err == nil {
+				if code, ok := containerExitCode(p, container); ok {
+					return fmt.Errorf("job %s failed: %s exited with code %d", created.Name, container, code)
+				}
+			}
+			return fmt.Errorf("job %s failed", created.Name)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(runPollInterval):
+		}
+	}
+}
+
+// waitForJobPod waits until the Job's pod has started its main container (or
+// already finished) and returns the pod name.
+func (c *Client) waitForJobPod(ctx context.Context, namespace, jobName string) (string, error) {
+	for {
+		pods, err := c.ListPods(ctx, namespace, "job-name="+jobName)
+		if err != nil {
+			return "", fmt.Errorf("failed to list pods of job %s: %w", jobName, err)
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodPending {
+				return pod.Name, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(runPollInterval):
+		}
+	}
+}
+
+// jobFinished reports whether the Job has a Complete or Failed condition,
+// and whether it failed.
+func jobFinished(job *batchv1.Job) (done, failed bool) {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return true, false
+		case batchv1.JobFailed:
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// containerExitCode returns the exit code of the named container once it has terminated.
+func containerExitCode(pod *corev1.Pod, container string) (int32, bool) {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == container && cs.State.Terminated != nil {
+			return cs.State.Terminated.ExitCode, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestJobFinished(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []batchv1.JobCondition
+		done       bool
+		failed     bool
+	}{
+		{name: "running"},
+		{name: "complete", conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}, done: true},
+		{name: "failed", conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}, done: true, failed: true},
+		{name: "condition not true", conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionFalse}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: tt.conditions}}
+			done, failed := jobFinished(job)
+			if done != tt.done || failed != tt.failed {
+				t.Errorf("jobFinished = (%v, %v), want (%v, %v)", done, failed, tt.done, tt.failed)
+			}
+		})
+	}
+}
+
+func TestContainerExitCode(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+		{Name: "sidecar", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		{Name: "web", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 3}}},
+	}}}
+	if code, ok := containerExitCode(pod, "web"); !ok || code != 3 {
+		t.Errorf("containerExitCode(web) = (%d, %v), want (3, true)", code, ok)
+	}
+	if _, ok := containerExitCode(pod, "sidecar"); ok {
+		t.Error("a running container has no exit code")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/compose"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -18,13 +19,14 @@ type ServiceStatus struct {
 	Total  int    `json:"total"`
 }
 
-// GetServiceStatuses returns the status of all services in a project
-func (c *Client) GetServiceStatuses(ctx context.Context, project *types.Project) ([]ServiceStatus, error) {
+// GetServiceStatuses returns the status of all services in a project deployed
+// to namespace
+func (c *Client) GetServiceStatuses(ctx context.Context, namespace string, project *types.Project) ([]ServiceStatus, error) {
 	var statuses []ServiceStatus
 
 	for _, svc := range project.Services {
-		// Skip services with profiles (not activated by default)
-		if len(svc.Profiles) > 0 {
+		// Skip services with profiles (not activated by default) unless always-on
+		if !compose.IsActive(project, svc) {
 			continue
 		}
 
@@ -34,7 +36,7 @@ func (c *Client) GetServiceStatuses(ctx context.Context, project *types.Project)
 		}
 
 		// Get pods for this service
-		pods, err := c.ListPods(ctx, project.Name, fmt.Sprintf("kappal.io/service=%s", svc.Name))
+		pods, err := c.ListPods(ctx, namespace, ServiceSelector(project.Name, svc.Name))
 		if err != nil {
 			statuses = append(statuses, status)
 			continue
@@ -3,9 +3,11 @@ package kubectl
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/kappal-app/kappal/pkg/workspace"
@@ -15,12 +17,17 @@ import (
 type ApplyOpts struct {
 	AutoApprove bool
 	DryRun      bool
+	Quiet       bool // If true, discard kubectl's per-object output (errors still go to stderr)
 }
 
 // DeleteOpts configures the delete operation
 type DeleteOpts struct {
 	AutoApprove   bool
 	DeleteVolumes bool // If true, delete namespace (including PVCs). If false, only delete deployments/services.
+	// Project, when set, limits deletion to resources labeled with this kappal
+	// project and never deletes the namespace itself. Used when the namespace
+	// is shared (--namespace differs from the project name).
+	Project string
 }
 
 // DiffOpts configures the diff operation
@@ -47,19 +54,50 @@ func Apply(ctx context.Context, ws *workspace.Workspace, kubeconfigPath string,
 
 	cmd := exec.CommandContext(ctx, "kubectl", args...)
 	cmd.Stdout = os.Stdout
+	if opts.Quiet {
+		cmd.Stdout = io.Discard
+	}
 	cmd.Stderr = os.Stderr
 
 	return cmd.Run()
 }
 
+// DeleteKinds are the resource kinds Delete removes without deleting the
+// namespace. PersistentVolumeClaims are only added with DeleteVolumes.
+var DeleteKinds = []string{
+	"deployments", "services", "configmaps", "secrets", "networkpolicies", "jobs",
+	"roles", "rolebindings", "horizontalpodautoscalers", "ingresses",
+}
+
 // Delete deletes resources in the namespace
 // If DeleteVolumes is true, deletes the entire namespace (including PVCs)
-// If DeleteVolumes is false, only deletes deployments and services (preserving PVCs)
+// If DeleteVolumes is false, only deletes the DeleteKinds (preserving PVCs)
+// With Project set, only that project's labeled DeleteKinds (and PVCs with
+// DeleteVolumes) are deleted and the namespace is kept
 func Delete(ctx context.Context, namespace, kubeconfigPath string, opts DeleteOpts) error {
 	// Use a timeout to prevent kubectl from hanging on stuck finalizers
 	deleteCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
+	if opts.Project != "" {
+		resources := strings.Join(DeleteKinds, ",")
+		if opts.DeleteVolumes {
+			resources += ",persistentvolumeclaims"
+		}
+		args := []string{
+			"--kubeconfig", kubeconfigPath,
+			"delete", resources,
+			"-n", namespace,
+			"-l", "kappal.io/project=" + opts.Project,
+			"--ignore-not-found",
+			"--wait=false",
+		}
+		cmd := exec.CommandContext(deleteCtx, "kubectl", args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+
 	if opts.DeleteVolumes {
 		// Delete entire namespace including PVCs
 		args := []string{
@@ -78,7 +116,7 @@ func Delete(ctx context.Context, namespace, kubeconfigPath string, opts DeleteOp
 	// This allows volumes to persist across down/up cycles
 	args := []string{
 		"--kubeconfig", kubeconfigPath,
-		"delete", "deployments,services,configmaps,secrets,networkpolicies,jobs,roles,rolebindings",
+		"delete", strings.Join(DeleteKinds, ","),
 		"-n", namespace,
 		"--all",
 		"--ignore-not-found",
@@ -112,7 +150,7 @@ func Diff(ctx context.Context, ws *workspace.Workspace, kubeconfigPath string, o
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
 			return string(output), nil
 		}
-		return "", fmt.Errorf("diff failed: %w", err)
+		return "", fmt.Errorf("diff failed: %w: %s", err, strings.TrimSpace(string(output)))
 	}
 
 	return string(output), nil
@@ -39,11 +39,19 @@ func Run(ctx context.Context) error {
 		return fmt.Errorf("docker is not running: %w", err)
 	}
 	defer func() { _ = dockerClient.Close() }()
+	if err := dockerClient.Ping(ctx); err != nil {
+		fmt.Println("FAILED")
+		return err
+	}
 	fmt.Println("OK")
 
-	// 2. Pull K3s image
-	fmt.Printf("Pulling K3s image (%s)... ", k3s.K3sImage)
-	if err := dockerClient.ImagePull(ctx, k3s.K3sImage); err != nil {
+	// 2. Pull K3s image (KAPPAL_K3S_IMAGE or the pinned default)
+	k3sImage, err := k3s.ImageFromEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pulling K3s image (%s)... ", k3sImage)
+	if err := dockerClient.ImagePull(ctx, k3sImage, ""); err != nil {
 		fmt.Println("FAILED")
 		return fmt.Errorf("failed to pull K3s image: %w", err)
 	}
@@ -57,7 +65,7 @@ func Run(ctx context.Context) error {
 	// 4. Write metadata
 	metadata := Metadata{
 		Version:  "1.0.0",
-		K3sImage: k3s.K3sImage,
+		K3sImage: k3sImage,
 		SetupAt:  time.Now(),
 	}
 	data, err := json.MarshalIndent(metadata, "", "  ")
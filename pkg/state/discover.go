@@ -14,7 +14,8 @@ import (
 
 // DiscoverOpts controls what data Discover fetches.
 type DiscoverOpts struct {
-	QueryK8s bool // if true, also queries K8s API for service/pod state
+	QueryK8s  bool   // if true, also queries K8s API for service/pod state
+	Namespace string // K8s namespace to query; defaults to the project name
 }
 
 // sanitizeDockerName replicates the sanitize logic from k3s.Manager so
@@ -38,13 +39,18 @@ func Discover(ctx context.Context, projectName string, workspaceDir string, opts
 	defer func() { _ = dockerClient.Close() }()
 
 	st := &State{
-		Project:  projectName,
-		PortMap:  make(map[string]int),
-		Services: make(map[string]*ServiceInfo),
+		Project:     projectName,
+		Namespace:   opts.Namespace,
+		PortMap:     make(map[string]int),
+		PortHostIPs: make(map[string]string),
+		Services:    make(map[string]*ServiceInfo),
 		K3s: K3sInfo{
 			Status: "not found",
 		},
 	}
+	if st.Namespace == "" {
+		st.Namespace = projectName
+	}
 
 	// 1. Find K3s container by labels (project + role)
 	containers, err := dockerClient.ContainerListByLabels(ctx, map[string]string{
@@ -121,7 +127,9 @@ func Discover(ctx context.Context, projectName string, workspaceDir string, opts
 		}
 		if len(bindings) > 0 {
 			if hp, err := strconv.Atoi(bindings[0].HostPort); err == nil {
-				st.PortMap[fmt.Sprintf("%d/%s", containerPort, proto)] = hp
+				key := fmt.Sprintf("%d/%s", containerPort, proto)
+				st.PortMap[key] = hp
+				st.PortHostIPs[key] = bindings[0].HostIP
 			}
 		}
 	}
@@ -156,24 +164,31 @@ func queryK8sState(ctx context.Context, st *State) bool {
 		return false
 	}
 
+	// A namespace stuck Terminating blocks the next 'up'; record it even if
+	// the workload queries below fail
+	if nsStatus, err := k8sClient.GetNamespaceStatus(ctx, st.Namespace); err == nil {
+		st.NamespacePhase = nsStatus.Phase
+		st.NamespaceConditions = nsStatus.Conditions
+	}
+
 	labelSelector := fmt.Sprintf("kappal.io/project=%s", st.Project)
 
-	deployments, err := k8sClient.ListDeployments(ctx, st.Project, labelSelector)
+	deployments, err := k8sClient.ListDeployments(ctx, st.Namespace, labelSelector)
 	if err != nil {
 		return false
 	}
 
-	jobs, err := k8sClient.ListJobs(ctx, st.Project, labelSelector)
+	jobs, err := k8sClient.ListJobs(ctx, st.Namespace, labelSelector)
 	if err != nil {
 		return false
 	}
 
-	k8sServices, err := k8sClient.ListServices(ctx, st.Project, labelSelector)
+	k8sServices, err := k8sClient.ListServices(ctx, st.Namespace, labelSelector)
 	if err != nil {
 		return false
 	}
 
-	pods, err := k8sClient.ListPods(ctx, st.Project, labelSelector)
+	pods, err := k8sClient.ListPods(ctx, st.Namespace, labelSelector)
 	if err != nil {
 		return false
 	}
@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kappal-app/kappal/pkg/compose"
 )
 
 // MergeCompose combines discovered live state with compose file definitions.
@@ -22,13 +23,13 @@ func MergeCompose(discovered *State, project *types.Project) []ServiceInfo {
 	for _, name := range serviceNames {
 		composeSvc := project.Services[name]
 
-		// Skip services with profiles (not activated by default)
-		if len(composeSvc.Profiles) > 0 {
+		// Skip services with profiles (not activated by default) unless always-on
+		if !compose.IsActive(project, composeSvc) {
 			continue
 		}
 
 		composeKind := "Deployment"
-		if composeSvc.Restart == "no" {
+		if compose.IsOneShot(composeSvc) {
 			composeKind = "Job"
 		}
 
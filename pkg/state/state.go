@@ -3,10 +3,16 @@ package state
 // State holds the discovered runtime state of a kappal project.
 type State struct {
 	Project      string
+	Namespace    string // K8s namespace holding the project's resources
 	K3s          K3sInfo
-	PortMap      map[string]int // "containerPort/proto" → hostPort (e.g. "80/tcp" → 8080)
+	PortMap      map[string]int    // "containerPort/proto" → hostPort (e.g. "80/tcp" → 8080)
+	PortHostIPs  map[string]string // "containerPort/proto" → host IP the port is bound on (e.g. "0.0.0.0")
 	Services     map[string]*ServiceInfo
 	K8sAvailable bool
+	// NamespacePhase is "Active", "Terminating" or "NotFound" ("" if K8s
+	// wasn't queried); NamespaceConditions explain a stuck deletion.
+	NamespacePhase      string
+	NamespaceConditions []string
 	Kubeconfig   string // path to working kubeconfig
 }
 
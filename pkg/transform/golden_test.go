@@ -0,0 +1,121 @@
+package transform
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+)
+
+// emitGolden rewrites the golden manifests instead of comparing against them:
+//
+//	go test ./pkg/transform -run TestGoldenManifests -emit-golden
+var emitGolden = flag.Bool("emit-golden", false, "rewrite testdata/golden/*.yaml from the current output")
+
+// TestGoldenManifests renders the conformance projects under the repo's
+// testdata/ and compares the full all.yaml with testdata/golden/<name>.yaml,
+// catching structural and ordering changes that substring checks miss.
+func TestGoldenManifests(t *testing.T) {
+	projects := []string{"config", "jobs", "network", "networks", "override", "scaling", "secret", "simple", "udp", "volume"}
+	for _, name := range projects {
+		t.Run(name, func(t *testing.T) {
+			dir := checkoutCopy(t, filepath.Join("..", "..", "testdata", name))
+			paths := []string{filepath.Join(dir, "docker-compose.yaml")}
+			if override := filepath.Join(dir, "docker-compose.override.yaml"); fileExists(override) {
+				paths = append(paths, override)
+			}
+			project, err := compose.Load(paths, name)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			got, err := NewTransformer(project).RenderManifests()
+			if err != nil {
+				t.Fatalf("RenderManifests failed: %v", err)
+			}
+			again, err := NewTransformer(project).RenderManifests()
+			if err != nil {
+				t.Fatalf("RenderManifests failed: %v", err)
+			}
+			if !bytes.Equal(got, again) {
+				t.Fatal("RenderManifests output is not deterministic")
+			}
+
+			golden := filepath.Join("testdata", "golden", name+".yaml")
+			if *emitGolden {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -emit-golden to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("rendered manifests differ from %s (run with -emit-golden to update after checking the change):\n%s", golden, lineDiff(string(want), string(got)))
+			}
+		})
+	}
+}
+
+// checkoutCopy copies a conformance project into a temp dir with the 0644
+// mode git checks files out with, so file-sourced secret modes in the
+// golden output don't depend on the local umask.
+func checkoutCopy(t *testing.T, dir string) string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(t.TempDir(), filepath.Base(dir))
+	if err := os.Mkdir(tmp, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(tmp, e.Name())
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmp
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// lineDiff describes the first line where two manifests differ.
+func lineDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %q\n  got:  %q", i+1, w, g)
+		}
+	}
+	return ""
+}
@@ -136,9 +136,13 @@ const KappalLibsonnet = `
             if std.length($.get(svc, 'networks', [])) > 0
             then { 'kappal.io/network': svc.networks[0] }
             else {}
-          ),
+          ) + {
+            ['kappal.io/network.' + network]: 'true'
+            for network in $.get(svc, 'networks', [])
+          },
         },
         spec: {
+          restartPolicy: 'Always',
           containers: [{
             name: serviceName,
             image: svc.image,
@@ -192,13 +196,13 @@ const KappalLibsonnet = `
       },
     },
     spec: {
-      accessModes: ['ReadWriteOnce'],
+      accessModes: [$.get(volumeSpec, 'access_mode', 'ReadWriteOnce')],
       resources: {
         requests: {
-          storage: '1Gi',
+          storage: $.get(volumeSpec, 'size', '1Gi'),
         },
       },
-      storageClassName: 'local-path',
+      storageClassName: $.get(volumeSpec, 'storage_class', 'local-path'),
     },
   },
 
@@ -245,21 +249,63 @@ const KappalLibsonnet = `
       },
     },
     spec: {
+      // Pods also on the default network stay reachable, as in compose
       podSelector: {
         matchLabels: {
-          'kappal.io/network': networkName,
+          ['kappal.io/network.' + networkName]: 'true',
         },
+        matchExpressions: [
+          { key: 'kappal.io/network.default', operator: 'DoesNotExist' },
+        ],
       },
-      policyTypes: ['Ingress'],
+      policyTypes: ['Ingress', 'Egress'],
       ingress: [{
         from: [{
           podSelector: {
             matchLabels: {
-              'kappal.io/network': networkName,
+              ['kappal.io/network.' + networkName]: 'true',
             },
           },
         }],
       }],
+      // Same-network pods, cluster DNS and anything outside the cluster
+      egress: [
+        {
+          to: [{
+            podSelector: {
+              matchLabels: {
+                ['kappal.io/network.' + networkName]: 'true',
+              },
+            },
+          }],
+        },
+        {
+          to: [{
+            namespaceSelector: {
+              matchLabels: {
+                'kubernetes.io/metadata.name': 'kube-system',
+              },
+            },
+            podSelector: {
+              matchLabels: {
+                'k8s-app': 'kube-dns',
+              },
+            },
+          }],
+          ports: [
+            { protocol: 'UDP', port: 53 },
+            { protocol: 'TCP', port: 53 },
+          ],
+        },
+        {
+          to: [{
+            ipBlock: {
+              cidr: '0.0.0.0/0',
+              except: ['10.42.0.0/16'],
+            },
+          }],
+        },
+      ],
     },
   },
 
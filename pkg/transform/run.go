@@ -0,0 +1,124 @@
+package transform
+
+import (
+	"fmt"
+	"path"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RunLabel marks the pods of a 'kappal run' Job with the service they were
+// started from. Run pods do not carry kappal.io/service, so the service's
+// K8s Service, exec, logs and ps never pick them up.
+const RunLabel = "kappal.io/run"
+
+// RunOverrides are the per-invocation overrides accepted by 'kappal run',
+// mirroring `docker compose run -w/-u/--entrypoint`. Empty fields keep the
+// service's own value.
+type RunOverrides struct {
+	WorkingDir string
+	User       string
+	Entrypoint []string
+	// Command replaces the service command when set
+	Command []string
+	// Environment is merged over the service environment by name
+	Environment []EnvSpec
+	// NoDeps skips waiting for the service's depends_on targets
+	NoDeps bool
+}
+
+// ApplyRunOverrides returns a copy of svc with the overrides applied.
+// The working directory must be absolute and the user numeric, since the
+// result has to be expressible in a K8s container spec.
+func ApplyRunOverrides(svc ServiceSpec, overrides RunOverrides) (ServiceSpec, error) {
+	if overrides.WorkingDir != "" {
+		if !path.IsAbs(overrides.WorkingDir) {
+			return svc, fmt.Errorf("working directory %q must be an absolute path", overrides.WorkingDir)
+		}
+		svc.WorkingDir = overrides.WorkingDir
+	}
+	if overrides.User != "" {
+		if _, _, err := ParseUser(overrides.User); err != nil {
+			return svc, err
+		}
+		svc.User = overrides.User
+	}
+	if len(overrides.Entrypoint) > 0 {
+		svc.Entrypoint = overrides.Entrypoint
+	}
+	if len(overrides.Command) > 0 {
+		svc.Command = overrides.Command
+	}
+	if len(overrides.Environment) > 0 {
+		svc.Environment = mergeEnvironment(svc.Environment, overrides.Environment)
+	}
+	if overrides.NoDeps {
+		svc.DependsOn = nil
+	}
+	return svc, nil
+}
+
+// mergeEnvironment returns base with each override replacing the variable of
+// the same name, or appended when base has none. base is not modified.
+func mergeEnvironment(base, overrides []EnvSpec) []EnvSpec {
+	merged := append([]EnvSpec(nil), base...)
+	for _, o := range overrides {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == o.Name {
+				merged[i].Value = o.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+// RunPodTemplate builds the pod template for a one-off run of serviceName
+// with overrides applied on top of its ServiceSpec. The pod never restarts.
+func (t *Transformer) RunPodTemplate(projectName, serviceName string, allServices map[string]ServiceSpec, overrides RunOverrides) (corev1.PodTemplateSpec, error) {
+	svc, ok := allServices[serviceName]
+	if !ok {
+		return corev1.PodTemplateSpec{}, fmt.Errorf("service %q not found in compose file", serviceName)
+	}
+	svc, err := ApplyRunOverrides(svc, overrides)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, fmt.Errorf("invalid override for service %q: %w", serviceName, err)
+	}
+
+	template := t.buildPodTemplate(projectName, serviceName, svc, allServices)
+	template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	return template, nil
+}
+
+// RunJob builds the one-shot Job for 'kappal run'. The name is generated by
+// the API server from "<service>-run-", the Job is never retried, and its pod
+// is labelled with RunLabel instead of kappal.io/service.
+func (t *Transformer) RunJob(projectName, serviceName string, allServices map[string]ServiceSpec, overrides RunOverrides) (*batchv1.Job, error) {
+	template, err := t.RunPodTemplate(projectName, serviceName, allServices, overrides)
+	if err != nil {
+		return nil, err
+	}
+	delete(template.Labels, "kappal.io/service")
+	template.Labels[RunLabel] = serviceName
+
+	labels := projectLabels(projectName)
+	labels[RunLabel] = serviceName
+	meta := objectMeta("", t.namespaceFor(projectName), labels)
+	meta.GenerateName = sanitizeName(serviceName) + "-run-"
+
+	backoffLimit := int32(0)
+	return &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: meta,
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     template,
+		},
+	}, nil
+}
@@ -0,0 +1,196 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kappal-app/kappal/pkg/compose"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRunPodTemplateOverrides(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+	services := map[string]ServiceSpec{
+		"web": {
+			Image:      "app:latest",
+			Entrypoint: []string{"/docker-entrypoint.sh"},
+			Command:    []string{"serve"},
+			WorkingDir: "/app",
+			User:       "1000",
+		},
+	}
+	container := func(t *testing.T, overrides RunOverrides) corev1.Container {
+		t.Helper()
+		template, err := transformer.RunPodTemplate("test", "web", services, overrides)
+		if err != nil {
+			t.Fatalf("RunPodTemplate failed: %v", err)
+		}
+		if template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+			t.Errorf("restartPolicy = %q, want Never", template.Spec.RestartPolicy)
+		}
+		return template.Spec.Containers[0]
+	}
+
+	t.Run("no overrides keeps the service spec", func(t *testing.T) {
+		c := container(t, RunOverrides{})
+		if c.WorkingDir != "/app" {
+			t.Errorf("workingDir = %q, want /app", c.WorkingDir)
+		}
+		if c.SecurityContext == nil || *c.SecurityContext.RunAsUser != 1000 || c.SecurityContext.RunAsGroup != nil {
+			t.Errorf("expected runAsUser 1000 without group, got %+v", c.SecurityContext)
+		}
+		if !reflect.DeepEqual(c.Command, []string{"/docker-entrypoint.sh"}) {
+			t.Errorf("command = %v, want service entrypoint", c.Command)
+		}
+	})
+
+	t.Run("working dir", func(t *testing.T) {
+		if c := container(t, RunOverrides{WorkingDir: "/srv"}); c.WorkingDir != "/srv" {
+			t.Errorf("workingDir = %q, want /srv", c.WorkingDir)
+		}
+	})
+
+	t.Run("user", func(t *testing.T) {
+		c := container(t, RunOverrides{User: "0:0"})
+		if c.SecurityContext == nil || *c.SecurityContext.RunAsUser != 0 || c.SecurityContext.RunAsGroup == nil || *c.SecurityContext.RunAsGroup != 0 {
+			t.Errorf("expected runAsUser 0 and runAsGroup 0, got %+v", c.SecurityContext)
+		}
+	})
+
+	t.Run("entrypoint keeps command", func(t *testing.T) {
+		c := container(t, RunOverrides{Entrypoint: []string{"sh", "-c"}})
+		if !reflect.DeepEqual(c.Command, []string{"sh", "-c"}) {
+			t.Errorf("command = %v, want [sh -c]", c.Command)
+		}
+		if !reflect.DeepEqual(c.Args, []string{"serve"}) {
+			t.Errorf("args = %v, want the service command", c.Args)
+		}
+	})
+
+	t.Run("base service is not modified", func(t *testing.T) {
+		container(t, RunOverrides{WorkingDir: "/srv", User: "0", Entrypoint: []string{"sh"}})
+		if services["web"].WorkingDir != "/app" || services["web"].User != "1000" || services["web"].Entrypoint[0] != "/docker-entrypoint.sh" {
+			t.Errorf("overrides leaked into the base service: %+v", services["web"])
+		}
+	})
+}
+
+func TestRunPodTemplateValidation(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+	services := map[string]ServiceSpec{"web": {Image: "app:latest"}}
+
+	tests := []struct {
+		name      string
+		service   string
+		overrides RunOverrides
+	}{
+		{name: "unknown service", service: "missing"},
+		{name: "relative working dir", service: "web", overrides: RunOverrides{WorkingDir: "src"}},
+		{name: "named user", service: "web", overrides: RunOverrides{User: "postgres"}},
+		{name: "named group", service: "web", overrides: RunOverrides{User: "1000:staff"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := transformer.RunPodTemplate("test", tt.service, services, tt.overrides); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+	services := map[string]ServiceSpec{
+		"db": {Image: "postgres:16", HealthCheck: &HealthCheckSpec{Test: []string{"CMD", "true"}}},
+		"web": {
+			Image:       "app:latest",
+			Command:     []string{"serve"},
+			Environment: []EnvSpec{{Name: "MODE", Value: "prod"}, {Name: "PORT", Value: "80"}},
+			DependsOn:   []DependsOnSpec{{Service: "db", Condition: "service_healthy"}},
+		},
+	}
+
+	job, err := transformer.RunJob("test", "web", services, RunOverrides{
+		Command:     []string{"rake", "db:migrate"},
+		Environment: []EnvSpec{{Name: "MODE", Value: "dev"}, {Name: "DEBUG", Value: "1"}},
+	})
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	if job.Name != "" || job.GenerateName != "web-run-" {
+		t.Errorf("name = %q, generateName = %q, want a generated web-run- name", job.Name, job.GenerateName)
+	}
+	if job.Spec.BackoffLimit == nil || *job.Spec.BackoffLimit != 0 {
+		t.Errorf("backoffLimit = %v, want 0", job.Spec.BackoffLimit)
+	}
+	labels := job.Spec.Template.Labels
+	if _, ok := labels["kappal.io/service"]; ok {
+		t.Errorf("run pod must not carry the service label: %v", labels)
+	}
+	if labels[RunLabel] != "web" || labels["kappal.io/project"] != "test" {
+		t.Errorf("pod labels = %v, want run=web and project=test", labels)
+	}
+
+	c := job.Spec.Template.Spec.Containers[0]
+	if !reflect.DeepEqual(c.Args, []string{"rake", "db:migrate"}) {
+		t.Errorf("args = %v, want the run command", c.Args)
+	}
+	wantEnv := []corev1.EnvVar{{Name: "MODE", Value: "dev"}, {Name: "PORT", Value: "80"}, {Name: "DEBUG", Value: "1"}}
+	if !reflect.DeepEqual(c.Env, wantEnv) {
+		t.Errorf("env = %v, want %v", c.Env, wantEnv)
+	}
+	if len(job.Spec.Template.Spec.InitContainers) != 1 {
+		t.Errorf("expected the depends_on init container without --no-deps")
+	}
+	if services["web"].Environment[0].Value != "prod" {
+		t.Errorf("env override leaked into the base service: %+v", services["web"].Environment)
+	}
+
+	job, err = transformer.RunJob("test", "web", services, RunOverrides{NoDeps: true})
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	if len(job.Spec.Template.Spec.InitContainers) != 0 {
+		t.Errorf("--no-deps should drop the dependency wait, got %+v", job.Spec.Template.Spec.InitContainers)
+	}
+	if !reflect.DeepEqual(job.Spec.Template.Spec.Containers[0].Args, []string{"serve"}) {
+		t.Errorf("without a command the service command should run")
+	}
+}
+
+func TestComposeWorkingDirAndUser(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`
+services:
+  app:
+    image: app:latest
+    working_dir: /app
+    user: "1000:2000"
+  db:
+    image: postgres
+    user: postgres
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	c := transformer.generateDeployment("test", "app", spec.Services["app"], spec.Services).Spec.Template.Spec.Containers[0]
+	if c.WorkingDir != "/app" || c.SecurityContext == nil || *c.SecurityContext.RunAsUser != 1000 || *c.SecurityContext.RunAsGroup != 2000 {
+		t.Errorf("compose working_dir/user not applied: workingDir %q securityContext %+v", c.WorkingDir, c.SecurityContext)
+	}
+	// Named users can't be resolved by K8s; the image default user runs
+	db := transformer.generateDeployment("test", "db", spec.Services["db"], spec.Services).Spec.Template.Spec.Containers[0]
+	if db.SecurityContext != nil {
+		t.Errorf("named user should be ignored, got securityContext %+v", db.SecurityContext)
+	}
+
+	job, err := transformer.RunJob("test", "app", spec.Services, RunOverrides{WorkingDir: "/srv", User: "0"})
+	if err != nil {
+		t.Fatalf("RunJob failed: %v", err)
+	}
+	c = job.Spec.Template.Spec.Containers[0]
+	if c.WorkingDir != "/srv" || c.SecurityContext == nil || *c.SecurityContext.RunAsUser != 0 {
+		t.Errorf("run overrides not applied: workingDir %q securityContext %+v", c.WorkingDir, c.SecurityContext)
+	}
+}
@@ -1,17 +1,40 @@
 package transform
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/compose-spec/compose-go/v2/template"
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/docker"
 	"github.com/kappal-app/kappal/pkg/workspace"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
 )
 
 // sanitizeName converts a name to be valid for Kubernetes resources
@@ -31,20 +54,136 @@ func sanitizeName(name string) string {
 	return name
 }
 
+// sortedKeys returns the keys of a string-keyed map in ascending order.
+// Used wherever map iteration would otherwise make generated output
+// non-deterministic across runs.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Transformer converts a Compose project to Kubernetes manifests
 type Transformer struct {
 	project    *types.Project
 	workingDir string
+	namespace  string // K8s namespace override; empty means the project name
+	hostDir    string // host path of wrapperProjectDir in Docker wrapper mode
+	noStart    bool   // create workloads without starting them (up --no-start)
+	// extraLabels are added to every generated resource and pod (up --label)
+	extraLabels map[string]string
+	// registryAuth holds pull credentials per registry host (up --registry-auth)
+	registryAuth map[string]registry.AuthConfig
+	// progressDeadline is the Deployments' progressDeadlineSeconds (up --progress-deadline)
+	progressDeadline int32
 }
 
+// DefaultProgressDeadlineSeconds is how long a Deployment rollout may go
+// without progress (e.g. pods in CrashLoopBackOff) before Kubernetes marks
+// it failed with ProgressDeadlineExceeded.
+const DefaultProgressDeadlineSeconds int32 = 600
+
+// wrapperProjectDir is where the Docker wrapper mounts the project root
+// (-v "<host-root>:/project"); KAPPAL_HOST_DIR is the host side of that mount.
+const wrapperProjectDir = "/project"
+
 // NewTransformer creates a new transformer for the given project
 func NewTransformer(project *types.Project) *Transformer {
 	return &Transformer{
-		project:    project,
-		workingDir: project.WorkingDir,
+		project:          project,
+		workingDir:       project.WorkingDir,
+		progressDeadline: DefaultProgressDeadlineSeconds,
 	}
 }
 
+// SetNamespace overrides the K8s namespace resources are generated into.
+// Labels keep using the project name, so several projects can share a namespace.
+func (t *Transformer) SetNamespace(namespace string) {
+	t.namespace = namespace
+}
+
+// SetHostDir sets the host path of the project root when kappal runs inside
+// the Docker wrapper (KAPPAL_HOST_DIR), so bind mount sources under
+// /project are rewritten to paths that exist on the Docker host.
+func (t *Transformer) SetHostDir(hostDir string) {
+	t.hostDir = hostDir
+}
+
+// SetNoStart makes generated workloads start stopped: Deployments get zero
+// replicas and Jobs are suspended. Used by 'kappal create' / 'up --no-start'.
+func (t *Transformer) SetNoStart(noStart bool) {
+	t.noStart = noStart
+}
+
+// SetExtraLabels adds labels (e.g. for cost attribution) to every generated
+// resource and pod template, alongside kappal.io/project. They never replace
+// kappal's own labels, so selectors keep working; a shared namespace (see
+// SetNamespace) isn't labeled.
+func (t *Transformer) SetExtraLabels(labels map[string]string) {
+	t.extraLabels = labels
+}
+
+// SetRegistryAuth sets the credentials, keyed by registry host (see
+// docker.RegistryHost), for pulling services' images from private
+// registries. They are rendered as the RegistryAuthSecret imagePullSecret.
+func (t *Transformer) SetRegistryAuth(auths map[string]registry.AuthConfig) {
+	t.registryAuth = auths
+}
+
+// SetProgressDeadline sets the progressDeadlineSeconds of generated
+// Deployments: after that long without rollout progress, 'up' reports the
+// service as failed instead of waiting out its whole --timeout.
+func (t *Transformer) SetProgressDeadline(seconds int32) {
+	t.progressDeadline = seconds
+}
+
+// DeploymentReplicas returns the replica count each Deployment runs with
+// when started, keyed by Deployment name.
+func (t *Transformer) DeploymentReplicas() map[string]int32 {
+	replicas := map[string]int32{}
+	for name, svc := range t.ToSpec().Services {
+		if !svc.IsJob {
+			replicas[name] = deploymentReplicas(svc)
+		}
+	}
+	return replicas
+}
+
+// deploymentReplicas returns deploy.replicas, at least 1.
+func deploymentReplicas(svc ServiceSpec) int32 {
+	if svc.Replicas < 1 {
+		return 1
+	}
+	return int32(svc.Replicas)
+}
+
+// resolveBindSource returns an absolute hostPath for a bind mount source.
+// Relative sources are resolved against the compose working directory.
+func (t *Transformer) resolveBindSource(source string) string {
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(t.workingDir, source)
+	}
+	source = filepath.Clean(source)
+	if t.hostDir != "" {
+		rel, err := filepath.Rel(wrapperProjectDir, source)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return filepath.Join(t.hostDir, rel)
+		}
+	}
+	return source
+}
+
+// namespaceFor returns the namespace for a project's resources.
+func (t *Transformer) namespaceFor(projectName string) string {
+	if t.namespace != "" {
+		return t.namespace
+	}
+	return projectName
+}
+
 // ComposeSpec is the simplified compose spec for Jsonnet
 type ComposeSpec struct {
 	Name     string                 `json:"name"`
@@ -74,15 +213,20 @@ func GetInitImage() string {
 
 // ServiceSpec represents a compose service
 type ServiceSpec struct {
-	Image       string            `json:"image,omitempty"`
-	Build       *BuildSpec        `json:"build,omitempty"`
-	Ports       []PortSpec        `json:"ports,omitempty"`
+	Image string     `json:"image,omitempty"`
+	Build *BuildSpec `json:"build,omitempty"`
+	Ports []PortSpec `json:"ports,omitempty"`
+	// Expose are the compose expose ports: Service ports reachable by
+	// other services but not published to the host.
+	Expose      []PortSpec        `json:"expose,omitempty"`
 	Environment []EnvSpec         `json:"environment,omitempty"`
 	Volumes     []VolumeMount     `json:"volumes,omitempty"`
 	Networks    []string          `json:"networks,omitempty"`
 	DependsOn   []DependsOnSpec   `json:"depends_on,omitempty"`
 	Command     []string          `json:"command,omitempty"`
 	Entrypoint  []string          `json:"entrypoint,omitempty"`
+	WorkingDir  string            `json:"working_dir,omitempty"`
+	User        string            `json:"user,omitempty"`
 	Replicas    int               `json:"replicas,omitempty"`
 	Secrets     []SecretRef       `json:"secrets,omitempty"`
 	Configs     []ConfigRef       `json:"configs,omitempty"`
@@ -90,14 +234,77 @@ type ServiceSpec struct {
 	Labels      map[string]string `json:"labels,omitempty"`
 	Restart     string            `json:"restart,omitempty"`
 	IsJob       bool              `json:"is_job,omitempty"`
+	GroupAdd    []string          `json:"group_add,omitempty"`
+	// RestartRetries is N from restart: on-failure:N; the Job restarts the
+	// failed container in place up to N times instead of using new pods.
+	RestartRetries *int32 `json:"restart_retries,omitempty"`
+	// FSGroup is the pod fsGroup for services with volumes, from the
+	// kappal.io/fs-group label, the compose user gid or the first numeric
+	// group_add entry. Nil means DefaultFSGroup.
+	FSGroup *int64 `json:"fs_group,omitempty"`
+	// Limits and Reservations are the CPU and memory of deploy.resources;
+	// see buildResources for how they become requests and limits.
+	Limits       *ResourceSpec `json:"limits,omitempty"`
+	Reservations *ResourceSpec `json:"reservations,omitempty"`
+	// GenericResources maps deploy.resources.reservations.generic_resources
+	// kinds to their counts, emitted as K8s extended resources.
+	GenericResources map[string]int64 `json:"generic_resources,omitempty"`
+	// MemSwapLimit is the compose memswap_limit in bytes (-1 = unlimited).
+	// It is captured for the spec only: K8s has no per-container swap limit.
+	MemSwapLimit int64 `json:"memswap_limit,omitempty"`
+	// HostNetwork is set for network_mode: host; the pod shares the K3s
+	// node's network namespace and gets no Service.
+	HostNetwork bool `json:"host_network,omitempty"`
+	// NetworkNone is set for network_mode: none; the pod gets no Service and
+	// joins no network's NetworkPolicy. K8s always gives pods an interface,
+	// so unlike Docker it is not fully cut off.
+	NetworkNone bool `json:"network_none,omitempty"`
+	// ExtraHosts are the compose extra_hosts grouped by IP, emitted as pod
+	// hostAliases.
+	ExtraHosts []HostAliasSpec `json:"extra_hosts,omitempty"`
+	// DNS and DNSSearch are the compose dns and dns_search. Custom servers
+	// replace cluster DNS (dnsPolicy: None); search domains alone are added
+	// to it.
+	DNS       []string `json:"dns,omitempty"`
+	DNSSearch []string `json:"dns_search,omitempty"`
+	// NodeSelector comes from the x-kappal-node-selector service extension.
+	NodeSelector map[string]string `json:"node_selector,omitempty"`
+	// Sysctls are the compose sysctls that can be set per pod (namespaced
+	// ones); node-level sysctls such as vm.max_map_count are dropped.
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+	// StdinOpen and TTY are the compose stdin_open and tty; together they
+	// let 'kappal attach' interact with the container's main process.
+	StdinOpen bool `json:"stdin_open,omitempty"`
+	TTY       bool `json:"tty,omitempty"`
+}
+
+// NodeSelectorExtension is the compose service extension (a map of node
+// label to value) rendered as the pod's nodeSelector.
+const NodeSelectorExtension = "x-kappal-node-selector"
+
+// HostAliasSpec maps hostnames to an IP in the pod's /etc/hosts.
+type HostAliasSpec struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+}
+
+// ResourceSpec is a CPU and memory amount from deploy.resources; zero
+// means unset.
+type ResourceSpec struct {
+	CPUs        float64 `json:"cpus,omitempty"`
+	MemoryBytes int64   `json:"memory,omitempty"`
 }
 
 type BuildSpec struct {
 	Context    string `json:"context,omitempty"`
 	Dockerfile string `json:"dockerfile,omitempty"`
+	// Platform is the compose service platform (e.g. linux/amd64) the image
+	// is built for; 'build --platform' overrides it.
+	Platform string `json:"platform,omitempty"`
 }
 
 type PortSpec struct {
+	HostIP    string `json:"host_ip,omitempty"` // e.g. "127.0.0.1" from "127.0.0.1:8080:80"
 	Target    uint32 `json:"target"`
 	Published uint32 `json:"published"`
 	Protocol  string `json:"protocol,omitempty"`
@@ -116,7 +323,55 @@ type VolumeMount struct {
 }
 
 type VolumeSpec struct {
-	Driver string `json:"driver,omitempty"`
+	Driver       string `json:"driver,omitempty"`
+	Size         string `json:"size,omitempty"`
+	StorageClass string `json:"storage_class,omitempty"`
+	AccessMode   string `json:"access_mode,omitempty"`
+}
+
+// VolumeSizeLabel, StorageClassLabel and AccessModeLabel are compose volume
+// labels that set the size, storage class and access mode of the volume's PVC.
+const (
+	VolumeSizeLabel   = "kappal.io/volume-size"
+	StorageClassLabel = "kappal.io/storage-class"
+	AccessModeLabel   = "kappal.io/access-mode"
+)
+
+// DefaultVolumeSize, DefaultStorageClass and DefaultAccessMode apply to
+// named volumes without the labels above. local-path is K3s's built-in
+// provisioner; it only supports ReadWriteOnce, so ReadWriteMany volumes need
+// a storage class that supports it (e.g. NFS or Longhorn).
+const (
+	DefaultVolumeSize   = "1Gi"
+	DefaultStorageClass = "local-path"
+	DefaultAccessMode   = string(corev1.ReadWriteOnce)
+)
+
+// mountedReadOnly reports whether every service mounting the named volume
+// mounts it read-only (and at least one does).
+func (t *Transformer) mountedReadOnly(volume string) bool {
+	mounted := false
+	for _, svc := range t.project.Services {
+		for _, v := range svc.Volumes {
+			if v.Type != types.VolumeTypeVolume || v.Source != volume {
+				continue
+			}
+			if !v.ReadOnly {
+				return false
+			}
+			mounted = true
+		}
+	}
+	return mounted
+}
+
+// isValidAccessMode reports whether mode is a PVC access mode K8s accepts.
+func isValidAccessMode(mode corev1.PersistentVolumeAccessMode) bool {
+	switch mode {
+	case corev1.ReadWriteOnce, corev1.ReadWriteMany, corev1.ReadOnlyMany, corev1.ReadWriteOncePod:
+		return true
+	}
+	return false
 }
 
 type NetworkSpec struct {
@@ -126,20 +381,68 @@ type NetworkSpec struct {
 type SecretSpec struct {
 	File        string `json:"file,omitempty"`
 	Environment string `json:"environment,omitempty"`
+	// Interpolate is set by the kappal.io/interpolate label
+	Interpolate bool `json:"interpolate,omitempty"`
 }
 
 type SecretRef struct {
-	Source string `json:"source"`
-	Target string `json:"target,omitempty"`
+	Source string  `json:"source"`
+	Target string  `json:"target,omitempty"`
+	UID    string  `json:"uid,omitempty"`
+	GID    string  `json:"gid,omitempty"`
+	Mode   *uint32 `json:"mode,omitempty"`
+	// Keys are the files of a directory-backed secret, mounted together as
+	// a directory at the target; empty for a single-file secret
+	Keys []string `json:"keys,omitempty"`
 }
 
 type ConfigSpec struct {
 	File string `json:"file,omitempty"`
+	// Interpolate is set by the kappal.io/interpolate label
+	Interpolate bool `json:"interpolate,omitempty"`
+}
+
+// InterpolateLabel is a compose secret/config label; "true" substitutes
+// ${VAR} references in the file's content from the compose environment
+// (shell and .env, with compose's ${VAR:-default} syntax) before it's stored
+// in the Secret/ConfigMap. It's opt-in so files with a literal $ are left
+// alone; with it, write $$ for a literal $.
+const InterpolateLabel = "kappal.io/interpolate"
+
+// Interpolate reports whether a secret or config's labels opt in to content
+// interpolation. ok is false if the label holds something other than a
+// boolean.
+func Interpolate(labels types.Labels) (enabled, ok bool) {
+	value, set := labels[InterpolateLabel]
+	if !set {
+		return false, true
+	}
+	enabled, err := strconv.ParseBool(value)
+	return enabled, err == nil
+}
+
+// interpolate substitutes ${VAR} references in a secret or config's content
+// from the compose environment.
+func (t *Transformer) interpolate(kind, name string, content []byte) ([]byte, error) {
+	if !utf8.Valid(content) {
+		return nil, fmt.Errorf("%s %q: %s is set but the file is not text", kind, name, InterpolateLabel)
+	}
+	out, err := template.Substitute(string(content), func(key string) (string, bool) {
+		value, ok := t.project.Environment[key]
+		return value, ok
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: failed to interpolate content: %w", kind, name, err)
+	}
+	return []byte(out), nil
 }
 
 type ConfigRef struct {
-	Source string `json:"source"`
-	Target string `json:"target,omitempty"`
+	Source string  `json:"source"`
+	Target string  `json:"target,omitempty"`
+	UID    string  `json:"uid,omitempty"`
+	GID    string  `json:"gid,omitempty"`
+	Mode   *uint32 `json:"mode,omitempty"`
 }
 
 type HealthCheckSpec struct {
@@ -164,7 +467,8 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 	// First pass: collect image->built_image mappings for services with build contexts
 	// This handles the pattern where multiple services share the same image but only one has a build
 	imageToBuilt := make(map[string]string)
-	for _, svc := range t.project.Services {
+	for _, name := range sortedKeys(t.project.Services) {
+		svc := t.project.Services[name]
 		if svc.Build != nil && svc.Image != "" {
 			builtName := fmt.Sprintf("%s-%s:latest", t.project.Name, svc.Name)
 			imageToBuilt[svc.Image] = builtName
@@ -173,8 +477,8 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 
 	// Convert services
 	for _, svc := range t.project.Services {
-		// Skip services with profiles (not activated by default)
-		if len(svc.Profiles) > 0 {
+		// Skip services with profiles (not activated by default) unless always-on
+		if !compose.IsActive(t.project, svc) {
 			continue
 		}
 
@@ -183,7 +487,32 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 			Replicas: 1,
 			Labels:   svc.Labels,
 			Restart:  svc.Restart,
-			IsJob:    svc.Restart == "no",
+			IsJob:    compose.IsOneShot(svc),
+		}
+		switch svc.NetworkMode {
+		case "host":
+			svcSpec.HostNetwork = true
+		case "none":
+			svcSpec.NetworkNone = true
+		}
+		svcSpec.ExtraHosts = hostAliases(svc.ExtraHosts)
+		svcSpec.DNS = svc.DNS
+		svcSpec.DNSSearch = svc.DNSSearch
+		svcSpec.StdinOpen = svc.StdinOpen
+		svcSpec.TTY = svc.Tty
+		svcSpec.NodeSelector, _ = NodeSelector(svc)
+		for name, value := range svc.Sysctls {
+			if _, namespaced := ClassifySysctl(name); !namespaced {
+				continue
+			}
+			if svcSpec.Sysctls == nil {
+				svcSpec.Sysctls = map[string]string{}
+			}
+			svcSpec.Sysctls[name] = value
+		}
+		if retries, ok := compose.OnFailureRetries(svc.Restart); ok && retries > 0 {
+			limit := int32(retries)
+			svcSpec.RestartRetries = &limit
 		}
 
 		// Build context
@@ -191,6 +520,7 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 			svcSpec.Build = &BuildSpec{
 				Context:    svc.Build.Context,
 				Dockerfile: svc.Build.Dockerfile,
+				Platform:   svc.Platform,
 			}
 			// Always use generated image name when building locally
 			// The compose 'image:' field is for registry pulls, not local builds
@@ -203,12 +533,14 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 
 		// Ports
 		for _, p := range svc.Ports {
-			published := p.Target // default to target if not specified
-			if p.Published != "" {
-				// Parse the published port string; on failure, keep Target as fallback
-				_, _ = fmt.Sscanf(p.Published, "%d", &published)
+			// On an invalid published port keep Target as fallback; up
+			// rejects it before anything is published
+			published, err := PublishedPort(p)
+			if err != nil {
+				published = p.Target
 			}
 			port := PortSpec{
+				HostIP:    p.HostIP,
 				Target:    p.Target,
 				Published: published,
 				Protocol:  p.Protocol,
@@ -218,9 +550,11 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 			}
 			svcSpec.Ports = append(svcSpec.Ports, port)
 		}
+		svcSpec.Expose = exposePorts(svc.Expose)
 
-		// Environment
-		for k, v := range svc.Environment {
+		// Environment (sorted so generated manifests are byte-stable)
+		for _, k := range sortedKeys(svc.Environment) {
+			v := svc.Environment[k]
 			val := ""
 			if v != nil {
 				val = *v
@@ -240,12 +574,11 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 		}
 
 		// Networks
-		for name := range svc.Networks {
-			svcSpec.Networks = append(svcSpec.Networks, name)
-		}
+		svcSpec.Networks = append(svcSpec.Networks, sortedKeys(svc.Networks)...)
 
 		// Dependencies with conditions
-		for dep, config := range svc.DependsOn {
+		for _, dep := range sortedKeys(svc.DependsOn) {
+			config := svc.DependsOn[dep]
 			condition := config.Condition
 			if condition == "" {
 				condition = "service_started"
@@ -266,14 +599,46 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 			svcSpec.Entrypoint = svc.Entrypoint
 		}
 
+		svcSpec.WorkingDir = svc.WorkingDir
+		svcSpec.User = svc.User
+
+		// Supplemental groups
+		if len(svc.GroupAdd) > 0 {
+			svcSpec.GroupAdd = svc.GroupAdd
+		}
+		svcSpec.FSGroup = resolveFSGroup(svc)
+
+		svcSpec.MemSwapLimit = int64(svc.MemSwapLimit)
+
 		// Replicas from deploy config
 		if svc.Deploy != nil && svc.Deploy.Replicas != nil {
 			svcSpec.Replicas = int(*svc.Deploy.Replicas)
 		}
 
+		if svc.Deploy != nil {
+			svcSpec.Limits = resourceSpec(svc.Deploy.Resources.Limits)
+			svcSpec.Reservations = resourceSpec(svc.Deploy.Resources.Reservations)
+		}
+
+		// Generic resource reservations
+		if svc.Deploy != nil && svc.Deploy.Resources.Reservations != nil {
+			for _, r := range svc.Deploy.Resources.Reservations.GenericResources {
+				if r.DiscreteResourceSpec == nil || r.DiscreteResourceSpec.Kind == "" {
+					continue
+				}
+				if svcSpec.GenericResources == nil {
+					svcSpec.GenericResources = map[string]int64{}
+				}
+				svcSpec.GenericResources[r.DiscreteResourceSpec.Kind] = r.DiscreteResourceSpec.Value
+			}
+		}
+
 		// Secrets
 		for _, s := range svc.Secrets {
-			ref := SecretRef{Source: s.Source}
+			ref := SecretRef{Source: s.Source, UID: s.UID, GID: s.GID, Mode: s.Mode, Keys: t.secretDirKeys(s.Source)}
+			if ref.Mode == nil {
+				ref.Mode = t.secretFileMode(s.Source)
+			}
 			if s.Target != "" {
 				ref.Target = s.Target
 			}
@@ -282,7 +647,7 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 
 		// Configs
 		for _, c := range svc.Configs {
-			ref := ConfigRef{Source: c.Source}
+			ref := ConfigRef{Source: c.Source, UID: c.UID, GID: c.GID, Mode: c.Mode}
 			if c.Target != "" {
 				ref.Target = c.Target
 			}
@@ -314,9 +679,26 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 
 	// Convert volumes
 	for name, vol := range t.project.Volumes {
-		spec.Volumes[name] = VolumeSpec{
-			Driver: vol.Driver,
+		volSpec := VolumeSpec{
+			Driver:       vol.Driver,
+			Size:         DefaultVolumeSize,
+			StorageClass: DefaultStorageClass,
+			AccessMode:   DefaultAccessMode,
+		}
+		if size := vol.Labels[VolumeSizeLabel]; size != "" {
+			volSpec.Size = size
+		}
+		if class := vol.Labels[StorageClassLabel]; class != "" {
+			volSpec.StorageClass = class
 		}
+		if mode := vol.Labels[AccessModeLabel]; mode != "" {
+			volSpec.AccessMode = mode
+		} else if volSpec.StorageClass != DefaultStorageClass && t.mountedReadOnly(name) {
+			// local-path can't provision ReadOnlyMany, so only opted-in
+			// storage classes get it
+			volSpec.AccessMode = string(corev1.ReadOnlyMany)
+		}
+		spec.Volumes[name] = volSpec
 	}
 
 	// Convert networks
@@ -329,16 +711,20 @@ func (t *Transformer) ToSpec() *ComposeSpec {
 
 	// Convert secrets
 	for name, secret := range t.project.Secrets {
+		interpolate, _ := Interpolate(secret.Labels)
 		spec.Secrets[name] = SecretSpec{
 			File:        secret.File,
 			Environment: secret.Environment,
+			Interpolate: interpolate,
 		}
 	}
 
 	// Convert configs
 	for name, cfg := range t.project.Configs {
+		interpolate, _ := Interpolate(cfg.Labels)
 		spec.Configs[name] = ConfigSpec{
-			File: cfg.File,
+			File:        cfg.File,
+			Interpolate: interpolate,
 		}
 	}
 
@@ -424,129 +810,122 @@ kappal.project(spec)
 
 // generateManifests creates K8s YAML manifests directly
 func (t *Transformer) generateManifests(ws *workspace.Workspace) error {
+	combined, err := t.RenderManifests()
+	if err != nil {
+		return err
+	}
+	return ws.WriteManifest("all.yaml", combined)
+}
+
+// RenderManifests returns the multi-document YAML written to all.yaml: the
+// namespace, secrets, configs, volumes, network policies and RBAC, then each
+// service's workload and Service in service name order. The output is
+// deterministic for a given project.
+func (t *Transformer) RenderManifests() ([]byte, error) {
 	spec := t.ToSpec()
-	var manifests []string
-
-	// Generate namespace
-	ns := fmt.Sprintf(`apiVersion: v1
-kind: Namespace
-metadata:
-  name: %s
-  labels:
-    kappal.io/project: "%s"
-`, spec.Name, spec.Name)
-	manifests = append(manifests, ns)
+	namespace := t.namespaceFor(spec.Name)
+	var objects []interface{}
+
+	// Generate namespace. An overridden namespace may be shared with other
+	// workloads, so it isn't labeled as belonging to this project.
+	ns := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: namespace},
+	}
+	if namespace == spec.Name {
+		ns.Labels = projectLabels(spec.Name)
+	}
+	objects = append(objects, ns)
+
+	// Registry credentials for the services' image pulls
+	if pullSecret, err := t.generateRegistrySecret(spec); err != nil {
+		return nil, err
+	} else if pullSecret != nil {
+		objects = append(objects, pullSecret)
+	}
 
 	// Generate secrets
-	for name, secret := range spec.Secrets {
+	secrets := make(map[string]*corev1.Secret)
+	for _, name := range sortedKeys(spec.Secrets) {
+		secret := spec.Secrets[name]
 		if secret.File != "" {
-			k8sName := sanitizeName(name)
-			// Read the secret file and base64 encode it
-			secretPath := secret.File
-			if !filepath.IsAbs(secretPath) {
-				secretPath = filepath.Join(t.workingDir, secretPath)
-			}
-			secretData := ""
-			if content, err := os.ReadFile(secretPath); err == nil {
-				secretData = base64.StdEncoding.EncodeToString(content)
+			k8sSecret, err := t.generateSecret(spec.Name, name, secret)
+			if err != nil {
+				return nil, err
 			}
-			// Use original name as key (for mount subPath), sanitized name for resource name
-			secretManifest := fmt.Sprintf(`---
-apiVersion: v1
-kind: Secret
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-type: Opaque
-data:
-  %s: %s
-`, k8sName, spec.Name, spec.Name, name, secretData)
-			manifests = append(manifests, secretManifest)
+			secrets[name] = k8sSecret
+			objects = append(objects, k8sSecret)
 		}
 	}
 
 	// Generate configmaps
-	for name, cfg := range spec.Configs {
-		k8sName := sanitizeName(name)
-		// Read the config file
-		configPath := cfg.File
-		if !filepath.IsAbs(configPath) {
-			configPath = filepath.Join(t.workingDir, configPath)
-		}
-		configData := ""
-		if content, err := os.ReadFile(configPath); err == nil {
-			// Escape for YAML multiline string
-			configData = strings.ReplaceAll(string(content), "\n", "\n    ")
-		}
-		// Use original name as key (for mount subPath), sanitized name for resource name
-		cmManifest := fmt.Sprintf(`---
-apiVersion: v1
-kind: ConfigMap
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-data:
-  %s: |
-    %s
-`, k8sName, spec.Name, spec.Name, name, configData)
-		manifests = append(manifests, cmManifest)
+	configMaps := make(map[string]*corev1.ConfigMap)
+	for _, name := range sortedKeys(spec.Configs) {
+		cm, err := t.generateConfigMap(spec.Name, name, spec.Configs[name])
+		if err != nil {
+			return nil, err
+		}
+		configMaps[name] = cm
+		objects = append(objects, cm)
 	}
 
 	// Generate PVCs for named volumes
-	for name := range spec.Volumes {
-		k8sName := sanitizeName(name)
-		pvcManifest := fmt.Sprintf(`---
-apiVersion: v1
-kind: PersistentVolumeClaim
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-    kappal.io/volume: "%s"
-spec:
-  accessModes:
-    - ReadWriteOnce
-  resources:
-    requests:
-      storage: 1Gi
-  storageClassName: local-path
-`, k8sName, spec.Name, spec.Name, name)
-		manifests = append(manifests, pvcManifest)
+	for _, name := range sortedKeys(spec.Volumes) {
+		vol := spec.Volumes[name]
+		size, err := resource.ParseQuantity(vol.Size)
+		if err != nil {
+			return nil, fmt.Errorf("volume %q: invalid %s %q (e.g. 10Gi): %w", name, VolumeSizeLabel, vol.Size, err)
+		}
+		accessMode := corev1.PersistentVolumeAccessMode(vol.AccessMode)
+		if !isValidAccessMode(accessMode) {
+			return nil, fmt.Errorf("volume %q: invalid %s %q (valid: ReadWriteOnce, ReadWriteMany, ReadOnlyMany, ReadWriteOncePod)", name, AccessModeLabel, vol.AccessMode)
+		}
+		labels := projectLabels(spec.Name)
+		labels["kappal.io/volume"] = name
+		storageClassName := vol.StorageClass
+		objects = append(objects, &corev1.PersistentVolumeClaim{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			ObjectMeta: objectMeta(sanitizeName(name), namespace, labels),
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{accessMode},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: size,
+					},
+				},
+				StorageClassName: &storageClassName,
+			},
+		})
 	}
 
 	// Generate NetworkPolicies for networks (for isolation)
-	for name := range spec.Networks {
+	for _, name := range sortedKeys(spec.Networks) {
 		if name == "default" {
 			continue
 		}
-		k8sName := sanitizeName(name)
-		npManifest := fmt.Sprintf(`---
-apiVersion: networking.k8s.io/v1
-kind: NetworkPolicy
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-    kappal.io/network: "%s"
-spec:
-  podSelector:
-    matchLabels:
-      kappal.io/network: "%s"
-  policyTypes:
-    - Ingress
-  ingress:
-    - from:
-        - podSelector:
-            matchLabels:
-              kappal.io/network: "%s"
-`, k8sName, spec.Name, spec.Name, name, name, name)
-		manifests = append(manifests, npManifest)
+		labels := projectLabels(spec.Name)
+		labels["kappal.io/network"] = name
+		networkSelector := metav1.LabelSelector{
+			MatchLabels: map[string]string{networkLabel(name): "true"},
+		}
+		// Pods also on the default network reach every default pod, as in
+		// compose, so only pods confined to named networks are isolated
+		isolated := *networkSelector.DeepCopy()
+		isolated.MatchExpressions = []metav1.LabelSelectorRequirement{
+			{Key: networkLabel("default"), Operator: metav1.LabelSelectorOpDoesNotExist},
+		}
+		objects = append(objects, &networkingv1.NetworkPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy"},
+			ObjectMeta: objectMeta(sanitizeName(name), namespace, labels),
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: isolated,
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{PodSelector: &networkSelector}},
+				}},
+				Egress: networkEgressRules(networkSelector),
+			},
+		})
 	}
 
 	// Generate RBAC if any service has init container dependencies
@@ -557,7 +936,8 @@ spec:
 			if dep.Condition == "service_completed_successfully" {
 				hasJobDependency = true
 			}
-			if dep.Condition == "service_healthy" {
+			// Both health and started waits list the dependency's pods
+			if dep.Condition == "service_healthy" || dep.Condition == "service_started" {
 				hasServiceDependency = true
 			}
 		}
@@ -566,160 +946,948 @@ spec:
 		}
 	}
 	if hasJobDependency || hasServiceDependency {
-		manifests = append(manifests, t.generateInitReaderRBAC(spec.Name, hasJobDependency, hasServiceDependency))
+		role, binding := t.generateInitReaderRBAC(spec.Name, hasJobDependency, hasServiceDependency)
+		objects = append(objects, role, binding)
 	}
 
 	// Generate Deployments/Jobs and Services for each service
 	// Services are created for ALL resources to enable DNS resolution,
 	// not just those with published ports
-	for name, svc := range spec.Services {
+	for _, name := range sortedKeys(spec.Services) {
+		svc := spec.Services[name]
+		if err := checkMountTargets(name, svc); err != nil {
+			return nil, err
+		}
 		if svc.IsJob {
-			manifests = append(manifests, t.generateJob(spec.Name, name, svc, spec.Services))
+			objects = append(objects, t.generateJob(spec.Name, name, svc, spec.Services))
 		} else {
-			manifests = append(manifests, t.generateDeployment(spec.Name, name, svc, spec.Services))
+			objects = append(objects, t.generateDeployment(spec.Name, name, svc, spec.Services))
+			hpa, err := t.generateHPA(spec.Name, name, svc)
+			if err != nil {
+				return nil, err
+			}
+			if hpa != nil {
+				objects = append(objects, hpa)
+			}
+		}
+		// Host-network pods are reached on the node's ports, not via a
+		// Service; network_mode: none services aren't reachable at all
+		if svc.HostNetwork || svc.NetworkNone {
+			continue
+		}
+		service := t.generateService(spec.Name, name, svc)
+		objects = append(objects, service)
+		if !svc.IsJob {
+			ingress, err := t.generateIngress(spec.Name, name, svc, service)
+			if err != nil {
+				return nil, err
+			}
+			if ingress != nil {
+				objects = append(objects, ingress)
+			}
 		}
-		manifests = append(manifests, t.generateService(spec.Name, name, svc))
 	}
 
 	// Write combined manifest
-	combined := strings.Join(manifests, "\n---\n")
-	return ws.WriteManifest("all.yaml", []byte(combined))
+	var combined []byte
+	for _, obj := range objects {
+		if obj != ns || namespace == spec.Name {
+			t.addExtraLabels(obj)
+		}
+		// Checksum the final Job, --label labels included
+		if job, ok := obj.(*batchv1.Job); ok {
+			job.Annotations = map[string]string{ConfigChecksumAnnotation: jobChecksum(job, spec.Services[job.Name], secrets, configMaps)}
+		}
+		doc, err := marshalManifest(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+		}
+		combined = append(combined, "---\n"...)
+		combined = append(combined, doc...)
+	}
+	return combined, nil
 }
 
-// containerSpecParts holds the reusable parts of a container/pod spec
-type containerSpecParts struct {
-	containerSpec string
-	volumeSpec    string
-	labels        string
+// generateSecret builds the Secret for a file-based compose secret, keyed by
+// the original secret name (for mount subPath). A directory becomes one key
+// per file in it (see secretDirKeys). A missing or unreadable file is an
+// error rather than an empty secret that fails confusingly at runtime.
+func (t *Transformer) generateSecret(projectName, name string, secret SecretSpec) (*corev1.Secret, error) {
+	secretPath := t.resolvePath(secret.File)
+	info, err := os.Stat(secretPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret %q: %w", name, err)
+	}
+	files := map[string]string{name: secretPath}
+	size := info.Size()
+	if info.IsDir() {
+		keys, err := dirKeys(secretPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %q: %w", name, err)
+		}
+		files = map[string]string{}
+		size = 0
+		for _, key := range keys {
+			if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+				return nil, fmt.Errorf("secret %q file %s is not a valid key: %s", name, filepath.Join(secretPath, key), strings.Join(errs, "; "))
+			}
+			keyInfo, err := os.Stat(filepath.Join(secretPath, key))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read secret %q: %w", name, err)
+			}
+			files[key] = filepath.Join(secretPath, key)
+			size += keyInfo.Size()
+		}
+	}
+	if err := checkDataSize("secret", name, secretPath, size); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string][]byte, len(files))
+	for key, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %q: %w", name, err)
+		}
+		if secret.Interpolate {
+			if content, err = t.interpolate("secret", name, content); err != nil {
+				return nil, err
+			}
+		}
+		data[key] = content
+	}
+
+	// The encoder base64 encodes Secret data
+	return &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: objectMeta(sanitizeName(name), t.namespaceFor(projectName), projectLabels(projectName)),
+		Type:       corev1.SecretTypeOpaque,
+		Data:       data,
+	}, nil
 }
 
-// buildContainerSpec extracts the shared container spec building logic
-func (t *Transformer) buildContainerSpec(projectName, serviceName string, svc ServiceSpec) containerSpecParts {
-	// Build labels with optional network label
-	labels := fmt.Sprintf(`        kappal.io/project: "%s"
-        kappal.io/service: "%s"`, projectName, serviceName)
-	if len(svc.Networks) > 0 {
-		labels += fmt.Sprintf(`
-        kappal.io/network: "%s"`, svc.Networks[0])
+// resolvePath resolves a secret or config file path against the project
+// directory.
+func (t *Transformer) resolvePath(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.Join(t.workingDir, path)
 	}
+	return path
+}
 
-	var containerParts []string
+// dirKeys returns the sorted names of the regular files directly in dir.
+// Subdirectories are skipped, since Secret keys are flat.
+func dirKeys(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		keys = append(keys, entry.Name())
+	}
+	return keys, nil
+}
 
-	// Ports
-	if len(svc.Ports) > 0 {
-		var portLines []string
-		for _, p := range svc.Ports {
-			protocol := strings.ToUpper(p.Protocol)
-			if protocol == "" {
-				protocol = "TCP"
-			}
-			portLines = append(portLines, fmt.Sprintf("        - containerPort: %d\n          protocol: %s", p.Target, protocol))
+// secretDirKeys returns the files of a secret whose file: is a directory,
+// which are mounted as a directory instead of a single file. Returns nil for
+// other secrets and unreadable paths (generateSecret reports those).
+func (t *Transformer) secretDirKeys(name string) []string {
+	secret, ok := t.project.Secrets[name]
+	if !ok || secret.File == "" {
+		return nil
+	}
+	dir := t.resolvePath(secret.File)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil
+	}
+	keys, err := dirKeys(dir)
+	if err != nil {
+		return nil
+	}
+	return keys
+}
+
+// RegistryAuthSecret prefixes the kubernetes.io/dockerconfigjson Secret
+// holding the registry credentials set by SetRegistryAuth.
+const RegistryAuthSecret = "kappal-registry-auth"
+
+// registryAuthSecretName returns a project's RegistryAuthSecret name,
+// suffixed with the project so projects sharing a namespace keep their own.
+func registryAuthSecretName(projectName string) string {
+	return RegistryAuthSecret + "-" + sanitizeName(projectName)
+}
+
+// pullsWithAuth reports whether a service's image is pulled from a registry
+// with credentials, so its pods need the RegistryAuthSecret. Images kappal
+// builds are never pulled.
+func (t *Transformer) pullsWithAuth(svc ServiceSpec) bool {
+	if svc.Build != nil || len(t.registryAuth) == 0 {
+		return false
+	}
+	_, ok := t.registryAuth[docker.RegistryHost(svc.Image)]
+	return ok
+}
+
+// generateRegistrySecret renders the credentials of the registries the
+// services pull from as an imagePullSecret, or returns nil if none need one.
+func (t *Transformer) generateRegistrySecret(spec *ComposeSpec) (*corev1.Secret, error) {
+	type entry struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+	auths := map[string]entry{}
+	for _, svc := range spec.Services {
+		if !t.pullsWithAuth(svc) {
+			continue
 		}
-		containerParts = append(containerParts, "        ports:\n"+strings.Join(portLines, "\n"))
+		host := docker.RegistryHost(svc.Image)
+		auth := t.registryAuth[host]
+		auths[docker.RegistryServer(host)] = entry{
+			Username: auth.Username,
+			Password: auth.Password,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password)),
+		}
+	}
+	if len(auths) == 0 {
+		return nil, nil
 	}
+	data, err := json.Marshal(map[string]interface{}{"auths": auths})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode registry credentials: %w", err)
+	}
+	return &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: objectMeta(registryAuthSecretName(spec.Name), t.namespaceFor(spec.Name), projectLabels(spec.Name)),
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: data},
+	}, nil
+}
 
-	// Environment
-	if len(svc.Environment) > 0 {
-		var envLines []string
-		for _, e := range svc.Environment {
-			envLines = append(envLines, fmt.Sprintf("        - name: \"%s\"\n          value: \"%s\"", e.Name, escapeYAML(e.Value)))
+// secretFileMode returns the permission bits of a file-based secret's source
+// file so a 0600 file isn't mounted world-readable in the pod. Returns nil when
+// the secret isn't file-based, can't be stat'ed (generateSecret reports that),
+// or already matches the K8s default of 0644.
+func (t *Transformer) secretFileMode(name string) *uint32 {
+	secret, ok := t.project.Secrets[name]
+	if !ok || secret.File == "" {
+		return nil
+	}
+	secretPath := secret.File
+	if !filepath.IsAbs(secretPath) {
+		secretPath = filepath.Join(t.workingDir, secretPath)
+	}
+	info, err := os.Stat(secretPath)
+	if err != nil || info.IsDir() {
+		return nil
+	}
+	// K8s only accepts permission bits (0-0777) for defaultMode
+	mode := uint32(info.Mode().Perm())
+	if mode == 0644 {
+		return nil
+	}
+	return &mode
+}
+
+// generateConfigMap builds the ConfigMap for a compose config, keyed by the
+// original config name (for mount subPath). File contents that aren't valid
+// UTF-8 go in binaryData, since data only holds strings.
+func (t *Transformer) generateConfigMap(projectName, name string, cfg ConfigSpec) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: objectMeta(sanitizeName(name), t.namespaceFor(projectName), projectLabels(projectName)),
+	}
+	if cfg.File == "" {
+		cm.Data = map[string]string{name: ""}
+		return cm, nil
+	}
+
+	configPath := cfg.File
+	if !filepath.IsAbs(configPath) {
+		configPath = filepath.Join(t.workingDir, configPath)
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %q: %w", name, err)
+	}
+	if err := checkDataSize("config", name, configPath, info.Size()); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %q: %w", name, err)
+	}
+	if cfg.Interpolate {
+		if content, err = t.interpolate("config", name, content); err != nil {
+			return nil, err
 		}
-		containerParts = append(containerParts, "        env:\n"+strings.Join(envLines, "\n"))
 	}
+	if utf8.Valid(content) {
+		cm.Data = map[string]string{name: string(content)}
+	} else {
+		cm.BinaryData = map[string][]byte{name: content}
+	}
+	return cm, nil
+}
+
+// maxObjectDataSize is the K8s limit on the data held by a Secret or ConfigMap.
+const maxObjectDataSize = 1 << 20
+
+// checkDataSize rejects secret and config files the API server would refuse
+// with an opaque "request is too large" error.
+func checkDataSize(kind, name, path string, size int64) error {
+	if size <= maxObjectDataSize {
+		return nil
+	}
+	return fmt.Errorf("%s %q file %s is %d bytes, over the 1MiB K8s limit for %ss; use a read-only bind mount or a volume for large files instead",
+		kind, name, path, size, kind)
+}
 
-	// Entrypoint -> K8s command (replaces ENTRYPOINT)
-	if len(svc.Entrypoint) > 0 {
-		var cmdLines []string
-		for _, c := range svc.Entrypoint {
-			cmdLines = append(cmdLines, fmt.Sprintf("        - \"%s\"", escapeYAML(c)))
+// marshalManifest encodes a typed Kubernetes object as a YAML document.
+// The empty status and null creationTimestamp fields that typed objects
+// always carry are dropped so the output only contains what kappal sets.
+func marshalManifest(obj interface{}) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var manifest map[string]interface{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	delete(manifest, "status")
+	pruneCreationTimestamps(manifest)
+	return yaml.Marshal(manifest)
+}
+
+// pruneCreationTimestamps removes null metadata.creationTimestamp entries,
+// including those nested in pod templates.
+func pruneCreationTimestamps(v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if ts, ok := val["creationTimestamp"]; ok && ts == nil {
+			delete(val, "creationTimestamp")
+		}
+		for _, child := range val {
+			pruneCreationTimestamps(child)
+		}
+	case []interface{}:
+		for _, child := range val {
+			pruneCreationTimestamps(child)
 		}
-		containerParts = append(containerParts, "        command:\n"+strings.Join(cmdLines, "\n"))
 	}
+}
+
+// projectLabels returns the label set shared by every project resource.
+func projectLabels(projectName string) map[string]string {
+	return map[string]string{"kappal.io/project": projectName}
+}
+
+// serviceLabels returns the labels identifying a compose service's resources.
+func serviceLabels(projectName, serviceName string) map[string]string {
+	return map[string]string{
+		"kappal.io/project": projectName,
+		"kappal.io/service": serviceName,
+	}
+}
+
+// addExtraLabels merges the --label labels into an object's labels and its
+// pod template's, without overriding labels kappal sets itself.
+func (t *Transformer) addExtraLabels(obj interface{}) {
+	if len(t.extraLabels) == 0 {
+		return
+	}
+	merge := func(labels map[string]string) map[string]string {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for k, v := range t.extraLabels {
+			if _, ok := labels[k]; !ok {
+				labels[k] = v
+			}
+		}
+		return labels
+	}
+	if o, ok := obj.(metav1.Object); ok {
+		o.SetLabels(merge(o.GetLabels()))
+	}
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		o.Spec.Template.Labels = merge(o.Spec.Template.Labels)
+	case *batchv1.Job:
+		o.Spec.Template.Labels = merge(o.Spec.Template.Labels)
+	}
+}
 
-	// Command -> K8s args (passed to entrypoint)
-	if len(svc.Command) > 0 {
-		var argLines []string
-		for _, c := range svc.Command {
-			argLines = append(argLines, fmt.Sprintf("        - \"%s\"", escapeYAML(c)))
+// objectMeta builds namespaced metadata for a project resource.
+func objectMeta(name, namespace string, labels map[string]string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    labels,
+	}
+}
+
+// networkLabel is the pod label key marking membership of a compose network.
+// Pods carry one per network, so a NetworkPolicy selects every member, not
+// just the pods whose first network it is (the kappal.io/network label).
+func networkLabel(network string) string {
+	return "kappal.io/network." + network
+}
+
+// K3sClusterCIDR is the pod CIDR of the K3s cluster (the K3s default).
+const K3sClusterCIDR = "10.42.0.0/16"
+
+// networkEgressRules limits a network's pods to other pods on the same
+// network, cluster DNS, and destinations outside the cluster. Like a
+// non-internal Docker network, pods keep internet (and K8s API) access;
+// only other pods in the cluster are cut off.
+func networkEgressRules(networkSelector metav1.LabelSelector) []networkingv1.NetworkPolicyEgressRule {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	dnsPort := intstr.FromInt32(53)
+	return []networkingv1.NetworkPolicyEgressRule{
+		{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &networkSelector}}},
+		{
+			To: []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"},
+				},
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"k8s-app": "kube-dns"},
+				},
+			}},
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &udp, Port: &dnsPort},
+				{Protocol: &tcp, Port: &dnsPort},
+			},
+		},
+		{To: []networkingv1.NetworkPolicyPeer{{
+			IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0", Except: []string{K3sClusterCIDR}},
+		}}},
+	}
+}
+
+// NodeSelector reads a service's x-kappal-node-selector extension. Scalar
+// values are stringified (e.g. gpu: true). It returns false if the extension
+// is set but isn't a map of scalars; the extension is then ignored.
+func NodeSelector(svc types.ServiceConfig) (map[string]string, bool) {
+	ext, ok := svc.Extensions[NodeSelectorExtension]
+	if !ok {
+		return nil, true
+	}
+	m, ok := ext.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	selector := make(map[string]string, len(m))
+	for key, value := range m {
+		switch v := value.(type) {
+		case string, bool, int, int64, uint64, float64:
+			selector[key] = fmt.Sprint(v)
+		default:
+			return nil, false
+		}
+	}
+	return selector, true
+}
+
+// hostAliases groups compose extra_hosts by IP, with IPs and hostnames
+// sorted. Entries that aren't IP addresses (e.g. Docker's host-gateway) are
+// skipped; the compatibility check reports them.
+func hostAliases(hosts types.HostsList) []HostAliasSpec {
+	byIP := map[string][]string{}
+	for _, host := range sortedKeys(hosts) {
+		for _, ip := range hosts[host] {
+			ip = strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+			if net.ParseIP(ip) == nil {
+				continue
+			}
+			byIP[ip] = append(byIP[ip], host)
 		}
-		containerParts = append(containerParts, "        args:\n"+strings.Join(argLines, "\n"))
+	}
+	var aliases []HostAliasSpec
+	for _, ip := range sortedKeys(byIP) {
+		aliases = append(aliases, HostAliasSpec{IP: ip, Hostnames: byIP[ip]})
+	}
+	return aliases
+}
+
+// buildPodTemplate builds the pod template shared by Deployments and Jobs
+func (t *Transformer) buildPodTemplate(projectName, serviceName string, svc ServiceSpec, allServices map[string]ServiceSpec) corev1.PodTemplateSpec {
+	// Build labels with optional network labels
+	labels := serviceLabels(projectName, serviceName)
+	if len(svc.Networks) > 0 {
+		labels["kappal.io/network"] = svc.Networks[0]
+	}
+	for _, network := range svc.Networks {
+		labels[networkLabel(network)] = "true"
+	}
+
+	container := corev1.Container{
+		Name:            serviceName,
+		Image:           svc.Image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		// Entrypoint -> K8s command (replaces ENTRYPOINT)
+		Command: svc.Entrypoint,
+		// Command -> K8s args (passed to entrypoint)
+		Args:            svc.Command,
+		WorkingDir:      svc.WorkingDir,
+		SecurityContext: buildContainerSecurityContext(svc),
+		Stdin:           svc.StdinOpen,
+		TTY:             svc.TTY,
+	}
+
+	// Ports
+	for _, p := range svc.Ports {
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			ContainerPort: int32(p.Target),
+			Protocol:      portProtocol(p.Protocol),
+		})
+	}
+
+	// Environment
+	for _, e := range svc.Environment {
+		container.Env = append(container.Env, corev1.EnvVar{Name: e.Name, Value: e.Value})
 	}
 
 	// Volume mounts and volumes
-	var volumeMountLines []string
-	var volumeLines []string
+	var volumes []corev1.Volume
 
 	for i, v := range svc.Volumes {
 		volName := fmt.Sprintf("vol-%d", i)
-		mountLine := fmt.Sprintf("        - name: %s\n          mountPath: \"%s\"", volName, v.Target)
-		if v.ReadOnly {
-			mountLine += "\n          readOnly: true"
-		}
-		volumeMountLines = append(volumeMountLines, mountLine)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volName,
+			MountPath: v.Target,
+			ReadOnly:  v.ReadOnly,
+		})
 
 		switch v.Type {
 		case "volume", "":
-			pvcName := sanitizeName(v.Source)
-			volumeLines = append(volumeLines, fmt.Sprintf("      - name: %s\n        persistentVolumeClaim:\n          claimName: %s", volName, pvcName))
+			volumes = append(volumes, corev1.Volume{
+				Name: volName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: sanitizeName(v.Source)},
+				},
+			})
 		case "bind":
-			volumeLines = append(volumeLines, fmt.Sprintf("      - name: %s\n        hostPath:\n          path: \"%s\"", volName, v.Source))
+			volumes = append(volumes, corev1.Volume{
+				Name: volName,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: t.resolveBindSource(v.Source)},
+				},
+			})
+		}
+	}
+
+	// Secret and config mounts. Files with a compose uid/gid are mounted
+	// from an emptyDir that the init container fills with an owned copy.
+	for _, f := range fileRefs(svc) {
+		volumes = append(volumes, corev1.Volume{Name: f.volName, VolumeSource: f.source})
+		mountVolName := f.volName
+		if f.owned() {
+			mountVolName = f.ownedVolName()
+			volumes = append(volumes, corev1.Volume{
+				Name:         mountVolName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
 		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      mountVolName,
+			MountPath: f.mountPath,
+			SubPath:   f.subPath(),
+			ReadOnly:  true,
+		})
 	}
 
-	// Secret mounts
+	// Convert compose healthcheck to K8s readiness probe
+	if svc.HealthCheck != nil {
+		container.ReadinessProbe = buildReadinessProbe(svc.HealthCheck)
+	}
+
+	container.Resources = buildResources(svc)
+
+	podSpec := corev1.PodSpec{
+		SecurityContext: buildPodSecurityContext(svc),
+		Containers:      []corev1.Container{container},
+		Volumes:         volumes,
+	}
+	if initContainer := t.buildInitContainerSpec(projectName, svc, allServices); initContainer != nil {
+		// Pod QoS counts init containers too; keep a Guaranteed service
+		// Guaranteed
+		if guaranteed(container.Resources) {
+			initContainer.Resources.Requests = initContainer.Resources.Limits.DeepCopy()
+		}
+		podSpec.InitContainers = []corev1.Container{*initContainer}
+	}
+	if t.pullsWithAuth(svc) {
+		podSpec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: registryAuthSecretName(projectName)}}
+	}
+	for _, alias := range svc.ExtraHosts {
+		podSpec.HostAliases = append(podSpec.HostAliases, corev1.HostAlias{IP: alias.IP, Hostnames: alias.Hostnames})
+	}
+	if svc.HostNetwork {
+		podSpec.HostNetwork = true
+		// Keep resolving other services by name from the node's network
+		podSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+	if len(svc.NodeSelector) > 0 {
+		podSpec.NodeSelector = svc.NodeSelector
+	}
+	if len(svc.DNS) > 0 || len(svc.DNSSearch) > 0 {
+		podSpec.DNSConfig = &corev1.PodDNSConfig{Nameservers: svc.DNS, Searches: svc.DNSSearch}
+		if len(svc.DNS) > 0 {
+			podSpec.DNSPolicy = corev1.DNSNone
+		}
+	}
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec:       podSpec,
+	}
+}
+
+// fileRef is a service secret or config reference resolved to its pod volume.
+type fileRef struct {
+	volName   string
+	source    corev1.VolumeSource
+	key       string
+	mountPath string
+	uid, gid  *int64
+	mode      *uint32
+	// dirKeys are the files of a directory-backed secret; the whole volume
+	// is mounted at mountPath instead of the single key
+	dirKeys []string
+}
+
+// keys returns the object keys the mount exposes.
+func (f fileRef) keys() []string {
+	if len(f.dirKeys) > 0 {
+		return f.dirKeys
+	}
+	return []string{f.key}
+}
+
+// subPath returns the key to mount, or "" to mount the whole volume.
+func (f fileRef) subPath() string {
+	if len(f.dirKeys) > 0 {
+		return ""
+	}
+	return f.key
+}
+
+// owned reports whether the file needs an init container to set its owner,
+// which secret and configMap volumes can't do beyond fsGroup.
+func (f fileRef) owned() bool {
+	return f.uid != nil || f.gid != nil
+}
+
+func (f fileRef) ownedVolName() string {
+	return f.volName + "-owned"
+}
+
+// String describes the mount for error messages, e.g. "secret db_password".
+func (f fileRef) String() string {
+	if f.source.Secret != nil {
+		return "secret " + f.key
+	}
+	return "config " + f.key
+}
+
+// checkMountTargets rejects a service that mounts two volumes, secrets or
+// configs at the same path, which the API server refuses with an opaque
+// duplicate mountPath error.
+func checkMountTargets(serviceName string, svc ServiceSpec) error {
+	mounts := map[string][]string{}
+	var targets []string
+	add := func(target, mount string) {
+		target = path.Clean(target)
+		if _, ok := mounts[target]; !ok {
+			targets = append(targets, target)
+		}
+		mounts[target] = append(mounts[target], mount)
+	}
+	for _, v := range svc.Volumes {
+		kind := v.Type
+		if kind == "" {
+			kind = "volume"
+		}
+		add(v.Target, kind+" "+v.Source)
+	}
+	for _, f := range fileRefs(svc) {
+		add(f.mountPath, f.String())
+	}
+
+	var conflicts []string
+	for _, target := range targets {
+		if len(mounts[target]) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", target, strings.Join(mounts[target], ", ")))
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("service %q mounts more than one source at the same target: %s; give each mount its own target",
+			serviceName, strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// fileRefs resolves a service's secrets and configs, in that order.
+// Secret targets that aren't absolute paths are placed under /run/secrets/;
+// config targets default to /<source>.
+func fileRefs(svc ServiceSpec) []fileRef {
+	var refs []fileRef
 	for _, s := range svc.Secrets {
 		target := s.Target
 		if target == "" {
 			target = s.Source
 		}
 		mountPath := target
-		if !strings.HasPrefix(target, "/run/secrets/") {
+		if !strings.HasPrefix(target, "/") {
 			mountPath = "/run/secrets/" + target
 		}
 		k8sSecretName := sanitizeName(s.Source)
-		volName := fmt.Sprintf("secret-%s", k8sSecretName)
-		volumeMountLines = append(volumeMountLines, fmt.Sprintf("        - name: %s\n          mountPath: \"%s\"\n          subPath: %s\n          readOnly: true", volName, mountPath, s.Source))
-		volumeLines = append(volumeLines, fmt.Sprintf("      - name: %s\n        secret:\n          secretName: %s", volName, k8sSecretName))
+		ref := newFileRef("secret-"+k8sSecretName, corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  k8sSecretName,
+				DefaultMode: volumeMode(s.Mode),
+			},
+		}, s.Source, mountPath, s.UID, s.GID, s.Mode)
+		ref.dirKeys = s.Keys
+		ref.source.Secret.Items = modeItems(ref.keys(), s.Mode)
+		refs = append(refs, ref)
 	}
-
-	// Config mounts
 	for _, c := range svc.Configs {
 		target := c.Target
 		if target == "" {
 			target = "/" + c.Source
 		}
 		k8sConfigName := sanitizeName(c.Source)
-		volName := fmt.Sprintf("config-%s", k8sConfigName)
-		volumeMountLines = append(volumeMountLines, fmt.Sprintf("        - name: %s\n          mountPath: \"%s\"\n          subPath: %s\n          readOnly: true", volName, target, c.Source))
-		volumeLines = append(volumeLines, fmt.Sprintf("      - name: %s\n        configMap:\n          name: %s", volName, k8sConfigName))
+		refs = append(refs, newFileRef("config-"+k8sConfigName, corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: k8sConfigName},
+				DefaultMode:          volumeMode(c.Mode),
+				Items:                modeItems([]string{c.Source}, c.Mode),
+			},
+		}, c.Source, target, c.UID, c.GID, c.Mode))
 	}
+	return refs
+}
 
-	if len(volumeMountLines) > 0 {
-		containerParts = append(containerParts, "        volumeMounts:\n"+strings.Join(volumeMountLines, "\n"))
+func newFileRef(volName string, source corev1.VolumeSource, key, mountPath, uid, gid string, mode *uint32) fileRef {
+	return fileRef{
+		volName:   volName,
+		source:    source,
+		key:       key,
+		mountPath: mountPath,
+		uid:       parseID(uid),
+		gid:       parseID(gid),
+		mode:      mode,
 	}
+}
 
-	// Convert compose healthcheck to K8s readiness probe
-	if svc.HealthCheck != nil && len(svc.HealthCheck.Test) > 0 {
-		if probe := buildReadinessProbe(svc.HealthCheck); probe != "" {
-			containerParts = append(containerParts, probe)
+// parseID parses a compose uid/gid. Empty or non-numeric values return nil;
+// callers warn about non-numeric values separately.
+func parseID(id string) *int64 {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n < 0 {
+		return nil
+	}
+	return &n
+}
+
+// volumeMode converts a compose file mode to a volume defaultMode.
+func volumeMode(mode *uint32) *int32 {
+	if mode == nil {
+		return nil
+	}
+	m := int32(*mode)
+	return &m
+}
+
+// modeItems pins the mode of a secret or configMap volume's keys per item,
+// so it holds even if the object gains keys. Returns nil when mode is unset.
+func modeItems(keys []string, mode *uint32) []corev1.KeyToPath {
+	if mode == nil {
+		return nil
+	}
+	items := make([]corev1.KeyToPath, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, corev1.KeyToPath{Key: key, Path: key, Mode: volumeMode(mode)})
+	}
+	return items
+}
+
+// portProtocol maps a compose port protocol to the K8s protocol, defaulting to TCP.
+func portProtocol(protocol string) corev1.Protocol {
+	if protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return corev1.Protocol(strings.ToUpper(protocol))
+}
+
+// PublishedPort returns the host port a compose port publishes on, defaulting
+// to the target. compose-go already expands short-syntax ranges
+// ("8000-8010:8000-8010") into one entry per port, so a range left in
+// published maps a block of host ports onto a single container port
+// ("8000-8010:80") and is rejected: each container port is published on
+// exactly one host port.
+func PublishedPort(p types.ServicePortConfig) (uint32, error) {
+	if p.Published == "" {
+		return p.Target, nil
+	}
+	start, end, isRange := strings.Cut(p.Published, "-")
+	first, err := strconv.ParseUint(start, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid published port %q", p.Published)
+	}
+	if isRange {
+		last, err := strconv.ParseUint(end, 10, 16)
+		if err != nil || last < first {
+			return 0, fmt.Errorf("invalid published port range %q", p.Published)
+		}
+		if last != first {
+			return 0, fmt.Errorf("published port range %s has %d ports but target %d is a single port; publish a range as \"%s:%s\"",
+				p.Published, last-first+1, p.Target, p.Published, p.Published)
 		}
 	}
+	return uint32(first), nil
+}
 
-	containerSpec := strings.Join(containerParts, "\n")
-	if containerSpec != "" {
-		containerSpec = "\n" + containerSpec
+// exposePorts parses compose expose entries ("3000", "5000/udp",
+// "8000-8010") into one PortSpec per port. Entries that aren't a port or
+// port range are skipped.
+func exposePorts(expose types.StringOrNumberList) []PortSpec {
+	var ports []PortSpec
+	for _, entry := range expose {
+		port, protocol, _ := strings.Cut(entry, "/")
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		start, end, isRange := strings.Cut(port, "-")
+		if !isRange {
+			end = start
+		}
+		first, err := strconv.ParseUint(start, 10, 16)
+		if err != nil {
+			continue
+		}
+		last, err := strconv.ParseUint(end, 10, 16)
+		if err != nil || last < first {
+			continue
+		}
+		for p := first; p <= last; p++ {
+			ports = append(ports, PortSpec{Target: uint32(p), Protocol: protocol})
+		}
+	}
+	return ports
+}
+
+// EphemeralStorageLabel is the compose service label that sets the container's
+// ephemeral-storage request and limit (e.g. "2Gi"). Compose has no deploy
+// field for it, so services writing large temp files opt in via this label.
+const EphemeralStorageLabel = "kappal.io/ephemeral-storage"
+
+// resourceSpec returns the CPU and memory of a deploy.resources limits or
+// reservations block, or nil if it sets neither.
+func resourceSpec(r *types.Resource) *ResourceSpec {
+	if r == nil || (r.NanoCPUs <= 0 && r.MemoryBytes <= 0) {
+		return nil
+	}
+	spec := &ResourceSpec{}
+	if r.NanoCPUs > 0 {
+		spec.CPUs = float64(r.NanoCPUs)
+	}
+	if r.MemoryBytes > 0 {
+		spec.MemoryBytes = int64(r.MemoryBytes)
+	}
+	return spec
+}
+
+// MinProbeCPUMillis is the CPU limit below which a healthcheck probe is
+// likely to time out while the container is CFS-throttled.
+const MinProbeCPUMillis = 100
+
+// ThrottledProbeWarning returns a warning for a service whose healthcheck
+// runs under a CPU limit below MinProbeCPUMillis, since throttled probes
+// time out and flap readiness. Returns "" otherwise.
+func ThrottledProbeWarning(svc types.ServiceConfig) string {
+	if svc.HealthCheck == nil || svc.HealthCheck.Disable || svc.Deploy == nil || svc.Deploy.Resources.Limits == nil {
+		return ""
+	}
+	millis := int64(math.Round(float64(svc.Deploy.Resources.Limits.NanoCPUs) * 1000))
+	if millis <= 0 || millis >= MinProbeCPUMillis {
+		return ""
 	}
+	return fmt.Sprintf("service %q limits CPU to %dm and has a healthcheck; under throttling its probes can time out and flap readiness, so consider deploy.resources.limits.cpus of at least %g",
+		svc.Name, millis, float64(MinProbeCPUMillis)/1000)
+}
 
-	volumeSpec := ""
-	if len(volumeLines) > 0 {
-		volumeSpec = "\n      volumes:\n" + strings.Join(volumeLines, "\n")
+// buildResources builds the container resource requirements.
+// Invalid quantities are skipped; callers warn about them separately.
+//
+// CPU and memory come from deploy.resources. A limit without a reservation
+// for the same resource is also used as its request, so a service with only
+// limits gets requests == limits and, with both CPU and memory limited,
+// Guaranteed QoS. A reservation is the request as-is (Burstable QoS unless it
+// equals the limit); reservations alone set requests without limits.
+func buildResources(svc ServiceSpec) corev1.ResourceRequirements {
+	var resources corev1.ResourceRequirements
+	set := func(list *corev1.ResourceList, name corev1.ResourceName, qty resource.Quantity) {
+		if *list == nil {
+			*list = corev1.ResourceList{}
+		}
+		(*list)[name] = qty
+	}
+	cpu := func(cpus float64) resource.Quantity {
+		return *resource.NewMilliQuantity(int64(math.Round(cpus*1000)), resource.DecimalSI)
+	}
+	memory := func(bytes int64) resource.Quantity {
+		return *resource.NewQuantity(bytes, resource.BinarySI)
+	}
+	var limits, reservations ResourceSpec
+	if svc.Limits != nil {
+		limits = *svc.Limits
+	}
+	if svc.Reservations != nil {
+		reservations = *svc.Reservations
+	}
+	switch {
+	case reservations.CPUs > 0:
+		set(&resources.Requests, corev1.ResourceCPU, cpu(reservations.CPUs))
+	case limits.CPUs > 0:
+		set(&resources.Requests, corev1.ResourceCPU, cpu(limits.CPUs))
+	}
+	if limits.CPUs > 0 {
+		set(&resources.Limits, corev1.ResourceCPU, cpu(limits.CPUs))
+	}
+	switch {
+	case reservations.MemoryBytes > 0:
+		set(&resources.Requests, corev1.ResourceMemory, memory(reservations.MemoryBytes))
+	case limits.MemoryBytes > 0:
+		set(&resources.Requests, corev1.ResourceMemory, memory(limits.MemoryBytes))
+	}
+	if limits.MemoryBytes > 0 {
+		set(&resources.Limits, corev1.ResourceMemory, memory(limits.MemoryBytes))
 	}
 
-	return containerSpecParts{
-		containerSpec: containerSpec,
-		volumeSpec:    volumeSpec,
-		labels:        labels,
+	setBoth := func(name corev1.ResourceName, qty resource.Quantity) {
+		set(&resources.Requests, name, qty)
+		set(&resources.Limits, name, qty)
 	}
+	if value, ok := svc.Labels[EphemeralStorageLabel]; ok {
+		if qty, err := resource.ParseQuantity(value); err == nil {
+			setBoth(corev1.ResourceEphemeralStorage, qty)
+		}
+	}
+	// Extended resources can't be overcommitted, so K8s requires the
+	// limit to equal the request
+	for _, kind := range sortedKeys(svc.GenericResources) {
+		setBoth(corev1.ResourceName(kind), *resource.NewQuantity(svc.GenericResources[kind], resource.DecimalSI))
+	}
+	return resources
 }
 
 // durationToSeconds parses a Go duration string and returns seconds (minimum 1).
@@ -735,10 +1903,10 @@ func durationToSeconds(s string) int {
 	return secs
 }
 
-// buildReadinessProbe converts a compose HealthCheckSpec to K8s readinessProbe YAML.
-func buildReadinessProbe(hc *HealthCheckSpec) string {
+// buildReadinessProbe converts a compose HealthCheckSpec to a K8s readinessProbe.
+func buildReadinessProbe(hc *HealthCheckSpec) *corev1.Probe {
 	if len(hc.Test) == 0 {
-		return ""
+		return nil
 	}
 
 	// Parse test command: ["CMD-SHELL", "cmd"] or ["CMD", "arg1", ...] or ["NONE"]
@@ -746,235 +1914,663 @@ func buildReadinessProbe(hc *HealthCheckSpec) string {
 	switch hc.Test[0] {
 	case "CMD-SHELL":
 		if len(hc.Test) < 2 {
-			return ""
+			return nil
 		}
 		command = []string{"/bin/sh", "-c", hc.Test[1]}
 	case "CMD":
 		if len(hc.Test) < 2 {
-			return ""
+			return nil
 		}
 		command = hc.Test[1:]
 	case "NONE":
-		return ""
+		return nil
 	default:
 		// Bare command (no prefix) — treat as shell command
 		command = []string{"/bin/sh", "-c", strings.Join(hc.Test, " ")}
 	}
 
-	var cmdLines []string
-	for _, c := range command {
-		cmdLines = append(cmdLines, fmt.Sprintf("            - \"%s\"", escapeYAML(c)))
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: command},
+		},
 	}
 
-	probe := "        readinessProbe:\n          exec:\n            command:\n" + strings.Join(cmdLines, "\n")
-
 	if hc.Interval != "" {
-		if secs := durationToSeconds(hc.Interval); secs > 0 {
-			probe += fmt.Sprintf("\n          periodSeconds: %d", secs)
-		}
+		probe.PeriodSeconds = int32(durationToSeconds(hc.Interval))
 	}
 	if hc.Timeout != "" {
-		if secs := durationToSeconds(hc.Timeout); secs > 0 {
-			probe += fmt.Sprintf("\n          timeoutSeconds: %d", secs)
-		}
+		probe.TimeoutSeconds = int32(durationToSeconds(hc.Timeout))
 	}
 	if hc.Retries > 0 {
-		probe += fmt.Sprintf("\n          failureThreshold: %d", hc.Retries)
+		probe.FailureThreshold = int32(hc.Retries)
 	}
 	if hc.StartPeriod != "" {
-		if secs := durationToSeconds(hc.StartPeriod); secs > 0 {
-			probe += fmt.Sprintf("\n          initialDelaySeconds: %d", secs)
-		}
+		probe.InitialDelaySeconds = int32(durationToSeconds(hc.StartPeriod))
 	}
 
 	return probe
 }
 
-// buildInitContainerSpec generates the init container YAML for waiting on dependencies.
-// It handles both service_completed_successfully (Jobs) and service_healthy (Deployments with healthchecks).
-func (t *Transformer) buildInitContainerSpec(projectName string, svc ServiceSpec, allServices map[string]ServiceSpec) string {
-	var waitForJobs []string
-	var waitForServices []string
-	var prepareWritablePaths []string
-	var initVolumeMountLines []string
+// supplementalGroups returns the numeric entries of a compose group_add list
+// as GIDs. Named groups (e.g. "docker") can't be resolved without the image's
+// /etc/group, so they are skipped; callers warn about them separately.
+func supplementalGroups(groups []string) []int64 {
+	var gids []int64
+	for _, g := range groups {
+		gid, err := strconv.ParseInt(g, 10, 64)
+		if err != nil || gid < 0 {
+			continue
+		}
+		gids = append(gids, gid)
+	}
+	return gids
+}
+
+// ParseUser parses a compose user of the form "uid" or "uid:gid".
+// K8s can only run containers as numeric IDs, so names are rejected.
+func ParseUser(user string) (uid, gid *int64, err error) {
+	uidPart, gidPart, hasGroup := strings.Cut(user, ":")
+	u, err := strconv.ParseInt(uidPart, 10, 64)
+	if err != nil || u < 0 {
+		return nil, nil, fmt.Errorf("user %q must be a numeric uid or uid:gid", user)
+	}
+	uid = &u
+	if hasGroup {
+		g, err := strconv.ParseInt(gidPart, 10, 64)
+		if err != nil || g < 0 {
+			return nil, nil, fmt.Errorf("user %q must be a numeric uid or uid:gid", user)
+		}
+		gid = &g
+	}
+	return uid, gid, nil
+}
+
+// buildContainerSecurityContext maps compose user (or the run -u override)
+// to runAsUser/runAsGroup. Non-numeric compose users are ignored; the
+// compatibility check warns about them.
+func buildContainerSecurityContext(svc ServiceSpec) *corev1.SecurityContext {
+	if svc.User == "" {
+		return nil
+	}
+	uid, gid, err := ParseUser(svc.User)
+	if err != nil {
+		return nil
+	}
+	return &corev1.SecurityContext{RunAsUser: uid, RunAsGroup: gid}
+}
+
+// FSGroupLabel is the compose service label that sets the pod fsGroup
+// (a numeric GID) owning mounted volumes, overriding the user/group_add gid.
+const FSGroupLabel = "kappal.io/fs-group"
+
+// DefaultFSGroup is the fsGroup used for services with volumes when neither
+// the kappal.io/fs-group label, the user gid nor group_add provides one.
+const DefaultFSGroup int64 = 999
+
+// resolveFSGroup picks the fsGroup for a compose service: the
+// kappal.io/fs-group label, then the gid of a numeric "uid:gid" user, then
+// the first numeric group_add entry. Returns nil when none applies or the
+// label is invalid (callers warn about invalid labels separately).
+func resolveFSGroup(svc types.ServiceConfig) *int64 {
+	if value, ok := svc.Labels[FSGroupLabel]; ok {
+		gid, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || gid < 0 {
+			return nil
+		}
+		return &gid
+	}
+	if _, gid, err := ParseUser(svc.User); err == nil && gid != nil {
+		return gid
+	}
+	if gids := supplementalGroups(svc.GroupAdd); len(gids) > 0 {
+		return &gids[0]
+	}
+	return nil
+}
+
+// buildPodSecurityContext builds the pod-level securityContext.
+// fsGroup is set when the service mounts volumes; supplementalGroups come
+// from numeric group_add entries. Returns nil when neither applies.
+func buildPodSecurityContext(svc ServiceSpec) *corev1.PodSecurityContext {
+	var sc corev1.PodSecurityContext
+	if len(svc.Volumes) > 0 {
+		fsGroup := DefaultFSGroup
+		if svc.FSGroup != nil {
+			fsGroup = *svc.FSGroup
+		}
+		sc.FSGroup = &fsGroup
+	}
+	sc.SupplementalGroups = supplementalGroups(svc.GroupAdd)
+	for _, name := range sortedKeys(svc.Sysctls) {
+		sc.Sysctls = append(sc.Sysctls, corev1.Sysctl{Name: name, Value: svc.Sysctls[name]})
+	}
+	if sc.FSGroup == nil && len(sc.SupplementalGroups) == 0 && len(sc.Sysctls) == 0 {
+		return nil
+	}
+	return &sc
+}
+
+// safeSysctls are the sysctls the kubelet allows by default (K8s 1.29).
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced":              true,
+	"net.ipv4.ip_local_port_range":        true,
+	"net.ipv4.ip_local_reserved_ports":    true,
+	"net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.ping_group_range":           true,
+	"net.ipv4.tcp_fin_timeout":            true,
+	"net.ipv4.tcp_keepalive_intvl":        true,
+	"net.ipv4.tcp_keepalive_probes":       true,
+	"net.ipv4.tcp_keepalive_time":         true,
+	"net.ipv4.tcp_syncookies":             true,
+}
+
+// ClassifySysctl reports whether a sysctl is in the kubelet's safe set and
+// whether it is namespaced, i.e. settable per pod at all. Unsafe namespaced
+// sysctls need the kubelet's --allowed-unsafe-sysctls; node-level ones (e.g.
+// vm.max_map_count) can only be set on the host.
+func ClassifySysctl(name string) (safe, namespaced bool) {
+	if safeSysctls[name] {
+		return true, true
+	}
+	for _, prefix := range []string{"kernel.shm", "kernel.msg", "fs.mqueue.", "net."} {
+		if strings.HasPrefix(name, prefix) {
+			return false, true
+		}
+	}
+	return false, name == "kernel.sem"
+}
+
+// initContainerSpec is the KAPPAL_INIT_SPEC payload read by kappal-init.
+type initContainerSpec struct {
+	Namespace            string          `json:"namespace"`
+	Project              string          `json:"project"`
+	WaitForJobs          []string        `json:"waitForJobs"`
+	WaitForServices      []string        `json:"waitForServices"`
+	WaitForStarted       []string        `json:"waitForStarted,omitempty"`
+	PrepareWritablePaths []string        `json:"prepareWritablePaths"`
+	OwnedFiles           []initOwnedFile `json:"ownedFiles,omitempty"`
+}
+
+// initOwnedFile asks kappal-init to copy a secret or config file into an
+// emptyDir with the given ownership and mode.
+type initOwnedFile struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	UID    int64  `json:"uid"`
+	GID    int64  `json:"gid"`
+	Mode   uint32 `json:"mode"`
+}
+
+// Mount roots used by the init container for owned secret/config files.
+const (
+	initSourceDir = "/kappal/src"
+	initOwnedDir  = "/kappal/owned"
+)
+
+// buildInitContainerSpec builds the init container for waiting on dependencies.
+// It handles service_completed_successfully (Jobs), service_healthy (Deployments with healthchecks)
+// and service_started (any pod of the dependency has started), and prepares writable bind mounts and secret/config files that need a specific owner.
+// Returns nil when the service needs no init container.
+func (t *Transformer) buildInitContainerSpec(projectName string, svc ServiceSpec, allServices map[string]ServiceSpec) *corev1.Container {
+	spec := initContainerSpec{
+		Namespace:            t.namespaceFor(projectName),
+		Project:              projectName,
+		WaitForJobs:          []string{},
+		WaitForServices:      []string{},
+		PrepareWritablePaths: []string{},
+	}
+	var initVolumeMounts []corev1.VolumeMount
 
 	for i, v := range svc.Volumes {
 		if v.Type == "bind" && !v.ReadOnly {
-			prepareWritablePaths = append(prepareWritablePaths, v.Target)
-			initVolumeMountLines = append(initVolumeMountLines, fmt.Sprintf("        - name: vol-%d\n          mountPath: \"%s\"", i, v.Target))
+			spec.PrepareWritablePaths = append(spec.PrepareWritablePaths, v.Target)
+			initVolumeMounts = append(initVolumeMounts, corev1.VolumeMount{
+				Name:      fmt.Sprintf("vol-%d", i),
+				MountPath: v.Target,
+			})
 		}
 	}
 
+	for _, f := range fileRefs(svc) {
+		if !f.owned() {
+			continue
+		}
+		for _, key := range f.keys() {
+			owned := initOwnedFile{
+				Source: initSourceDir + "/" + f.volName + "/" + key,
+				Target: initOwnedDir + "/" + f.volName + "/" + key,
+				// Compose defaults secret and config files to world-readable
+				Mode: 0444,
+			}
+			if f.uid != nil {
+				owned.UID = *f.uid
+			}
+			if f.gid != nil {
+				owned.GID = *f.gid
+			}
+			if f.mode != nil {
+				owned.Mode = *f.mode
+			}
+			spec.OwnedFiles = append(spec.OwnedFiles, owned)
+		}
+		initVolumeMounts = append(initVolumeMounts,
+			corev1.VolumeMount{Name: f.volName, MountPath: initSourceDir + "/" + f.volName, ReadOnly: true},
+			corev1.VolumeMount{Name: f.ownedVolName(), MountPath: initOwnedDir + "/" + f.volName},
+		)
+	}
+
 	for _, dep := range svc.DependsOn {
 		switch dep.Condition {
 		case "service_completed_successfully":
 			if depSvc, ok := allServices[dep.Service]; ok && depSvc.IsJob {
-				waitForJobs = append(waitForJobs, dep.Service)
+				spec.WaitForJobs = append(spec.WaitForJobs, dep.Service)
 			}
 		case "service_healthy":
 			if depSvc, ok := allServices[dep.Service]; ok && !depSvc.IsJob {
-				waitForServices = append(waitForServices, dep.Service)
+				spec.WaitForServices = append(spec.WaitForServices, dep.Service)
+			}
+		case "service_started":
+			if _, ok := allServices[dep.Service]; ok {
+				spec.WaitForStarted = append(spec.WaitForStarted, dep.Service)
 			}
 		}
 	}
 
-	if len(waitForJobs) == 0 && len(waitForServices) == 0 && len(prepareWritablePaths) == 0 {
-		return ""
+	if len(spec.WaitForJobs) == 0 && len(spec.WaitForServices) == 0 && len(spec.WaitForStarted) == 0 && len(spec.PrepareWritablePaths) == 0 && len(spec.OwnedFiles) == 0 {
+		return nil
 	}
 
-	toJSONArray := func(items []string) string {
-		if len(items) == 0 {
-			return "[]"
+	// Marshaling a struct of strings cannot fail
+	specJSON, _ := json.Marshal(spec)
+
+	container := &corev1.Container{
+		Name:            "wait-for-deps",
+		Image:           GetInitImage(),
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"kappal-init"},
+		Env: []corev1.EnvVar{
+			{Name: "KAPPAL_INIT_SPEC", Value: string(specJSON)},
+		},
+		Resources: initContainerResources(),
+	}
+
+	if len(initVolumeMounts) > 0 {
+		root := int64(0)
+		container.SecurityContext = &corev1.SecurityContext{
+			RunAsUser:  &root,
+			RunAsGroup: &root,
 		}
-		parts := make([]string, len(items))
-		for i, item := range items {
-			parts[i] = fmt.Sprintf(`"%s"`, item)
+		container.VolumeMounts = initVolumeMounts
+	}
+
+	return container
+}
+
+// initContainerResources gives the wait-for-deps container a small fixed
+// request, so dependency waits are scheduled CPU time on a busy node, and a
+// limit well above what kappal-init needs. Init container requests don't
+// add to the pod's total, which is the max of init and app requests.
+func initContainerResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("16Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("250m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+}
+
+// guaranteed reports whether resources qualify a container for Guaranteed
+// QoS: CPU and memory limits, with every request equal to its limit.
+func guaranteed(resources corev1.ResourceRequirements) bool {
+	if _, ok := resources.Limits[corev1.ResourceCPU]; !ok {
+		return false
+	}
+	if _, ok := resources.Limits[corev1.ResourceMemory]; !ok {
+		return false
+	}
+	for name, request := range resources.Requests {
+		limit, ok := resources.Limits[name]
+		if !ok || limit.Cmp(request) != 0 {
+			return false
 		}
-		return "[" + strings.Join(parts, ",") + "]"
+	}
+	return true
+}
+
+func (t *Transformer) generateDeployment(projectName, serviceName string, svc ServiceSpec, allServices map[string]ServiceSpec) *appsv1.Deployment {
+	replicas := deploymentReplicas(svc)
+	if t.noStart {
+		replicas = 0
 	}
 
-	specJSON := fmt.Sprintf(`{"namespace":"%s","waitForJobs":%s,"waitForServices":%s,"prepareWritablePaths":%s}`,
-		projectName, toJSONArray(waitForJobs), toJSONArray(waitForServices), toJSONArray(prepareWritablePaths))
+	// Deployments only accept Always; set it explicitly rather than relying on the API default
+	template := t.buildPodTemplate(projectName, serviceName, svc, allServices)
+	template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	progressDeadline := t.progressDeadline
+
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: objectMeta(serviceName, t.namespaceFor(projectName), serviceLabels(projectName, serviceName)),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: serviceLabels(projectName, serviceName),
+			},
+			Template:                template,
+			ProgressDeadlineSeconds: &progressDeadline,
+		},
+	}
+	// The HPA owns the replica count; setting it here would reset the
+	// autoscaled count on every up
+	if _, ok := svc.Labels[HPAMaxReplicasLabel]; ok && !t.noStart {
+		deployment.Spec.Replicas = nil
+	}
+	return deployment
+}
 
-	initSpec := fmt.Sprintf(`
-      initContainers:
-      - name: wait-for-deps
-        image: %s
-        imagePullPolicy: IfNotPresent
-        command: ["kappal-init"]
-        env:
-        - name: KAPPAL_INIT_SPEC
-          value: '%s'`, GetInitImage(), specJSON)
+// Compose service labels that autoscale a Deployment with a
+// HorizontalPodAutoscaler. Setting HPAMaxReplicasLabel enables it.
+const (
+	HPAMinReplicasLabel = "kappal.io/hpa.minReplicas"
+	HPAMaxReplicasLabel = "kappal.io/hpa.maxReplicas"
+	// HPATargetCPULabel is either a percentage of the CPU request (e.g. "70")
+	// or an absolute average usage per pod (e.g. "250m")
+	HPATargetCPULabel = "kappal.io/hpa.targetCPU"
+)
 
-	if len(initVolumeMountLines) > 0 {
-		initSpec += `
-        securityContext:
-          runAsUser: 0
-          runAsGroup: 0
-        volumeMounts:
-` + strings.Join(initVolumeMountLines, "\n")
+// DefaultHPATargetCPU is the target CPU utilization, in percent, of
+// autoscaled services without the kappal.io/hpa.targetCPU label.
+const DefaultHPATargetCPU int32 = 80
+
+// generateHPA builds the HorizontalPodAutoscaler for a service with the
+// kappal.io/hpa.maxReplicas label, or returns nil without it. minReplicas
+// defaults to deploy.replicas.
+func (t *Transformer) generateHPA(projectName, serviceName string, svc ServiceSpec) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	maxValue, ok := svc.Labels[HPAMaxReplicasLabel]
+	if !ok {
+		return nil, nil
+	}
+	maxReplicas, err := strconv.ParseInt(maxValue, 10, 32)
+	if err != nil || maxReplicas < 1 {
+		return nil, fmt.Errorf("service %q: invalid %s %q (must be a positive integer)", serviceName, HPAMaxReplicasLabel, maxValue)
+	}
+	minReplicas := deploymentReplicas(svc)
+	if minValue, ok := svc.Labels[HPAMinReplicasLabel]; ok {
+		v, err := strconv.ParseInt(minValue, 10, 32)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("service %q: invalid %s %q (must be a positive integer)", serviceName, HPAMinReplicasLabel, minValue)
+		}
+		minReplicas = int32(v)
+	}
+	if int32(maxReplicas) < minReplicas {
+		return nil, fmt.Errorf("service %q: %s %d is below the minimum of %d replicas", serviceName, HPAMaxReplicasLabel, maxReplicas, minReplicas)
 	}
 
-	return initSpec
+	target, err := hpaCPUTarget(svc.Labels[HPATargetCPULabel])
+	if err != nil {
+		return nil, fmt.Errorf("service %q: invalid %s: %w", serviceName, HPATargetCPULabel, err)
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+		ObjectMeta: objectMeta(serviceName, t.namespaceFor(projectName), serviceLabels(projectName, serviceName)),
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       serviceName,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: int32(maxReplicas),
+			Metrics: []autoscalingv2.MetricSpec{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   corev1.ResourceCPU,
+					Target: target,
+				},
+			}},
+		},
+	}, nil
 }
 
-func (t *Transformer) generateDeployment(projectName, serviceName string, svc ServiceSpec, allServices map[string]ServiceSpec) string {
-	replicas := svc.Replicas
-	if replicas < 1 {
-		replicas = 1
+// hpaCPUTarget parses kappal.io/hpa.targetCPU: a plain integer is a
+// utilization percentage, a quantity (e.g. "250m") an average value.
+func hpaCPUTarget(value string) (autoscalingv2.MetricTarget, error) {
+	if value == "" {
+		utilization := DefaultHPATargetCPU
+		return autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &utilization}, nil
 	}
+	if percent, err := strconv.ParseInt(value, 10, 32); err == nil {
+		if percent < 1 {
+			return autoscalingv2.MetricTarget{}, fmt.Errorf("%q must be a positive percentage", value)
+		}
+		utilization := int32(percent)
+		return autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &utilization}, nil
+	}
+	qty, err := resource.ParseQuantity(value)
+	if err != nil || qty.Sign() <= 0 {
+		return autoscalingv2.MetricTarget{}, fmt.Errorf("%q is neither a percentage (e.g. 70) nor a CPU quantity (e.g. 250m)", value)
+	}
+	return autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: &qty}, nil
+}
 
-	parts := t.buildContainerSpec(projectName, serviceName, svc)
+// IngressHostLabel is the compose service label that routes a hostname
+// (e.g. "app.localhost") to the service through an Ingress.
+const IngressHostLabel = "kappal.io/ingress-host"
 
-	securityContextSpec := ""
-	if len(svc.Volumes) > 0 {
-		securityContextSpec = "\n      securityContext:\n        fsGroup: 999"
-	}
-
-	initContainerSpec := t.buildInitContainerSpec(projectName, svc, allServices)
-
-	return fmt.Sprintf(`---
-apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-    kappal.io/service: "%s"
-spec:
-  replicas: %d
-  selector:
-    matchLabels:
-      kappal.io/project: "%s"
-      kappal.io/service: "%s"
-  template:
-    metadata:
-      labels:
-%s
-    spec:%s%s
-      containers:
-      - name: %s
-        image: %s
-        imagePullPolicy: IfNotPresent%s%s`, serviceName, projectName, projectName, serviceName, replicas,
-		projectName, serviceName, parts.labels, securityContextSpec, initContainerSpec,
-		serviceName, svc.Image, parts.containerSpec, parts.volumeSpec)
-}
-
-func (t *Transformer) generateJob(projectName, serviceName string, svc ServiceSpec, allServices map[string]ServiceSpec) string {
-	parts := t.buildContainerSpec(projectName, serviceName, svc)
-
-	securityContextSpec := ""
-	if len(svc.Volumes) > 0 {
-		securityContextSpec = "\n      securityContext:\n        fsGroup: 999"
-	}
-
-	initContainerSpec := t.buildInitContainerSpec(projectName, svc, allServices)
-
-	return fmt.Sprintf(`---
-apiVersion: batch/v1
-kind: Job
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-    kappal.io/service: "%s"
-spec:
-  backoffLimit: 3
-  template:
-    metadata:
-      labels:
-%s
-    spec:
-      restartPolicy: Never%s%s
-      containers:
-      - name: %s
-        image: %s
-        imagePullPolicy: IfNotPresent%s%s`, serviceName, projectName, projectName, serviceName,
-		parts.labels, securityContextSpec, initContainerSpec,
-		serviceName, svc.Image, parts.containerSpec, parts.volumeSpec)
-}
-
-func (t *Transformer) generateInitReaderRBAC(projectName string, needJobs, needPods bool) string {
-	var rules []string
+// generateIngress builds the Ingress routing the kappal.io/ingress-host
+// hostname to the service's first TCP port, or returns nil without the label.
+func (t *Transformer) generateIngress(projectName, serviceName string, svc ServiceSpec, service *corev1.Service) (*networkingv1.Ingress, error) {
+	host, ok := svc.Labels[IngressHostLabel]
+	if !ok {
+		return nil, nil
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return nil, fmt.Errorf("service %q: invalid %s %q: %s", serviceName, IngressHostLabel, host, strings.Join(errs, "; "))
+	}
+	var port int32
+	for _, p := range service.Spec.Ports {
+		if p.Protocol == corev1.ProtocolTCP {
+			port = p.Port
+			break
+		}
+	}
+	if port == 0 {
+		return nil, fmt.Errorf("service %q: %s needs a TCP port to route to", serviceName, IngressHostLabel)
+	}
+
+	pathType := networkingv1.PathTypePrefix
+	return &networkingv1.Ingress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+		ObjectMeta: objectMeta(serviceName, t.namespaceFor(projectName), serviceLabels(projectName, serviceName)),
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: serviceName,
+									Port: networkingv1.ServiceBackendPort{Number: port},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}, nil
+}
+
+// JobTTLLabel is the compose service label that sets how long, in seconds,
+// a finished one-shot Job and its pod are kept before K8s deletes them
+// (ttlSecondsAfterFinished). "never" keeps them until 'kappal down'.
+const JobTTLLabel = "kappal.io/job-ttl"
+
+// DefaultJobTTL is the ttlSecondsAfterFinished of one-shot Jobs without
+// the kappal.io/job-ttl label.
+const DefaultJobTTL int32 = 3600
+
+// jobTTL returns the ttlSecondsAfterFinished for a service's Job, or nil
+// when the label asks to keep it or another service waits for it with
+// service_completed_successfully: kappal-init in the dependents' pods needs
+// the finished Job whenever they are recreated. Invalid labels fall back to
+// the default; callers warn about them separately.
+func jobTTL(serviceName string, svc ServiceSpec, allServices map[string]ServiceSpec) *int32 {
+	if awaitedJob(serviceName, allServices) {
+		return nil
+	}
+	ttl := DefaultJobTTL
+	if value, ok := svc.Labels[JobTTLLabel]; ok {
+		if value == "never" {
+			return nil
+		}
+		if v, err := strconv.ParseInt(value, 10, 32); err == nil && v >= 0 {
+			ttl = int32(v)
+		}
+	}
+	return &ttl
+}
+
+// awaitedJob reports whether a service depends on serviceName with
+// service_completed_successfully.
+func awaitedJob(serviceName string, allServices map[string]ServiceSpec) bool {
+	for _, svc := range allServices {
+		for _, dep := range svc.DependsOn {
+			if dep.Service == serviceName && dep.Condition == "service_completed_successfully" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *Transformer) generateJob(projectName, serviceName string, svc ServiceSpec, allServices map[string]ServiceSpec) *batchv1.Job {
+	backoffLimit := int32(3)
+	restartPolicy := corev1.RestartPolicyNever
+	if _, onFailure := compose.OnFailureRetries(svc.Restart); onFailure {
+		restartPolicy = corev1.RestartPolicyOnFailure
+		if svc.RestartRetries != nil {
+			backoffLimit = *svc.RestartRetries
+		}
+	}
+	// A one-shot service runs exactly once; deploy.replicas does not turn it
+	// into a multi-completion Job (analyzeCompatibility warns about it)
+	once := int32(1)
+
+	template := t.buildPodTemplate(projectName, serviceName, svc, allServices)
+	template.Spec.RestartPolicy = restartPolicy
+
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: objectMeta(serviceName, t.namespaceFor(projectName), serviceLabels(projectName, serviceName)),
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			Completions:             &once,
+			Parallelism:             &once,
+			TTLSecondsAfterFinished: jobTTL(serviceName, svc, allServices),
+			Template:                template,
+		},
+	}
+	if t.noStart {
+		suspend := true
+		job.Spec.Suspend = &suspend
+	}
+	return job
+}
+
+// ConfigChecksumAnnotation holds a hash of a Job's spec and the content of
+// the secrets and configs it mounts. Jobs are immutable, so up deletes and
+// re-runs only the Jobs whose live checksum differs from the rendered one
+// (k8s.Client.DeleteJobs), even when the files' mtimes are unchanged.
+const ConfigChecksumAnnotation = "kappal.io/config-checksum"
+
+// jobChecksum hashes a Job's spec and the data of the generated Secrets and
+// ConfigMaps svc mounts. Secrets kappal doesn't generate (environment,
+// external) have no content to hash. Suspend is left out: start resumes a
+// Job created by up --no-start, and the next up must not re-run it.
+func jobChecksum(job *batchv1.Job, svc ServiceSpec, secrets map[string]*corev1.Secret, configMaps map[string]*corev1.ConfigMap) string {
+	h := sha256.New()
+	spec := job.Spec.DeepCopy()
+	spec.Suspend = nil
+	// encoding/json sorts map keys, so equal specs hash equally
+	data, _ := json.Marshal(spec)
+	h.Write(data)
+	write := func(kind, name, key string, value []byte) {
+		fmt.Fprintf(h, "%s %s %s %d\n", kind, name, key, len(value))
+		h.Write(value)
+	}
+	for _, ref := range svc.Secrets {
+		if secret, ok := secrets[ref.Source]; ok {
+			for _, key := range sortedKeys(secret.Data) {
+				write("secret", ref.Source, key, secret.Data[key])
+			}
+		}
+	}
+	for _, ref := range svc.Configs {
+		if cm, ok := configMaps[ref.Source]; ok {
+			for _, key := range sortedKeys(cm.Data) {
+				write("config", ref.Source, key, []byte(cm.Data[key]))
+			}
+			for _, key := range sortedKeys(cm.BinaryData) {
+				write("config", ref.Source, key, cm.BinaryData[key])
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// initReaderName returns the name of the Role and RoleBinding that let
+// kappal-init read Jobs and pods, suffixed with the project so projects
+// sharing a namespace don't overwrite each other's rules.
+func initReaderName(projectName string) string {
+	return "kappal-init-reader-" + sanitizeName(projectName)
+}
+
+func (t *Transformer) generateInitReaderRBAC(projectName string, needJobs, needPods bool) (*rbacv1.Role, *rbacv1.RoleBinding) {
+	var rules []rbacv1.PolicyRule
 	if needJobs {
-		rules = append(rules, `- apiGroups: ["batch"]
-  resources: ["jobs"]
-  verbs: ["get", "list", "watch"]`)
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{"batch"},
+			Resources: []string{"jobs"},
+			Verbs:     []string{"get", "list", "watch"},
+		})
 	}
 	if needPods {
-		rules = append(rules, `- apiGroups: [""]
-  resources: ["pods"]
-  verbs: ["get", "list"]`)
-	}
-
-	return fmt.Sprintf(`---
-apiVersion: rbac.authorization.k8s.io/v1
-kind: Role
-metadata:
-  name: kappal-init-reader
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-rules:
-%s
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: RoleBinding
-metadata:
-  name: kappal-init-reader
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-subjects:
-- kind: ServiceAccount
-  name: default
-  namespace: %s
-roleRef:
-  kind: Role
-  name: kappal-init-reader
-  apiGroup: rbac.authorization.k8s.io`, projectName, projectName, strings.Join(rules, "\n"), projectName, projectName, projectName)
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"get", "list"},
+		})
+	}
+
+	role := &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+		ObjectMeta: objectMeta(initReaderName(projectName), t.namespaceFor(projectName), projectLabels(projectName)),
+		Rules:      rules,
+	}
+	binding := &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+		ObjectMeta: objectMeta(initReaderName(projectName), t.namespaceFor(projectName), projectLabels(projectName)),
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      "default",
+			Namespace: t.namespaceFor(projectName),
+		}},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "Role",
+			Name:     initReaderName(projectName),
+			APIGroup: "rbac.authorization.k8s.io",
+		},
+	}
+	return role, binding
 }
 
 // getDefaultPort returns the default port for well-known images
@@ -1004,8 +2600,8 @@ func getDefaultPort(image string) uint32 {
 	return 0
 }
 
-func (t *Transformer) generateService(projectName, serviceName string, svc ServiceSpec) string {
-	var portItems []string
+func (t *Transformer) generateService(projectName, serviceName string, svc ServiceSpec) *corev1.Service {
+	var ports []corev1.ServicePort
 	hasExternalPorts := len(svc.Ports) > 0
 
 	if hasExternalPorts {
@@ -1014,63 +2610,65 @@ func (t *Transformer) generateService(projectName, serviceName string, svc Servi
 		// The published (host) port is handled by Docker port bindings on the K3s container,
 		// not by the K8s Service. Port chain: Host:published → K3s:target → ServiceLB:target → Pod:target
 		for i, p := range svc.Ports {
-			protocol := strings.ToUpper(p.Protocol)
-			if protocol == "" {
-				protocol = "TCP"
+			ports = append(ports, corev1.ServicePort{
+				Name:       fmt.Sprintf("port-%d", i),
+				Port:       int32(p.Target),
+				TargetPort: intstr.FromInt32(int32(p.Target)),
+				Protocol:   portProtocol(p.Protocol),
+			})
+		}
+	}
+
+	// expose ports are only reachable inside the cluster; skip those that
+	// ports already covers
+	for _, p := range svc.Expose {
+		covered := false
+		for _, published := range svc.Ports {
+			if published.Target == p.Target && portProtocol(published.Protocol) == portProtocol(p.Protocol) {
+				covered = true
+				break
 			}
-			portItems = append(portItems, fmt.Sprintf(`  - name: port-%d
-    port: %d
-    targetPort: %d
-    protocol: %s`, i, p.Target, p.Target, protocol))
 		}
-	} else {
-		// No explicit ports - try to infer from image for internal service discovery
-		defaultPort := getDefaultPort(svc.Image)
-		if defaultPort > 0 {
-			portItems = append(portItems, fmt.Sprintf(`  - name: port-0
-    port: %d
-    targetPort: %d
-    protocol: TCP`, defaultPort, defaultPort))
-		} else {
-			// Can't determine port - use a placeholder port 80
-			// This at least enables DNS resolution
-			portItems = append(portItems, `  - name: port-0
-    port: 80
-    targetPort: 80
-    protocol: TCP`)
+		if covered {
+			continue
 		}
+		ports = append(ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("port-%d", len(ports)),
+			Port:       int32(p.Target),
+			TargetPort: intstr.FromInt32(int32(p.Target)),
+			Protocol:   portProtocol(p.Protocol),
+		})
 	}
 
-	// Use LoadBalancer for services with external ports, ClusterIP for internal-only
-	serviceType := "ClusterIP"
-	externalTrafficPolicy := ""
+	if len(ports) == 0 {
+		// No explicit ports - try to infer from image for internal service discovery.
+		// If the port can't be determined, use a placeholder port 80;
+		// this at least enables DNS resolution
+		port := int32(getDefaultPort(svc.Image))
+		if port == 0 {
+			port = 80
+		}
+		ports = append(ports, corev1.ServicePort{
+			Name:       "port-0",
+			Port:       port,
+			TargetPort: intstr.FromInt32(port),
+			Protocol:   corev1.ProtocolTCP,
+		})
+	}
+
+	service := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: objectMeta(serviceName, t.namespaceFor(projectName), serviceLabels(projectName, serviceName)),
+		Spec: corev1.ServiceSpec{
+			// Use LoadBalancer for services with external ports, ClusterIP for internal-only
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: serviceLabels(projectName, serviceName),
+			Ports:    ports,
+		},
+	}
 	if hasExternalPorts {
-		serviceType = "LoadBalancer"
-		externalTrafficPolicy = "\n  externalTrafficPolicy: Local"
-	}
-
-	return fmt.Sprintf(`---
-apiVersion: v1
-kind: Service
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    kappal.io/project: "%s"
-    kappal.io/service: "%s"
-spec:
-  type: %s%s
-  selector:
-    kappal.io/project: "%s"
-    kappal.io/service: "%s"
-  ports:
-%s
-`, serviceName, projectName, projectName, serviceName, serviceType, externalTrafficPolicy, projectName, serviceName, strings.Join(portItems, "\n"))
-}
-
-func escapeYAML(s string) string {
-	s = strings.ReplaceAll(s, `\`, `\\`)
-	s = strings.ReplaceAll(s, `"`, `\"`)
-	s = strings.ReplaceAll(s, "\n", `\n`)
-	return s
+		service.Spec.Type = corev1.ServiceTypeLoadBalancer
+		service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyLocal
+	}
+	return service
 }
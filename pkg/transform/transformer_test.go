@@ -1,11 +1,94 @@
 package transform
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"unicode"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/kappal-app/kappal/pkg/compose"
+	"github.com/kappal-app/kappal/pkg/workspace"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
 )
 
+// toYAML marshals a generated object the same way generateManifests does.
+func toYAML(t *testing.T, obj interface{}) string {
+	t.Helper()
+	data, err := marshalManifest(obj)
+	if err != nil {
+		t.Fatalf("marshalManifest failed: %v", err)
+	}
+	return string(data)
+}
+
+// loadProject loads a compose file from content.
+func loadProject(t *testing.T, content string) *types.Project {
+	t.Helper()
+	project, err := compose.LoadFromContent([]byte(content), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	return project
+}
+
+// renderObjects renders the transformer's manifests and decodes every
+// document into its typed object, in manifest order.
+func renderObjects(t *testing.T, transformer *Transformer) []runtime.Object {
+	t.Helper()
+	data, err := transformer.RenderManifests()
+	if err != nil {
+		t.Fatalf("RenderManifests failed: %v", err)
+	}
+	var objects []runtime.Object
+	for _, doc := range strings.Split(string(data), "---\n") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		obj, _, err := scheme.Codecs.UniversalDeserializer().Decode([]byte(doc), nil, nil)
+		if err != nil {
+			t.Fatalf("decoding manifest document failed: %v\n%s", err, doc)
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// objectsOf returns the rendered objects of type T, e.g. *appsv1.Deployment.
+func objectsOf[T runtime.Object](objects []runtime.Object) []T {
+	var matched []T
+	for _, obj := range objects {
+		if typed, ok := obj.(T); ok {
+			matched = append(matched, typed)
+		}
+	}
+	return matched
+}
+
+// objectNames returns the names of objs in order.
+func objectNames[T metav1.Object](objs []T) []string {
+	names := []string{}
+	for _, obj := range objs {
+		names = append(names, obj.GetName())
+	}
+	return names
+}
+
 func TestSanitizeName(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -112,16 +195,16 @@ func TestCommandVsArgsMapping(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		deployment := transformer.generateDeployment("test", "postgres", svc, nil)
+		container := transformer.generateDeployment("test", "postgres", svc, nil).Spec.Template.Spec.Containers[0]
 
 		// Should have args, not command (to preserve entrypoint)
-		if strings.Contains(deployment, "        command:") {
+		if len(container.Command) > 0 {
 			t.Error("compose command should generate K8s args, not command (to preserve ENTRYPOINT)")
 		}
-		if !strings.Contains(deployment, "        args:") {
+		if len(container.Args) == 0 {
 			t.Error("compose command should generate K8s args")
 		}
-		if !strings.Contains(deployment, `"postgres"`) {
+		if container.Args[0] != "postgres" {
 			t.Error("args should contain the command values")
 		}
 	})
@@ -134,16 +217,16 @@ func TestCommandVsArgsMapping(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		deployment := transformer.generateDeployment("test", "myapp", svc, nil)
+		container := transformer.generateDeployment("test", "myapp", svc, nil).Spec.Template.Spec.Containers[0]
 
 		// Should have both command (from entrypoint) and args (from command)
-		if !strings.Contains(deployment, "        command:") {
+		if len(container.Command) == 0 {
 			t.Error("compose entrypoint should generate K8s command")
 		}
-		if !strings.Contains(deployment, `"/custom-entrypoint.sh"`) {
+		if len(container.Command) > 0 && container.Command[0] != "/custom-entrypoint.sh" {
 			t.Error("command should contain entrypoint values")
 		}
-		if !strings.Contains(deployment, "        args:") {
+		if len(container.Args) == 0 {
 			t.Error("compose command should generate K8s args")
 		}
 	})
@@ -154,13 +237,13 @@ func TestCommandVsArgsMapping(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		deployment := transformer.generateDeployment("test", "nginx", svc, nil)
+		deployment := toYAML(t, transformer.generateDeployment("test", "nginx", svc, nil))
 
 		// Should have neither command nor args
-		if strings.Contains(deployment, "        command:") {
+		if strings.Contains(deployment, "command:") {
 			t.Error("should not have command when entrypoint not specified")
 		}
-		if strings.Contains(deployment, "        args:") {
+		if strings.Contains(deployment, "args:") {
 			t.Error("should not have args when command not specified")
 		}
 	})
@@ -180,7 +263,7 @@ func TestReadinessProbeFromHealthcheck(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		deployment := transformer.generateDeployment("test", "postgres", svc, nil)
+		deployment := toYAML(t, transformer.generateDeployment("test", "postgres", svc, nil))
 
 		if !strings.Contains(deployment, "readinessProbe:") {
 			t.Fatal("deployment should contain readinessProbe")
@@ -188,10 +271,10 @@ func TestReadinessProbeFromHealthcheck(t *testing.T) {
 		if !strings.Contains(deployment, "exec:") {
 			t.Error("readiness probe should use exec")
 		}
-		if !strings.Contains(deployment, `"/bin/sh"`) {
+		if !strings.Contains(deployment, "- /bin/sh") {
 			t.Error("CMD-SHELL should use /bin/sh -c")
 		}
-		if !strings.Contains(deployment, `"pg_isready -U postgres"`) {
+		if !strings.Contains(deployment, "- pg_isready -U postgres") {
 			t.Error("probe should contain healthcheck command")
 		}
 		if !strings.Contains(deployment, "periodSeconds: 10") {
@@ -218,19 +301,19 @@ func TestReadinessProbeFromHealthcheck(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		deployment := transformer.generateDeployment("test", "redis", svc, nil)
+		deployment := toYAML(t, transformer.generateDeployment("test", "redis", svc, nil))
 
 		if !strings.Contains(deployment, "readinessProbe:") {
 			t.Fatal("deployment should contain readinessProbe")
 		}
-		if !strings.Contains(deployment, `"redis-cli"`) {
+		if !strings.Contains(deployment, "- redis-cli") {
 			t.Error("CMD probe should use command args directly")
 		}
-		if !strings.Contains(deployment, `"ping"`) {
+		if !strings.Contains(deployment, "- ping") {
 			t.Error("CMD probe should include all args")
 		}
 		// CMD should NOT wrap in /bin/sh -c
-		if strings.Contains(deployment, `"/bin/sh"`) {
+		if strings.Contains(deployment, "- /bin/sh") {
 			t.Error("CMD probe should not use /bin/sh")
 		}
 		if !strings.Contains(deployment, "failureThreshold: 5") {
@@ -247,7 +330,7 @@ func TestReadinessProbeFromHealthcheck(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		deployment := transformer.generateDeployment("test", "myapp", svc, nil)
+		deployment := toYAML(t, transformer.generateDeployment("test", "myapp", svc, nil))
 
 		if strings.Contains(deployment, "readinessProbe:") {
 			t.Error("NONE healthcheck should not produce a readiness probe")
@@ -260,7 +343,7 @@ func TestReadinessProbeFromHealthcheck(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		deployment := transformer.generateDeployment("test", "nginx", svc, nil)
+		deployment := toYAML(t, transformer.generateDeployment("test", "nginx", svc, nil))
 
 		if strings.Contains(deployment, "readinessProbe:") {
 			t.Error("no healthcheck should not produce a readiness probe")
@@ -268,6 +351,34 @@ func TestReadinessProbeFromHealthcheck(t *testing.T) {
 	})
 }
 
+// Compose unescapes $$ to $ at load time; the probe must pass $VAR through
+// to the container's shell, which expands it from the service environment.
+func TestReadinessProbeKeepsEnvReferences(t *testing.T) {
+	project := loadProject(t, `services:
+  db:
+    image: postgres:16
+    environment:
+      POSTGRES_USER: app
+      POSTGRES_DB: appdb
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U $$POSTGRES_USER -d $${POSTGRES_DB}"]
+      interval: 5s
+`)
+
+	deployments := objectsOf[*appsv1.Deployment](renderObjects(t, NewTransformer(project)))
+	if len(deployments) != 1 {
+		t.Fatalf("expected one Deployment, got %v", objectNames(deployments))
+	}
+	probe := deployments[0].Spec.Template.Spec.Containers[0].ReadinessProbe
+	if probe == nil || probe.Exec == nil {
+		t.Fatalf("expected an exec readiness probe, got %+v", probe)
+	}
+	want := []string{"/bin/sh", "-c", "pg_isready -U $POSTGRES_USER -d ${POSTGRES_DB}"}
+	if !reflect.DeepEqual(probe.Exec.Command, want) {
+		t.Errorf("probe command = %q, want %q", probe.Exec.Command, want)
+	}
+}
+
 func TestInitContainerServiceHealthy(t *testing.T) {
 	allServices := map[string]ServiceSpec{
 		"postgres": {Image: "postgres:16", IsJob: false},
@@ -284,11 +395,12 @@ func TestInitContainerServiceHealthy(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		initSpec := transformer.buildInitContainerSpec("test", svc, allServices)
+		initContainer := transformer.buildInitContainerSpec("test", svc, allServices)
 
-		if initSpec == "" {
+		if initContainer == nil {
 			t.Fatal("service_healthy dep should generate init container")
 		}
+		initSpec := toYAML(t, initContainer)
 		if !strings.Contains(initSpec, "wait-for-deps") {
 			t.Error("init container should be named wait-for-deps")
 		}
@@ -309,11 +421,12 @@ func TestInitContainerServiceHealthy(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		initSpec := transformer.buildInitContainerSpec("test", svc, allServices)
+		initContainer := transformer.buildInitContainerSpec("test", svc, allServices)
 
-		if initSpec == "" {
+		if initContainer == nil {
 			t.Fatal("service_completed_successfully dep should generate init container")
 		}
+		initSpec := toYAML(t, initContainer)
 		if !strings.Contains(initSpec, `"waitForJobs":["migrate"]`) {
 			t.Error("init spec should include waitForJobs with migrate")
 		}
@@ -332,11 +445,12 @@ func TestInitContainerServiceHealthy(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		initSpec := transformer.buildInitContainerSpec("test", svc, allServices)
+		initContainer := transformer.buildInitContainerSpec("test", svc, allServices)
 
-		if initSpec == "" {
+		if initContainer == nil {
 			t.Fatal("combined deps should generate init container")
 		}
+		initSpec := toYAML(t, initContainer)
 		if !strings.Contains(initSpec, `"waitForJobs":["migrate"]`) {
 			t.Error("init spec should include waitForJobs")
 		}
@@ -354,30 +468,91 @@ func TestInitContainerServiceHealthy(t *testing.T) {
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		initSpec := transformer.buildInitContainerSpec("test", svc, allServices)
+		initContainer := transformer.buildInitContainerSpec("test", svc, allServices)
 
-		if initSpec != "" {
+		if initContainer != nil {
 			t.Error("service_healthy on a Job should not generate init container")
 		}
 	})
 
-	t.Run("service_started generates no init container", func(t *testing.T) {
+	t.Run("service_started generates init with waitForStarted", func(t *testing.T) {
 		svc := ServiceSpec{
 			Image: "app:latest",
 			DependsOn: []DependsOnSpec{
 				{Service: "postgres", Condition: "service_started"},
+				{Service: "migrate", Condition: "service_started"},
+				{Service: "missing", Condition: "service_started"},
 			},
 		}
 
 		transformer := &Transformer{workingDir: "/tmp"}
-		initSpec := transformer.buildInitContainerSpec("test", svc, allServices)
+		initContainer := transformer.buildInitContainerSpec("test", svc, allServices)
 
-		if initSpec != "" {
-			t.Error("service_started should not generate init container")
+		if initContainer == nil {
+			t.Fatal("service_started dep should generate init container")
+		}
+		initSpec := toYAML(t, initContainer)
+		if !strings.Contains(initSpec, `"waitForStarted":["postgres","migrate"]`) {
+			t.Errorf("init spec should wait for postgres and migrate to start, got:\n%s", initSpec)
 		}
 	})
 }
 
+func TestBindSourceResolution(t *testing.T) {
+	svc := ServiceSpec{
+		Image:   "app:latest",
+		Volumes: []VolumeMount{{Source: "./data", Target: "/data", Type: "bind"}},
+	}
+	hostPath := func(tr *Transformer) string {
+		volume := tr.generateDeployment("test", "app", svc, nil).Spec.Template.Spec.Volumes[0]
+		if volume.HostPath == nil {
+			t.Fatalf("expected hostPath volume, got %+v", volume)
+		}
+		return volume.HostPath.Path
+	}
+
+	if got := hostPath(&Transformer{workingDir: "/home/user/app"}); got != "/home/user/app/data" {
+		t.Errorf("expected relative source resolved to /home/user/app/data, got %s", got)
+	}
+
+	wrapper := &Transformer{workingDir: "/project/services/app", hostDir: "/home/user/repo"}
+	if got := hostPath(wrapper); got != "/home/user/repo/services/app/data" {
+		t.Errorf("expected wrapper source mapped to host dir, got %s", got)
+	}
+
+	svc.Volumes[0].Source = "/var/log"
+	if got := hostPath(wrapper); got != "/var/log" {
+		t.Errorf("expected absolute source outside /project to be kept, got %s", got)
+	}
+}
+
+func TestInitContainerResources(t *testing.T) {
+	allServices := map[string]ServiceSpec{
+		"postgres": {Image: "postgres:16"},
+	}
+	svc := ServiceSpec{
+		Image:     "app:latest",
+		DependsOn: []DependsOnSpec{{Service: "postgres", Condition: "service_healthy"}},
+	}
+
+	transformer := &Transformer{workingDir: "/tmp"}
+	initContainer := transformer.buildInitContainerSpec("test", svc, allServices)
+	if initContainer == nil {
+		t.Fatal("service_healthy dep should generate init container")
+	}
+	resources := initContainer.Resources
+	for name, want := range map[corev1.ResourceName]string{corev1.ResourceCPU: "10m", corev1.ResourceMemory: "16Mi"} {
+		if got := resources.Requests[name]; got.String() != want {
+			t.Errorf("init container %s request = %s, want %s", name, got.String(), want)
+		}
+	}
+	for name, want := range map[corev1.ResourceName]string{corev1.ResourceCPU: "250m", corev1.ResourceMemory: "128Mi"} {
+		if got := resources.Limits[name]; got.String() != want {
+			t.Errorf("init container %s limit = %s, want %s", name, got.String(), want)
+		}
+	}
+}
+
 func TestInitContainerWritableBindMounts(t *testing.T) {
 	transformer := &Transformer{workingDir: "/tmp"}
 
@@ -389,10 +564,11 @@ func TestInitContainerWritableBindMounts(t *testing.T) {
 			},
 		}
 
-		initSpec := transformer.buildInitContainerSpec("test", svc, nil)
-		if initSpec == "" {
+		initContainer := transformer.buildInitContainerSpec("test", svc, nil)
+		if initContainer == nil {
 			t.Fatal("writable bind mount should generate init container")
 		}
+		initSpec := toYAML(t, initContainer)
 		if !strings.Contains(initSpec, `"prepareWritablePaths":["/data"]`) {
 			t.Error("init spec should include prepareWritablePaths with /data")
 		}
@@ -402,7 +578,7 @@ func TestInitContainerWritableBindMounts(t *testing.T) {
 		if !strings.Contains(initSpec, "volumeMounts:") {
 			t.Error("init spec should include volume mounts")
 		}
-		if !strings.Contains(initSpec, "mountPath: \"/data\"") {
+		if !strings.Contains(initSpec, "mountPath: /data") {
 			t.Error("init spec should mount the bind target path")
 		}
 	})
@@ -415,8 +591,8 @@ func TestInitContainerWritableBindMounts(t *testing.T) {
 			},
 		}
 
-		initSpec := transformer.buildInitContainerSpec("test", svc, nil)
-		if initSpec != "" {
+		initContainer := transformer.buildInitContainerSpec("test", svc, nil)
+		if initContainer != nil {
 			t.Error("read-only bind mount should not generate init container by itself")
 		}
 	})
@@ -435,10 +611,11 @@ func TestInitContainerWritableBindMounts(t *testing.T) {
 			},
 		}
 
-		initSpec := transformer.buildInitContainerSpec("test", svc, allServices)
-		if initSpec == "" {
+		initContainer := transformer.buildInitContainerSpec("test", svc, allServices)
+		if initContainer == nil {
 			t.Fatal("combined dependency + writable bind should generate init container")
 		}
+		initSpec := toYAML(t, initContainer)
 		if !strings.Contains(initSpec, `"waitForJobs":["migrate"]`) {
 			t.Error("init spec should include waitForJobs")
 		}
@@ -448,47 +625,245 @@ func TestInitContainerWritableBindMounts(t *testing.T) {
 	})
 }
 
+func TestInitContainerOwnedFiles(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+	mode := uint32(0400)
+	svc := ServiceSpec{
+		Image: "app:latest",
+		Configs: []ConfigRef{
+			{Source: "app_config", Target: "/etc/app/config.yaml", UID: "1000", Mode: &mode},
+		},
+		Secrets: []SecretRef{
+			{Source: "plain"},
+		},
+	}
+
+	t.Run("uid generates chown init container", func(t *testing.T) {
+		initContainer := transformer.buildInitContainerSpec("test", svc, nil)
+		if initContainer == nil {
+			t.Fatal("config with uid should generate init container")
+		}
+		initSpec := toYAML(t, initContainer)
+		if !strings.Contains(initSpec, `"ownedFiles":[{"source":"/kappal/src/config-app-config/app_config","target":"/kappal/owned/config-app-config/app_config","uid":1000,"gid":0,"mode":256}]`) {
+			t.Errorf("init spec should include the owned config file, got:\n%s", initSpec)
+		}
+		if !strings.Contains(initSpec, "runAsUser: 0") {
+			t.Error("chown init should run as root")
+		}
+		if strings.Contains(initSpec, "secret-plain") {
+			t.Error("secrets without uid/gid should not be copied by init")
+		}
+	})
+
+	t.Run("workload mounts the owned copy at the target path", func(t *testing.T) {
+		podSpec := transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec
+		mounts := map[string]string{}
+		for _, m := range podSpec.Containers[0].VolumeMounts {
+			mounts[m.MountPath] = m.Name
+		}
+		if mounts["/etc/app/config.yaml"] != "config-app-config-owned" {
+			t.Errorf("expected config mounted from owned emptyDir, got mounts %v", mounts)
+		}
+		if mounts["/run/secrets/plain"] != "secret-plain" {
+			t.Errorf("expected plain secret mounted directly, got mounts %v", mounts)
+		}
+		var hasEmptyDir bool
+		for _, v := range podSpec.Volumes {
+			if v.Name == "config-app-config-owned" && v.EmptyDir != nil {
+				hasEmptyDir = true
+			}
+		}
+		if !hasEmptyDir {
+			t.Error("expected emptyDir volume for the owned config copy")
+		}
+	})
+
+	t.Run("absolute secret target and mode without ownership", func(t *testing.T) {
+		secretMode := uint32(0440)
+		svc := ServiceSpec{
+			Image:   "app:latest",
+			Secrets: []SecretRef{{Source: "tls_key", Target: "/etc/tls/key.pem", Mode: &secretMode}},
+		}
+		if transformer.buildInitContainerSpec("test", svc, nil) != nil {
+			t.Error("mode alone should not require an init container")
+		}
+		podSpec := transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec
+		if got := podSpec.Containers[0].VolumeMounts[0].MountPath; got != "/etc/tls/key.pem" {
+			t.Errorf("expected absolute secret target to be used as-is, got %q", got)
+		}
+		if dm := podSpec.Volumes[0].Secret.DefaultMode; dm == nil || *dm != 0440 {
+			t.Errorf("expected secret defaultMode 0440, got %v", dm)
+		}
+	})
+}
+
+func TestSecretVolumeMode(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+
+	t.Run("mode sets defaultMode and item mode", func(t *testing.T) {
+		mode := uint32(0400)
+		svc := ServiceSpec{
+			Image:   "app:latest",
+			Secrets: []SecretRef{{Source: "ssh_key", Mode: &mode}},
+		}
+		volume := transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec.Volumes[0]
+		if volume.Secret == nil || volume.Secret.DefaultMode == nil || *volume.Secret.DefaultMode != 0400 {
+			t.Fatalf("expected defaultMode 0400, got %+v", volume.Secret)
+		}
+		if len(volume.Secret.Items) != 1 {
+			t.Fatalf("expected one secret item, got %+v", volume.Secret.Items)
+		}
+		item := volume.Secret.Items[0]
+		if item.Key != "ssh_key" || item.Path != "ssh_key" || item.Mode == nil || *item.Mode != 0400 {
+			t.Errorf("unexpected secret item %+v", item)
+		}
+		if !strings.Contains(toYAML(t, transformer.generateDeployment("test", "app", svc, nil)), "defaultMode: 256") {
+			t.Error("manifest should include defaultMode")
+		}
+	})
+
+	t.Run("no mode keeps the default volume", func(t *testing.T) {
+		svc := ServiceSpec{
+			Image:   "app:latest",
+			Secrets: []SecretRef{{Source: "ssh_key"}},
+		}
+		volume := transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec.Volumes[0]
+		if volume.Secret.DefaultMode != nil || len(volume.Secret.Items) != 0 {
+			t.Errorf("expected no defaultMode or items without mode, got %+v", volume.Secret)
+		}
+	})
+}
+
+func TestSecretSourceFileMode(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "ssh_key")
+	if err := os.WriteFile(keyPath, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// WriteFile is subject to umask, so pin the mode explicitly
+	if err := os.Chmod(keyPath, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenPath, []byte("token"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(tokenPath, 0644); err != nil {
+		t.Fatal(err)
+	}
+	explicit := uint32(0400)
+	project := &types.Project{
+		Name:       "test",
+		WorkingDir: dir,
+		Services: types.Services{
+			"app": {
+				Name:  "app",
+				Image: "app:latest",
+				Secrets: []types.ServiceSecretConfig{
+					{Source: "ssh_key"},
+					{Source: "token"},
+					{Source: "ssh_key", Target: "/etc/override", Mode: &explicit},
+				},
+			},
+		},
+		Secrets: types.Secrets{
+			"ssh_key": {File: keyPath},
+			"token":   {File: "token"},
+		},
+	}
+
+	secrets := NewTransformer(project).ToSpec().Services["app"].Secrets
+	if secrets[0].Mode == nil || *secrets[0].Mode != 0600 {
+		t.Errorf("0600 source file should mount with mode 0600, got %v", secrets[0].Mode)
+	}
+	if secrets[1].Mode != nil {
+		t.Errorf("0644 source file should keep the default mode, got %o", *secrets[1].Mode)
+	}
+	if secrets[2].Mode == nil || *secrets[2].Mode != 0400 {
+		t.Errorf("explicit compose mode should win over the file mode, got %v", secrets[2].Mode)
+	}
+
+	var modes []int32
+	for _, d := range objectsOf[*appsv1.Deployment](renderObjects(t, NewTransformer(project))) {
+		for _, v := range d.Spec.Template.Spec.Volumes {
+			if v.Secret != nil && v.Secret.DefaultMode != nil && v.Secret.SecretName == sanitizeName("ssh_key") {
+				modes = append(modes, *v.Secret.DefaultMode)
+			}
+		}
+	}
+	if !reflect.DeepEqual(modes, []int32{0600, 0400}) {
+		t.Errorf("ssh_key volumes should mount with defaultMode 0600 and the explicit 0400, got %o", modes)
+	}
+}
+
+func TestConfigVolumeMode(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+	mode := uint32(0555)
+	svc := ServiceSpec{
+		Image:   "app:latest",
+		Configs: []ConfigRef{{Source: "entrypoint_sh", Target: "/usr/local/bin/entrypoint.sh", Mode: &mode}},
+	}
+
+	volume := transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec.Volumes[0]
+	if volume.ConfigMap == nil || volume.ConfigMap.DefaultMode == nil || *volume.ConfigMap.DefaultMode != 0555 {
+		t.Fatalf("expected configMap defaultMode 0555, got %+v", volume.ConfigMap)
+	}
+	if len(volume.ConfigMap.Items) != 1 || volume.ConfigMap.Items[0].Mode == nil || *volume.ConfigMap.Items[0].Mode != 0555 {
+		t.Errorf("expected item mode 0555, got %+v", volume.ConfigMap.Items)
+	}
+
+	svc.Configs[0].Mode = nil
+	volume = transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec.Volumes[0]
+	if volume.ConfigMap.DefaultMode != nil || len(volume.ConfigMap.Items) != 0 {
+		t.Errorf("expected default configMap volume without mode, got %+v", volume.ConfigMap)
+	}
+}
+
 func TestRBACGenerationForDependencies(t *testing.T) {
 	transformer := &Transformer{workingDir: "/tmp"}
 
 	t.Run("job dependency generates batch/jobs RBAC", func(t *testing.T) {
-		rbac := transformer.generateInitReaderRBAC("test", true, false)
+		role, binding := transformer.generateInitReaderRBAC("test", true, false)
+		rbac := toYAML(t, role)
 
-		if !strings.Contains(rbac, `apiGroups: ["batch"]`) {
+		if !strings.Contains(rbac, "- batch") {
 			t.Error("should include batch apiGroup for jobs")
 		}
-		if !strings.Contains(rbac, `resources: ["jobs"]`) {
+		if !strings.Contains(rbac, "- jobs") {
 			t.Error("should include jobs resource")
 		}
-		if strings.Contains(rbac, `resources: ["pods"]`) {
+		if strings.Contains(rbac, "- pods") {
 			t.Error("should not include pods when only job deps")
 		}
-		if !strings.Contains(rbac, "kappal-init-reader") {
-			t.Error("role should be named kappal-init-reader")
+		// Suffixed with the project so projects sharing a namespace keep their own
+		if role.Name != "kappal-init-reader-test" || binding.Name != role.Name || binding.RoleRef.Name != role.Name {
+			t.Errorf("role %q, binding %q -> %q should all be kappal-init-reader-test", role.Name, binding.Name, binding.RoleRef.Name)
 		}
 	})
 
 	t.Run("service_healthy dependency generates pods RBAC", func(t *testing.T) {
-		rbac := transformer.generateInitReaderRBAC("test", false, true)
+		role, _ := transformer.generateInitReaderRBAC("test", false, true)
+		rbac := toYAML(t, role)
 
-		if !strings.Contains(rbac, `resources: ["pods"]`) {
+		if !strings.Contains(rbac, "- pods") {
 			t.Error("should include pods resource")
 		}
-		if !strings.Contains(rbac, `apiGroups: [""]`) {
+		if !strings.Contains(rbac, `- ""`) {
 			t.Error("should include core apiGroup for pods")
 		}
-		if strings.Contains(rbac, `resources: ["jobs"]`) {
+		if strings.Contains(rbac, "- jobs") {
 			t.Error("should not include jobs when only service deps")
 		}
 	})
 
 	t.Run("both dependencies generate combined RBAC", func(t *testing.T) {
-		rbac := transformer.generateInitReaderRBAC("test", true, true)
+		role, _ := transformer.generateInitReaderRBAC("test", true, true)
+		rbac := toYAML(t, role)
 
-		if !strings.Contains(rbac, `resources: ["jobs"]`) {
+		if !strings.Contains(rbac, "- jobs") {
 			t.Error("should include jobs resource")
 		}
-		if !strings.Contains(rbac, `resources: ["pods"]`) {
+		if !strings.Contains(rbac, "- pods") {
 			t.Error("should include pods resource")
 		}
 	})
@@ -543,7 +918,7 @@ func TestGenerateManifestsServiceHealthyEndToEnd(t *testing.T) {
 	transformer := &Transformer{workingDir: "/tmp"}
 
 	// Check postgres deployment has readiness probe
-	pgDeployment := transformer.generateDeployment("test", "postgres", allServices["postgres"], allServices)
+	pgDeployment := toYAML(t, transformer.generateDeployment("test", "postgres", allServices["postgres"], allServices))
 	if !strings.Contains(pgDeployment, "readinessProbe:") {
 		t.Error("postgres deployment should have readinessProbe from healthcheck")
 	}
@@ -552,7 +927,7 @@ func TestGenerateManifestsServiceHealthyEndToEnd(t *testing.T) {
 	}
 
 	// Check app deployment has init container waiting for postgres
-	appDeployment := transformer.generateDeployment("test", "app", allServices["app"], allServices)
+	appDeployment := toYAML(t, transformer.generateDeployment("test", "app", allServices["app"], allServices))
 	if !strings.Contains(appDeployment, "initContainers:") {
 		t.Error("app deployment should have init containers")
 	}
@@ -582,7 +957,7 @@ func TestServicePortUsesTargetNotPublished(t *testing.T) {
 	}
 
 	transformer := &Transformer{workingDir: "/tmp"}
-	service := transformer.generateService("test", "web", svc)
+	service := toYAML(t, transformer.generateService("test", "web", svc))
 
 	// Should contain port: 8080 (target), NOT port: 8082 (published)
 	if !strings.Contains(service, "port: 8080") {
@@ -595,3 +970,2041 @@ func TestServicePortUsesTargetNotPublished(t *testing.T) {
 		t.Error("K8s Service targetPort should use target port (8080)")
 	}
 }
+
+func TestExposePorts(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  api:
+    image: myorg/api
+    expose: ["3000", "9000-9001/udp"]
+  web:
+    image: nginx
+    ports: ["8080:80"]
+    expose: ["80", "9090"]
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	wantExpose := []PortSpec{
+		{Target: 3000, Protocol: "tcp"},
+		{Target: 9000, Protocol: "udp"},
+		{Target: 9001, Protocol: "udp"},
+	}
+	if got := spec.Services["api"].Expose; !reflect.DeepEqual(got, wantExpose) {
+		t.Errorf("expose = %+v, want %+v", got, wantExpose)
+	}
+
+	t.Run("expose only gives a ClusterIP Service without the placeholder port", func(t *testing.T) {
+		service := transformer.generateService("test", "api", spec.Services["api"])
+		if service.Spec.Type != corev1.ServiceTypeClusterIP {
+			t.Errorf("service type = %s, want ClusterIP", service.Spec.Type)
+		}
+		var got []string
+		for _, p := range service.Spec.Ports {
+			got = append(got, fmt.Sprintf("%s %d/%s", p.Name, p.Port, p.Protocol))
+		}
+		want := []string{"port-0 3000/TCP", "port-1 9000/UDP", "port-2 9001/UDP"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("service ports = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("expose adds ports not already published", func(t *testing.T) {
+		service := transformer.generateService("test", "web", spec.Services["web"])
+		var got []int32
+		for _, p := range service.Spec.Ports {
+			got = append(got, p.Port)
+		}
+		if !reflect.DeepEqual(got, []int32{80, 9090}) {
+			t.Errorf("service ports = %v, want [80 9090]", got)
+		}
+	})
+}
+
+func TestPortSpecHostIP(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: nginx
+    ports: ["127.0.0.1:8080:80", "0.0.0.0:8443:443", "9090:90"]
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	got := NewTransformer(project).ToSpec().Services["web"].Ports
+	want := []PortSpec{
+		{HostIP: "127.0.0.1", Target: 80, Published: 8080, Protocol: "tcp"},
+		{HostIP: "0.0.0.0", Target: 443, Published: 8443, Protocol: "tcp"},
+		{Target: 90, Published: 9090, Protocol: "tcp"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ports = %+v, want %+v", got, want)
+	}
+}
+
+func TestPortSpecRanges(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  media:
+    image: media
+    ports: ["8000-8002:8000-8002/udp"]
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	got := NewTransformer(project).ToSpec().Services["media"].Ports
+	want := []PortSpec{
+		{Target: 8000, Published: 8000, Protocol: "udp"},
+		{Target: 8001, Published: 8001, Protocol: "udp"},
+		{Target: 8002, Published: 8002, Protocol: "udp"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ports = %+v, want %+v", got, want)
+	}
+}
+
+func TestPublishedPort(t *testing.T) {
+	tests := []struct {
+		published string
+		want      uint32
+		wantErr   bool
+	}{
+		{"", 80, false},
+		{"8080", 8080, false},
+		{"8080-8080", 8080, false},
+		{"8000-8010", 0, true},
+		{"8010-8000", 0, true},
+		{"http", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := PublishedPort(types.ServicePortConfig{Target: 80, Published: tt.published})
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("PublishedPort(%q) = %d, %v; want %d, error %v", tt.published, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestConfigMapContentRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	transformer := &Transformer{workingDir: dir}
+
+	t.Run("multi-line text survives marshaling", func(t *testing.T) {
+		content := "server {\n\tlisten 80;  \n}\nkey: value\n: leading colon\n"
+		if err := os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cm, err := transformer.generateConfigMap("test", "nginx_conf", ConfigSpec{File: "nginx.conf"})
+		if err != nil {
+			t.Fatalf("generateConfigMap failed: %v", err)
+		}
+		var decoded corev1.ConfigMap
+		if err := yaml.Unmarshal([]byte(toYAML(t, cm)), &decoded); err != nil {
+			t.Fatalf("generated ConfigMap is not valid YAML: %v", err)
+		}
+		if decoded.Name != "nginx-conf" {
+			t.Errorf("expected sanitized name nginx-conf, got %q", decoded.Name)
+		}
+		if decoded.Data["nginx_conf"] != content {
+			t.Errorf("config content mangled:\n%q\nwant:\n%q", decoded.Data["nginx_conf"], content)
+		}
+	})
+
+	t.Run("non-UTF8 content uses binaryData", func(t *testing.T) {
+		content := []byte{0x00, 0xff, 0xfe, 0x80}
+		if err := os.WriteFile(filepath.Join(dir, "blob.bin"), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cm, err := transformer.generateConfigMap("test", "blob", ConfigSpec{File: "blob.bin"})
+		if err != nil {
+			t.Fatalf("generateConfigMap failed: %v", err)
+		}
+		if _, ok := cm.Data["blob"]; ok {
+			t.Error("binary content should not be stored in data")
+		}
+		var decoded corev1.ConfigMap
+		if err := yaml.Unmarshal([]byte(toYAML(t, cm)), &decoded); err != nil {
+			t.Fatalf("generated ConfigMap is not valid YAML: %v", err)
+		}
+		if string(decoded.BinaryData["blob"]) != string(content) {
+			t.Errorf("binaryData = %v, want %v", decoded.BinaryData["blob"], content)
+		}
+	})
+
+	t.Run("unreadable file returns an error", func(t *testing.T) {
+		if _, err := transformer.generateConfigMap("test", "missing", ConfigSpec{File: "missing.conf"}); err == nil {
+			t.Error("expected an error for a missing config file")
+		}
+	})
+}
+
+func TestSecretFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	transformer := &Transformer{workingDir: dir}
+
+	t.Run("readable file populates data", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "db_password.txt"), []byte("hunter2"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		secret, err := transformer.generateSecret("test", "db_password", SecretSpec{File: "db_password.txt"})
+		if err != nil {
+			t.Fatalf("generateSecret failed: %v", err)
+		}
+		if string(secret.Data["db_password"]) != "hunter2" {
+			t.Errorf("secret data = %q, want hunter2", secret.Data["db_password"])
+		}
+	})
+
+	t.Run("missing file names the path", func(t *testing.T) {
+		_, err := transformer.generateSecret("test", "api_key", SecretSpec{File: "missing.txt"})
+		if err == nil {
+			t.Fatal("expected an error for a missing secret file")
+		}
+		if !strings.Contains(err.Error(), filepath.Join(dir, "missing.txt")) {
+			t.Errorf("error should name the missing path, got: %v", err)
+		}
+	})
+
+	t.Run("directory file with an invalid key is rejected", func(t *testing.T) {
+		if err := os.Mkdir(filepath.Join(dir, "bad"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "bad", "a b"), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := transformer.generateSecret("test", "bad", SecretSpec{File: "bad"})
+		if err == nil || !strings.Contains(err.Error(), "not a valid key") {
+			t.Errorf("expected an invalid key error, got: %v", err)
+		}
+	})
+
+	t.Run("Generate fails instead of emitting an empty secret", func(t *testing.T) {
+		project := &types.Project{
+			Name:       "test",
+			WorkingDir: dir,
+			Secrets: types.Secrets{
+				"api_key": {File: filepath.Join(dir, "missing.txt")},
+			},
+		}
+		ws, err := workspace.New(filepath.Join(dir, ".kappal"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := NewTransformer(project).Generate(ws); err == nil {
+			t.Error("expected Generate to fail for an unreadable secret file")
+		}
+	})
+}
+
+func TestDirectorySecret(t *testing.T) {
+	dir := t.TempDir()
+	certs := filepath.Join(dir, "certs")
+	if err := os.MkdirAll(filepath.Join(certs, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"tls.crt": "cert", "tls.key": "key", "nested/ignored": "x"} {
+		if err := os.WriteFile(filepath.Join(certs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project := &types.Project{
+		Name:       "test",
+		WorkingDir: dir,
+		Services: types.Services{
+			"app": {
+				Name:    "app",
+				Image:   "app:latest",
+				Secrets: []types.ServiceSecretConfig{{Source: "certs", Target: "/etc/certs"}},
+			},
+		},
+		Secrets: types.Secrets{"certs": {File: "certs"}},
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	secret, err := transformer.generateSecret("test", "certs", spec.Secrets["certs"])
+	if err != nil {
+		t.Fatalf("generateSecret failed: %v", err)
+	}
+	want := map[string]string{"tls.crt": "cert", "tls.key": "key"}
+	if len(secret.Data) != len(want) {
+		t.Errorf("secret keys = %v, want one per file in %v", sortedKeys(secret.Data), sortedKeys(want))
+	}
+	for key, content := range want {
+		if string(secret.Data[key]) != content {
+			t.Errorf("secret data[%q] = %q, want %q", key, secret.Data[key], content)
+		}
+	}
+
+	podSpec := transformer.generateDeployment("test", "app", spec.Services["app"], spec.Services).Spec.Template.Spec
+	mount := podSpec.Containers[0].VolumeMounts[0]
+	if mount.MountPath != "/etc/certs" || mount.SubPath != "" {
+		t.Errorf("directory secret should mount the whole Secret at /etc/certs, got path %q subPath %q", mount.MountPath, mount.SubPath)
+	}
+	if items := podSpec.Volumes[0].Secret.Items; items != nil {
+		t.Errorf("directory secret without a mode should project every key, got items %v", items)
+	}
+}
+
+func TestMountTargetCollisions(t *testing.T) {
+	tests := []struct {
+		name string
+		svc  ServiceSpec
+		want string
+	}{
+		{
+			name: "two volumes",
+			svc: ServiceSpec{Volumes: []VolumeMount{
+				{Source: "data", Target: "/data", Type: "volume"},
+				{Source: "/host/data", Target: "/data/", Type: "bind"},
+			}},
+			want: "/data (volume data, bind /host/data)",
+		},
+		{
+			name: "secret and volume",
+			svc: ServiceSpec{
+				Volumes: []VolumeMount{{Source: "secrets", Target: "/run/secrets/token"}},
+				Secrets: []SecretRef{{Source: "token"}},
+			},
+			want: "/run/secrets/token (volume secrets, secret token)",
+		},
+		{
+			name: "config and secret",
+			svc: ServiceSpec{
+				Secrets: []SecretRef{{Source: "tls", Target: "/etc/app.conf"}},
+				Configs: []ConfigRef{{Source: "app", Target: "/etc/app.conf"}},
+			},
+			want: "/etc/app.conf (secret tls, config app)",
+		},
+		{
+			name: "distinct targets",
+			svc: ServiceSpec{
+				Volumes: []VolumeMount{{Source: "data", Target: "/data"}},
+				Secrets: []SecretRef{{Source: "token"}},
+				Configs: []ConfigRef{{Source: "app"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMountTargets("app", tt.svc)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected no collision, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected a collision listing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	t.Run("RenderManifests fails on a collision", func(t *testing.T) {
+		project := &types.Project{
+			Name: "test",
+			Services: types.Services{
+				"app": {
+					Name:  "app",
+					Image: "app:latest",
+					Volumes: []types.ServiceVolumeConfig{
+						{Type: "volume", Source: "a", Target: "/data"},
+						{Type: "volume", Source: "b", Target: "/data"},
+					},
+				},
+			},
+			Volumes: types.Volumes{"a": {}, "b": {}},
+		}
+		if _, err := NewTransformer(project).RenderManifests(); err == nil || !strings.Contains(err.Error(), `service "app"`) {
+			t.Errorf("expected a mount collision error, got: %v", err)
+		}
+	})
+}
+
+func TestInterpolatedSecretsAndConfigs(t *testing.T) {
+	dir := t.TempDir()
+	content := "listen ${PORT};\nhost ${HOST:-localhost};\nprice $$5\n"
+	for _, name := range []string{"app.conf", "token.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project, err := compose.LoadFromContent([]byte(fmt.Sprintf(`services:
+  web:
+    image: nginx
+configs:
+  templated:
+    file: %[1]s/app.conf
+    labels:
+      kappal.io/interpolate: "true"
+  literal:
+    file: %[1]s/app.conf
+secrets:
+  token:
+    file: %[1]s/token.txt
+    labels:
+      kappal.io/interpolate: "true"
+`, dir)), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	project.WorkingDir = dir
+	project.Environment = types.Mapping{"PORT": "8080"}
+
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+	if !spec.Configs["templated"].Interpolate || spec.Configs["literal"].Interpolate || !spec.Secrets["token"].Interpolate {
+		t.Fatalf("Interpolate should follow the %s label, got %+v / %+v", InterpolateLabel, spec.Configs, spec.Secrets)
+	}
+
+	want := "listen 8080;\nhost localhost;\nprice $5\n"
+	cm, err := transformer.generateConfigMap("test", "templated", spec.Configs["templated"])
+	if err != nil {
+		t.Fatalf("generateConfigMap failed: %v", err)
+	}
+	if cm.Data["templated"] != want {
+		t.Errorf("interpolated config = %q, want %q", cm.Data["templated"], want)
+	}
+	secret, err := transformer.generateSecret("test", "token", spec.Secrets["token"])
+	if err != nil {
+		t.Fatalf("generateSecret failed: %v", err)
+	}
+	if string(secret.Data["token"]) != want {
+		t.Errorf("interpolated secret = %q, want %q", secret.Data["token"], want)
+	}
+
+	// Without the label, $ is left alone
+	cm, err = transformer.generateConfigMap("test", "literal", spec.Configs["literal"])
+	if err != nil {
+		t.Fatalf("generateConfigMap failed: %v", err)
+	}
+	if cm.Data["literal"] != content {
+		t.Errorf("literal config = %q, want it unchanged", cm.Data["literal"])
+	}
+
+	// A required variable that is unset fails the generate
+	if err := os.WriteFile(filepath.Join(dir, "strict.conf"), []byte("${MISSING:?must be set}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = transformer.generateConfigMap("test", "strict", ConfigSpec{File: "strict.conf", Interpolate: true})
+	if err == nil || !strings.Contains(err.Error(), "must be set") {
+		t.Errorf("expected a required-variable error, got %v", err)
+	}
+
+	for value, want := range map[string][2]bool{"": {false, true}, "true": {true, true}, "0": {false, true}, "yes": {false, false}} {
+		labels := types.Labels{}
+		if value != "" {
+			labels[InterpolateLabel] = value
+		}
+		if enabled, ok := Interpolate(labels); enabled != want[0] || ok != want[1] {
+			t.Errorf("Interpolate(%q) = %v, %v; want %v, %v", value, enabled, ok, want[0], want[1])
+		}
+	}
+}
+
+func TestOversizedSecretAndConfigRejected(t *testing.T) {
+	dir := t.TempDir()
+	transformer := &Transformer{workingDir: dir}
+	if err := os.WriteFile(filepath.Join(dir, "big.bin"), make([]byte, maxObjectDataSize+1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "limit.bin"), make([]byte, maxObjectDataSize), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := transformer.generateSecret("test", "blob", SecretSpec{File: "big.bin"})
+	if err == nil || !strings.Contains(err.Error(), `secret "blob"`) || !strings.Contains(err.Error(), "1MiB") {
+		t.Errorf("expected a size error for the secret, got: %v", err)
+	}
+	_, err = transformer.generateConfigMap("test", "blob", ConfigSpec{File: "big.bin"})
+	if err == nil || !strings.Contains(err.Error(), `config "blob"`) || !strings.Contains(err.Error(), "bind mount") {
+		t.Errorf("expected a size error for the config, got: %v", err)
+	}
+
+	if _, err := transformer.generateSecret("test", "blob", SecretSpec{File: "limit.bin"}); err != nil {
+		t.Errorf("a file of exactly 1MiB should be accepted, got: %v", err)
+	}
+	if _, err := transformer.generateConfigMap("test", "blob", ConfigSpec{File: "limit.bin"}); err != nil {
+		t.Errorf("a file of exactly 1MiB should be accepted, got: %v", err)
+	}
+}
+
+func TestEphemeralStorageLabel(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+
+	t.Run("label sets request and limit", func(t *testing.T) {
+		svc := ServiceSpec{
+			Image:  "app:latest",
+			Labels: map[string]string{EphemeralStorageLabel: "2Gi"},
+		}
+		resources := transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec.Containers[0].Resources
+		if limit := resources.Limits[corev1.ResourceEphemeralStorage]; limit.String() != "2Gi" {
+			t.Errorf("ephemeral-storage limit = %q, want 2Gi", limit.String())
+		}
+		if request := resources.Requests[corev1.ResourceEphemeralStorage]; request.String() != "2Gi" {
+			t.Errorf("ephemeral-storage request = %q, want 2Gi", request.String())
+		}
+		if !strings.Contains(toYAML(t, transformer.generateJob("test", "app", svc, nil)), "ephemeral-storage: 2Gi") {
+			t.Error("job manifest should include the ephemeral-storage limit")
+		}
+	})
+
+	t.Run("invalid quantity is ignored", func(t *testing.T) {
+		svc := ServiceSpec{
+			Image:  "app:latest",
+			Labels: map[string]string{EphemeralStorageLabel: "lots"},
+		}
+		resources := transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec.Containers[0].Resources
+		if len(resources.Limits) != 0 || len(resources.Requests) != 0 {
+			t.Errorf("invalid quantity should not set resources, got %+v", resources)
+		}
+	})
+}
+
+func TestDeployResources(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  limited:
+    image: app
+    deploy:
+      resources:
+        limits: {cpus: "0.5", memory: 256M}
+  reserved:
+    image: app
+    deploy:
+      resources:
+        limits: {cpus: "1", memory: 512M}
+        reservations: {cpus: "0.25"}
+  floor:
+    image: app
+    deploy:
+      resources:
+        reservations: {memory: 64M}
+  waiter:
+    image: app
+    depends_on: [limited]
+    deploy:
+      resources:
+        limits: {cpus: "0.5", memory: 256M}
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+	resourcesOf := func(name string) corev1.ResourceRequirements {
+		return transformer.generateDeployment("test", name, spec.Services[name], spec.Services).Spec.Template.Spec.Containers[0].Resources
+	}
+	quantities := func(list corev1.ResourceList) map[string]string {
+		out := map[string]string{}
+		for name, qty := range list {
+			out[string(name)] = qty.String()
+		}
+		return out
+	}
+
+	// Limits only: requests == limits, so the pod gets Guaranteed QoS
+	limited := resourcesOf("limited")
+	want := map[string]string{"cpu": "500m", "memory": "256Mi"}
+	if got := quantities(limited.Limits); !reflect.DeepEqual(got, want) {
+		t.Errorf("limited limits = %v, want %v", got, want)
+	}
+	if got := quantities(limited.Requests); !reflect.DeepEqual(got, want) {
+		t.Errorf("limited requests = %v, want requests == limits %v", got, want)
+	}
+	if !guaranteed(limited) {
+		t.Error("limits-only service should qualify for Guaranteed QoS")
+	}
+	yaml := toYAML(t, transformer.generateDeployment("test", "limited", spec.Services["limited"], spec.Services))
+	if strings.Count(yaml, "cpu: 500m") != 2 || strings.Count(yaml, "memory: 256Mi") != 2 {
+		t.Errorf("manifest should request the limits:\n%s", yaml)
+	}
+
+	// A reservation replaces the copied limit for that resource only
+	reserved := resourcesOf("reserved")
+	if got := quantities(reserved.Requests); !reflect.DeepEqual(got, map[string]string{"cpu": "250m", "memory": "512Mi"}) {
+		t.Errorf("reserved requests = %v, want cpu from the reservation and memory from the limit", got)
+	}
+	if guaranteed(reserved) {
+		t.Error("a reservation below the limit is Burstable")
+	}
+
+	// Reservations alone set requests without limits
+	floor := resourcesOf("floor")
+	if got := quantities(floor.Requests); !reflect.DeepEqual(got, map[string]string{"memory": "64Mi"}) || len(floor.Limits) != 0 {
+		t.Errorf("floor requests/limits = %v/%v, want a 64Mi request and no limits", got, quantities(floor.Limits))
+	}
+
+	// The init container must not demote a Guaranteed pod
+	pod := transformer.generateDeployment("test", "waiter", spec.Services["waiter"], spec.Services).Spec.Template.Spec
+	if len(pod.InitContainers) != 1 {
+		t.Fatalf("expected a wait-for-deps init container, got %d", len(pod.InitContainers))
+	}
+	if init := pod.InitContainers[0].Resources; !guaranteed(init) {
+		t.Errorf("init container resources = %+v, want requests == limits for a Guaranteed service", init)
+	}
+	if init := transformer.generateDeployment("test", "reserved", spec.Services["reserved"], spec.Services).Spec.Template.Spec.InitContainers; len(init) > 0 && guaranteed(init[0].Resources) {
+		t.Error("init container of a Burstable service should keep its small request")
+	}
+}
+
+func TestThrottledProbeWarning(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  starved:
+    image: app
+    healthcheck: {test: ["CMD", "true"]}
+    deploy:
+      resources:
+        limits: {cpus: "0.05"}
+  roomy:
+    image: app
+    healthcheck: {test: ["CMD", "true"]}
+    deploy:
+      resources:
+        limits: {cpus: "0.1"}
+  unprobed:
+    image: app
+    deploy:
+      resources:
+        limits: {cpus: "0.05"}
+  disabled:
+    image: app
+    healthcheck: {disable: true}
+    deploy:
+      resources:
+        limits: {cpus: "0.05"}
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	warning := ThrottledProbeWarning(project.Services["starved"])
+	if !strings.Contains(warning, `"starved"`) || !strings.Contains(warning, "50m") {
+		t.Errorf("expected a throttling warning for a 50m limit with a healthcheck, got %q", warning)
+	}
+	for _, name := range []string{"roomy", "unprobed", "disabled"} {
+		if warning := ThrottledProbeWarning(project.Services[name]); warning != "" {
+			t.Errorf("service %s should not warn, got %q", name, warning)
+		}
+	}
+}
+
+func TestGenericResourceRequests(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  app:
+    image: app:latest
+    labels:
+      kappal.io/ephemeral-storage: 1Gi
+    deploy:
+      resources:
+        reservations:
+          generic_resources:
+            - discrete_resource_spec:
+                kind: licenses
+                value: 1
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	spec := NewTransformer(project).ToSpec()
+	if got := spec.Services["app"].GenericResources["licenses"]; got != 1 {
+		t.Fatalf("expected licenses=1 in spec, got %v", spec.Services["app"].GenericResources)
+	}
+
+	transformer := &Transformer{workingDir: "/tmp"}
+	resources := transformer.generateDeployment("test", "app", spec.Services["app"], spec.Services).Spec.Template.Spec.Containers[0].Resources
+	if request := resources.Requests["licenses"]; request.String() != "1" {
+		t.Errorf("licenses request = %q, want 1", request.String())
+	}
+	if limit := resources.Limits["licenses"]; limit.String() != "1" {
+		t.Errorf("licenses limit = %q, want 1 (extended resources need limit == request)", limit.String())
+	}
+	if request := resources.Requests[corev1.ResourceEphemeralStorage]; request.String() != "1Gi" {
+		t.Errorf("ephemeral-storage request = %q, want 1Gi alongside the extended resource", request.String())
+	}
+}
+
+func TestRestartPolicyAndJobCompletions(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+	svc := ServiceSpec{Image: "app:latest", Replicas: 3}
+
+	deployment := transformer.generateDeployment("test", "app", svc, nil)
+	if policy := deployment.Spec.Template.Spec.RestartPolicy; policy != corev1.RestartPolicyAlways {
+		t.Errorf("deployment restartPolicy = %q, want Always", policy)
+	}
+
+	svc.IsJob = true
+	job := transformer.generateJob("test", "migrate", svc, nil)
+	if policy := job.Spec.Template.Spec.RestartPolicy; policy != corev1.RestartPolicyNever {
+		t.Errorf("job restartPolicy = %q, want Never", policy)
+	}
+	if job.Spec.Completions == nil || *job.Spec.Completions != 1 {
+		t.Errorf("job completions = %v, want 1 regardless of replicas", job.Spec.Completions)
+	}
+	if job.Spec.Parallelism == nil || *job.Spec.Parallelism != 1 {
+		t.Errorf("job parallelism = %v, want 1 regardless of replicas", job.Spec.Parallelism)
+	}
+}
+
+func TestNamespaceOverride(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp", namespace: "shared"}
+	allServices := map[string]ServiceSpec{
+		"db":  {Image: "postgres:16"},
+		"app": {Image: "app:latest", DependsOn: []DependsOnSpec{{Service: "db", Condition: "service_healthy"}}},
+	}
+
+	deployment := transformer.generateDeployment("myproj", "app", allServices["app"], allServices)
+	if deployment.Namespace != "shared" {
+		t.Errorf("deployment namespace = %q, want shared", deployment.Namespace)
+	}
+	if deployment.Labels["kappal.io/project"] != "myproj" {
+		t.Errorf("deployment project label = %q, want myproj", deployment.Labels["kappal.io/project"])
+	}
+	if deployment.Spec.Template.Labels["kappal.io/project"] != "myproj" {
+		t.Error("pod template should keep the project label")
+	}
+	if !strings.Contains(toYAML(t, deployment), `"namespace":"shared"`) {
+		t.Error("init container should wait for dependencies in the overridden namespace")
+	}
+	if !strings.Contains(toYAML(t, deployment), `"project":"myproj"`) {
+		t.Error("init container should select dependency pods by project")
+	}
+
+	service := transformer.generateService("myproj", "app", allServices["app"])
+	if service.Namespace != "shared" || service.Spec.Selector["kappal.io/project"] != "myproj" {
+		t.Errorf("service namespace/selector = %q/%v", service.Namespace, service.Spec.Selector)
+	}
+
+	role, binding := transformer.generateInitReaderRBAC("myproj", false, true)
+	if role.Namespace != "shared" || binding.Subjects[0].Namespace != "shared" {
+		t.Error("RBAC should be created in and bind the service account of the overridden namespace")
+	}
+
+	cm, err := transformer.generateConfigMap("myproj", "cfg", ConfigSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Namespace != "shared" || cm.Labels["kappal.io/project"] != "myproj" {
+		t.Errorf("configmap namespace/labels = %q/%v", cm.Namespace, cm.Labels)
+	}
+
+	// Without an override the project name is the namespace
+	plain := &Transformer{workingDir: "/tmp"}
+	if ns := plain.generateDeployment("myproj", "db", allServices["db"], allServices).Namespace; ns != "myproj" {
+		t.Errorf("default namespace = %q, want myproj", ns)
+	}
+}
+
+func TestEnvFileVariablesInDeployment(t *testing.T) {
+	dir := t.TempDir()
+	composeFile := filepath.Join(dir, "docker-compose.yaml")
+	content := `services:
+  app:
+    image: app:latest
+    env_file: ./app.env
+    environment:
+      LOG_LEVEL: debug
+`
+	if err := os.WriteFile(composeFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.env"), []byte("DB_HOST=db\nDB_PORT=5432\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := compose.Load([]string{composeFile}, "test")
+	if err != nil {
+		t.Fatalf("compose.Load failed: %v", err)
+	}
+	spec := NewTransformer(project).ToSpec()
+	deployment := (&Transformer{workingDir: dir}).generateDeployment("test", "app", spec.Services["app"], spec.Services)
+
+	env := map[string]string{}
+	for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	want := map[string]string{"DB_HOST": "db", "DB_PORT": "5432", "LOG_LEVEL": "debug"}
+	for name, value := range want {
+		if env[name] != value {
+			t.Errorf("expected env %s=%q, got %q (env: %v)", name, value, env[name], env)
+		}
+	}
+}
+
+func TestToSpecDeterministicOrdering(t *testing.T) {
+	str := func(s string) *string { return &s }
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"app": {
+				Name:  "app",
+				Image: "app:latest",
+				Environment: types.MappingWithEquals{
+					"ZETA":  str("z"),
+					"ALPHA": str("a"),
+					"MID":   str("m"),
+				},
+				Networks: map[string]*types.ServiceNetworkConfig{
+					"frontend": nil,
+					"backend":  nil,
+				},
+			},
+		},
+	}
+
+	transformer := NewTransformer(project)
+	first := transformer.ToSpec().Services["app"]
+
+	var names []string
+	for _, e := range first.Environment {
+		names = append(names, e.Name)
+	}
+	if got := strings.Join(names, ","); got != "ALPHA,MID,ZETA" {
+		t.Errorf("environment order = %s, want ALPHA,MID,ZETA", got)
+	}
+	if got := strings.Join(first.Networks, ","); got != "backend,frontend" {
+		t.Errorf("network order = %s, want backend,frontend", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		again := transformer.ToSpec().Services["app"]
+		deployment := toYAML(t, transformer.generateDeployment("test", "app", again, nil))
+		if deployment != toYAML(t, transformer.generateDeployment("test", "app", first, nil)) {
+			t.Fatal("generated deployment differs between runs")
+		}
+	}
+}
+
+func TestGroupAddSupplementalGroups(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+
+	t.Run("numeric group_add maps to supplementalGroups", func(t *testing.T) {
+		svc := ServiceSpec{
+			Image:    "app:latest",
+			GroupAdd: []string{"1001"},
+		}
+
+		sc := transformer.generateDeployment("test", "app", svc, nil).Spec.Template.Spec.SecurityContext
+		if sc == nil || fmt.Sprint(sc.SupplementalGroups) != "[1001]" {
+			t.Errorf("expected supplementalGroups [1001], got %+v", sc)
+		}
+	})
+
+	t.Run("named groups are skipped", func(t *testing.T) {
+		svc := ServiceSpec{
+			Image:    "app:latest",
+			GroupAdd: []string{"docker", "1001", "2002"},
+		}
+
+		job := transformer.generateJob("test", "app", svc, nil)
+		sc := job.Spec.Template.Spec.SecurityContext
+		if sc == nil || fmt.Sprint(sc.SupplementalGroups) != "[1001 2002]" {
+			t.Errorf("expected only numeric groups in supplementalGroups, got %+v", sc)
+		}
+		if strings.Contains(toYAML(t, job), "docker") {
+			t.Error("named group should not be emitted")
+		}
+	})
+
+	t.Run("no group_add generates no securityContext", func(t *testing.T) {
+		svc := ServiceSpec{Image: "app:latest"}
+
+		deployment := toYAML(t, transformer.generateDeployment("test", "app", svc, nil))
+		if strings.Contains(deployment, "securityContext:") {
+			t.Error("should not emit securityContext without volumes or group_add")
+		}
+	})
+}
+
+func TestFSGroup(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  plain:
+    image: app
+    volumes: [data:/data]
+  user:
+    image: app
+    user: "1000:2000"
+    group_add: ["3000"]
+    volumes: [data:/data]
+  groups:
+    image: app
+    group_add: ["docker", "3000"]
+    volumes: [data:/data]
+  label:
+    image: app
+    user: "1000:2000"
+    labels:
+      kappal.io/fs-group: "4000"
+    volumes: [data:/data]
+  novolumes:
+    image: app
+    user: "1000:2000"
+volumes:
+  data:
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	for name, want := range map[string]int64{"plain": DefaultFSGroup, "user": 2000, "groups": 3000, "label": 4000} {
+		sc := transformer.generateDeployment("test", name, spec.Services[name], nil).Spec.Template.Spec.SecurityContext
+		if sc == nil || sc.FSGroup == nil || *sc.FSGroup != want {
+			t.Errorf("%s: fsGroup = %+v, want %d", name, sc, want)
+		}
+	}
+
+	sc := transformer.generateDeployment("test", "novolumes", spec.Services["novolumes"], nil).Spec.Template.Spec.SecurityContext
+	if sc != nil {
+		t.Errorf("novolumes: expected no securityContext, got %+v", sc)
+	}
+}
+
+func TestNetworkPolicyEgress(t *testing.T) {
+	project := loadProject(t, `services:
+  api:
+    image: app
+    networks: [backend]
+  web:
+    image: app
+networks:
+  backend:
+`)
+
+	policies := objectsOf[*networkingv1.NetworkPolicy](renderObjects(t, NewTransformer(project)))
+	if len(policies) != 1 {
+		t.Fatalf("expected one NetworkPolicy; the default network is exempt, got %v", objectNames(policies))
+	}
+	np := policies[0]
+	wantTypes := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+	if !reflect.DeepEqual(np.Spec.PolicyTypes, wantTypes) {
+		t.Errorf("policyTypes = %v, want %v", np.Spec.PolicyTypes, wantTypes)
+	}
+	if len(np.Spec.Egress) != 3 {
+		t.Fatalf("expected same-network, DNS and external egress rules, got %+v", np.Spec.Egress)
+	}
+	if np.Spec.Egress[0].To[0].PodSelector.MatchLabels["kappal.io/network.backend"] != "true" {
+		t.Errorf("first egress rule should allow same-network pods, got %+v", np.Spec.Egress[0])
+	}
+	dns := np.Spec.Egress[1]
+	if dns.To[0].PodSelector.MatchLabels["k8s-app"] != "kube-dns" || len(dns.Ports) != 2 ||
+		*dns.Ports[0].Protocol != corev1.ProtocolUDP || dns.Ports[0].Port.IntValue() != 53 {
+		t.Errorf("second egress rule should allow cluster DNS, got %+v", dns)
+	}
+	external := np.Spec.Egress[2].To[0].IPBlock
+	if external == nil || external.CIDR != "0.0.0.0/0" || !reflect.DeepEqual(external.Except, []string{K3sClusterCIDR}) {
+		t.Errorf("third egress rule should allow everything outside %s, got %+v", K3sClusterCIDR, external)
+	}
+}
+
+// A service on default and a named network keeps reaching default-only
+// pods; only pods confined to named networks are isolated.
+func TestNetworkPolicyDefaultMembers(t *testing.T) {
+	project := loadProject(t, `services:
+  api:
+    image: app
+    networks: [default, backend]
+  db:
+    image: postgres:16
+    networks: [backend]
+  edge:
+    image: app
+    networks: [frontend, backend]
+  web:
+    image: app
+networks:
+  backend:
+  frontend:
+`)
+	objects := renderObjects(t, NewTransformer(project))
+
+	podLabels := map[string]labels.Set{}
+	for _, d := range objectsOf[*appsv1.Deployment](objects) {
+		podLabels[d.Name] = d.Spec.Template.Labels
+	}
+	if got := podLabels["api"]; got["kappal.io/network.default"] != "true" || got["kappal.io/network.backend"] != "true" {
+		t.Errorf("api should be labeled with both networks, got %v", got)
+	}
+
+	selected := map[string][]string{}
+	for _, np := range objectsOf[*networkingv1.NetworkPolicy](objects) {
+		selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range sortedKeys(podLabels) {
+			if selector.Matches(podLabels[name]) {
+				selected[np.Name] = append(selected[np.Name], name)
+			}
+		}
+		peers, err := metav1.LabelSelectorAsSelector(np.Spec.Egress[0].To[0].PodSelector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if np.Name == "backend" && !peers.Matches(podLabels["api"]) {
+			t.Error("backend pods should be allowed to reach api, which is on backend too")
+		}
+	}
+	// api is on default, so it isn't isolated and still reaches web; edge
+	// is isolated by both of its networks, whose rules add up
+	want := map[string][]string{"backend": {"db", "edge"}, "frontend": {"edge"}}
+	if !reflect.DeepEqual(selected, want) {
+		t.Errorf("NetworkPolicies select %v, want %v", selected, want)
+	}
+}
+
+func TestVolumeSizeAndStorageClass(t *testing.T) {
+	project := loadProject(t, `services:
+  db:
+    image: postgres:16
+    volumes: [pgdata:/var/lib/postgresql/data, cache:/cache]
+volumes:
+  pgdata:
+    labels:
+      kappal.io/volume-size: 10Gi
+      kappal.io/storage-class: fast-ssd
+  cache:
+`)
+
+	volumes := NewTransformer(project).ToSpec().Volumes
+	if got := volumes["pgdata"]; got.Size != "10Gi" || got.StorageClass != "fast-ssd" {
+		t.Errorf("pgdata = %+v, want 10Gi/fast-ssd", got)
+	}
+	if got := volumes["cache"]; got.Size != DefaultVolumeSize || got.StorageClass != DefaultStorageClass {
+		t.Errorf("cache = %+v, want the %s/%s defaults", got, DefaultVolumeSize, DefaultStorageClass)
+	}
+
+	claims := objectsOf[*corev1.PersistentVolumeClaim](renderObjects(t, NewTransformer(project)))
+	if names := objectNames(claims); !reflect.DeepEqual(names, []string{"cache", "pgdata"}) {
+		t.Fatalf("PVCs = %v, want cache and pgdata", names)
+	}
+	for i, want := range [][2]string{{DefaultVolumeSize, DefaultStorageClass}, {"10Gi", "fast-ssd"}} {
+		size := claims[i].Spec.Resources.Requests[corev1.ResourceStorage]
+		if class := claims[i].Spec.StorageClassName; size.String() != want[0] || class == nil || *class != want[1] {
+			t.Errorf("PVC %s = %s/%v, want %s/%s", claims[i].Name, size.String(), class, want[0], want[1])
+		}
+	}
+
+	project.Volumes["pgdata"].Labels[VolumeSizeLabel] = "lots"
+	if _, err := NewTransformer(project).RenderManifests(); err == nil || !strings.Contains(err.Error(), `volume "pgdata"`) {
+		t.Errorf("expected an invalid size error, got: %v", err)
+	}
+}
+
+func TestVolumeAccessMode(t *testing.T) {
+	project := loadProject(t, `services:
+  web:
+    image: app
+    volumes: [uploads:/uploads, cache:/cache]
+  worker:
+    image: app
+    volumes: [uploads:/uploads]
+volumes:
+  uploads:
+    labels:
+      kappal.io/access-mode: ReadWriteMany
+      kappal.io/storage-class: nfs
+  cache:
+`)
+
+	volumes := NewTransformer(project).ToSpec().Volumes
+	if got := volumes["uploads"].AccessMode; got != "ReadWriteMany" {
+		t.Errorf("uploads access mode = %q, want ReadWriteMany", got)
+	}
+	if got := volumes["cache"].AccessMode; got != DefaultAccessMode {
+		t.Errorf("cache access mode = %q, want %s", got, DefaultAccessMode)
+	}
+
+	for _, pvc := range objectsOf[*corev1.PersistentVolumeClaim](renderObjects(t, NewTransformer(project))) {
+		want := corev1.ReadWriteOnce
+		if pvc.Name == "uploads" {
+			want = corev1.ReadWriteMany
+		}
+		if !reflect.DeepEqual(pvc.Spec.AccessModes, []corev1.PersistentVolumeAccessMode{want}) {
+			t.Errorf("PVC %s access modes = %v, want %s", pvc.Name, pvc.Spec.AccessModes, want)
+		}
+	}
+
+	project.Volumes["uploads"].Labels[AccessModeLabel] = "Shared"
+	if _, err := NewTransformer(project).RenderManifests(); err == nil || !strings.Contains(err.Error(), "kappal.io/access-mode") {
+		t.Errorf("expected an invalid access mode error, got: %v", err)
+	}
+}
+
+func TestReadOnlyConsumersAccessMode(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: app
+    volumes: [assets:/assets:ro, models:/models:ro, local:/local:ro, mixed:/mixed:ro, pinned:/pinned:ro]
+  worker:
+    image: app
+    volumes: [assets:/assets:ro, mixed:/mixed]
+volumes:
+  assets:
+    labels:
+      kappal.io/storage-class: nfs
+  models:
+    labels:
+      kappal.io/storage-class: nfs
+  local:
+  mixed:
+    labels:
+      kappal.io/storage-class: nfs
+  pinned:
+    labels:
+      kappal.io/storage-class: nfs
+      kappal.io/access-mode: ReadWriteMany
+  unused:
+    labels:
+      kappal.io/storage-class: nfs
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+
+	volumes := NewTransformer(project).ToSpec().Volumes
+	for name, want := range map[string]string{
+		"assets": "ReadOnlyMany",    // read-only in every consumer
+		"models": "ReadOnlyMany",    // single read-only consumer
+		"local":  DefaultAccessMode, // local-path can't provision ReadOnlyMany
+		"mixed":  DefaultAccessMode, // worker writes to it
+		"pinned": "ReadWriteMany",   // explicit label wins
+		"unused": DefaultAccessMode, // no consumers
+	} {
+		if got := volumes[name].AccessMode; got != want {
+			t.Errorf("%s access mode = %q, want %s", name, got, want)
+		}
+	}
+}
+
+func TestHostNetworkMode(t *testing.T) {
+	project := loadProject(t, `services:
+  agent:
+    image: agent
+    network_mode: host
+  web:
+    image: nginx
+`)
+
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+	if !spec.Services["agent"].HostNetwork || spec.Services["web"].HostNetwork {
+		t.Fatalf("HostNetwork should only be set for network_mode: host, got agent=%v web=%v",
+			spec.Services["agent"].HostNetwork, spec.Services["web"].HostNetwork)
+	}
+
+	podSpec := transformer.generateDeployment("test", "agent", spec.Services["agent"], nil).Spec.Template.Spec
+	if !podSpec.HostNetwork || podSpec.DNSPolicy != corev1.DNSClusterFirstWithHostNet {
+		t.Errorf("expected hostNetwork with ClusterFirstWithHostNet, got hostNetwork=%v dnsPolicy=%q", podSpec.HostNetwork, podSpec.DNSPolicy)
+	}
+	podSpec = transformer.generateDeployment("test", "web", spec.Services["web"], nil).Spec.Template.Spec
+	if podSpec.HostNetwork || podSpec.DNSPolicy != "" {
+		t.Errorf("web should use the pod network, got hostNetwork=%v dnsPolicy=%q", podSpec.HostNetwork, podSpec.DNSPolicy)
+	}
+
+	services := objectNames(objectsOf[*corev1.Service](renderObjects(t, transformer)))
+	if !reflect.DeepEqual(services, []string{"web"}) {
+		t.Errorf("expected a Service for web only, got %v", services)
+	}
+}
+
+func TestNetworkModeNone(t *testing.T) {
+	project := loadProject(t, `services:
+  batch:
+    image: batch
+    network_mode: none
+  web:
+    image: nginx
+    networks: [frontend]
+networks:
+  frontend:
+`)
+
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+	if !spec.Services["batch"].NetworkNone || spec.Services["web"].NetworkNone {
+		t.Fatalf("NetworkNone should only be set for network_mode: none, got batch=%v web=%v",
+			spec.Services["batch"].NetworkNone, spec.Services["web"].NetworkNone)
+	}
+	labels := transformer.generateDeployment("test", "batch", spec.Services["batch"], nil).Spec.Template.Labels
+	if _, ok := labels["kappal.io/network"]; ok {
+		t.Errorf("network_mode: none pod should join no network, got labels %v", labels)
+	}
+
+	objects := renderObjects(t, transformer)
+	if services := objectNames(objectsOf[*corev1.Service](objects)); !reflect.DeepEqual(services, []string{"web"}) {
+		t.Errorf("expected a Service for web only, got %v", services)
+	}
+	if deployments := objectNames(objectsOf[*appsv1.Deployment](objects)); !reflect.DeepEqual(deployments, []string{"batch", "web"}) {
+		t.Errorf("batch should still get a Deployment, got %v", deployments)
+	}
+}
+
+func TestExtraHostsHostAliases(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  app:
+    image: app
+    extra_hosts:
+      - "db:10.0.0.5"
+      - "cache=10.0.0.5"
+      - "api:10.0.0.7"
+      - "v6host=[::1]"
+      - "host.docker.internal:host-gateway"
+  plain:
+    image: app
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	want := []corev1.HostAlias{
+		{IP: "10.0.0.5", Hostnames: []string{"cache", "db"}},
+		{IP: "10.0.0.7", Hostnames: []string{"api"}},
+		{IP: "::1", Hostnames: []string{"v6host"}},
+	}
+	got := transformer.generateDeployment("test", "app", spec.Services["app"], nil).Spec.Template.Spec.HostAliases
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hostAliases = %+v, want %+v", got, want)
+	}
+	if aliases := transformer.generateDeployment("test", "plain", spec.Services["plain"], nil).Spec.Template.Spec.HostAliases; aliases != nil {
+		t.Errorf("expected no hostAliases without extra_hosts, got %+v", aliases)
+	}
+}
+
+func TestCustomDNS(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  both:
+    image: app
+    dns: [10.0.0.2, 10.0.0.3]
+    dns_search: [corp.example.com]
+  search:
+    image: app
+    dns_search: corp.example.com
+  plain:
+    image: app
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+	podSpec := func(name string) corev1.PodSpec {
+		return transformer.generateDeployment("test", name, spec.Services[name], nil).Spec.Template.Spec
+	}
+
+	both := podSpec("both")
+	if both.DNSPolicy != corev1.DNSNone {
+		t.Errorf("custom servers should set dnsPolicy None, got %q", both.DNSPolicy)
+	}
+	want := &corev1.PodDNSConfig{Nameservers: []string{"10.0.0.2", "10.0.0.3"}, Searches: []string{"corp.example.com"}}
+	if !reflect.DeepEqual(both.DNSConfig, want) {
+		t.Errorf("dnsConfig = %+v, want %+v", both.DNSConfig, want)
+	}
+
+	search := podSpec("search")
+	if search.DNSPolicy != "" || search.DNSConfig == nil || !reflect.DeepEqual(search.DNSConfig.Searches, []string{"corp.example.com"}) {
+		t.Errorf("dns_search alone should add searches to cluster DNS, got policy %q config %+v", search.DNSPolicy, search.DNSConfig)
+	}
+
+	plain := podSpec("plain")
+	if plain.DNSPolicy != "" || plain.DNSConfig != nil {
+		t.Errorf("expected untouched DNS, got policy %q config %+v", plain.DNSPolicy, plain.DNSConfig)
+	}
+}
+
+func TestNodeSelectorExtension(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  trainer:
+    image: app
+    x-kappal-node-selector:
+      kubernetes.io/arch: arm64
+      gpu: true
+  broken:
+    image: app
+    x-kappal-node-selector: [gpu=true]
+  plain:
+    image: app
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	want := map[string]string{"kubernetes.io/arch": "arm64", "gpu": "true"}
+	deployment := transformer.generateDeployment("test", "trainer", spec.Services["trainer"], nil)
+	if got := deployment.Spec.Template.Spec.NodeSelector; !reflect.DeepEqual(got, want) {
+		t.Errorf("nodeSelector = %v, want %v", got, want)
+	}
+	manifest := toYAML(t, deployment)
+	if !strings.Contains(manifest, "nodeSelector:") || !strings.Contains(manifest, `gpu: "true"`) {
+		t.Errorf("manifest should render the nodeSelector:\n%s", manifest)
+	}
+
+	for _, name := range []string{"broken", "plain"} {
+		if got := transformer.generateDeployment("test", name, spec.Services[name], nil).Spec.Template.Spec.NodeSelector; got != nil {
+			t.Errorf("%s: expected no nodeSelector, got %v", name, got)
+		}
+	}
+}
+
+func TestBuildPlatform(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  legacy:
+    build: .
+    platform: linux/amd64
+  native:
+    build: .
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	spec := NewTransformer(project).ToSpec()
+	if got := spec.Services["legacy"].Build.Platform; got != "linux/amd64" {
+		t.Errorf("legacy build platform = %q, want linux/amd64", got)
+	}
+	if got := spec.Services["native"].Build.Platform; got != "" {
+		t.Errorf("native build platform = %q, want empty", got)
+	}
+}
+
+func TestStdinOpenTTY(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  console:
+    image: app
+    stdin_open: true
+    tty: true
+  plain:
+    image: app
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	c := transformer.generateDeployment("test", "console", spec.Services["console"], nil).Spec.Template.Spec.Containers[0]
+	if !c.Stdin || !c.TTY {
+		t.Errorf("console container stdin=%v tty=%v, want both true", c.Stdin, c.TTY)
+	}
+	yaml := toYAML(t, transformer.generateDeployment("test", "console", spec.Services["console"], nil))
+	for _, want := range []string{"stdin: true", "tty: true"} {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("deployment YAML missing %q:\n%s", want, yaml)
+		}
+	}
+
+	c = transformer.generateDeployment("test", "plain", spec.Services["plain"], nil).Spec.Template.Spec.Containers[0]
+	if c.Stdin || c.TTY {
+		t.Errorf("plain container stdin=%v tty=%v, want both false", c.Stdin, c.TTY)
+	}
+}
+
+func TestSysctls(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  redis:
+    image: redis
+    sysctls:
+      net.core.somaxconn: 1024
+      net.ipv4.tcp_syncookies: 1
+      vm.max_map_count: 262144
+  plain:
+    image: app
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	sc := transformer.generateDeployment("test", "redis", spec.Services["redis"], nil).Spec.Template.Spec.SecurityContext
+	want := []corev1.Sysctl{
+		{Name: "net.core.somaxconn", Value: "1024"},
+		{Name: "net.ipv4.tcp_syncookies", Value: "1"},
+	}
+	if sc == nil || !reflect.DeepEqual(sc.Sysctls, want) {
+		t.Errorf("sysctls = %+v, want %+v (node-level vm.max_map_count dropped)", sc, want)
+	}
+	if sc := transformer.generateDeployment("test", "plain", spec.Services["plain"], nil).Spec.Template.Spec.SecurityContext; sc != nil {
+		t.Errorf("expected no securityContext without sysctls, got %+v", sc)
+	}
+
+	for name, want := range map[string][2]bool{
+		"net.ipv4.tcp_syncookies": {true, true},
+		"net.core.somaxconn":      {false, true},
+		"kernel.sem":              {false, true},
+		"fs.mqueue.msg_max":       {false, true},
+		"vm.max_map_count":        {false, false},
+		"fs.file-max":             {false, false},
+	} {
+		if safe, namespaced := ClassifySysctl(name); safe != want[0] || namespaced != want[1] {
+			t.Errorf("ClassifySysctl(%s) = %v, %v; want %v, %v", name, safe, namespaced, want[0], want[1])
+		}
+	}
+}
+
+func TestMemSwapLimitCaptured(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  db:
+    image: postgres:16
+    memswap_limit: 2g
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	if got := NewTransformer(project).ToSpec().Services["db"].MemSwapLimit; got != 2<<30 {
+		t.Errorf("MemSwapLimit = %d, want %d", got, int64(2<<30))
+	}
+}
+
+func TestGenerateHPA(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+
+	t.Run("no label, no HPA", func(t *testing.T) {
+		hpa, err := transformer.generateHPA("test", "web", ServiceSpec{Image: "app:latest"})
+		if err != nil || hpa != nil {
+			t.Errorf("expected no HPA, got %+v, %v", hpa, err)
+		}
+	})
+
+	t.Run("labels set replicas and target", func(t *testing.T) {
+		svc := ServiceSpec{Image: "app:latest", Replicas: 2, Labels: map[string]string{
+			HPAMaxReplicasLabel: "6",
+			HPATargetCPULabel:   "250m",
+		}}
+		hpa, err := transformer.generateHPA("test", "web", svc)
+		if err != nil {
+			t.Fatalf("generateHPA failed: %v", err)
+		}
+		manifest := toYAML(t, hpa)
+		for _, want := range []string{
+			"apiVersion: autoscaling/v2",
+			"kind: HorizontalPodAutoscaler",
+			"kind: Deployment",
+			"name: web",
+			"minReplicas: 2",
+			"maxReplicas: 6",
+			"averageValue: 250m",
+			"type: AverageValue",
+		} {
+			if !strings.Contains(manifest, want) {
+				t.Errorf("HPA manifest missing %q:\n%s", want, manifest)
+			}
+		}
+	})
+
+	t.Run("Deployment leaves replicas to the HPA", func(t *testing.T) {
+		svc := ServiceSpec{Image: "app:latest", Replicas: 2, Labels: map[string]string{HPAMaxReplicasLabel: "6"}}
+		if replicas := transformer.generateDeployment("test", "web", svc, nil).Spec.Replicas; replicas != nil {
+			t.Errorf("autoscaled Deployment replicas = %d, want unset", *replicas)
+		}
+		if replicas := transformer.generateDeployment("test", "web", ServiceSpec{Image: "app:latest", Replicas: 2}, nil).Spec.Replicas; replicas == nil || *replicas != 2 {
+			t.Errorf("Deployment without an HPA should keep deploy.replicas, got %v", replicas)
+		}
+		noStart := &Transformer{workingDir: "/tmp", noStart: true}
+		if replicas := noStart.generateDeployment("test", "web", svc, nil).Spec.Replicas; replicas == nil || *replicas != 0 {
+			t.Errorf("--no-start should still create the Deployment stopped, got %v", replicas)
+		}
+	})
+
+	t.Run("default utilization target", func(t *testing.T) {
+		svc := ServiceSpec{Image: "app:latest", Labels: map[string]string{
+			HPAMinReplicasLabel: "2",
+			HPAMaxReplicasLabel: "4",
+		}}
+		hpa, err := transformer.generateHPA("test", "web", svc)
+		if err != nil {
+			t.Fatalf("generateHPA failed: %v", err)
+		}
+		target := hpa.Spec.Metrics[0].Resource.Target
+		if *hpa.Spec.MinReplicas != 2 || target.AverageUtilization == nil || *target.AverageUtilization != DefaultHPATargetCPU {
+			t.Errorf("unexpected HPA spec: %+v", hpa.Spec)
+		}
+	})
+
+	for name, labels := range map[string]map[string]string{
+		"max below min":  {HPAMinReplicasLabel: "5", HPAMaxReplicasLabel: "2"},
+		"invalid max":    {HPAMaxReplicasLabel: "many"},
+		"invalid target": {HPAMaxReplicasLabel: "3", HPATargetCPULabel: "fast"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := transformer.generateHPA("test", "web", ServiceSpec{Image: "app:latest", Labels: labels}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestGenerateIngress(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+	ingressFor := func(svc ServiceSpec) (*networkingv1.Ingress, error) {
+		return transformer.generateIngress("test", "web", svc, transformer.generateService("test", "web", svc))
+	}
+
+	t.Run("no label, no Ingress", func(t *testing.T) {
+		ingress, err := ingressFor(ServiceSpec{Image: "app:latest"})
+		if err != nil || ingress != nil {
+			t.Errorf("expected no Ingress, got %+v, %v", ingress, err)
+		}
+	})
+
+	t.Run("host routes to the first TCP port", func(t *testing.T) {
+		svc := ServiceSpec{
+			Image:  "app:latest",
+			Ports:  []PortSpec{{Target: 53, Published: 53, Protocol: "udp"}, {Target: 8080, Published: 80}},
+			Labels: map[string]string{IngressHostLabel: "app.localhost"},
+		}
+		ingress, err := ingressFor(svc)
+		if err != nil {
+			t.Fatalf("generateIngress failed: %v", err)
+		}
+		manifest := toYAML(t, ingress)
+		for _, want := range []string{
+			"apiVersion: networking.k8s.io/v1",
+			"kind: Ingress",
+			"host: app.localhost",
+			"pathType: Prefix",
+			"name: web",
+			"number: 8080",
+		} {
+			if !strings.Contains(manifest, want) {
+				t.Errorf("Ingress manifest missing %q:\n%s", want, manifest)
+			}
+		}
+	})
+
+	t.Run("service without ports uses the placeholder port", func(t *testing.T) {
+		ingress, err := ingressFor(ServiceSpec{Image: "app:latest", Labels: map[string]string{IngressHostLabel: "app.localhost"}})
+		if err != nil {
+			t.Fatalf("generateIngress failed: %v", err)
+		}
+		if got := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number; got != 80 {
+			t.Errorf("backend port = %d, want 80", got)
+		}
+	})
+
+	t.Run("invalid host is an error", func(t *testing.T) {
+		_, err := ingressFor(ServiceSpec{Image: "app:latest", Labels: map[string]string{IngressHostLabel: "http://app"}})
+		if err == nil || !strings.Contains(err.Error(), IngressHostLabel) {
+			t.Errorf("expected invalid host error, got: %v", err)
+		}
+	})
+
+	t.Run("UDP-only service is an error", func(t *testing.T) {
+		svc := ServiceSpec{
+			Image:  "app:latest",
+			Ports:  []PortSpec{{Target: 53, Published: 53, Protocol: "udp"}},
+			Labels: map[string]string{IngressHostLabel: "dns.localhost"},
+		}
+		if _, err := ingressFor(svc); err == nil {
+			t.Error("expected an error for a service without TCP ports")
+		}
+	})
+}
+
+func TestOnFailureRestartJob(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  migrate:
+    image: app
+    restart: on-failure:5
+  seed:
+    image: app
+    restart: on-failure
+  once:
+    image: app
+    restart: "no"
+  web:
+    image: app
+    restart: always
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	if spec.Services["web"].IsJob {
+		t.Error("restart: always should stay a Deployment")
+	}
+	for name, want := range map[string]struct {
+		policy  corev1.RestartPolicy
+		backoff int32
+	}{
+		"migrate": {corev1.RestartPolicyOnFailure, 5},
+		"seed":    {corev1.RestartPolicyOnFailure, 3},
+		"once":    {corev1.RestartPolicyNever, 3},
+	} {
+		svc := spec.Services[name]
+		if !svc.IsJob {
+			t.Errorf("%s: expected a Job", name)
+			continue
+		}
+		job := transformer.generateJob("test", name, svc, spec.Services)
+		if got := job.Spec.Template.Spec.RestartPolicy; got != want.policy {
+			t.Errorf("%s: restartPolicy = %s, want %s", name, got, want.policy)
+		}
+		if got := *job.Spec.BackoffLimit; got != want.backoff {
+			t.Errorf("%s: backoffLimit = %d, want %d", name, got, want.backoff)
+		}
+	}
+}
+
+func TestJobTTL(t *testing.T) {
+	transformer := &Transformer{workingDir: "/tmp"}
+	ttlFor := func(labels map[string]string) *int32 {
+		svc := ServiceSpec{Image: "app:latest", IsJob: true, Labels: labels}
+		return transformer.generateJob("test", "migrate", svc, nil).Spec.TTLSecondsAfterFinished
+	}
+
+	if ttl := ttlFor(nil); ttl == nil || *ttl != DefaultJobTTL {
+		t.Errorf("default ttl = %v, want %d", ttl, DefaultJobTTL)
+	}
+	if ttl := ttlFor(map[string]string{JobTTLLabel: "60"}); ttl == nil || *ttl != 60 {
+		t.Errorf("labelled ttl = %v, want 60", ttl)
+	}
+	if ttl := ttlFor(map[string]string{JobTTLLabel: "never"}); ttl != nil {
+		t.Errorf("never should keep the Job, got ttl %d", *ttl)
+	}
+	if ttl := ttlFor(map[string]string{JobTTLLabel: "soon"}); ttl == nil || *ttl != DefaultJobTTL {
+		t.Errorf("invalid label should fall back to the default, got %v", ttl)
+	}
+
+	// A Job other services wait on is kept, or their recreated pods would
+	// wait in kappal-init for a Job the TTL controller deleted
+	migrate := ServiceSpec{Image: "app:latest", IsJob: true, Labels: map[string]string{JobTTLLabel: "60"}}
+	services := map[string]ServiceSpec{
+		"migrate": migrate,
+		"web":     {Image: "web", DependsOn: []DependsOnSpec{{Service: "migrate", Condition: "service_completed_successfully"}}},
+		"worker":  {Image: "worker", DependsOn: []DependsOnSpec{{Service: "seed", Condition: "service_started"}}},
+	}
+	if ttl := transformer.generateJob("test", "migrate", migrate, services).Spec.TTLSecondsAfterFinished; ttl != nil {
+		t.Errorf("awaited Job should have no ttl, got %d", *ttl)
+	}
+	if ttl := transformer.generateJob("test", "seed", ServiceSpec{Image: "seed", IsJob: true}, services).Spec.TTLSecondsAfterFinished; ttl == nil || *ttl != DefaultJobTTL {
+		t.Errorf("a service_started dependency shouldn't keep the Job, got ttl %v", ttl)
+	}
+}
+
+func TestJobConfigChecksum(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("migrate.sql", "CREATE TABLE users;")
+	write("db_password", "hunter2")
+	project, err := compose.LoadFromContent([]byte(fmt.Sprintf(`services:
+  migrate:
+    image: migrate
+    restart: "no"
+    configs: [schema]
+    secrets: [db_password]
+  seed:
+    image: seed
+    restart: "no"
+configs:
+  schema:
+    file: %[1]s/migrate.sql
+secrets:
+  db_password:
+    file: %[1]s/db_password
+`, dir)), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	project.WorkingDir = dir
+
+	checksums := func(opts ...func(*Transformer)) map[string]string {
+		t.Helper()
+		transformer := NewTransformer(project)
+		for _, opt := range opts {
+			opt(transformer)
+		}
+		data, err := transformer.RenderManifests()
+		if err != nil {
+			t.Fatalf("RenderManifests failed: %v", err)
+		}
+		got := map[string]string{}
+		for _, doc := range strings.Split(string(data), "---\n") {
+			if strings.Contains(doc, "kind: Job\n") {
+				var job batchv1.Job
+				if err := yaml.Unmarshal([]byte(doc), &job); err != nil {
+					t.Fatal(err)
+				}
+				got[job.Name] = job.Annotations[ConfigChecksumAnnotation]
+			}
+		}
+		return got
+	}
+
+	first := checksums()
+	if first["migrate"] == "" || first["seed"] == "" {
+		t.Fatalf("every Job should carry %s, got %v", ConfigChecksumAnnotation, first)
+	}
+	if again := checksums(); !reflect.DeepEqual(again, first) {
+		t.Errorf("unchanged content changed the checksums: %v -> %v", first, again)
+	}
+
+	write("migrate.sql", "CREATE TABLE users; CREATE TABLE orders;")
+	second := checksums()
+	if second["migrate"] == first["migrate"] {
+		t.Error("changed config content should change the Job checksum")
+	}
+	// seed mounts neither, so it isn't re-run
+	if second["seed"] != first["seed"] {
+		t.Errorf("seed checksum changed with content it doesn't mount: %q -> %q", first["seed"], second["seed"])
+	}
+
+	write("db_password", "correct horse")
+	if third := checksums(); third["migrate"] == second["migrate"] {
+		t.Error("changed secret content should change the Job checksum")
+	}
+
+	// An immutable spec change must re-run the Job too
+	seed := project.Services["seed"]
+	seed.Image = "seed:2"
+	project.Services["seed"] = seed
+	fourth := checksums()
+	if fourth["seed"] == second["seed"] {
+		t.Error("changed Job spec should change the Job checksum")
+	}
+
+	// up --no-start then start resumes the Job; a later up must keep it
+	noStart := checksums(func(tr *Transformer) { tr.SetNoStart(true) })
+	if !reflect.DeepEqual(noStart, fourth) {
+		t.Errorf("--no-start changed the checksums: %v -> %v", fourth, noStart)
+	}
+
+	// --label lands in the immutable pod template, so it's checksummed too
+	labelled := checksums(func(tr *Transformer) { tr.SetExtraLabels(map[string]string{"team": "data"}) })
+	if labelled["seed"] == fourth["seed"] {
+		t.Error("--label should change the Job checksum")
+	}
+}
+
+func TestNoStart(t *testing.T) {
+	three := 3
+	project := &types.Project{
+		Name:       "test",
+		WorkingDir: "/tmp",
+		Services: types.Services{
+			"web":     {Name: "web", Image: "nginx", Deploy: &types.DeployConfig{Replicas: &three}},
+			"worker":  {Name: "worker", Image: "worker"},
+			"migrate": {Name: "migrate", Image: "migrate", Restart: "no"},
+		},
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	started := transformer.generateDeployment("test", "web", spec.Services["web"], spec.Services)
+	if *started.Spec.Replicas != 3 {
+		t.Fatalf("replicas = %d, want 3 without --no-start", *started.Spec.Replicas)
+	}
+	if job := transformer.generateJob("test", "migrate", spec.Services["migrate"], spec.Services); job.Spec.Suspend != nil {
+		t.Errorf("Job should not be suspended without --no-start")
+	}
+
+	transformer.SetNoStart(true)
+	for _, name := range []string{"web", "worker"} {
+		d := transformer.generateDeployment("test", name, spec.Services[name], spec.Services)
+		if *d.Spec.Replicas != 0 {
+			t.Errorf("%s replicas = %d, want 0 with --no-start", name, *d.Spec.Replicas)
+		}
+	}
+	job := transformer.generateJob("test", "migrate", spec.Services["migrate"], spec.Services)
+	if job.Spec.Suspend == nil || !*job.Spec.Suspend {
+		t.Errorf("Job should be suspended with --no-start")
+	}
+
+	want := map[string]int32{"web": 3, "worker": 1}
+	if got := transformer.DeploymentReplicas(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DeploymentReplicas = %v, want %v for 'kappal start'", got, want)
+	}
+}
+
+func TestExtraLabels(t *testing.T) {
+	dir := t.TempDir()
+	project := loadProject(t, fmt.Sprintf(`services:
+  web:
+    image: nginx
+    ports: ["8080:80"]
+    volumes: [data:/data]
+    configs: [app]
+  migrate:
+    image: migrate
+    restart: "no"
+volumes:
+  data:
+configs:
+  app:
+    file: %s
+`, filepath.Join(dir, "app.conf")))
+	if err := os.WriteFile(filepath.Join(dir, "app.conf"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	transformer := NewTransformer(project)
+	transformer.SetExtraLabels(map[string]string{"team": "payments", "kappal.io/project": "ignored"})
+
+	kinds := map[string]bool{}
+	for _, obj := range renderObjects(t, transformer) {
+		kind := reflect.TypeOf(obj).Elem().Name()
+		kinds[kind] = true
+		meta := obj.(metav1.Object)
+		labels := meta.GetLabels()
+		if labels["team"] != "payments" {
+			t.Errorf("%s/%s labels = %v, want team=payments", kind, meta.GetName(), labels)
+		}
+		if labels["kappal.io/project"] != "test" {
+			t.Errorf("%s/%s project label = %q, extra labels must not override it", kind, meta.GetName(), labels["kappal.io/project"])
+		}
+		var template map[string]string
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			template = o.Spec.Template.Labels
+		case *batchv1.Job:
+			template = o.Spec.Template.Labels
+		default:
+			continue
+		}
+		if template["team"] != "payments" || template["kappal.io/project"] != "test" {
+			t.Errorf("%s/%s pod template labels = %v", kind, meta.GetName(), template)
+		}
+	}
+	for _, kind := range []string{"Namespace", "Deployment", "Job", "Service", "PersistentVolumeClaim", "ConfigMap"} {
+		if !kinds[kind] {
+			t.Errorf("manifest has no %s", kind)
+		}
+	}
+
+	// A shared namespace isn't owned by the project, so it isn't labeled
+	transformer.SetNamespace("shared")
+	for _, ns := range objectsOf[*corev1.Namespace](renderObjects(t, transformer)) {
+		if len(ns.Labels) != 0 {
+			t.Errorf("shared namespace labels = %v, want none", ns.Labels)
+		}
+	}
+}
+
+func TestRegistryAuthPullSecret(t *testing.T) {
+	project := loadProject(t, `services:
+  api:
+    image: ghcr.io/org/api:1.0
+  web:
+    image: nginx
+  app:
+    image: ghcr.io/org/app:dev
+    build: .
+`)
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+
+	// Without credentials nothing changes
+	if secret, err := transformer.generateRegistrySecret(spec); err != nil || secret != nil {
+		t.Fatalf("expected no pull secret without credentials, got %v, %v", secret, err)
+	}
+
+	transformer.SetRegistryAuth(map[string]registry.AuthConfig{
+		"ghcr.io": {Username: "octocat", Password: "ghp_token"},
+		"quay.io": {Username: "unused", Password: "x"},
+	})
+	secret, err := transformer.generateRegistrySecret(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == nil || secret.Name != registryAuthSecretName("test") || secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Fatalf("unexpected pull secret: %+v", secret)
+	}
+	var config struct {
+		Auths map[string]struct{ Username, Password, Auth string }
+	}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+		t.Fatal(err)
+	}
+	ghcr, ok := config.Auths["ghcr.io"]
+	if len(config.Auths) != 1 || !ok {
+		t.Fatalf("pull secret should only hold registries the services pull from, got %v", config.Auths)
+	}
+	if ghcr.Username != "octocat" || ghcr.Password != "ghp_token" || ghcr.Auth != base64.StdEncoding.EncodeToString([]byte("octocat:ghp_token")) {
+		t.Errorf("ghcr.io entry = %+v", ghcr)
+	}
+
+	pullSecrets := func(name string) []corev1.LocalObjectReference {
+		return transformer.generateDeployment("test", name, spec.Services[name], spec.Services).Spec.Template.Spec.ImagePullSecrets
+	}
+	if got := pullSecrets("api"); len(got) != 1 || got[0].Name != registryAuthSecretName("test") {
+		t.Errorf("api imagePullSecrets = %v, want %s", got, registryAuthSecretName("test"))
+	}
+	if got := pullSecrets("web"); len(got) != 0 {
+		t.Errorf("web pulls from Docker Hub without credentials, got imagePullSecrets %v", got)
+	}
+	if got := pullSecrets("app"); len(got) != 0 {
+		t.Errorf("built images are never pulled, got imagePullSecrets %v", got)
+	}
+
+	rendered := objectsOf[*corev1.Secret](renderObjects(t, transformer))
+	if len(rendered) != 1 || rendered[0].Name != registryAuthSecretName("test") || rendered[0].Type != corev1.SecretTypeDockerConfigJson {
+		t.Errorf("manifest should hold the %s pull secret, got %v", registryAuthSecretName("test"), objectNames(rendered))
+	}
+}
+
+func TestProgressDeadline(t *testing.T) {
+	project, err := compose.LoadFromContent([]byte(`services:
+  web:
+    image: nginx
+`), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+	transformer := NewTransformer(project)
+	spec := transformer.ToSpec()
+	d := transformer.generateDeployment("test", "web", spec.Services["web"], spec.Services)
+	if d.Spec.ProgressDeadlineSeconds == nil || *d.Spec.ProgressDeadlineSeconds != DefaultProgressDeadlineSeconds {
+		t.Errorf("progressDeadlineSeconds = %v, want %d", d.Spec.ProgressDeadlineSeconds, DefaultProgressDeadlineSeconds)
+	}
+
+	transformer.SetProgressDeadline(120)
+	d = transformer.generateDeployment("test", "web", spec.Services["web"], spec.Services)
+	if d.Spec.ProgressDeadlineSeconds == nil || *d.Spec.ProgressDeadlineSeconds != 120 {
+		t.Errorf("progressDeadlineSeconds = %v, want 120", d.Spec.ProgressDeadlineSeconds)
+	}
+	if !strings.Contains(toYAML(t, d), "progressDeadlineSeconds: 120") {
+		t.Error("rendered Deployment should include progressDeadlineSeconds")
+	}
+}
+
+// Every map in the compose model (services, volumes, networks, secrets,
+// configs, environment, depends_on, sysctls, extra_hosts, labels) must be
+// iterated in key order, or all.yaml changes from run to run. The golden
+// projects are small; this renders a larger project many times with its
+// maps rebuilt in shuffled insertion order.
+func TestRenderManifestsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.conf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content := `services:
+  web:
+    image: nginx
+    ports: ["8080:80", "8443:443", "5353:53/udp"]
+    environment: {ZED: "1", ALPHA: "2", MIDDLE: "3", BETA: "4"}
+    labels: {kappal.io/hpa.maxReplicas: "3", team: web, env: dev}
+    sysctls: {net.ipv4.tcp_keepalive_time: "600", net.core.somaxconn: "1024"}
+    extra_hosts: ["db.local:10.0.0.2", "cache.local:10.0.0.3", "api.local:10.0.0.2"]
+    networks: [front, back, default]
+    volumes: [data:/data, logs:/logs]
+    secrets: [b, a]
+    configs: [c]
+    depends_on:
+      worker: {condition: service_started}
+      db: {condition: service_healthy}
+      migrate: {condition: service_completed_successfully}
+  worker:
+    image: busybox
+    networks: [back]
+  db:
+    image: postgres:16
+    healthcheck: {test: ["CMD-SHELL", "pg_isready"]}
+    volumes: [pgdata:/var/lib/postgresql/data]
+  migrate:
+    image: busybox
+    restart: "no"
+`
+	// Enough services, volumes and networks that map order varies widely
+	volumes := "volumes: {data: {}, logs: {}, pgdata: {}"
+	networks := "networks: {front: {}, back: {}"
+	for i := 0; i < 12; i++ {
+		content += fmt.Sprintf(`  svc%02[1]d:
+    image: busybox
+    environment: {X%02[1]d: "1", A%02[1]d: "2"}
+    networks: [net%02[1]d, back]
+    volumes: [vol%02[1]d:/data]
+    depends_on: [db, worker]
+`, i)
+		volumes += fmt.Sprintf(", vol%02d: {}", i)
+		networks += fmt.Sprintf(", net%02d: {}", i)
+	}
+	content += volumes + "}\n" + networks + fmt.Sprintf(`}
+secrets:
+  b: {file: %[1]s/b.txt}
+  a: {file: %[1]s/a.txt}
+configs:
+  c: {file: %[1]s/c.conf}
+`, dir)
+	project, err := compose.LoadFromContent([]byte(content), "test")
+	if err != nil {
+		t.Fatalf("LoadFromContent failed: %v", err)
+	}
+
+	first, err := NewTransformer(project).RenderManifests()
+	if err != nil {
+		t.Fatalf("RenderManifests failed: %v", err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		again, err := NewTransformer(shuffledProject(project, rng)).RenderManifests()
+		if err != nil {
+			t.Fatalf("RenderManifests failed: %v", err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("render %d differs from the first:\n%s", i+2, lineDiff(string(first), string(again)))
+		}
+	}
+
+	// Generate writes the same bytes to all.yaml
+	ws, err := workspace.New(filepath.Join(dir, ".kappal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewTransformer(project).Generate(ws); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	written, err := os.ReadFile(filepath.Join(ws.GetManifestDir(), "all.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != string(first) {
+		t.Errorf("all.yaml differs from RenderManifests:\n%s", lineDiff(string(first), string(written)))
+	}
+}
+
+// shuffledProject copies project with its maps rebuilt in random insertion
+// order.
+func shuffledProject(project *types.Project, rng *rand.Rand) *types.Project {
+	p := *project
+	p.Services = shuffledMap(project.Services, rng)
+	for name, svc := range p.Services {
+		svc.Environment = shuffledMap(svc.Environment, rng)
+		svc.Labels = shuffledMap(svc.Labels, rng)
+		svc.DependsOn = shuffledMap(svc.DependsOn, rng)
+		svc.Sysctls = shuffledMap(svc.Sysctls, rng)
+		svc.Networks = shuffledMap(svc.Networks, rng)
+		p.Services[name] = svc
+	}
+	p.Volumes = shuffledMap(project.Volumes, rng)
+	p.Networks = shuffledMap(project.Networks, rng)
+	p.Secrets = shuffledMap(project.Secrets, rng)
+	p.Configs = shuffledMap(project.Configs, rng)
+	return &p
+}
+
+func shuffledMap[M ~map[K]V, K comparable, V any](m M, rng *rand.Rand) M {
+	if m == nil {
+		return nil
+	}
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	rng.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	out := make(M, len(m))
+	for _, k := range keys {
+		out[k] = m[k]
+	}
+	return out
+}
@@ -185,6 +185,47 @@ func (w *Workspace) GetKubeconfigPath() string {
 	return filepath.Join(w.RuntimeDir, "kubeconfig.yaml")
 }
 
+// stoppedReplicasFile holds the replica counts recorded by 'kappal stop'.
+const stoppedReplicasFile = "stopped-replicas.json"
+
+// ReadStoppedReplicas returns the Deployment replica counts recorded by
+// 'kappal stop', keyed by Deployment name. It returns an empty map when
+// nothing is stopped.
+func (w *Workspace) ReadStoppedReplicas() (map[string]int32, error) {
+	replicas := map[string]int32{}
+	data, err := os.ReadFile(filepath.Join(w.RuntimeDir, stoppedReplicasFile))
+	if os.IsNotExist(err) {
+		return replicas, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stopped replicas: %w", err)
+	}
+	if err := json.Unmarshal(data, &replicas); err != nil {
+		return nil, fmt.Errorf("failed to parse stopped replicas: %w", err)
+	}
+	return replicas, nil
+}
+
+// WriteStoppedReplicas records Deployment replica counts for 'kappal start'.
+// An empty map removes the record.
+func (w *Workspace) WriteStoppedReplicas(replicas map[string]int32) error {
+	path := filepath.Join(w.RuntimeDir, stoppedReplicasFile)
+	if len(replicas) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stopped replicas: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(replicas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal stopped replicas: %w", err)
+	}
+	if err := os.MkdirAll(w.RuntimeDir, 0755); err != nil {
+		return fmt.Errorf("failed to create runtime directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
 // CleanRuntime removes the runtime directory
 func (w *Workspace) CleanRuntime() error {
 	return os.RemoveAll(w.RuntimeDir)
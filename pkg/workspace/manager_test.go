@@ -0,0 +1,37 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoppedReplicasRoundTrip(t *testing.T) {
+	ws, err := New(filepath.Join(t.TempDir(), ".kappal"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	replicas, err := ws.ReadStoppedReplicas()
+	if err != nil || len(replicas) != 0 {
+		t.Fatalf("expected empty record before stop, got %v (err %v)", replicas, err)
+	}
+
+	if err := ws.WriteStoppedReplicas(map[string]int32{"web": 3, "worker": 1}); err != nil {
+		t.Fatalf("WriteStoppedReplicas failed: %v", err)
+	}
+	replicas, err = ws.ReadStoppedReplicas()
+	if err != nil {
+		t.Fatalf("ReadStoppedReplicas failed: %v", err)
+	}
+	if replicas["web"] != 3 || replicas["worker"] != 1 {
+		t.Errorf("expected web=3 worker=1, got %v", replicas)
+	}
+
+	if err := ws.WriteStoppedReplicas(nil); err != nil {
+		t.Fatalf("WriteStoppedReplicas(nil) failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ws.RuntimeDir, stoppedReplicasFile)); !os.IsNotExist(err) {
+		t.Errorf("expected empty record to remove the file, stat err: %v", err)
+	}
+}
@@ -36,7 +36,7 @@ require_pattern "cmd/kappal/up.go" 'compat\.NeedInitImage' \
 # transformer must emit writable-path prep into KAPPAL_INIT_SPEC.
 require_pattern "pkg/transform/transformer.go" '"prepareWritablePaths"' \
     "transformer must include prepareWritablePaths in init spec"
-require_pattern "pkg/transform/transformer.go" 'runAsUser:\s*0' \
+require_pattern "pkg/transform/transformer.go" 'RunAsUser:\s*&root' \
     "init container for bind-mount prep must run as root"
 
 # kappal-init must parse and execute writable path prep.
@@ -25,8 +25,9 @@ check_build_calls() {
     fi
 
     # Check if any BuildImage call has Build.Context but not dockerfile nearby
-    # The correct pattern is to have dockerfile variable defined and passed
-    local has_context=$(grep 'BuildImage.*Build\.Context' "$file" 2>/dev/null || true)
+    # The correct pattern is to pass Build.Dockerfile directly, or to have a
+    # dockerfile variable defined and passed
+    local has_context=$(grep 'BuildImage.*Build\.Context' "$file" 2>/dev/null | grep -v 'Build\.Dockerfile' || true)
     local has_dockerfile_var=$(grep -E '^\s*dockerfile\s*:?=' "$file" 2>/dev/null || true)
 
     if [ -n "$has_context" ] && [ -z "$has_dockerfile_var" ]; then
@@ -44,8 +44,12 @@ check_kubectl_delete() {
         echo "  Verify that DeleteVolumes=true triggers namespace deletion"
     fi
 
-    # Check that default case does NOT delete namespace
+    # Check that default case does NOT delete namespace: it deletes resource
+    # kinds, either listed inline or through the DeleteKinds list
     local default_case=$(grep -A 30 'func Delete' "$apply_file" | grep -E 'delete.*deployments|delete.*services')
+    if [ -z "$default_case" ] && grep -A 60 'func Delete' "$apply_file" | grep -q '"delete", strings.Join(DeleteKinds'; then
+        default_case=$(grep -A 3 '^var DeleteKinds' "$apply_file" | grep -E '"deployments".*"services"')
+    fi
     if [ -z "$default_case" ]; then
         echo "WARNING: No selective resource deletion found"
         echo "  Without DeleteVolumes, only delete deployments/services/etc, not namespace"